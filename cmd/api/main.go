@@ -12,8 +12,11 @@ import (
 	"hosting-backend/internal/handlers/domain"
 	"hosting-backend/internal/handlers/products"
 	"hosting-backend/internal/handlers/webhooks"
+	"hosting-backend/internal/jobs"
 	"hosting-backend/internal/logger"
 	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/modules"
+	"hosting-backend/internal/orchestrator"
 	"hosting-backend/internal/provisioning" // Importa o provisionador
 	"hosting-backend/internal/services"
 	"hosting-backend/internal/utils"
@@ -76,10 +79,49 @@ func main() {
 	// Instancia os serviços com o banco de dados e o provisionador
 	clientService := services.NewClientService(db)
 	adminService := services.NewAdminService(db, whmProvisioner) // Injeta o provisionador
+	fiscalService := services.NewFiscalService(db)
+	serverAlertService := services.NewServerAlertService(db)
+	accountImporter := services.NewAccountImporter(db)
+	accountMover := services.NewAccountMover(db)
+	domainProvisioning := services.NewDomainProvisioningService(db)
+	domainSearch := services.NewDomainSearchService(db)
+	domainPricing := services.NewDomainPricingService(db)
+	domainRenewals := services.NewDomainRenewalService(db)
+	domainTransfers := services.NewDomainTransferService(db)
+	domainManagement := services.NewDomainManagementService(db)
+	dnsZones := services.NewDNSZoneService(db, whmProvisioner)
+	sslCertificates := services.NewSSLCertificateService(db, whmProvisioner)
+	mailboxes := services.NewMailboxService(db, whmProvisioner)
+	staffTasks := services.NewStaffTaskService(db)
+
+	// Fila persistente de provisionamento: registra os handlers de cada tipo de tarefa
+	jobQueue := jobs.NewQueue(db)
+	orchestrator.RegisterJobHandlers(jobQueue, db, services.NewServerPlacement(db, whmProvisioner))
+	adminService.RegisterJobHandlers(jobQueue)
+
+	// Registra os módulos do sistema (feature flags) e carrega o estado atual
+	moduleRegistry := modules.NewRegistry(db)
+	if err := moduleRegistry.Sync(); err != nil {
+		logger.Log.WithFields(logrus.Fields{
+			"module": "modules",
+			"error":  err.Error(),
+		}).Error("Erro ao sincronizar os módulos do sistema")
+	}
+	requireFiscal := middleware.RequireModule(moduleRegistry, modules.FiscalNFSe)
+	requireVPSSales := middleware.RequireModule(moduleRegistry, modules.VPSSales)
+	requireDomainSales := middleware.RequireModule(moduleRegistry, modules.DomainSales)
 
 	// Inicia os workers em goroutines
 	go workers.GenerateInvoicesWorker(db)
 	go workers.SuspensionWorker(db)
+	go workers.FiscalSyncWorker(db, moduleRegistry)
+	go workers.ServerMetricsWorker(db)
+	go workers.ServerAlertsWorker(db)
+	go workers.AccountMoveWorker(db)
+	go workers.DomainRenewalWorker(db, moduleRegistry)
+	go workers.DomainTransferWorker(db, moduleRegistry)
+	go workers.SSLExpiryWorker(db)
+	go workers.JobQueueWorker(jobQueue)
 
 	r := mux.NewRouter()
 
@@ -95,8 +137,8 @@ func main() {
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
-	r.HandleFunc("/domains/check", domain.CheckDomainHandler()).Methods("POST")
-	r.HandleFunc("/products/vps", products.GetVpsProductsHandler()).Methods("GET")
+	r.Handle("/domains/check", requireDomainSales(domain.CheckDomainHandler(db, domainSearch))).Methods("POST")
+	r.Handle("/products/vps", requireVPSSales(products.GetVpsProductsHandler())).Methods("GET")
 
 	// --- Rotas de Autenticação ---
 	authRouter := r.PathPrefix("/api").Subrouter()
@@ -108,9 +150,43 @@ func main() {
 	clientRouter.Use(middleware.AuthMiddleware)
 	clientRouter.HandleFunc("/api/me", client.MeHandler(db)).Methods("GET")
 	clientRouter.HandleFunc("/api/my-services", client.GetServicesHandler(clientService)).Methods("GET")
-	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}", client.GetServiceDetailsHandler(db)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}", client.GetServiceDetailsHandler(db, sslCertificates, mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/ssl", client.GetMySSLStatusHandler(sslCertificates)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/ssl/autossl", client.RunMyAutoSSLHandler(sslCertificates)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/ssl/certificates", client.InstallMySSLCertificateHandler(sslCertificates)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones", client.ListMyDNSZonesHandler(dnsZones)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}", client.GetMyDNSZoneHandler(dnsZones)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/records", client.AddMyDNSRecordHandler(dnsZones)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/records/{line:[0-9]+}", client.UpdateMyDNSRecordHandler(dnsZones)).Methods("PUT")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/records/{line:[0-9]+}", client.DeleteMyDNSRecordHandler(dnsZones)).Methods("DELETE")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/templates", client.ListMyDNSTemplatesHandler(dnsZones)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/templates/{template}", client.ApplyMyDNSTemplateHandler(dnsZones)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/dns/zones/{zone}/history", client.GetMyDNSZoneHistoryHandler(dnsZones)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email", client.GetMyEmailOverviewHandler(mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/accounts", client.ListMyMailboxesHandler(mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/accounts", client.CreateMyMailboxHandler(mailboxes)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/accounts/{email}", client.DeleteMyMailboxHandler(mailboxes)).Methods("DELETE")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/accounts/{email}/password", client.ChangeMyMailboxPasswordHandler(mailboxes)).Methods("PUT")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/accounts/{email}/quota", client.ChangeMyMailboxQuotaHandler(mailboxes)).Methods("PUT")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/forwarders", client.ListMyEmailForwardersHandler(mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/forwarders", client.AddMyEmailForwarderHandler(mailboxes)).Methods("POST")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/forwarders/{source}/{destination}", client.DeleteMyEmailForwarderHandler(mailboxes)).Methods("DELETE")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/autoresponders", client.ListMyAutorespondersHandler(mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/autoresponders/{email}", client.GetMyAutoresponderHandler(mailboxes)).Methods("GET")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/autoresponders/{email}", client.SaveMyAutoresponderHandler(mailboxes)).Methods("PUT")
+	clientRouter.HandleFunc("/api/my-services/{id:[0-9]+}/email/autoresponders/{email}", client.DeleteMyAutoresponderHandler(mailboxes)).Methods("DELETE")
 	clientRouter.HandleFunc("/api/my-invoices", client.GetInvoicesHandler(clientService)).Methods("GET")
+	clientRouter.Handle("/api/my-fiscal-documents", requireFiscal(client.ListMyFiscalDocumentsHandler(db))).Methods("GET")
+	clientRouter.Handle("/api/my-fiscal-documents/{id:[0-9]+}/{format:pdf|xml}", requireFiscal(client.DownloadMyFiscalDocumentHandler(db))).Methods("GET")
 	clientRouter.HandleFunc("//api/checkout", client.CheckoutHandler(db)).Methods("POST")
+	clientRouter.Handle("/api/domains/order", requireDomainSales(client.OrderDomainHandler(db))).Methods("POST")
+	clientRouter.Handle("/api/my-domains", requireDomainSales(client.ListMyDomainsHandler(domainManagement))).Methods("GET")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}", requireDomainSales(client.GetMyDomainHandler(domainManagement))).Methods("GET")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}/nameservers", requireDomainSales(client.UpdateMyDomainNameserversHandler(domainManagement))).Methods("PUT")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}/lock", requireDomainSales(client.SetMyDomainLockHandler(domainManagement))).Methods("PUT")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}/privacy", requireDomainSales(client.SetMyDomainPrivacyHandler(domainManagement))).Methods("PUT")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}/transfer", requireDomainSales(client.GetDomainTransferHandler(domainTransfers))).Methods("GET")
+	clientRouter.Handle("/api/my-domains/{id:[0-9]+}/transfer", requireDomainSales(client.CorrectDomainTransferHandler(domainTransfers))).Methods("PUT")
 
 	// --- Rotas de Administração ---
 	adminRouter := r.PathPrefix("/admin").Subrouter()
@@ -124,19 +200,70 @@ func main() {
 	adminRouter.HandleFunc("/clients/{id:[0-a-z0-9]+}", admin.GetClientHandler(adminService)).Methods("GET")
 	adminRouter.HandleFunc("/clients/{id:[0-9]+}", admin.UpdateClientHandler(adminService)).Methods("PUT")
 	adminRouter.HandleFunc("/clients/{id:[0-9]+}", admin.DeleteClientHandler(adminService)).Methods("DELETE")
-	adminRouter.HandleFunc("/domain-orders", admin.GetDomainOrdersHandler(db)).Methods("GET")
-	adminRouter.HandleFunc("/domain-orders/{id:[0-9]+}", admin.UpdateDomainOrderHandler(db)).Methods("PUT")
+	adminRouter.Handle("/domain-orders", requireDomainSales(admin.GetDomainOrdersHandler(db))).Methods("GET")
+	adminRouter.Handle("/domain-orders/{id:[0-9]+}", requireDomainSales(admin.UpdateDomainOrderHandler(db))).Methods("PUT")
+	adminRouter.Handle("/domains/{id:[0-9]+}/timeline", requireDomainSales(admin.GetDomainTimelineHandler(domainProvisioning))).Methods("GET")
+	adminRouter.Handle("/domains/{id:[0-9]+}/resume", requireDomainSales(admin.ResumeDomainProvisioningHandler(domainProvisioning))).Methods("POST")
+	adminRouter.Handle("/domains/{id:[0-9]+}/steps/{step}/retry", requireDomainSales(admin.RetryDomainStepHandler(domainProvisioning))).Methods("POST")
+
+	// Rotas da tabela de preços de domínios (Admin)
+	adminRouter.Handle("/domain-pricing/tlds", requireDomainSales(admin.GetDomainPricesHandler(domainPricing))).Methods("GET")
+	adminRouter.Handle("/domain-pricing/tlds/{tld}", requireDomainSales(admin.UpdateDomainPricesHandler(domainPricing))).Methods("PUT")
+	adminRouter.Handle("/domain-pricing/tlds/{tld}", requireDomainSales(admin.DeleteDomainPricesHandler(domainPricing))).Methods("DELETE")
+	adminRouter.Handle("/domain-pricing/quote", requireDomainSales(admin.GetDomainPriceQuoteHandler(domainPricing))).Methods("GET")
+	adminRouter.Handle("/domain-pricing/sync-costs", requireDomainSales(admin.SyncDomainCostsHandler(domainPricing))).Methods("POST")
+	adminRouter.Handle("/domain-pricing/margin-rules", requireDomainSales(admin.GetDomainMarginRulesHandler(domainPricing))).Methods("GET")
+	adminRouter.Handle("/domain-pricing/margin-rules", requireDomainSales(admin.SaveDomainMarginRuleHandler(domainPricing))).Methods("PUT")
+	adminRouter.Handle("/domain-pricing/margin-rules/{id:[0-9]+}", requireDomainSales(admin.DeleteDomainMarginRuleHandler(domainPricing))).Methods("DELETE")
+	adminRouter.Handle("/domain-pricing/promotions", requireDomainSales(admin.GetDomainPromotionsHandler(domainPricing))).Methods("GET")
+	adminRouter.Handle("/domain-pricing/promotions", requireDomainSales(admin.CreateDomainPromotionHandler(domainPricing))).Methods("POST")
+	adminRouter.Handle("/domain-pricing/promotions/{id:[0-9]+}", requireDomainSales(admin.DeleteDomainPromotionHandler(domainPricing))).Methods("DELETE")
+	adminRouter.Handle("/domain-renewals/settings", requireDomainSales(admin.GetDomainRenewalSettingsHandler(domainRenewals))).Methods("GET")
+	adminRouter.Handle("/domain-renewals/settings", requireDomainSales(admin.UpdateDomainRenewalSettingsHandler(domainRenewals))).Methods("PUT")
+
 	adminRouter.HandleFunc("/financials/balance", admin.GetBalanceHandler(db)).Methods("GET")
 	adminRouter.HandleFunc("/financials/transactions", admin.GetTransactionsHandler(db)).Methods("GET")
 
 	// Rotas de Configurações Fiscais (Admin)
 	adminRouter.HandleFunc("/fiscal/settings", admin.GetFiscalSettingsHandler(db)).Methods("GET")
-	adminRouter.HandleFunc("//fiscal/settings", admin.UpdateFiscalSettingsHandler(db)).Methods("PUT")
+	adminRouter.HandleFunc("/fiscal/settings", admin.UpdateFiscalSettingsHandler(fiscalService)).Methods("PUT")
+	adminRouter.HandleFunc("/fiscal/plans/{id:[0-9]+}", admin.GetPlanFiscalCodeHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/fiscal/plans/{id:[0-9]+}", admin.UpdatePlanFiscalCodeHandler(fiscalService)).Methods("PUT")
+	adminRouter.Handle("/fiscal/documents/{id:[0-9]+}/cancel", requireFiscal(admin.CancelFiscalDocumentHandler(fiscalService))).Methods("POST")
+	adminRouter.Handle("/fiscal/documents/{id:[0-9]+}/reissue", requireFiscal(admin.ReissueFiscalDocumentHandler(fiscalService))).Methods("POST")
+
+	// Rotas de Módulos do Sistema (Admin)
+	adminRouter.HandleFunc("/modules", admin.GetModulesHandler(moduleRegistry)).Methods("GET")
+	adminRouter.HandleFunc("/modules/{name}", admin.UpdateModuleHandler(moduleRegistry)).Methods("PUT")
 
 	// Rotas de gerenciamento de serviços (Admin)
-	adminRouter.HandleFunc("/services", admin.CreateServiceHandler(db)).Methods("POST")
-	adminRouter.HandleFunc("/services/{id:[0-9]+}/suspend", admin.SuspendServiceHandler(db)).Methods("PUT")
-	adminRouter.HandleFunc("/services/{id:[0-9]+}/reactivate", admin.ReactivateServiceHandler(db)).Methods("PUT")
+	adminRouter.HandleFunc("/services", admin.CreateServiceHandler(db, adminService)).Methods("POST")
+	adminRouter.HandleFunc("/services/{id:[0-9]+}/suspend", admin.SuspendServiceHandler(adminService)).Methods("PUT")
+	adminRouter.HandleFunc("/services/{id:[0-9]+}/reactivate", admin.ReactivateServiceHandler(adminService)).Methods("PUT")
+	adminRouter.HandleFunc("/services/{id:[0-9]+}/move", admin.MoveServiceHandler(accountMover)).Methods("POST")
+	adminRouter.HandleFunc("/account-moves", admin.GetAccountMovesHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/account-moves/{id:[0-9]+}", admin.GetAccountMoveHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/account-moves/{id:[0-9]+}/resume", admin.ResumeAccountMoveHandler(accountMover)).Methods("POST")
+
+	// Rotas do pool de servidores (Admin)
+	adminRouter.HandleFunc("/servers", admin.GetServersHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/servers", admin.CreateServerHandler(db)).Methods("POST")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}", admin.UpdateServerHandler(db)).Methods("PUT")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}", admin.DeleteServerHandler(db)).Methods("DELETE")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}/credentials", admin.UpdateServerCredentialsHandler(db)).Methods("PUT")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}/metrics", admin.GetServerMetricsHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}/metrics/history", admin.GetServerMetricHistoryHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/servers/{id:[0-9]+}/import-accounts", admin.ImportServerAccountsHandler(accountImporter)).Methods("POST")
+	adminRouter.HandleFunc("/alerts", admin.GetServerAlertsHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/alerts/{id:[0-9]+}/acknowledge", admin.AcknowledgeServerAlertHandler(serverAlertService)).Methods("POST")
+
+	// Rotas da fila de provisionamento (Admin)
+	adminRouter.HandleFunc("/jobs", admin.GetJobsHandler(db)).Methods("GET")
+	adminRouter.HandleFunc("/jobs/{id:[0-9]+}/retry", admin.RetryJobHandler(adminService)).Methods("POST")
+	adminRouter.HandleFunc("/rdap/bootstrap/refresh", admin.RefreshRdapBootstrapHandler()).Methods("POST")
+	adminRouter.HandleFunc("/invoices/{id:[0-9]+}/mark-paid", admin.MarkInvoicePaidHandler(adminService)).Methods("POST")
+	adminRouter.HandleFunc("/staff-tasks", admin.GetStaffTasksHandler(staffTasks)).Methods("GET")
+	adminRouter.HandleFunc("/staff-tasks/{id:[0-9]+}/resolve", admin.ResolveStaffTaskHandler(staffTasks)).Methods("POST")
 
 	// Rota de Monitoramento (Admin)
 	adminRouter.HandleFunc("/monitoring/logs", admin.GetSystemLogsHandler()).Methods("GET")
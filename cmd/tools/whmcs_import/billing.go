@@ -0,0 +1,235 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hosting-backend/internal/models"
+)
+
+type whmcsInvoice struct {
+	ID       int64
+	ClientID int64
+	Date     sql.NullTime
+	DueDate  sql.NullTime
+	Total    float64
+	Status   string
+}
+
+// migrateInvoices importa as faturas de tblinvoices. A fatura é vinculada ao serviço do seu primeiro
+// item de hospedagem, quando houver. Em uma nova execução, apenas o status é atualizado.
+func (m *migrator) migrateInvoices() error {
+	clients, err := m.localClients()
+	if err != nil {
+		return err
+	}
+	services, err := m.loadMap(entityService)
+	if err != nil {
+		return err
+	}
+
+	// Serviço de hospedagem de cada fatura, pelo primeiro item do tipo Hosting.
+	invoiceServices := make(map[int64]int64)
+	itemRows, err := m.source.Query(`SELECT invoiceid, MIN(relid) FROM tblinvoiceitems WHERE type = 'Hosting' AND relid > 0 GROUP BY invoiceid`)
+	if err != nil {
+		return err
+	}
+	for itemRows.Next() {
+		var invoiceID, hostingID int64
+		if err := itemRows.Scan(&invoiceID, &hostingID); err != nil {
+			itemRows.Close()
+			return err
+		}
+		invoiceServices[invoiceID] = hostingID
+	}
+	itemRows.Close()
+	if err := itemRows.Err(); err != nil {
+		return err
+	}
+
+	rows, err := m.source.Query(`SELECT id, userid, date, duedate, total, status FROM tblinvoices ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var invoices []whmcsInvoice
+	for rows.Next() {
+		var inv whmcsInvoice
+		if err := rows.Scan(&inv.ID, &inv.ClientID, &inv.Date, &inv.DueDate, &inv.Total, &inv.Status); err != nil {
+			return err
+		}
+		invoices = append(invoices, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, inv := range invoices {
+		m.record(entityInvoice, inv.ID, func() (outcome, error) {
+			client, ok := clients[inv.ClientID]
+			if !ok {
+				return 0, fmt.Errorf("cliente #%d do WHMCS não foi importado", inv.ClientID)
+			}
+			return m.migrateInvoice(inv, client, services[invoiceServices[inv.ID]])
+		})
+	}
+	return nil
+}
+
+func (m *migrator) migrateInvoice(inv whmcsInvoice, client localClient, serviceID int64) (outcome, error) {
+	status := whmcsInvoiceStatus(inv.Status)
+
+	localID, err := m.lookup(entityInvoice, inv.ID)
+	if err != nil {
+		return 0, err
+	}
+	if localID != 0 {
+		_, err := m.tx.Exec(`UPDATE invoices SET status = $1 WHERE id = $2`, status, localID)
+		return outcomeUpdated, err
+	}
+
+	issueDate := dateOr(inv.Date, time.Now())
+	var invoiceID int64
+	err = m.tx.QueryRow(`INSERT INTO invoices (user_id, service_id, issue_date, due_date, total_amount, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		client.UserID, sql.NullInt64{Int64: serviceID, Valid: serviceID != 0}, issueDate, dateOr(inv.DueDate, issueDate), inv.Total, status).Scan(&invoiceID)
+	if err != nil {
+		return 0, err
+	}
+	return outcomeCreated, m.remember(entityInvoice, inv.ID, invoiceID)
+}
+
+// migrateInvoiceItems importa os itens das faturas já importadas. Itens de desconto e crédito
+// mantêm o valor negativo do WHMCS.
+func (m *migrator) migrateInvoiceItems() error {
+	invoices, err := m.loadMap(entityInvoice)
+	if err != nil {
+		return err
+	}
+	services, err := m.loadMap(entityService)
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.source.Query(`SELECT id, invoiceid, type, relid, description, amount FROM tblinvoiceitems ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type whmcsInvoiceItem struct {
+		ID          int64
+		InvoiceID   int64
+		Type        string
+		RelID       int64
+		Description string
+		Amount      float64
+	}
+	var items []whmcsInvoiceItem
+	for rows.Next() {
+		var it whmcsInvoiceItem
+		if err := rows.Scan(&it.ID, &it.InvoiceID, &it.Type, &it.RelID, &it.Description, &it.Amount); err != nil {
+			return err
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, it := range items {
+		m.record(entityInvoiceItem, it.ID, func() (outcome, error) {
+			invoiceID, ok := invoices[it.InvoiceID]
+			if !ok {
+				return 0, fmt.Errorf("fatura #%d do WHMCS não foi importada", it.InvoiceID)
+			}
+			localID, err := m.lookup(entityInvoiceItem, it.ID)
+			if err != nil || localID != 0 {
+				return outcomeSkipped, err
+			}
+
+			var serviceID sql.NullInt64
+			if it.Type == "Hosting" && services[it.RelID] != 0 {
+				serviceID = sql.NullInt64{Int64: services[it.RelID], Valid: true}
+			}
+
+			var itemID int64
+			err = m.tx.QueryRow(`INSERT INTO invoice_items (invoice_id, service_id, description, amount, item_type) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+				invoiceID, serviceID, it.Description, it.Amount, models.InvoiceItemTypeService).Scan(&itemID)
+			if err != nil {
+				return 0, err
+			}
+			return outcomeCreated, m.remember(entityInvoiceItem, it.ID, itemID)
+		})
+	}
+	return nil
+}
+
+// migrateTransactions importa os lançamentos financeiros de tblaccounts (pagamentos e estornos).
+func (m *migrator) migrateTransactions() error {
+	clients, err := m.localClients()
+	if err != nil {
+		return err
+	}
+	invoices, err := m.loadMap(entityInvoice)
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.source.Query(`SELECT id, userid, invoiceid, gateway, transid, description, amountin, amountout, fees, date FROM tblaccounts ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type whmcsTransaction struct {
+		ID          int64
+		ClientID    int64
+		InvoiceID   int64
+		Gateway     string
+		TransID     string
+		Description string
+		AmountIn    float64
+		AmountOut   float64
+		Fees        float64
+		Date        sql.NullTime
+	}
+	var transactions []whmcsTransaction
+	for rows.Next() {
+		var t whmcsTransaction
+		if err := rows.Scan(&t.ID, &t.ClientID, &t.InvoiceID, &t.Gateway, &t.TransID, &t.Description, &t.AmountIn, &t.AmountOut, &t.Fees, &t.Date); err != nil {
+			return err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range transactions {
+		m.record(entityTransaction, t.ID, func() (outcome, error) {
+			localID, err := m.lookup(entityTransaction, t.ID)
+			if err != nil || localID != 0 {
+				return outcomeSkipped, err
+			}
+			// Lançamentos sem cliente (ex: despesas gerais) não pertencem a nenhuma fatura da plataforma.
+			client, ok := clients[t.ClientID]
+			if !ok {
+				return 0, fmt.Errorf("cliente #%d do WHMCS não foi importado", t.ClientID)
+			}
+			invoiceID := sql.NullInt64{Int64: invoices[t.InvoiceID], Valid: invoices[t.InvoiceID] != 0}
+
+			var transactionID int64
+			err = m.tx.QueryRow(`INSERT INTO transactions (user_id, invoice_id, gateway, transaction_id, description, amount_in, amount_out, fees, paid_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+				client.UserID, invoiceID, nullString(t.Gateway), nullString(t.TransID), nullString(t.Description),
+				t.AmountIn, t.AmountOut, t.Fees, dateOr(t.Date, time.Now())).Scan(&transactionID)
+			if err != nil {
+				return 0, err
+			}
+			return outcomeCreated, m.remember(entityTransaction, t.ID, transactionID)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type whmcsClient struct {
+	ID          int64
+	FirstName   string
+	LastName    string
+	CompanyName string
+	Email       string
+	Address1    string
+	Address2    string
+	City        string
+	State       string
+	Postcode    string
+	Country     string
+	Phone       string
+	TaxID       string
+	Password    string
+	Status      string
+}
+
+// migrateClients cria um usuário e um cliente para cada registro de tblclients. Clientes cujo e-mail
+// já existe na plataforma são associados ao usuário existente, sem alterar os seus dados.
+func (m *migrator) migrateClients() error {
+	rows, err := m.source.Query(`SELECT id, firstname, lastname, companyname, email, address1, address2, city, state,
+		postcode, country, phonenumber, tax_id, password, status FROM tblclients ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var clients []whmcsClient
+	for rows.Next() {
+		var c whmcsClient
+		if err := rows.Scan(&c.ID, &c.FirstName, &c.LastName, &c.CompanyName, &c.Email, &c.Address1, &c.Address2, &c.City,
+			&c.State, &c.Postcode, &c.Country, &c.Phone, &c.TaxID, &c.Password, &c.Status); err != nil {
+			return err
+		}
+		clients = append(clients, c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range clients {
+		m.record(entityClient, c.ID, func() (outcome, error) { return m.migrateClient(c) })
+	}
+	return nil
+}
+
+func (m *migrator) migrateClient(c whmcsClient) (outcome, error) {
+	localID, err := m.lookup(entityClient, c.ID)
+	if err != nil || localID != 0 {
+		return outcomeSkipped, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(c.Email))
+
+	// O cliente já se cadastrou na plataforma: apenas associa os registros do WHMCS a ele.
+	var clientID int64
+	err = m.tx.QueryRow(`SELECT id FROM clients WHERE LOWER(email) = $1 ORDER BY id LIMIT 1`, email).Scan(&clientID)
+	if err == nil {
+		return outcomeLinked, m.remember(entityClient, c.ID, clientID)
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	passwordHash, err := migratedPasswordHash(c.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID int64
+	err = m.tx.QueryRow(`SELECT id FROM users WHERE LOWER(email) = $1`, email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		err = m.tx.QueryRow(`INSERT INTO users (first_name, last_name, email, password_hash, is_active) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			c.FirstName, c.LastName, email, passwordHash, c.Status != "Closed").Scan(&userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	address := strings.TrimSpace(strings.TrimSpace(c.Address1) + " " + strings.TrimSpace(c.Address2))
+	contactName := strings.TrimSpace(c.FirstName + " " + c.LastName)
+	err = m.tx.QueryRow(`INSERT INTO clients (user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		userID, nullString(c.CompanyName), nullString(contactName), email, nullString(c.Phone), nullString(address),
+		nullString(c.City), nullString(c.State), nullString(c.Postcode), nullString(c.Country), nullString(c.TaxID)).Scan(&clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	return outcomeCreated, m.remember(entityClient, c.ID, clientID)
+}
+
+// migratedPasswordHash reaproveita os hashes bcrypt das versões recentes do WHMCS. Hashes em outros
+// formatos (MD5 das versões antigas) são substituídos por uma senha aleatória, e o cliente precisará
+// redefinir a senha no primeiro acesso.
+func migratedPasswordHash(whmcsHash string) (string, error) {
+	if strings.HasPrefix(whmcsHash, "$2y$") || strings.HasPrefix(whmcsHash, "$2a$") || strings.HasPrefix(whmcsHash, "$2b$") {
+		return whmcsHash, nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(random)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// localClient identifica, na plataforma, um cliente importado do WHMCS.
+type localClient struct {
+	ClientID int64
+	UserID   int64
+}
+
+// localClients retorna o cliente da plataforma de cada cliente do WHMCS importado.
+func (m *migrator) localClients() (map[int64]localClient, error) {
+	rows, err := m.tx.Query(`SELECT idm.whmcs_id, c.id, c.user_id FROM whmcs_id_map idm JOIN clients c ON c.id = idm.local_id WHERE idm.entity = $1`, entityClient)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]localClient)
+	for rows.Next() {
+		var whmcsID, clientID, userID int64
+		if err := rows.Scan(&whmcsID, &clientID, &userID); err != nil {
+			return nil, err
+		}
+		ids[whmcsID] = localClient{ClientID: clientID, UserID: userID}
+	}
+	return ids, rows.Err()
+}
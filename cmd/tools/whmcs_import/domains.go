@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"hosting-backend/internal/models"
+)
+
+type whmcsDomain struct {
+	ID         int64
+	ClientID   int64
+	Type       string
+	Domain     string
+	Registrar  string
+	ExpiryDate sql.NullTime
+	Status     string
+}
+
+// migrateDomains importa os domínios de tbldomains. O domínio é vinculado ao serviço de hospedagem
+// do mesmo cliente com o mesmo domínio, quando houver. Em uma nova execução, o status e a
+// expiração dos domínios já importados são atualizados.
+func (m *migrator) migrateDomains() error {
+	clients, err := m.localClients()
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.source.Query(`SELECT id, userid, type, domain, registrar, expirydate, status FROM tbldomains ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var domains []whmcsDomain
+	for rows.Next() {
+		var d whmcsDomain
+		if err := rows.Scan(&d.ID, &d.ClientID, &d.Type, &d.Domain, &d.Registrar, &d.ExpiryDate, &d.Status); err != nil {
+			return err
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		m.record(entityDomain, d.ID, func() (outcome, error) {
+			client, ok := clients[d.ClientID]
+			if !ok {
+				return 0, fmt.Errorf("cliente #%d do WHMCS não foi importado", d.ClientID)
+			}
+			return m.migrateDomain(d, client)
+		})
+	}
+	return nil
+}
+
+func (m *migrator) migrateDomain(d whmcsDomain, client localClient) (outcome, error) {
+	name := strings.ToLower(strings.TrimSpace(d.Domain))
+	status := whmcsDomainStatus(d.Status)
+	expiresAt := whmcsDate(d.ExpiryDate)
+
+	localID, err := m.lookup(entityDomain, d.ID)
+	if err != nil {
+		return 0, err
+	}
+	if localID != 0 {
+		_, err := m.tx.Exec(`UPDATE domains SET status = $1, expires_at = $2 WHERE id = $3`, status, expiresAt, localID)
+		return outcomeUpdated, err
+	}
+
+	domainType := models.DomainTypeRegister
+	if d.Type == "Transfer" {
+		domainType = models.DomainTypeTransfer
+	}
+
+	var serviceID sql.NullInt64
+	err = m.tx.QueryRow(`SELECT id FROM services WHERE user_id = $1 AND LOWER(domain) = $2 ORDER BY id LIMIT 1`, client.UserID, name).Scan(&serviceID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	var domainID int64
+	err = m.tx.QueryRow(`INSERT INTO domains (client_id, service_id, domain_name, type, status, provider, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		client.ClientID, serviceID, name, domainType, status, nullString(strings.ToLower(d.Registrar)), expiresAt).Scan(&domainID)
+	if err != nil {
+		return 0, err
+	}
+	return outcomeCreated, m.remember(entityDomain, d.ID, domainID)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"hosting-backend/internal/database"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+)
+
+// Este programa migra os dados de uma instalação do WHMCS para a plataforma: clientes, produtos (planos),
+// serviços de hospedagem, domínios, faturas com itens e transações.
+//
+// A origem é o banco MySQL do WHMCS. Para migrar a partir de um dump, importe-o antes em um MySQL local:
+//
+//	mysql -u root whmcs < whmcs_dump.sql
+//	go run ./cmd/tools/whmcs_import -mysql "root:senha@tcp(localhost:3306)/whmcs?parseTime=true"
+//
+// O destino é o PostgreSQL configurado nas variáveis DB_* (.env). Cada registro importado é gravado em
+// whmcs_id_map, então a importação pode ser executada novamente: registros já mapeados têm apenas o
+// status atualizado. Com -dry-run, tudo é executado em uma transação desfeita ao final.
+func main() {
+	_ = godotenv.Load()
+
+	dsn := flag.String("mysql", os.Getenv("WHMCS_DSN"), "DSN do banco MySQL do WHMCS (padrão: WHMCS_DSN)")
+	currencyID := flag.Int("currency", 1, "ID da moeda do WHMCS usada nos preços dos produtos")
+	dryRun := flag.Bool("dry-run", false, "executa a migração e desfaz todas as alterações ao final")
+	reportPath := flag.String("report", "", "grava o relatório de conciliação em JSON no caminho informado")
+	flag.Parse()
+
+	if *dsn == "" {
+		log.Fatalf("Uso: go run ./cmd/tools/whmcs_import -mysql <dsn> [-currency 1] [-dry-run] [-report relatorio.json]")
+	}
+
+	source, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatalf("Erro ao abrir a conexão com o WHMCS: %v", err)
+	}
+	defer source.Close()
+	if err := source.Ping(); err != nil {
+		log.Fatalf("Erro ao conectar ao banco do WHMCS: %v", err)
+	}
+
+	target, err := database.ConnectPostgres()
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco da plataforma: %v", err)
+	}
+	defer target.Close()
+
+	tx, err := target.Begin()
+	if err != nil {
+		log.Fatalf("Erro ao iniciar a transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	m := &migrator{
+		source:     source,
+		tx:         tx,
+		currencyID: *currencyID,
+		report:     newReport(*dryRun),
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"clientes", m.migrateClients},
+		{"produtos", m.migrateProducts},
+		{"servidores", m.mapServers},
+		{"serviços", m.migrateServices},
+		{"domínios", m.migrateDomains},
+		{"faturas", m.migrateInvoices},
+		{"itens de fatura", m.migrateInvoiceItems},
+		{"transações", m.migrateTransactions},
+	}
+	for _, step := range steps {
+		log.Printf("[WHMCS Import] Migrando %s...", step.name)
+		if err := step.run(); err != nil {
+			log.Fatalf("[WHMCS Import] Erro ao migrar %s: %v", step.name, err)
+		}
+	}
+
+	if err := m.reconcile(); err != nil {
+		log.Fatalf("[WHMCS Import] Erro ao conciliar os dados: %v", err)
+	}
+
+	if *dryRun {
+		log.Println("[WHMCS Import] Modo simulação: desfazendo todas as alterações.")
+	} else if err := tx.Commit(); err != nil {
+		log.Fatalf("[WHMCS Import] Erro ao gravar a migração: %v", err)
+	}
+
+	m.report.Print(os.Stdout)
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(m.report, "", "  ")
+		if err != nil {
+			log.Fatalf("Erro ao gerar o relatório: %v", err)
+		}
+		if err := os.WriteFile(*reportPath, data, 0o644); err != nil {
+			log.Fatalf("Erro ao gravar o relatório: %v", err)
+		}
+	}
+}
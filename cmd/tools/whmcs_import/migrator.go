@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+)
+
+// Entidades registradas em whmcs_id_map.
+const (
+	entityClient      = "client"
+	entityProduct     = "product"
+	entityServer      = "server"
+	entityService     = "service"
+	entityDomain      = "domain"
+	entityInvoice     = "invoice"
+	entityInvoiceItem = "invoice_item"
+	entityTransaction = "transaction"
+)
+
+// migrator mantém as conexões e o estado de uma execução da migração.
+type migrator struct {
+	source     *sql.DB // Banco MySQL do WHMCS
+	tx         *sql.Tx // Transação única no banco da plataforma
+	currencyID int
+	report     *Report
+	savepoints int
+}
+
+// lookup retorna o ID local de um registro do WHMCS já importado, ou 0 se ainda não houver mapeamento.
+func (m *migrator) lookup(entity string, whmcsID int64) (int64, error) {
+	var localID int64
+	err := m.tx.QueryRow(`SELECT local_id FROM whmcs_id_map WHERE entity = $1 AND whmcs_id = $2`, entity, whmcsID).Scan(&localID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return localID, err
+}
+
+// remember grava (ou atualiza) o mapeamento entre o ID do WHMCS e o ID local.
+func (m *migrator) remember(entity string, whmcsID, localID int64) error {
+	_, err := m.tx.Exec(`INSERT INTO whmcs_id_map (entity, whmcs_id, local_id) VALUES ($1, $2, $3)
+		ON CONFLICT (entity, whmcs_id) DO UPDATE SET local_id = EXCLUDED.local_id, updated_at = NOW()`,
+		entity, whmcsID, localID)
+	return err
+}
+
+// loadMap carrega todos os mapeamentos de uma entidade.
+func (m *migrator) loadMap(entity string) (map[int64]int64, error) {
+	rows, err := m.tx.Query(`SELECT whmcs_id, local_id FROM whmcs_id_map WHERE entity = $1`, entity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]int64)
+	for rows.Next() {
+		var whmcsID, localID int64
+		if err := rows.Scan(&whmcsID, &localID); err != nil {
+			return nil, err
+		}
+		ids[whmcsID] = localID
+	}
+	return ids, rows.Err()
+}
+
+// record executa a migração de um registro dentro de um savepoint, para que a falha de um registro
+// não invalide a transação inteira. O resultado é contabilizado no relatório da entidade.
+func (m *migrator) record(entity string, whmcsID int64, fn func() (outcome, error)) {
+	m.savepoints++
+	name := fmt.Sprintf("whmcs_%d", m.savepoints)
+
+	stats := m.report.entity(entity)
+	stats.Source++
+
+	if _, err := m.tx.Exec("SAVEPOINT " + name); err != nil {
+		stats.fail(whmcsID, err)
+		return
+	}
+
+	result, err := fn()
+	if err != nil {
+		if _, rbErr := m.tx.Exec("ROLLBACK TO SAVEPOINT " + name); rbErr != nil {
+			log.Fatalf("[WHMCS Import] Erro ao desfazer o savepoint: %v", rbErr)
+		}
+		stats.fail(whmcsID, err)
+		return
+	}
+
+	if _, err := m.tx.Exec("RELEASE SAVEPOINT " + name); err != nil {
+		stats.fail(whmcsID, err)
+		return
+	}
+	stats.count(result)
+}
+
+// --- Conversões de valores do WHMCS ---
+
+// whmcsBillingCycle converte o ciclo de faturamento do WHMCS (ex: "Semi-Annually").
+func whmcsBillingCycle(cycle string) models.BillingCycle {
+	switch strings.ToLower(strings.ReplaceAll(cycle, "-", "")) {
+	case "monthly":
+		return models.Monthly
+	case "quarterly":
+		return models.Quarterly
+	case "semiannually":
+		return models.Semiannually
+	case "annually":
+		return models.Annually
+	case "biennially":
+		return models.Biennially
+	case "triennially":
+		return models.Triennially
+	case "free account", "free":
+		return models.Free
+	default:
+		return models.OneTime
+	}
+}
+
+// whmcsServiceStatus converte o status de um serviço (tblhosting.domainstatus).
+func whmcsServiceStatus(status string) models.ServiceStatus {
+	switch status {
+	case "Active":
+		return models.ServiceStatusActive
+	case "Suspended":
+		return models.ServiceStatusSuspended
+	case "Pending":
+		return models.ServiceStatusPending
+	default: // Terminated, Cancelled, Fraud, Completed
+		return models.ServiceStatusTerminated
+	}
+}
+
+// whmcsDomainStatus converte o status de um domínio (tbldomains.status).
+func whmcsDomainStatus(status string) models.DomainStatus {
+	switch status {
+	case "Active":
+		return models.StatusActive
+	case "Pending", "Pending Registration", "Pending Transfer":
+		return models.StatusPendingProvisioning
+	default: // Expired, Cancelled, Fraud, Transferred Away, Redemption, Grace
+		return models.StatusCancelled
+	}
+}
+
+// whmcsInvoiceStatus converte o status de uma fatura (tblinvoices.status).
+func whmcsInvoiceStatus(status string) models.InvoiceStatus {
+	switch status {
+	case "Paid":
+		return models.InvoiceStatusPaid
+	case "Unpaid", "Payment Pending", "Collections", "Draft":
+		return models.InvoiceStatusUnpaid
+	default: // Cancelled, Refunded
+		return models.InvoiceStatusVoid
+	}
+}
+
+// whmcsDate trata as datas zeradas ("0000-00-00") usadas pelo WHMCS no lugar de NULL.
+func whmcsDate(t sql.NullTime) sql.NullTime {
+	if !t.Valid || t.Time.Year() <= 1 {
+		return sql.NullTime{}
+	}
+	return t
+}
+
+// dateOr retorna a data do WHMCS ou o valor padrão, quando a data for vazia.
+func dateOr(t sql.NullTime, fallback time.Time) time.Time {
+	if t = whmcsDate(t); t.Valid {
+		return t.Time
+	}
+	return fallback
+}
+
+func nullString(s string) sql.NullString {
+	s = strings.TrimSpace(s)
+	return sql.NullString{String: s, Valid: s != ""}
+}
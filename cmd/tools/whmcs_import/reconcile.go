@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+)
+
+// reconcile compara as contagens e os valores do WHMCS com os registros mapeados na plataforma.
+// Diferenças apontam registros que falharam ou dados alterados na plataforma após a importação.
+func (m *migrator) reconcile() error {
+	counts := []struct {
+		name   string
+		entity string
+		source string
+	}{
+		{"Clientes", entityClient, `SELECT COUNT(*) FROM tblclients`},
+		{"Serviços de hospedagem", entityService, `SELECT COUNT(*) FROM tblhosting h JOIN tblproducts p ON p.id = h.packageid WHERE p.type IN ('hostingaccount', 'reselleraccount')`},
+		{"Domínios", entityDomain, `SELECT COUNT(*) FROM tbldomains`},
+		{"Faturas", entityInvoice, `SELECT COUNT(*) FROM tblinvoices`},
+		{"Itens de fatura", entityInvoiceItem, `SELECT COUNT(*) FROM tblinvoiceitems`},
+		{"Transações", entityTransaction, `SELECT COUNT(*) FROM tblaccounts`},
+	}
+	for _, c := range counts {
+		var source, local int
+		if err := m.source.QueryRow(c.source).Scan(&source); err != nil {
+			return fmt.Errorf("falha ao contar %s no WHMCS: %w", c.name, err)
+		}
+		if err := m.tx.QueryRow(`SELECT COUNT(*) FROM whmcs_id_map WHERE entity = $1`, c.entity).Scan(&local); err != nil {
+			return fmt.Errorf("falha ao contar %s importados: %w", c.name, err)
+		}
+		m.report.addCheck(c.name, fmt.Sprint(source), fmt.Sprint(local))
+	}
+
+	sums := []struct {
+		name   string
+		source string
+		local  string
+	}{
+		{
+			"Total faturado",
+			`SELECT COALESCE(SUM(total), 0) FROM tblinvoices`,
+			`SELECT COALESCE(SUM(i.total_amount), 0) FROM invoices i JOIN whmcs_id_map idm ON idm.local_id = i.id AND idm.entity = 'invoice'`,
+		},
+		{
+			"Total de faturas pagas",
+			`SELECT COALESCE(SUM(total), 0) FROM tblinvoices WHERE status = 'Paid'`,
+			`SELECT COALESCE(SUM(i.total_amount), 0) FROM invoices i JOIN whmcs_id_map idm ON idm.local_id = i.id AND idm.entity = 'invoice' WHERE i.status = 'paid'`,
+		},
+		{
+			"Saldo das transações",
+			`SELECT COALESCE(SUM(amountin - amountout), 0) FROM tblaccounts`,
+			`SELECT COALESCE(SUM(t.amount_in - t.amount_out), 0) FROM transactions t JOIN whmcs_id_map idm ON idm.local_id = t.id AND idm.entity = 'transaction'`,
+		},
+	}
+	for _, s := range sums {
+		var source, local float64
+		if err := m.source.QueryRow(s.source).Scan(&source); err != nil {
+			return fmt.Errorf("falha ao somar '%s' no WHMCS: %w", s.name, err)
+		}
+		if err := m.tx.QueryRow(s.local).Scan(&local); err != nil {
+			return fmt.Errorf("falha ao somar '%s' na plataforma: %w", s.name, err)
+		}
+		m.report.addCheck(s.name, fmt.Sprintf("%.2f", source), fmt.Sprintf("%.2f", local))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// outcome é o resultado da migração de um registro.
+type outcome int
+
+const (
+	outcomeCreated outcome = iota // Registro criado na plataforma
+	outcomeLinked                 // Associado a um registro que já existia na plataforma (ex: mesmo e-mail)
+	outcomeUpdated                // Já importado; status atualizado
+	outcomeSkipped                // Já importado ou ignorado de propósito
+)
+
+// Quantidade máxima de falhas detalhadas por entidade no relatório.
+const maxReportedFailures = 50
+
+// Failure descreve um registro do WHMCS que não pôde ser migrado.
+type Failure struct {
+	WhmcsID int64  `json:"whmcs_id"`
+	Error   string `json:"error"`
+}
+
+// EntityStats é a contagem de uma entidade na execução.
+type EntityStats struct {
+	Entity   string    `json:"entity"`
+	Source   int       `json:"source"` // Registros lidos do WHMCS
+	Created  int       `json:"created"`
+	Linked   int       `json:"linked"`
+	Updated  int       `json:"updated"`
+	Skipped  int       `json:"skipped"`
+	Failed   int       `json:"failed"`
+	Failures []Failure `json:"failures,omitempty"`
+}
+
+func (s *EntityStats) count(o outcome) {
+	switch o {
+	case outcomeCreated:
+		s.Created++
+	case outcomeLinked:
+		s.Linked++
+	case outcomeUpdated:
+		s.Updated++
+	default:
+		s.Skipped++
+	}
+}
+
+func (s *EntityStats) fail(whmcsID int64, err error) {
+	s.Failed++
+	if len(s.Failures) < maxReportedFailures {
+		s.Failures = append(s.Failures, Failure{WhmcsID: whmcsID, Error: err.Error()})
+	}
+}
+
+// Check é uma verificação de conciliação entre o WHMCS e a plataforma.
+type Check struct {
+	Name  string `json:"name"`
+	Whmcs string `json:"whmcs"`
+	Local string `json:"local"`
+	OK    bool   `json:"ok"`
+}
+
+// Report é o relatório de conciliação de uma execução da migração.
+type Report struct {
+	StartedAt time.Time      `json:"started_at"`
+	DryRun    bool           `json:"dry_run"`
+	Entities  []*EntityStats `json:"entities"`
+	Checks    []Check        `json:"checks"`
+}
+
+func newReport(dryRun bool) *Report {
+	return &Report{StartedAt: time.Now(), DryRun: dryRun}
+}
+
+// entity retorna as estatísticas da entidade, criando-as na primeira vez.
+func (r *Report) entity(name string) *EntityStats {
+	for _, e := range r.Entities {
+		if e.Entity == name {
+			return e
+		}
+	}
+	e := &EntityStats{Entity: name}
+	r.Entities = append(r.Entities, e)
+	return e
+}
+
+func (r *Report) addCheck(name, whmcs, local string) {
+	r.Checks = append(r.Checks, Check{Name: name, Whmcs: whmcs, Local: local, OK: whmcs == local})
+}
+
+// Print escreve o relatório em formato de tabela.
+func (r *Report) Print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	mode := "gravação"
+	if r.DryRun {
+		mode = "simulação (nada foi gravado)"
+	}
+	fmt.Fprintf(w, "Migração WHMCS iniciada em %s, modo %s\n\n", r.StartedAt.Format("02/01/2006 15:04"), mode)
+
+	fmt.Fprintln(w, "ENTIDADE\tWHMCS\tCRIADOS\tASSOCIADOS\tATUALIZADOS\tIGNORADOS\tFALHAS")
+	for _, e := range r.Entities {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", e.Entity, e.Source, e.Created, e.Linked, e.Updated, e.Skipped, e.Failed)
+	}
+
+	fmt.Fprintln(w, "\nCONCILIAÇÃO\tWHMCS\tPLATAFORMA\tOK")
+	for _, c := range r.Checks {
+		status := "sim"
+		if !c.OK {
+			status = "NÃO"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, c.Whmcs, c.Local, status)
+	}
+	w.Flush()
+
+	for _, e := range r.Entities {
+		for _, f := range e.Failures {
+			fmt.Fprintf(out, "FALHA %s #%d: %s\n", e.Entity, f.WhmcsID, f.Error)
+		}
+	}
+}
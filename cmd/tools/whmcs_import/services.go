@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+)
+
+type whmcsProduct struct {
+	ID          int64
+	Name        string
+	Description string
+	Hidden      bool
+	Package     string // configoption1: nome do pacote no WHM
+	PayType     string
+	Prices      map[models.BillingCycle]float64
+}
+
+// Ordem de preferência do ciclo de faturamento usado como preço do plano.
+var productCycles = []models.BillingCycle{
+	models.Monthly, models.Quarterly, models.Semiannually, models.Annually, models.Biennially, models.Triennially,
+}
+
+// migrateProducts cria um plano para cada produto de hospedagem do WHMCS. O preço e o ciclo do plano
+// são os do menor ciclo com preço definido na moeda escolhida.
+func (m *migrator) migrateProducts() error {
+	rows, err := m.source.Query(`SELECT p.id, p.name, p.description, p.hidden, p.configoption1, p.paytype,
+		COALESCE(pr.monthly, -1), COALESCE(pr.quarterly, -1), COALESCE(pr.semiannually, -1),
+		COALESCE(pr.annually, -1), COALESCE(pr.biennially, -1), COALESCE(pr.triennially, -1)
+		FROM tblproducts p
+		LEFT JOIN tblpricing pr ON pr.type = 'product' AND pr.relid = p.id AND pr.currency = ?
+		WHERE p.type IN ('hostingaccount', 'reselleraccount')
+		ORDER BY p.id`, m.currencyID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var products []whmcsProduct
+	for rows.Next() {
+		p := whmcsProduct{Prices: make(map[models.BillingCycle]float64)}
+		var prices [6]float64
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Hidden, &p.Package, &p.PayType,
+			&prices[0], &prices[1], &prices[2], &prices[3], &prices[4], &prices[5]); err != nil {
+			return err
+		}
+		// O WHMCS usa -1 para ciclos desativados.
+		for i, cycle := range productCycles {
+			if prices[i] >= 0 {
+				p.Prices[cycle] = prices[i]
+			}
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		m.record(entityProduct, p.ID, func() (outcome, error) { return m.migrateProduct(p) })
+	}
+	return nil
+}
+
+func (m *migrator) migrateProduct(p whmcsProduct) (outcome, error) {
+	localID, err := m.lookup(entityProduct, p.ID)
+	if err != nil || localID != 0 {
+		return outcomeSkipped, err
+	}
+
+	cycle, price := models.Free, 0.0
+	switch strings.ToLower(p.PayType) {
+	case "free":
+	case "onetime":
+		cycle, price = models.OneTime, p.Prices[models.Monthly]
+	default:
+		for _, c := range productCycles {
+			if v, ok := p.Prices[c]; ok {
+				cycle, price = c, v
+				break
+			}
+		}
+	}
+
+	status := models.PlanStatusActive
+	if p.Hidden {
+		status = models.PlanStatusHidden
+	}
+
+	var planID int64
+	err = m.tx.QueryRow(`INSERT INTO plans (name, description, category, price, billing_cycle, features, whm_package_name, status, placement_policy, server_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		p.Name, nullString(p.Description), "hosting", price, cycle, "{}", nullString(p.Package), status,
+		models.PlacementPolicyDefault, models.ServerTypeCPanel).Scan(&planID)
+	if err != nil {
+		return 0, err
+	}
+	return outcomeCreated, m.remember(entityProduct, p.ID, planID)
+}
+
+// mapServers associa os servidores do WHMCS aos servidores do pool com o mesmo hostname ou IP.
+// Servidores sem correspondência não são criados: os serviços deles ficam sem server_id.
+func (m *migrator) mapServers() error {
+	rows, err := m.source.Query(`SELECT id, hostname, ipaddress FROM tblservers ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type whmcsServer struct {
+		ID       int64
+		Hostname string
+		IP       string
+	}
+	var servers []whmcsServer
+	for rows.Next() {
+		var s whmcsServer
+		if err := rows.Scan(&s.ID, &s.Hostname, &s.IP); err != nil {
+			return err
+		}
+		servers = append(servers, s)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range servers {
+		m.record(entityServer, s.ID, func() (outcome, error) {
+			var serverID int64
+			err := m.tx.QueryRow(`SELECT id FROM servers WHERE LOWER(hostname) = LOWER($1) OR (ip_address != '' AND ip_address = $2) ORDER BY id LIMIT 1`,
+				s.Hostname, s.IP).Scan(&serverID)
+			if err == sql.ErrNoRows {
+				return 0, fmt.Errorf("nenhum servidor cadastrado com o hostname '%s' ou IP '%s'", s.Hostname, s.IP)
+			}
+			if err != nil {
+				return 0, err
+			}
+			return outcomeLinked, m.remember(entityServer, s.ID, serverID)
+		})
+	}
+	return nil
+}
+
+type whmcsService struct {
+	ID           int64
+	ClientID     int64
+	ProductID    int64
+	ServerID     int64
+	Domain       string
+	Username     string
+	Amount       float64
+	BillingCycle string
+	NextDueDate  sql.NullTime
+	Status       string
+}
+
+// migrateServices cria um serviço para cada conta de hospedagem de tblhosting. Em uma nova execução,
+// os serviços já importados têm o status, o valor e o vencimento atualizados.
+func (m *migrator) migrateServices() error {
+	clients, err := m.localClients()
+	if err != nil {
+		return err
+	}
+	plans, err := m.loadMap(entityProduct)
+	if err != nil {
+		return err
+	}
+	servers, err := m.loadMap(entityServer)
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.source.Query(`SELECT h.id, h.userid, h.packageid, h.server, h.domain, h.username, h.amount, h.billingcycle, h.nextduedate, h.domainstatus
+		FROM tblhosting h JOIN tblproducts p ON p.id = h.packageid
+		WHERE p.type IN ('hostingaccount', 'reselleraccount')
+		ORDER BY h.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var hostings []whmcsService
+	for rows.Next() {
+		var h whmcsService
+		if err := rows.Scan(&h.ID, &h.ClientID, &h.ProductID, &h.ServerID, &h.Domain, &h.Username, &h.Amount, &h.BillingCycle, &h.NextDueDate, &h.Status); err != nil {
+			return err
+		}
+		hostings = append(hostings, h)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, h := range hostings {
+		m.record(entityService, h.ID, func() (outcome, error) {
+			client, ok := clients[h.ClientID]
+			if !ok {
+				return 0, fmt.Errorf("cliente #%d do WHMCS não foi importado", h.ClientID)
+			}
+			planID, ok := plans[h.ProductID]
+			if !ok {
+				return 0, fmt.Errorf("produto #%d do WHMCS não foi importado", h.ProductID)
+			}
+			serverID := sql.NullInt64{Int64: servers[h.ServerID], Valid: servers[h.ServerID] != 0}
+			return m.migrateService(h, client.UserID, planID, serverID)
+		})
+	}
+	return nil
+}
+
+func (m *migrator) migrateService(h whmcsService, userID, planID int64, serverID sql.NullInt64) (outcome, error) {
+	status := whmcsServiceStatus(h.Status)
+	nextDueDate := dateOr(h.NextDueDate, time.Now())
+
+	localID, err := m.lookup(entityService, h.ID)
+	if err != nil {
+		return 0, err
+	}
+	if localID != 0 {
+		_, err := m.tx.Exec(`UPDATE services SET status = $1, price = $2, next_due_date = $3, updated_at = NOW() WHERE id = $4`,
+			status, h.Amount, nextDueDate, localID)
+		return outcomeUpdated, err
+	}
+
+	var serviceID int64
+	err = m.tx.QueryRow(`INSERT INTO services (user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		userID, planID, strings.ToLower(h.Domain), h.Username, status, h.Amount, whmcsBillingCycle(h.BillingCycle), nextDueDate, serverID).Scan(&serviceID)
+	if err != nil {
+		return 0, err
+	}
+	return outcomeCreated, m.remember(entityService, h.ID, serviceID)
+}
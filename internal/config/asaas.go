@@ -7,10 +7,14 @@ import (
 var (
 	AsaasAPIKey string
 	AsaasBaseURL string
+	// AsaasWebhookToken é o token de autenticação configurado no webhook do Asaas,
+	// enviado por ele no cabeçalho asaas-access-token.
+	AsaasWebhookToken string
 )
 
 // InitAsaas inicializa a configuração da API do Asaas.
 func InitAsaas() {
 	AsaasAPIKey = os.Getenv("ASAAS_API_KEY")
 	AsaasBaseURL = os.Getenv("ASAAS_BASE_URL")
+	AsaasWebhookToken = os.Getenv("ASAAS_WEBHOOK_TOKEN")
 }
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Quantidade máxima de migrações retornadas na listagem.
+const accountMovesListLimit = 100
+
+// MoveServiceHandler inicia a migração da conta de um serviço para outro servidor cPanel.
+// A conta é empacotada na origem e restaurada no destino pelo Transfer Tool do WHM, que acessa a
+// origem por SSH com a senha ou a chave informada.
+// Rota: POST /admin/services/{id}/move
+func MoveServiceHandler(mover *services.AccountMover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serviceID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de serviço inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req services.AccountMoveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		if req.TargetServerID == 0 {
+			http.Error(w, "O servidor de destino é obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		move, err := mover.Start(serviceID, req, adminID, models.GetIP(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrServiceNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrInvalidAccountMove):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(move)
+	}
+}
+
+// GetAccountMovesHandler lista as migrações de contas, da mais recente para a mais antiga.
+// Rota: GET /admin/account-moves
+func GetAccountMovesHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		moves, err := models.GetAllAccountMoves(db, accountMovesListLimit)
+		if err != nil {
+			http.Error(w, "Erro ao buscar as migrações", http.StatusInternalServerError)
+			return
+		}
+		if moves == nil {
+			moves = []models.AccountMove{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(moves)
+	}
+}
+
+// GetAccountMoveHandler retorna uma migração com o histórico de execução dos passos.
+// Rota: GET /admin/account-moves/{id}
+func GetAccountMoveHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		moveID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de migração inválido", http.StatusBadRequest)
+			return
+		}
+
+		move, err := models.GetAccountMoveByID(db, moveID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar a migração", http.StatusInternalServerError)
+			return
+		}
+		if move == nil {
+			http.Error(w, "Migração não encontrada", http.StatusNotFound)
+			return
+		}
+
+		events, err := models.GetAccountMoveEvents(db, moveID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar o histórico da migração", http.StatusInternalServerError)
+			return
+		}
+		if events == nil {
+			events = []models.AccountMoveEvent{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"move":   move,
+			"events": events,
+		})
+	}
+}
+
+// ResumeAccountMoveHandler retoma uma migração com falha a partir do passo em que ela parou.
+// Rota: POST /admin/account-moves/{id}/resume
+func ResumeAccountMoveHandler(mover *services.AccountMover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		moveID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de migração inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		move, err := mover.Resume(moveID, adminID, models.GetIP(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrAccountMoveNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrAccountMoveNotResumable):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Erro ao retomar a migração", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(move)
+	}
+}
@@ -34,20 +34,24 @@ func CreateClientAndProvisionHandler(adminService *services.AdminService) http.H
 			return
 		}
 
-		clientID, err := adminService.CreateClientAndProvisionAccount(&req.Client, req.Domain, req.Username, req.Plan, req.Password)
+		clientID, job, err := adminService.CreateClientAndProvisionAccount(&req.Client, req.Domain, req.Username, req.Plan, req.Password)
 		if err != nil {
 			// O erro do serviço já é bem descritivo.
 			http.Error(w, "Erro no processo de criação e provisionamento: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		// Retorna uma resposta de sucesso
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message":  "Cliente criado e conta provisionada com sucesso.",
+		// O cliente foi criado; a conta é provisionada pela fila de tarefas.
+		response := map[string]interface{}{
+			"message":  "Cliente criado. O provisionamento da conta foi enfileirado.",
 			"clientID": clientID,
-		})
+		}
+		if job != nil {
+			response["jobID"] = job.ID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
 	}
 }
 
@@ -0,0 +1,302 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDomainPricesHandler lista a tabela de preços de domínios (?tld= filtra uma TLD).
+// Rota: GET /admin/domain-pricing/tlds
+func GetDomainPricesHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prices, err := pricing.ListPrices(r.URL.Query().Get("tld"))
+		if err != nil {
+			http.Error(w, "Erro ao buscar a tabela de preços", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prices)
+	}
+}
+
+// UpdateDomainPricesHandler grava os preços e custos de uma TLD por operação e período.
+// Rota: PUT /admin/domain-pricing/tlds/{tld}
+func UpdateDomainPricesHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Prices []services.DomainTLDPriceInput `json:"prices"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		prices, err := pricing.SetTLDPrices(mux.Vars(r)["tld"], req.Prices, adminID, models.GetIP(r))
+		if err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prices)
+	}
+}
+
+// DeleteDomainPricesHandler remove a tabela de preços de uma TLD, que deixa de ser vendida.
+// Rota: DELETE /admin/domain-pricing/tlds/{tld}
+func DeleteDomainPricesHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := pricing.DeleteTLD(mux.Vars(r)["tld"], adminID, models.GetIP(r)); err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDomainPriceQuoteHandler calcula o preço de uma TLD como no checkout, com margem e promoções.
+// Rota: GET /admin/domain-pricing/quote?tld=com.br&operation=register&years=1
+func GetDomainPriceQuoteHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		operation := models.DomainPriceOperation(query.Get("operation"))
+		if operation == "" {
+			operation = models.DomainPriceRegister
+		}
+		years := 1
+		if v := query.Get("years"); v != "" {
+			var err error
+			if years, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Período inválido", http.StatusBadRequest)
+				return
+			}
+		}
+
+		quote, err := pricing.Quote(query.Get("tld"), operation, years)
+		if err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quote)
+	}
+}
+
+// SyncDomainCostsHandler importa os custos do catálogo de domínios da Hostinger.
+// Rota: POST /admin/domain-pricing/sync-costs
+func SyncDomainCostsHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		result, err := pricing.SyncProviderCosts(adminID, models.GetIP(r))
+		if err != nil {
+			http.Error(w, "Erro ao sincronizar os custos: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// GetDomainMarginRulesHandler lista as regras de margem.
+// Rota: GET /admin/domain-pricing/margin-rules
+func GetDomainMarginRulesHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := pricing.ListMarginRules()
+		if err != nil {
+			http.Error(w, "Erro ao buscar as regras de margem", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+// SaveDomainMarginRuleHandler grava a regra de margem de um escopo. Sem tld e operation, a regra
+// vale para todas as TLDs e operações; a regra do mesmo escopo é substituída.
+// Rota: PUT /admin/domain-pricing/margin-rules
+func SaveDomainMarginRuleHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			TLD           string  `json:"tld"`
+			Operation     string  `json:"operation"`
+			MarginPercent float64 `json:"margin_percent"`
+			MarginFixed   float64 `json:"margin_fixed"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		rule := &models.DomainMarginRule{
+			TLD:           sql.NullString{String: req.TLD, Valid: req.TLD != ""},
+			Operation:     sql.NullString{String: req.Operation, Valid: req.Operation != ""},
+			MarginPercent: req.MarginPercent,
+			MarginFixed:   req.MarginFixed,
+		}
+		if err := pricing.SaveMarginRule(rule, adminID, models.GetIP(r)); err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// DeleteDomainMarginRuleHandler remove uma regra de margem.
+// Rota: DELETE /admin/domain-pricing/margin-rules/{id}
+func DeleteDomainMarginRuleHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ruleID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de regra inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := pricing.DeleteMarginRule(ruleID, adminID, models.GetIP(r)); err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetDomainPromotionsHandler lista as promoções vigentes e futuras (?include_ended=true inclui as encerradas).
+// Rota: GET /admin/domain-pricing/promotions
+func GetDomainPromotionsHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		includeEnded, _ := strconv.ParseBool(r.URL.Query().Get("include_ended"))
+		promotions, err := pricing.ListPromotions(includeEnded)
+		if err != nil {
+			http.Error(w, "Erro ao buscar as promoções", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promotions)
+	}
+}
+
+// CreateDomainPromotionHandler cria um preço promocional com período de validade.
+// Rota: POST /admin/domain-pricing/promotions
+func CreateDomainPromotionHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			TLD         string                      `json:"tld"`
+			Operation   models.DomainPriceOperation `json:"operation"`
+			Years       int                         `json:"years"`
+			Price       float64                     `json:"price"`
+			StartsAt    time.Time                   `json:"starts_at"`
+			EndsAt      time.Time                   `json:"ends_at"`
+			Description string                      `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		promotion := &models.DomainPromotion{
+			TLD:         req.TLD,
+			Operation:   req.Operation,
+			Years:       req.Years,
+			Price:       req.Price,
+			StartsAt:    req.StartsAt,
+			EndsAt:      req.EndsAt,
+			Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
+		}
+		if err := pricing.CreatePromotion(promotion, adminID, models.GetIP(r)); err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(promotion)
+	}
+}
+
+// DeleteDomainPromotionHandler remove uma promoção, encerrando-a imediatamente.
+// Rota: DELETE /admin/domain-pricing/promotions/{id}
+func DeleteDomainPromotionHandler(pricing *services.DomainPricingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		promotionID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de promoção inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := pricing.DeletePromotion(promotionID, adminID, models.GetIP(r)); err != nil {
+			writeDomainPricingError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeDomainPricingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrDomainPriceNotFound),
+		errors.Is(err, services.ErrDomainMarginRuleNotFound),
+		errors.Is(err, services.ErrDomainPromotionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidDomainPricing):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Erro ao processar a tabela de preços de domínios", http.StatusInternalServerError)
+	}
+}
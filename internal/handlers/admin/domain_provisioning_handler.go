@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDomainTimelineHandler retorna a situação de cada etapa do provisionamento e os eventos do domínio.
+// Rota: GET /admin/domains/{id}/timeline
+func GetDomainTimelineHandler(provisioning *services.DomainProvisioningService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		domainID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de domínio inválido", http.StatusBadRequest)
+			return
+		}
+
+		timeline, err := provisioning.Timeline(domainID)
+		if err != nil {
+			if errors.Is(err, services.ErrDomainNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Erro ao buscar o histórico do domínio", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(timeline)
+	}
+}
+
+// ResumeDomainProvisioningHandler retoma o provisionamento de um domínio com falha a partir da
+// etapa que falhou. As etapas concluídas não são repetidas.
+// Rota: POST /admin/domains/{id}/resume
+func ResumeDomainProvisioningHandler(provisioning *services.DomainProvisioningService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		domainID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de domínio inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := provisioning.Resume(domainID, adminID, models.GetIP(r)); err != nil {
+			writeDomainProvisioningError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Provisionamento retomado."})
+	}
+}
+
+// RetryDomainStepHandler executa novamente uma única etapa do provisionamento
+// (register_domain, create_account, configure_dns ou send_welcome_email).
+// Rota: POST /admin/domains/{id}/steps/{step}/retry
+func RetryDomainStepHandler(provisioning *services.DomainProvisioningService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		domainID, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			http.Error(w, "ID de domínio inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := provisioning.RetryStep(domainID, vars["step"], adminID, models.GetIP(r)); err != nil {
+			writeDomainProvisioningError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Nova tentativa da etapa enfileirada."})
+	}
+}
+
+func writeDomainProvisioningError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrDomainNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidDomainStep):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrDomainNotFailed), errors.Is(err, services.ErrDomainCancelled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Erro ao processar o provisionamento do domínio", http.StatusInternalServerError)
+	}
+}
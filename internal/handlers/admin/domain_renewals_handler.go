@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+)
+
+// GetDomainRenewalSettingsHandler retorna as regras de renovação de domínios.
+// Rota: GET /admin/domain-renewals/settings
+func GetDomainRenewalSettingsHandler(renewals *services.DomainRenewalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings, err := renewals.GetSettings()
+		if err != nil {
+			http.Error(w, "Erro ao buscar as regras de renovação", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	}
+}
+
+// UpdateDomainRenewalSettingsHandler grava a antecedência da fatura e dos lembretes e os prazos de
+// carência e de redenção.
+// Rota: PUT /admin/domain-renewals/settings
+func UpdateDomainRenewalSettingsHandler(renewals *services.DomainRenewalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req models.DomainRenewalSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		settings, err := renewals.UpdateSettings(&req, adminID, models.GetIP(r))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidDomainRenewalSettings) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			http.Error(w, "Erro ao gravar as regras de renovação", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	}
+}
@@ -3,43 +3,24 @@ package admin
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 
+	"hosting-backend/internal/middleware"
 	"hosting-backend/internal/models"
-)
-
-// --- Funções de Acesso ao Banco de Dados (integradas no handler) ---
-
-func getFiscalSettings(db *sql.DB) (*models.FiscalSettings, error) {
-	var settings models.FiscalSettings
-	query := "SELECT id, provider, company_name, cnpj, municipal_registration, city, state, iss_rate, environment, created_at, updated_at FROM fiscal_settings WHERE id = 1"
-	err := db.QueryRow(query).Scan(
-		&settings.ID, &settings.Provider, &settings.CompanyName, &settings.CNPJ, 
-		&settings.MunicipalRegistration, &settings.City, &settings.State, 
-		&settings.ISSRate, &settings.Environment, &settings.CreatedAt, &settings.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &settings, nil
-}
-
-func updateFiscalSettings(db *sql.DB, settings *models.FiscalSettings) error {
-	query := `UPDATE fiscal_settings SET 
-		provider = ?, company_name = ?, cnpj = ?, municipal_registration = ?, 
-		city = ?, state = ?, iss_rate = ?, environment = ? 
-		WHERE id = 1`
-	_, err := db.Exec(query, settings.Provider, settings.CompanyName, settings.CNPJ, settings.MunicipalRegistration, settings.City, settings.State, settings.ISSRate, settings.Environment)
-	return err
-}
+	"hosting-backend/internal/services"
 
+	"github.com/gorilla/mux"
+)
 
 // --- Handlers da API ---
 
 // GetFiscalSettingsHandler retorna as configurações fiscais atuais.
 func GetFiscalSettingsHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		settings, err := getFiscalSettings(db)
+		settings, err := models.GetFiscalSettings(db)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				http.Error(w, "Configurações fiscais ainda não definidas", http.StatusNotFound)
@@ -54,16 +35,26 @@ func GetFiscalSettingsHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// UpdateFiscalSettingsHandler atualiza as configurações fiscais.
-func UpdateFiscalSettingsHandler(db *sql.DB) http.HandlerFunc {
+// UpdateFiscalSettingsHandler valida e atualiza as configurações fiscais.
+func UpdateFiscalSettingsHandler(fiscalService *services.FiscalService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
 		var settings models.FiscalSettings
 		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
 			http.Error(w, "Payload inválido: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		if err := updateFiscalSettings(db, &settings); err != nil {
+		if err := fiscalService.UpdateSettings(&settings, adminID, models.GetIP(r)); err != nil {
+			if errors.Is(err, services.ErrInvalidFiscalSettings) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
 			http.Error(w, "Erro ao atualizar as configurações fiscais: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -72,3 +63,153 @@ func UpdateFiscalSettingsHandler(db *sql.DB) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]string{"message": "Configurações fiscais atualizadas com sucesso!"})
 	}
 }
+
+// GetPlanFiscalCodeHandler retorna o código de serviço e o CNAE configurados para um plano.
+// Rota: GET /admin/fiscal/plans/{id}
+func GetPlanFiscalCodeHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		planID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de plano inválido", http.StatusBadRequest)
+			return
+		}
+
+		code, err := models.GetPlanFiscalCode(db, planID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar os códigos fiscais do plano", http.StatusInternalServerError)
+			return
+		}
+		if code == nil {
+			http.Error(w, "Códigos fiscais não configurados para este plano", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(code)
+	}
+}
+
+// UpdatePlanFiscalCodeHandler define o código de serviço, o CNAE e a alíquota de ISS de um plano.
+// Rota: PUT /admin/fiscal/plans/{id}
+func UpdatePlanFiscalCodeHandler(fiscalService *services.FiscalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		planID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de plano inválido", http.StatusBadRequest)
+			return
+		}
+
+		var code models.PlanFiscalCode
+		if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+			http.Error(w, "Payload inválido: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		code.PlanID = planID
+
+		if err := fiscalService.UpdatePlanFiscalCode(&code, adminID, models.GetIP(r)); err != nil {
+			if errors.Is(err, services.ErrInvalidFiscalSettings) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			http.Error(w, "Erro ao atualizar os códigos fiscais do plano: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(code)
+	}
+}
+
+// FiscalDocumentActionRequest é o corpo das requisições de cancelamento e reemissão de NFS-e.
+type FiscalDocumentActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelFiscalDocumentHandler cancela uma NFS-e autorizada no provedor.
+// Rota: POST /admin/fiscal/documents/{id}/cancel
+func CancelFiscalDocumentHandler(fiscalService *services.FiscalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		documentID, req, adminID, ok := parseFiscalDocumentAction(w, r)
+		if !ok {
+			return
+		}
+
+		doc, err := fiscalService.CancelDocument(documentID, req.Reason, adminID)
+		if err != nil {
+			writeFiscalServiceError(w, documentID, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// ReissueFiscalDocumentHandler emite uma NFS-e substituta para a mesma fatura,
+// cancelando a original antes se ela ainda estiver autorizada.
+// Rota: POST /admin/fiscal/documents/{id}/reissue
+func ReissueFiscalDocumentHandler(fiscalService *services.FiscalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		documentID, req, adminID, ok := parseFiscalDocumentAction(w, r)
+		if !ok {
+			return
+		}
+
+		doc, err := fiscalService.ReissueDocument(documentID, req.Reason, adminID)
+		if err != nil {
+			writeFiscalServiceError(w, documentID, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// parseFiscalDocumentAction extrai o ID do documento, o corpo e o admin autenticado da requisição.
+func parseFiscalDocumentAction(w http.ResponseWriter, r *http.Request) (int64, FiscalDocumentActionRequest, int, bool) {
+	var req FiscalDocumentActionRequest
+
+	documentID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "ID de documento inválido", http.StatusBadRequest)
+		return 0, req, 0, false
+	}
+
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+		return 0, req, 0, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+		return 0, req, 0, false
+	}
+
+	return documentID, req, adminID, true
+}
+
+// writeFiscalServiceError traduz os erros do FiscalService para respostas HTTP.
+func writeFiscalServiceError(w http.ResponseWriter, documentID int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrFiscalDocumentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidCancellationReason):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrFiscalDocumentNotCancelable),
+		errors.Is(err, services.ErrFiscalDocumentNotReissuable),
+		errors.Is(err, services.ErrCancellationWindowExpired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[Admin] Erro na operação fiscal do documento #%d: %v", documentID, err)
+		http.Error(w, "Erro ao processar a operação fiscal: "+err.Error(), http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// MarkInvoicePaidHandler marca uma fatura como paga e inicia o provisionamento dos pedidos
+// vinculados a ela (respeitando a aprovação manual de pedidos).
+// Rota: POST /admin/invoices/{id}/mark-paid
+func MarkInvoicePaidHandler(adminService *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invoiceID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de fatura inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		if err := adminService.MarkInvoicePaid(invoiceID, adminID, models.GetIP(r)); err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvoiceNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrInvoiceAlreadyPaid):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				log.Printf("Erro ao marcar a fatura #%d como paga: %v", invoiceID, err)
+				http.Error(w, "Erro ao marcar a fatura como paga", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Fatura marcada como paga. O provisionamento dos pedidos foi iniciado."})
+	}
+}
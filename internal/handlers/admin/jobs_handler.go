@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Quantidade máxima de tarefas retornadas na listagem.
+const jobsListLimit = 100
+
+// GetJobsHandler lista as últimas tarefas da fila de provisionamento com o status informado
+// (?status=queued|running|succeeded|dead, padrão: dead, a fila de mortas).
+// Rota: GET /admin/jobs
+func GetJobsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := models.JobStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = models.JobStatusDead
+		case models.JobStatusQueued, models.JobStatusRunning, models.JobStatusSucceeded, models.JobStatusDead:
+		default:
+			http.Error(w, "Status de tarefa inválido", http.StatusBadRequest)
+			return
+		}
+
+		jobs, err := models.GetJobsByStatus(db, status, jobsListLimit)
+		if err != nil {
+			http.Error(w, "Erro ao buscar as tarefas", http.StatusInternalServerError)
+			return
+		}
+		if jobs == nil {
+			jobs = []models.Job{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobs)
+	}
+}
+
+// RetryJobHandler devolve uma tarefa da fila de mortas para a fila de provisionamento.
+// Rota: POST /admin/jobs/{id}/retry
+func RetryJobHandler(adminService *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de tarefa inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		job, err := adminService.RetryJob(jobID, adminID, models.GetIP(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrJobNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrJobNotDead):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Erro ao reenfileirar a tarefa", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+	}
+}
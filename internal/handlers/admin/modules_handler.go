@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/modules"
+
+	"github.com/gorilla/mux"
+)
+
+// GetModulesHandler lista os módulos do sistema e o estado de cada um.
+// Rota: GET /admin/modules
+func GetModulesHandler(registry *modules.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := registry.List()
+		if err != nil {
+			http.Error(w, "Erro ao buscar os módulos", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// UpdateModuleHandler ativa ou desativa um módulo.
+// Rota: PUT /admin/modules/{name}
+func UpdateModuleHandler(registry *modules.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var payload struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Enabled == nil {
+			http.Error(w, "Corpo da requisição inválido: informe o campo 'enabled'", http.StatusBadRequest)
+			return
+		}
+
+		module, err := registry.SetEnabled(mux.Vars(r)["name"], *payload.Enabled, adminID, models.GetIP(r))
+		if err != nil {
+			if errors.Is(err, modules.ErrUnknownModule) {
+				http.Error(w, "Módulo não encontrado", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Erro ao atualizar o módulo: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(module)
+	}
+}
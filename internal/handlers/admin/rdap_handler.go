@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"hosting-backend/internal/services/rdap"
+)
+
+// RefreshRdapBootstrapHandler baixa novamente o arquivo de bootstrap RDAP da IANA, sem esperar o fim
+// da validade do cache (ex: após a IANA publicar o servidor de uma nova TLD).
+// Rota: POST /admin/rdap/bootstrap/refresh
+func RefreshRdapBootstrapHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := rdap.Default().Refresh(); err != nil {
+			log.Printf("Erro ao atualizar o bootstrap RDAP: %v", err)
+			http.Error(w, "Erro ao atualizar o bootstrap RDAP", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Bootstrap RDAP atualizado."})
+	}
+}
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Quantidade máxima de alertas retornados na listagem filtrada por status.
+const serverAlertsListLimit = 100
+
+// GetServerAlertsHandler lista os alertas dos servidores. Sem filtro, retorna os alertas
+// abertos e reconhecidos; com ?status=open|acknowledged|resolved, os últimos alertas do status.
+// Rota: GET /admin/alerts
+func GetServerAlertsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var alerts []models.ServerAlert
+		var err error
+
+		switch status := models.ServerAlertStatus(r.URL.Query().Get("status")); status {
+		case "":
+			alerts, err = models.GetUnresolvedServerAlerts(db)
+		case models.ServerAlertStatusOpen, models.ServerAlertStatusAcknowledged, models.ServerAlertStatusResolved:
+			alerts, err = models.GetServerAlertsByStatus(db, status, serverAlertsListLimit)
+		default:
+			http.Error(w, "Status de alerta inválido", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Erro ao buscar os alertas", http.StatusInternalServerError)
+			return
+		}
+		if alerts == nil {
+			alerts = []models.ServerAlert{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alerts)
+	}
+}
+
+// AcknowledgeServerAlertHandler reconhece um alerta aberto, interrompendo novas notificações.
+// Rota: POST /admin/alerts/{id}/acknowledge
+func AcknowledgeServerAlertHandler(alertService *services.ServerAlertService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alertID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de alerta inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		alert, err := alertService.Acknowledge(alertID, adminID, models.GetIP(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrServerAlertNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrServerAlertNotOpen):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Erro ao reconhecer o alerta", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alert)
+	}
+}
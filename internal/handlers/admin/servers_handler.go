@@ -0,0 +1,296 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ServerCredentialsRequest define a credencial de API de um servidor do pool.
+type ServerCredentialsRequest struct {
+	Username string `json:"username"`
+	APIToken string `json:"api_token"`
+}
+
+// Janela padrão e máxima, em horas, do histórico de métricas de um servidor.
+const (
+	defaultMetricHistoryHours = 24
+	maxMetricHistoryHours     = 30 * 24
+)
+
+// ServerMetricsResponse é o estado atual de um servidor e as últimas métricas coletadas.
+type ServerMetricsResponse struct {
+	ServerID      int                   `json:"server_id"`
+	Status        models.ServerStatus   `json:"status"`
+	Metrics       *models.ServerMetrics `json:"metrics"`
+	LastSyncAt    *time.Time            `json:"last_sync_at"`
+	SyncFailures  int                   `json:"sync_failures"`
+	LastSyncError string                `json:"last_sync_error,omitempty"`
+}
+
+// GetServersHandler lista os servidores do pool.
+// Rota: GET /admin/servers
+func GetServersHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		servers, err := models.GetAllServers(db)
+		if err != nil {
+			http.Error(w, "Erro ao buscar servidores", http.StatusInternalServerError)
+			return
+		}
+		if servers == nil {
+			servers = []models.Server{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(servers)
+	}
+}
+
+// CreateServerHandler cadastra um novo servidor no pool.
+// Rota: POST /admin/servers
+func CreateServerHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var server models.Server
+		if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		if server.Name == "" || server.Hostname == "" {
+			http.Error(w, "Nome e hostname são obrigatórios", http.StatusBadRequest)
+			return
+		}
+		if server.ServerType == "" {
+			server.ServerType = models.ServerTypeCPanel
+		}
+		if server.Status == "" {
+			server.Status = models.ServerStatusActive
+		}
+
+		id, err := models.CreateServer(db, &server)
+		if err != nil {
+			http.Error(w, "Erro ao cadastrar o servidor", http.StatusInternalServerError)
+			return
+		}
+		server.ID = int(id)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(server)
+	}
+}
+
+// UpdateServerHandler atualiza os dados de um servidor do pool.
+// Rota: PUT /admin/servers/{id}
+func UpdateServerHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		var server models.Server
+		if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		server.ID = serverID
+
+		if err := models.UpdateServer(db, &server); err != nil {
+			http.Error(w, "Erro ao atualizar o servidor", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteServerHandler remove um servidor do pool.
+// Rota: DELETE /admin/servers/{id}
+func DeleteServerHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		if err := models.DeleteServer(db, serverID); err != nil {
+			// Servidores com serviços vinculados não podem ser removidos (FK em services.server_id).
+			http.Error(w, "Erro ao remover o servidor. Verifique se ainda há serviços nele.", http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UpdateServerCredentialsHandler grava a credencial de API do servidor em api_credentials.
+// Rota: PUT /admin/servers/{id}/credentials
+func UpdateServerCredentialsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		var req ServerCredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" || req.APIToken == "" {
+			http.Error(w, "Usuário e token da API são obrigatórios", http.StatusBadRequest)
+			return
+		}
+
+		server, err := models.GetServerByID(db, serverID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar o servidor", http.StatusInternalServerError)
+			return
+		}
+		if server == nil {
+			http.Error(w, "Servidor não encontrado", http.StatusNotFound)
+			return
+		}
+
+		apiKeyID, err := models.SaveServerCredential(db, server.APIKeyID, models.ApiProvider(server.ServerType), req.Username, req.APIToken)
+		if err != nil {
+			http.Error(w, "Erro ao salvar a credencial do servidor", http.StatusInternalServerError)
+			return
+		}
+		if err := models.SetServerAPIKey(db, serverID, apiKeyID); err != nil {
+			http.Error(w, "Erro ao associar a credencial ao servidor", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetServerMetricsHandler retorna o último snapshot de métricas de um servidor.
+// Rota: GET /admin/servers/{id}/metrics
+func GetServerMetricsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		server, err := models.GetServerByID(db, serverID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar o servidor", http.StatusInternalServerError)
+			return
+		}
+		if server == nil {
+			http.Error(w, "Servidor não encontrado", http.StatusNotFound)
+			return
+		}
+
+		resp := ServerMetricsResponse{
+			ServerID:      server.ID,
+			Status:        server.Status,
+			Metrics:       server.ParsedMetrics(),
+			SyncFailures:  server.SyncFailures,
+			LastSyncError: server.LastSyncError.String,
+		}
+		if server.LastSyncAt.Valid {
+			resp.LastSyncAt = &server.LastSyncAt.Time
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// GetServerMetricHistoryHandler retorna a série histórica de métricas de um servidor.
+// O parâmetro "hours" define a janela consultada (padrão 24, máximo 720).
+// Rota: GET /admin/servers/{id}/metrics/history
+func GetServerMetricHistoryHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		hours := defaultMetricHistoryHours
+		if raw := r.URL.Query().Get("hours"); raw != "" {
+			hours, err = strconv.Atoi(raw)
+			if err != nil || hours <= 0 || hours > maxMetricHistoryHours {
+				http.Error(w, "Parâmetro 'hours' inválido", http.StatusBadRequest)
+				return
+			}
+		}
+
+		points, err := models.GetServerMetricHistory(db, serverID, time.Now().Add(-time.Duration(hours)*time.Hour))
+		if err != nil {
+			http.Error(w, "Erro ao buscar o histórico de métricas", http.StatusInternalServerError)
+			return
+		}
+		if points == nil {
+			points = []models.ServerMetrics{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(points)
+	}
+}
+
+// ImportServerAccountsRequest define o modo da importação de contas. Sem corpo, a importação é simulada.
+type ImportServerAccountsRequest struct {
+	DryRun *bool `json:"dry_run"`
+}
+
+// ImportServerAccountsHandler importa as contas existentes no WHM do servidor como serviços da plataforma.
+// Por padrão roda em modo simulação e apenas retorna o diff; com {"dry_run": false} grava os serviços.
+// Rota: POST /admin/servers/{id}/import-accounts
+func ImportServerAccountsHandler(importer *services.AccountImporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "ID de servidor inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req ImportServerAccountsRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+				return
+			}
+		}
+		dryRun := req.DryRun == nil || *req.DryRun
+
+		report, err := importer.Import(serverID, dryRun, adminID, models.GetIP(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrServerNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrImportNotSupported):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			default:
+				log.Printf("Erro ao importar as contas do servidor #%d: %v", serverID, err)
+				http.Error(w, "Erro ao importar as contas do servidor", http.StatusBadGateway)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -1,12 +1,13 @@
 package admin
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
-	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
 
 	"github.com/gorilla/mux"
 )
@@ -16,9 +17,9 @@ type ActionResponse struct {
 	Message string `json:"message"`
 }
 
-// SuspendServiceHandler suspende um serviço.
+// SuspendServiceHandler suspende um serviço no painel do seu servidor (cPanel ou Plesk).
 // Rota: PUT /admin/services/{id}/suspend
-func SuspendServiceHandler(db *sql.DB) http.HandlerFunc {
+func SuspendServiceHandler(adminService *services.AdminService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		serviceID, err := strconv.Atoi(vars["id"])
@@ -27,9 +28,13 @@ func SuspendServiceHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		err = models.UpdateServiceStatus(db, serviceID, models.ServiceStatusSuspended)
+		err = adminService.SuspendService(serviceID, "Suspenso pela administração")
 		if err != nil {
-			// TODO: Adicionar um log mais detalhado aqui
+			if errors.Is(err, services.ErrServiceNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Erro ao suspender o serviço #%d: %v", serviceID, err)
 			http.Error(w, "Erro ao suspender o serviço", http.StatusInternalServerError)
 			return
 		}
@@ -40,9 +45,9 @@ func SuspendServiceHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// ReactivateServiceHandler reativa um serviço.
+// ReactivateServiceHandler reativa um serviço no painel do seu servidor (cPanel ou Plesk).
 // Rota: PUT /admin/services/{id}/reactivate
-func ReactivateServiceHandler(db *sql.DB) http.HandlerFunc {
+func ReactivateServiceHandler(adminService *services.AdminService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		serviceID, err := strconv.Atoi(vars["id"])
@@ -51,9 +56,13 @@ func ReactivateServiceHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		err = models.UpdateServiceStatus(db, serviceID, models.ServiceStatusActive)
+		err = adminService.UnsuspendService(serviceID)
 		if err != nil {
-			// TODO: Adicionar um log mais detalhado aqui
+			if errors.Is(err, services.ErrServiceNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Erro ao reativar o serviço #%d: %v", serviceID, err)
 			http.Error(w, "Erro ao reativar o serviço", http.StatusInternalServerError)
 			return
 		}
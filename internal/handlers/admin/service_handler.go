@@ -7,7 +7,7 @@ import (
 	"net/http"
 
 	"hosting-backend/internal/models"
-	"hosting-backend/internal/whm" // Caminho corrigido
+	"hosting-backend/internal/services"
 )
 
 // CreateServicePayload é a estrutura de dados para criar uma nova conta de hospedagem.
@@ -15,14 +15,14 @@ type CreateServicePayload struct {
 	Domain         string `json:"domain"`
 	CpanelUser     string `json:"cpanel_user"`
 	CpanelPassword string `json:"cpanel_password"`
-	PlanName       string `json:"plan_name"` // Nome do plano no WHM
 	UserID         int    `json:"user_id"`
-	ProductID      int    `json:"product_id"`
+	ProductID      int    `json:"product_id"` // Plano contratado; define o pacote do WHM e o servidor
 }
 
-// CreateServiceHandler cria uma nova conta de hospedagem no WHM e a registra como um serviço.
+// CreateServiceHandler enfileira a criação de uma nova conta de hospedagem e o registro do serviço.
+// O servidor é escolhido conforme a política de alocação do plano, no momento da execução.
 // Rota: POST /admin/services
-func CreateServiceHandler(db *sql.DB) http.HandlerFunc {
+func CreateServiceHandler(db *sql.DB, adminService *services.AdminService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var payload CreateServicePayload
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -30,54 +30,45 @@ func CreateServiceHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		if payload.Domain == "" || payload.CpanelUser == "" || payload.CpanelPassword == "" || payload.PlanName == "" || payload.UserID == 0 || payload.ProductID == 0 {
-			http.Error(w, "Campos inválidos. Domain, CpanelUser, CpanelPassword, PlanName, UserID e ProductID são obrigatórios.", http.StatusBadRequest)
+		if payload.Domain == "" || payload.CpanelUser == "" || payload.CpanelPassword == "" || payload.UserID == 0 || payload.ProductID == 0 {
+			http.Error(w, "Campos inválidos. Domain, CpanelUser, CpanelPassword, UserID e ProductID são obrigatórios.", http.StatusBadRequest)
 			return
 		}
 
 		// Buscar o email do cliente usando o UserID
 		user, err := models.GetUserByID(db, payload.UserID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "Usuário não encontrado", http.StatusNotFound)
-				return
-			}
 			http.Error(w, "Erro ao buscar dados do usuário", http.StatusInternalServerError)
 			return
 		}
-
-		whmClient, err := whm.NewClient()
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Erro ao inicializar o cliente WHM: %v", err), http.StatusInternalServerError)
+		if user == nil {
+			http.Error(w, "Usuário não encontrado", http.StatusNotFound)
 			return
 		}
 
-		// Chamada corrigida para CreateAccount, incluindo o email do usuário
-		err = whmClient.CreateAccount(payload.Domain, payload.CpanelUser, payload.CpanelPassword, user.Email, payload.PlanName)
+		plan, err := models.GetPlanByID(db, payload.ProductID)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Falha ao criar conta no WHM: %v", err), http.StatusServiceUnavailable)
+			http.Error(w, "Erro ao buscar o plano", http.StatusInternalServerError)
 			return
 		}
-
-		service := models.Service{
-			UserID:     payload.UserID,
-			ProductID:  payload.ProductID,
-			Domain:     payload.Domain,
-			CpanelUser: payload.CpanelUser,
-			Status:     models.ServiceStatusActive,
+		if plan == nil || !plan.WhmPackageName.Valid {
+			http.Error(w, "Plano não encontrado ou sem pacote do WHM configurado", http.StatusNotFound)
+			return
 		}
 
-		id, err := models.CreateService(db, &service)
+		job, err := adminService.EnqueueServiceAccount(payload.UserID, plan, payload.Domain, payload.CpanelUser, payload.CpanelPassword, user.Email)
 		if err != nil {
-			// Este é um estado crítico. A conta foi criada no servidor, mas não no nosso sistema.
-			// A mensagem de erro deve ser clara sobre a necessidade de uma ação manual.
-			http.Error(w, "Conta criada no WHM, mas falhou ao salvar localmente. Verifique os logs e registre o serviço manualmente.", http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Falha ao enfileirar o provisionamento da conta: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if job == nil {
+			http.Error(w, "Já existe um provisionamento pendente para este usuário de cPanel", http.StatusConflict)
 			return
 		}
-		service.ID = int(id)
 
+		// A conta é criada pela fila de tarefas; o andamento pode ser acompanhado em /admin/jobs.
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(service)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
 	}
 }
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetStaffTasksHandler lista a fila "precisa de atenção": as pendências abertas, das mais urgentes
+// para as demais, ou as últimas resolvidas (?status=open|resolved, padrão: open).
+// Rota: GET /admin/staff-tasks
+func GetStaffTasksHandler(staffTasks *services.StaffTaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := models.StaffTaskStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = models.StaffTaskStatusOpen
+		case models.StaffTaskStatusOpen, models.StaffTaskStatusResolved:
+		default:
+			http.Error(w, "Status de pendência inválido", http.StatusBadRequest)
+			return
+		}
+
+		tasks, err := staffTasks.List(status)
+		if err != nil {
+			http.Error(w, "Erro ao buscar as pendências", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tasks)
+	}
+}
+
+// ResolveStaffTaskHandler marca uma pendência como resolvida, com a descrição do que foi feito.
+// Rota: POST /admin/staff-tasks/{id}/resolve
+func ResolveStaffTaskHandler(staffTasks *services.StaffTaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de pendência inválido", http.StatusBadRequest)
+			return
+		}
+
+		adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Resolution string `json:"resolution"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		req.Resolution = strings.TrimSpace(req.Resolution)
+		if req.Resolution == "" {
+			http.Error(w, "Descreva a resolução da pendência", http.StatusBadRequest)
+			return
+		}
+
+		task, err := staffTasks.Resolve(taskID, adminID, models.GetIP(r), req.Resolution)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrStaffTaskNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, services.ErrStaffTaskNotOpen):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Erro ao resolver a pendência", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(task)
+	}
+}
@@ -36,6 +36,7 @@ func UpdateDomainOrderHandler(db *sql.DB) http.HandlerFunc {
 		// Validação do status
 		allowedStatus := map[string]bool{
 			"pending_payment":      true, // Cliente ainda não pagou
+			"pending_approval":     true, // Pago, aguardando aprovação manual
 			"pending_registration": true, // Pago, aguardando nossa ação
 			"completed":            true, // Processo finalizado com sucesso
 			"failed":               true, // Falha em alguma etapa
@@ -84,12 +85,15 @@ func UpdateDomainOrderHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// Se o status for 'pending_registration', inicie o processo de provisionamento
+		// Se o status for 'pending_registration' (ex: aprovação de um pedido pago), enfileira o provisionamento do domínio.
+		// A execução ocorre na fila de tarefas, com novas tentativas em caso de falha.
 		if req.Status == "pending_registration" {
-			log.Printf("Disparando provisionamento para o pedido de domínio #%s", orderID)
-			// A função de provisionamento deve ser chamada em uma goroutine
-			// para não bloquear a resposta HTTP.
-			go orchestrator.ProcessDomainProvisioning(db, orderID) // Passando o ID do pedido
+			log.Printf("Enfileirando provisionamento para o pedido de domínio #%s", orderID)
+			if _, err := orchestrator.ProcessDomainProvisioning(db, orderID); err != nil {
+				log.Printf("Erro ao enfileirar o provisionamento do pedido #%s: %v", orderID, err)
+				http.Error(w, "Status atualizado, mas falhou ao enfileirar o provisionamento", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		log.Printf("[Admin] Status do pedido de domínio #%s atualizado para '%s'", orderID, req.Status)
@@ -142,7 +142,7 @@ func LoginHandler(db *sql.DB, jwtSecret string) http.HandlerFunc {
 		}
 
 		// Gerar o token JWT para o cliente
-		token, err := utils.GenerateToken(user.ID, "client", jwtSecret)
+		token, err := utils.GenerateToken(user.ID, "client")
 		if err != nil {
 			http.Error(w, "Erro ao gerar token de autenticação", http.StatusInternalServerError)
 			return
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ListMyDNSZonesHandler lista os domínios da conta do serviço que têm zona DNS própria.
+// Rota: GET /api/my-services/{id}/dns/zones
+func ListMyDNSZonesHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		list, err := zones.ListZones(userID, serviceID)
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetMyDNSZoneHandler retorna os registros da zona. Cada registro traz a linha usada para alterá-lo.
+// Rota: GET /api/my-services/{id}/dns/zones/{zone}
+func GetMyDNSZoneHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		zone, err := zones.GetZone(userID, serviceID, vars["zone"])
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+// AddMyDNSRecordHandler adiciona um registro (A, AAAA, CNAME, MX, TXT, SRV ou CAA) à zona.
+// Rota: POST /api/my-services/{id}/dns/zones/{zone}/records
+func AddMyDNSRecordHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		var record provisioning.ZoneRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		zone, err := zones.AddRecord(userID, serviceID, vars["zone"], record, models.GetIP(r))
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+// UpdateMyDNSRecordHandler substitui o registro da linha informada, mantendo o tipo.
+// Rota: PUT /api/my-services/{id}/dns/zones/{zone}/records/{line}
+func UpdateMyDNSRecordHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+		line, _ := strconv.Atoi(vars["line"])
+
+		var record provisioning.ZoneRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		zone, err := zones.UpdateRecord(userID, serviceID, vars["zone"], line, record, models.GetIP(r))
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+// DeleteMyDNSRecordHandler remove o registro da linha informada. Os parâmetros opcionais type e
+// name confirmam o registro exibido ao cliente, já que as linhas mudam a cada remoção.
+// Rota: DELETE /api/my-services/{id}/dns/zones/{zone}/records/{line}?type=TXT&name=exemplo.com.br
+func DeleteMyDNSRecordHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+		line, _ := strconv.Atoi(vars["line"])
+		query := r.URL.Query()
+
+		zone, err := zones.DeleteRecord(userID, serviceID, vars["zone"], line, query.Get("type"), query.Get("name"), models.GetIP(r))
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+// ListMyDNSTemplatesHandler lista os modelos de registros (Google Workspace, Microsoft 365) para a zona.
+// Rota: GET /api/my-services/{id}/dns/zones/{zone}/templates
+func ListMyDNSTemplatesHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		templates, err := zones.ListTemplates(userID, serviceID, vars["zone"])
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(templates)
+	}
+}
+
+// ApplyMyDNSTemplateHandler aplica um modelo de registros à zona.
+// Rota: POST /api/my-services/{id}/dns/zones/{zone}/templates/{template}
+func ApplyMyDNSTemplateHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		zone, err := zones.ApplyTemplate(userID, serviceID, vars["zone"], vars["template"], models.GetIP(r))
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+// GetMyDNSZoneHistoryHandler lista as alterações mais recentes da zona.
+// Rota: GET /api/my-services/{id}/dns/zones/{zone}/history
+func GetMyDNSZoneHistoryHandler(zones *services.DNSZoneService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		history, err := zones.History(userID, serviceID, vars["zone"])
+		if err != nil {
+			writeDNSZoneError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
+	}
+}
+
+func writeDNSZoneError(w http.ResponseWriter, err error) {
+	var whmErr *provisioning.WhmAPIError
+	switch {
+	case errors.Is(err, services.ErrServiceNotFound), errors.Is(err, services.ErrDNSZoneNotFound),
+		errors.Is(err, services.ErrDNSRecordNotFound), errors.Is(err, services.ErrDNSTemplateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrServiceNotManageable), errors.Is(err, services.ErrDNSRecordChanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidDNSRecord):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &whmErr):
+		http.Error(w, "O servidor recusou a alteração: "+whmErr.Reason, http.StatusBadGateway)
+	default:
+		http.Error(w, "Erro ao processar a zona DNS", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ListMyDomainsHandler lista os domínios do cliente autenticado.
+// Rota: GET /api/my-domains
+func ListMyDomainsHandler(domains *services.DomainManagementService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		list, err := domains.ListForClient(userID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar os domínios", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetMyDomainHandler retorna um domínio do cliente com os nameservers, o bloqueio de transferência e
+// a privacidade do WHOIS (?refresh=true lê o estado no registrador em vez do cache).
+// Rota: GET /api/my-domains/{id}
+func GetMyDomainHandler(domains *services.DomainManagementService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		domain, err := domains.GetForClient(userID, domainID, r.URL.Query().Get("refresh") == "true")
+		if err != nil {
+			writeDomainManagementError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+// UpdateMyDomainNameserversHandler aponta o domínio para outros nameservers (de 2 a 4).
+// Rota: PUT /api/my-domains/{id}/nameservers
+func UpdateMyDomainNameserversHandler(domains *services.DomainManagementService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req struct {
+			Nameservers []string `json:"nameservers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		domain, err := domains.UpdateNameservers(userID, domainID, req.Nameservers)
+		if err != nil {
+			writeDomainManagementError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+// SetMyDomainLockHandler ativa ou desativa o bloqueio de transferência do domínio.
+// Rota: PUT /api/my-domains/{id}/lock
+func SetMyDomainLockHandler(domains *services.DomainManagementService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req struct {
+			Locked *bool `json:"locked"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Locked == nil {
+			http.Error(w, "Informe locked (true ou false)", http.StatusBadRequest)
+			return
+		}
+
+		domain, err := domains.SetLock(userID, domainID, *req.Locked)
+		if err != nil {
+			writeDomainManagementError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+// SetMyDomainPrivacyHandler ativa ou desativa a proteção de privacidade do WHOIS do domínio.
+// Rota: PUT /api/my-domains/{id}/privacy
+func SetMyDomainPrivacyHandler(domains *services.DomainManagementService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+			http.Error(w, "Informe enabled (true ou false)", http.StatusBadRequest)
+			return
+		}
+
+		domain, err := domains.SetPrivacy(userID, domainID, *req.Enabled)
+		if err != nil {
+			writeDomainManagementError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+func writeDomainManagementError(w http.ResponseWriter, err error) {
+	var apiErr *provisioning.HostingerAPIError
+	switch {
+	case errors.Is(err, services.ErrDomainNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrDomainNotManageable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidNameservers), errors.Is(err, services.ErrDomainFeatureUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &apiErr):
+		http.Error(w, "O registrador recusou a alteração: "+apiErr.Body, http.StatusBadGateway)
+	default:
+		http.Error(w, "Erro ao processar o domínio", http.StatusInternalServerError)
+	}
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,12 +11,19 @@ import (
 	"time"
 
 	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+	"hosting-backend/internal/services/rdap"
+	"hosting-backend/internal/utils"
 )
 
-// OrderDomainRequest representa a requisição para registrar um domínio.
+// OrderDomainRequest representa a requisição para registrar ou transferir um domínio.
 type OrderDomainRequest struct {
-	Domain   string `json:"domain"`
-	Document string `json:"document"` // CPF ou CNPJ
+	Domain   string            `json:"domain"`
+	Document string            `json:"document"`  // CPF ou CNPJ
+	Type     models.DomainType `json:"type"`      // register (padrão) ou transfer
+	Years    int               `json:"years"`     // Período do registro, de 1 a 10 anos (padrão: 1)
+	AuthCode string            `json:"auth_code"` // Código de transferência (EPP), obrigatório em transferências
 }
 
 // OrderDomainResponse representa a resposta após um pedido de domínio bem-sucedido.
@@ -25,9 +33,8 @@ type OrderDomainResponse struct {
 	Domain    string `json:"domain"`
 }
 
-const domainRegistrationPrice = 40.00
-
-// OrderDomainHandler processa um novo pedido de registro de domínio.
+// OrderDomainHandler processa um novo pedido de registro ou de transferência de domínio. A
+// transferência acrescenta um ano ao vencimento e é cobrada pelo preço de transferência da TLD.
 func OrderDomainHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Decodifica o corpo da requisição
@@ -38,6 +45,42 @@ func OrderDomainHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		domain := strings.ToLower(strings.TrimSpace(req.Domain))
+		if req.Type == "" {
+			req.Type = models.DomainTypeRegister
+		}
+		if req.Years == 0 {
+			req.Years = 1
+		}
+		operation := models.DomainPriceRegister
+		var authCodeEncrypted sql.NullString
+		switch req.Type {
+		case models.DomainTypeRegister:
+			if req.Years < models.DomainMinYears || req.Years > models.DomainMaxYears {
+				http.Error(w, fmt.Sprintf("O período do registro deve ser de %d a %d anos.", models.DomainMinYears, models.DomainMaxYears), http.StatusBadRequest)
+				return
+			}
+		case models.DomainTypeTransfer:
+			if req.Years != 1 {
+				http.Error(w, "A transferência acrescenta 1 ano ao vencimento do domínio.", http.StatusBadRequest)
+				return
+			}
+			authCode, err := services.ValidateAuthCode(req.AuthCode)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			encrypted, err := utils.Encrypt([]byte(authCode))
+			if err != nil {
+				log.Printf("Erro ao criptografar o código de transferência: %v", err)
+				http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+				return
+			}
+			authCodeEncrypted = sql.NullString{String: encrypted, Valid: true}
+			operation = models.DomainPriceTransfer
+		default:
+			http.Error(w, "Tipo de pedido inválido: use register ou transfer.", http.StatusBadRequest)
+			return
+		}
 
 		// 2. Extrai o ID do cliente do token JWT
 		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
@@ -46,13 +89,46 @@ func OrderDomainHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// 3. Verifica novamente a disponibilidade do domínio
-		if !isDomainAvailable(domain) {
+		// 3. Verifica novamente a disponibilidade do domínio: só domínios livres são registrados e só
+		// domínios já registrados são transferidos. Se a consulta não for conclusiva, o pedido segue e o
+		// registrador confirma a situação do domínio.
+		availability := services.CheckDomainAvailability(domain)
+		switch {
+		case availability.Status == rdap.StatusRegistered && req.Type == models.DomainTypeRegister:
 			http.Error(w, fmt.Sprintf("O domínio %s não está mais disponível para registro.", domain), http.StatusConflict) // 409 Conflict
 			return
+		case availability.Status == rdap.StatusAvailable && req.Type == models.DomainTypeTransfer:
+			http.Error(w, fmt.Sprintf("O domínio %s não está registrado e não pode ser transferido.", domain), http.StatusConflict)
+			return
+		case availability.Status == rdap.StatusUnknown:
+			log.Printf("Disponibilidade de '%s' não confirmada (%s); o pedido segue para o registrador.", domain, availability.Reason)
 		}
 
-		// 4. Inicia uma transação no banco de dados
+		_, tld, _ := strings.Cut(domain, ".")
+		quote, err := services.QuoteDomainPrice(db, tld, operation, req.Years)
+		if err != nil {
+			if errors.Is(err, services.ErrDomainPriceNotFound) {
+				if req.Type == models.DomainTypeTransfer {
+					http.Error(w, fmt.Sprintf("Domínios .%s não estão disponíveis para transferência.", tld), http.StatusUnprocessableEntity)
+					return
+				}
+				http.Error(w, fmt.Sprintf("Domínios .%s não estão disponíveis para registro por %d ano(s).", tld, req.Years), http.StatusUnprocessableEntity)
+				return
+			}
+			log.Printf("Erro ao buscar o preço de .%s: %v", tld, err)
+			http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+			return
+		}
+		price := quote.Price
+
+		// 4. O pedido e a fatura pertencem ao cadastro de cliente do usuário
+		clientRecord, err := models.GetClientByUserID(db, userID)
+		if err != nil || clientRecord == nil {
+			http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+			return
+		}
+
+		// 5. Inicia uma transação no banco de dados
 		tx, err := db.Begin()
 		if err != nil {
 			log.Printf("Erro ao iniciar transação: %v", err)
@@ -61,41 +137,44 @@ func OrderDomainHandler(db *sql.DB) http.HandlerFunc {
 		}
 		defer tx.Rollback() // Rollback em caso de erro
 
-		// 5. Insere o pedido de domínio
-		orderRes, err := tx.Exec("INSERT INTO domain_orders (client_id, domain_name, document, status) VALUES (?, ?, ?, ?)",
-			userID, domain, req.Document, "pending_payment")
+		// 6. Insere o pedido de domínio
+		var orderID int
+		err = tx.QueryRow("INSERT INTO domain_orders (client_id, domain_name, document, type, years, auth_code_encrypted, status) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+			clientRecord.ID, domain, req.Document, req.Type, req.Years, authCodeEncrypted, models.DomainOrderStatusPendingPayment).Scan(&orderID)
 		if err != nil {
 			log.Printf("Erro ao inserir pedido de domínio: %v", err)
 			http.Error(w, "Erro ao processar seu pedido.", http.StatusInternalServerError)
 			return
 		}
-		orderID, _ := orderRes.LastInsertId()
 
-		// 6. Cria a fatura para o pedido
+		// 7. Cria a fatura para o pedido
 		now := time.Now()
 		dueDate := now.Add(7 * 24 * time.Hour) // Vencimento em 7 dias
-		invoiceRes, err := tx.Exec("INSERT INTO invoices (client_id, issue_date, due_date, total_amount, status) VALUES (?, ?, ?, ?, ?)",
-			userID, now, dueDate, domainRegistrationPrice, "unpaid")
+		var invoiceID int
+		err = tx.QueryRow("INSERT INTO invoices (user_id, issue_date, due_date, total_amount, status) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			userID, now, dueDate, price, models.InvoiceStatusUnpaid).Scan(&invoiceID)
 		if err != nil {
 			log.Printf("Erro ao criar fatura: %v", err)
 			http.Error(w, "Erro ao processar seu pedido.", http.StatusInternalServerError)
 			return
 		}
-		invoiceID, _ := invoiceRes.LastInsertId()
 
-		// 7. Adiciona o item à fatura (associando ao pedido de domínio)
-		// Usaremos um service_id nulo para itens que não são serviços recorrentes, como um registro de domínio.
-		description := fmt.Sprintf("Registro de domínio: %s - 1 ano", domain)
-		_, err = tx.Exec("INSERT INTO invoice_items (invoice_id, service_id, description, amount) VALUES (?, NULL, ?, ?)",
-			invoiceID, description, domainRegistrationPrice)
+		// 8. Adiciona o item à fatura (associando ao pedido de domínio)
+		// O serviço do pedido só é criado no provisionamento, então o item não tem service_id.
+		description := fmt.Sprintf("Registro de domínio: %s - %d ano(s)", domain, req.Years)
+		if req.Type == models.DomainTypeTransfer {
+			description = fmt.Sprintf("Transferência de domínio: %s - 1 ano", domain)
+		}
+		_, err = tx.Exec("INSERT INTO invoice_items (invoice_id, service_id, description, amount) VALUES ($1, NULL, $2, $3)",
+			invoiceID, description, price)
 		if err != nil {
 			log.Printf("Erro ao criar item de fatura: %v", err)
 			http.Error(w, "Erro ao processar seu pedido.", http.StatusInternalServerError)
 			return
 		}
 
-		// 8. Associa o pedido de domínio à fatura (para referência futura)
-		_, err = tx.Exec("UPDATE domain_orders SET invoice_id = ? WHERE id = ?", invoiceID, orderID)
+		// 9. Associa o pedido de domínio à fatura: o pagamento dela inicia o provisionamento
+		_, err = tx.Exec("UPDATE domain_orders SET invoice_id = $1 WHERE id = $2", invoiceID, orderID)
 		if err != nil {
 			log.Printf("Erro ao associar fatura ao pedido: %v", err)
 			http.Error(w, "Erro ao processar seu pedido.", http.StatusInternalServerError)
@@ -109,29 +188,23 @@ func OrderDomainHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// 9. Gera a notificação para o admin (neste ponto, apenas um log)
-		log.Printf("### [ADMIN] NOVA PRIORIDADE: REGISTRO DE DOMÍNIO MANUAL ###")
-		log.Printf("### Cliente: %d | Domínio: %s | Documento: %s | Fatura: %d ###", userID, domain, req.Document, invoiceID)
+		log.Printf("Pedido de domínio #%d criado | Cliente: %d | Domínio: %s | Fatura: %d", orderID, clientRecord.ID, domain, invoiceID)
 
 		// 10. Retorna sucesso para o cliente
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(OrderDomainResponse{
-			Message:   fmt.Sprintf("Pedido para o domínio %s criado com sucesso! Pague a fatura para iniciar o processo de registro.", domain),
-			InvoiceID: int(invoiceID),
+			Message:   fmt.Sprintf("Pedido para o domínio %s criado com sucesso! Pague a fatura para iniciar o processo de %s.", domain, orderProcessName(req.Type)),
+			InvoiceID: invoiceID,
 			Domain:    domain,
 		})
 	}
 }
 
-// isDomainAvailable é uma função auxiliar para verificar a disponibilidade no RDAP.
-func isDomainAvailable(domain string) bool {
-	rdapURL := fmt.Sprintf("https://rdap.registro.br/domain/%s", domain)
-	resp, err := http.Get(rdapURL)
-	if err != nil {
-		log.Printf("Erro ao consultar RDAP para %s: %v", domain, err)
-		return false // Por segurança, se a API falhar, não permite o registro
+// orderProcessName descreve o processo iniciado pelo pagamento do pedido.
+func orderProcessName(domainType models.DomainType) string {
+	if domainType == models.DomainTypeTransfer {
+		return "transferência"
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusNotFound
+	return "registro"
 }
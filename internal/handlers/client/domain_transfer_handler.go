@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDomainTransferHandler retorna a situação da transferência de um domínio do cliente, com o motivo
+// da recusa quando a transferência aguarda correção.
+// Rota: GET /api/my-domains/{id}/transfer
+func GetDomainTransferHandler(transfers *services.DomainTransferService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		transfer, err := transfers.GetForClient(userID, domainID)
+		if err != nil {
+			writeDomainTransferError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transfer)
+	}
+}
+
+// CorrectDomainTransferHandler reenvia uma transferência recusada: com um novo código EPP ou, se o
+// motivo foi o bloqueio no registrador atual, com a confirmação de que o domínio foi desbloqueado.
+// Rota: PUT /api/my-domains/{id}/transfer
+func CorrectDomainTransferHandler(transfers *services.DomainTransferService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		domainID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req struct {
+			AuthCode string `json:"auth_code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		transfer, err := transfers.Correct(userID, domainID, req.AuthCode)
+		if err != nil {
+			writeDomainTransferError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(transfer)
+	}
+}
+
+func writeDomainTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrDomainNotFound), errors.Is(err, services.ErrDomainTransferNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrDomainTransferNotCorrectable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidAuthCode):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Erro ao processar a transferência do domínio", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,358 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetMyEmailOverviewHandler retorna os domínios, os limites do plano e o uso de e-mail da conta do serviço.
+// Rota: GET /api/my-services/{id}/email
+func GetMyEmailOverviewHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		overview, err := mailboxes.Overview(userID, serviceID)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(overview)
+	}
+}
+
+// ListMyMailboxesHandler lista as contas de e-mail do serviço com o uso de disco e a cota.
+// Rota: GET /api/my-services/{id}/email/accounts
+func ListMyMailboxesHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		list, err := mailboxes.ListMailboxes(userID, serviceID)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// CreateMyMailboxHandler cria uma conta de e-mail. Sem quota_mb, usa a cota máxima do plano.
+// Rota: POST /api/my-services/{id}/email/accounts
+func CreateMyMailboxHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+			QuotaMB  *int   `json:"quota_mb"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" || req.Password == "" {
+			http.Error(w, "Informe email e password", http.StatusBadRequest)
+			return
+		}
+
+		mailbox, err := mailboxes.CreateMailbox(userID, serviceID, req.Email, req.Password, req.QuotaMB)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(mailbox)
+	}
+}
+
+// DeleteMyMailboxHandler remove uma conta de e-mail e as mensagens dela.
+// Rota: DELETE /api/my-services/{id}/email/accounts/{email}
+func DeleteMyMailboxHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		if err := mailboxes.DeleteMailbox(userID, serviceID, vars["email"]); err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ChangeMyMailboxPasswordHandler altera a senha de uma conta de e-mail.
+// Rota: PUT /api/my-services/{id}/email/accounts/{email}/password
+func ChangeMyMailboxPasswordHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+			http.Error(w, "Informe password", http.StatusBadRequest)
+			return
+		}
+
+		if err := mailboxes.ChangePassword(userID, serviceID, vars["email"], req.Password); err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ChangeMyMailboxQuotaHandler altera a cota de uma conta de e-mail, em MB.
+// Rota: PUT /api/my-services/{id}/email/accounts/{email}/quota
+func ChangeMyMailboxQuotaHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		var req struct {
+			QuotaMB *int `json:"quota_mb"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.QuotaMB == nil {
+			http.Error(w, "Informe quota_mb", http.StatusBadRequest)
+			return
+		}
+
+		mailbox, err := mailboxes.ChangeQuota(userID, serviceID, vars["email"], *req.QuotaMB)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mailbox)
+	}
+}
+
+// ListMyEmailForwardersHandler lista os redirecionamentos de e-mail do serviço.
+// Rota: GET /api/my-services/{id}/email/forwarders
+func ListMyEmailForwardersHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		list, err := mailboxes.ListForwarders(userID, serviceID)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// AddMyEmailForwarderHandler redireciona um endereço da conta para outro endereço.
+// Rota: POST /api/my-services/{id}/email/forwarders
+func AddMyEmailForwarderHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req provisioning.EmailForwarder
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Source == "" || req.Destination == "" {
+			http.Error(w, "Informe source e destination", http.StatusBadRequest)
+			return
+		}
+
+		forwarder, err := mailboxes.AddForwarder(userID, serviceID, req.Source, req.Destination)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(forwarder)
+	}
+}
+
+// DeleteMyEmailForwarderHandler remove um redirecionamento.
+// Rota: DELETE /api/my-services/{id}/email/forwarders/{source}/{destination}
+func DeleteMyEmailForwarderHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		if err := mailboxes.DeleteForwarder(userID, serviceID, vars["source"], vars["destination"]); err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListMyAutorespondersHandler lista as respostas automáticas de todos os domínios do serviço.
+// Rota: GET /api/my-services/{id}/email/autoresponders
+func ListMyAutorespondersHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		list, err := mailboxes.ListAutoresponders(userID, serviceID)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetMyAutoresponderHandler retorna a resposta automática completa de um endereço.
+// Rota: GET /api/my-services/{id}/email/autoresponders/{email}
+func GetMyAutoresponderHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		autoresponder, err := mailboxes.GetAutoresponder(userID, serviceID, vars["email"])
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(autoresponder)
+	}
+}
+
+// SaveMyAutoresponderHandler cria ou substitui a resposta automática de um endereço.
+// Rota: PUT /api/my-services/{id}/email/autoresponders/{email}
+func SaveMyAutoresponderHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		var req provisioning.Autoresponder
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		req.Email = vars["email"]
+
+		autoresponder, err := mailboxes.SaveAutoresponder(userID, serviceID, req)
+		if err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(autoresponder)
+	}
+}
+
+// DeleteMyAutoresponderHandler remove a resposta automática de um endereço.
+// Rota: DELETE /api/my-services/{id}/email/autoresponders/{email}
+func DeleteMyAutoresponderHandler(mailboxes *services.MailboxService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		serviceID, _ := strconv.Atoi(vars["id"])
+
+		if err := mailboxes.DeleteAutoresponder(userID, serviceID, vars["email"]); err != nil {
+			writeEmailError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeEmailError(w http.ResponseWriter, err error) {
+	var whmErr *provisioning.WhmAPIError
+	switch {
+	case errors.Is(err, services.ErrServiceNotFound), errors.Is(err, services.ErrEmailDomainNotFound),
+		errors.Is(err, services.ErrMailboxNotFound), errors.Is(err, services.ErrForwarderNotFound),
+		errors.Is(err, services.ErrAutoresponderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrServiceNotManageable), errors.Is(err, services.ErrEmailLimitReached):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidEmailRequest):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &whmErr):
+		http.Error(w, "O servidor recusou a operação: "+whmErr.Reason, http.StatusBadGateway)
+	default:
+		http.Error(w, "Erro ao processar as contas de e-mail", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// MyFiscalDocument é a visão de uma nota fiscal exposta ao cliente: apenas número, status, datas
+// e os links de download. Dados internos do provedor e do cancelamento ficam de fora.
+type MyFiscalDocument struct {
+	ID           int64                       `json:"id"`
+	InvoiceID    int64                       `json:"invoice_id"`
+	NFNumber     string                      `json:"nf_number,omitempty"`
+	Status       models.FiscalDocumentStatus `json:"status"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	AuthorizedAt *time.Time                  `json:"authorized_at,omitempty"`
+	CancelledAt  *time.Time                  `json:"cancelled_at,omitempty"`
+	PDFURL       string                      `json:"pdf_url,omitempty"`
+	XMLURL       string                      `json:"xml_url,omitempty"`
+}
+
+func newMyFiscalDocument(doc *models.FiscalDocument) MyFiscalDocument {
+	view := MyFiscalDocument{
+		ID:        doc.ID,
+		InvoiceID: doc.InvoiceID,
+		NFNumber:  doc.NFNumber.String,
+		Status:    doc.Status,
+		CreatedAt: doc.CreatedAt,
+	}
+	if doc.AuthorizedAt.Valid {
+		view.AuthorizedAt = &doc.AuthorizedAt.Time
+	}
+	if doc.CancelledAt.Valid {
+		view.CancelledAt = &doc.CancelledAt.Time
+	}
+
+	// Os links apontam para a rota de download, que confere o dono da nota antes de redirecionar.
+	if fiscalDocumentDownloadable(doc) {
+		if doc.PDFURL.Valid && doc.PDFURL.String != "" {
+			view.PDFURL = fmt.Sprintf("/api/my-fiscal-documents/%d/pdf", doc.ID)
+		}
+		if doc.XMLURL.Valid && doc.XMLURL.String != "" {
+			view.XMLURL = fmt.Sprintf("/api/my-fiscal-documents/%d/xml", doc.ID)
+		}
+	}
+	return view
+}
+
+func fiscalDocumentDownloadable(doc *models.FiscalDocument) bool {
+	return doc.Status == models.FiscalDocumentStatusAuthorized || doc.Status == models.FiscalDocumentStatusCancelled
+}
+
+// ListMyFiscalDocumentsHandler lista as notas fiscais (NFS-e) das faturas do cliente autenticado.
+// Rota: GET /api/my-fiscal-documents
+func ListMyFiscalDocumentsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		docs, err := models.GetFiscalDocumentsByUserID(db, userID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar as notas fiscais", http.StatusInternalServerError)
+			return
+		}
+
+		views := make([]MyFiscalDocument, 0, len(docs))
+		for i := range docs {
+			views = append(views, newMyFiscalDocument(&docs[i]))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(views)
+	}
+}
+
+// DownloadMyFiscalDocumentHandler redireciona o cliente para o PDF ou o XML de uma nota fiscal sua.
+// Rota: GET /api/my-fiscal-documents/{id}/{format:pdf|xml}
+func DownloadMyFiscalDocumentHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+
+		vars := mux.Vars(r)
+		documentID, err := strconv.ParseInt(vars["id"], 10, 64)
+		if err != nil {
+			http.Error(w, "ID de documento inválido", http.StatusBadRequest)
+			return
+		}
+
+		// A busca já filtra pelo dono da fatura, então documentos de outros clientes aparecem como inexistentes.
+		doc, err := models.GetFiscalDocumentForUser(db, documentID, userID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar a nota fiscal", http.StatusInternalServerError)
+			return
+		}
+		if doc == nil {
+			http.Error(w, "Nota fiscal não encontrada", http.StatusNotFound)
+			return
+		}
+
+		if !fiscalDocumentDownloadable(doc) {
+			http.Error(w, "A nota fiscal ainda não está disponível para download", http.StatusConflict)
+			return
+		}
+
+		target := doc.PDFURL
+		if vars["format"] == "xml" {
+			target = doc.XMLURL
+		}
+		if !target.Valid || target.String == "" {
+			http.Error(w, "Arquivo da nota fiscal indisponível", http.StatusNotFound)
+			return
+		}
+
+		http.Redirect(w, r, target.String, http.StatusFound)
+	}
+}
@@ -37,7 +37,7 @@ func CheckoutHandler(db *sql.DB) http.HandlerFunc {
 		// Busca os detalhes da fatura no banco de dados
 		var totalAmount float64
 		var clientID int
-		err := db.QueryRow("SELECT client_id, total_amount FROM invoices WHERE id = ? AND status = 'unpaid'", invoiceID).Scan(&clientID, &totalAmount)
+		err := db.QueryRow("SELECT client_id, total_amount FROM invoices WHERE id = $1 AND status = 'unpaid'", invoiceID).Scan(&clientID, &totalAmount)
 		if err == sql.ErrNoRows {
 			http.Error(w, "Fatura não encontrada ou já paga", http.StatusNotFound)
 			return
@@ -48,7 +48,7 @@ func CheckoutHandler(db *sql.DB) http.HandlerFunc {
 
 		// Obter o asaas_customer_id do cliente
 		var asaasCustomerID sql.NullString
-		err = db.QueryRow("SELECT asaas_customer_id FROM clients WHERE id = ?", clientID).Scan(&asaasCustomerID)
+		err = db.QueryRow("SELECT asaas_customer_id FROM clients WHERE id = $1", clientID).Scan(&asaasCustomerID)
 		if err != nil {
 			http.Error(w, "Erro ao buscar cliente", http.StatusInternalServerError)
 			return
@@ -77,7 +77,7 @@ func CheckoutHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Salvar o ID de pagamento do Asaas na fatura
-		_, err = db.Exec("UPDATE invoices SET asaas_payment_id = ? WHERE id = ?", asaasPayment.ID, invoiceID)
+		_, err = db.Exec("UPDATE invoices SET asaas_payment_id = $1 WHERE id = $2", asaasPayment.ID, invoiceID)
 		if err != nil {
 			log.Printf("Erro ao salvar ID de pagamento do Asaas: %v", err)
 			// Não bloqueia a resposta para o cliente, mas loga o erro.
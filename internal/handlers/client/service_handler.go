@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"strconv"
 
+	"hosting-backend/internal/middleware"
 	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
 )
 
 // ListMyServicesHandler lista todos os serviços associados ao cliente autenticado.
@@ -33,16 +35,20 @@ func ListMyServicesHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// GetServiceDetailsHandler busca os detalhes de um serviço específico do cliente.
+// GetServiceDetailsHandler busca os detalhes de um serviço específico do cliente, com a situação dos
+// certificados SSL da última leitura do servidor e os limites de e-mail do plano.
 // Rota: GET /api/my-services/{id}
-func GetServiceDetailsHandler(db *sql.DB) http.HandlerFunc {
+func GetServiceDetailsHandler(db *sql.DB, certificates *services.SSLCertificateService, mailboxes *services.MailboxService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Obter o ID do usuário autenticado a partir do contexto.
-		// TODO: Implementar a obtenção do UserID a partir do contexto da requisição
-		userID := 1 // Placeholder
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
 
 		// 2. Obter o ID do serviço a partir dos parâmetros da URL.
-		serviceIDStr := chi.URLParam(r, "id")
+		serviceIDStr := mux.Vars(r)["id"]
 		serviceID, err := strconv.Atoi(serviceIDStr)
 		if err != nil {
 			http.Error(w, "ID de serviço inválido", http.StatusBadRequest)
@@ -63,8 +69,26 @@ func GetServiceDetailsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// 5. Retornar os detalhes do serviço.
+		// 5. Certificados SSL em cache; a leitura atualizada fica em GET /api/my-services/{id}/ssl.
+		ssl, err := certificates.CachedStatus(service.ID)
+		if err != nil {
+			http.Error(w, "Erro ao buscar os certificados SSL do serviço", http.StatusInternalServerError)
+			return
+		}
+
+		// 6. Limites de e-mail do plano; o uso atual fica em GET /api/my-services/{id}/email.
+		emailLimits, err := mailboxes.Limits(service)
+		if err != nil {
+			http.Error(w, "Erro ao buscar os limites de e-mail do serviço", http.StatusInternalServerError)
+			return
+		}
+
+		// 7. Retornar os detalhes do serviço.
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(service)
+		json.NewEncoder(w).Encode(struct {
+			*models.Service
+			SSL         *services.ServiceSSLStatus `json:"ssl"`
+			EmailLimits *services.EmailLimits      `json:"email_limits"`
+		}{service, ssl, emailLimits})
 	}
 }
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hosting-backend/internal/middleware"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetMySSLStatusHandler lê no servidor os certificados SSL da conta do serviço: emissor, vencimento,
+// domínios cobertos e os problemas registrados pelo AutoSSL.
+// Rota: GET /api/my-services/{id}/ssl
+func GetMySSLStatusHandler(certificates *services.SSLCertificateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		status, err := certificates.GetStatus(userID, serviceID)
+		if err != nil {
+			writeSSLError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// RunMyAutoSSLHandler inicia uma verificação do AutoSSL para a conta do serviço.
+// Rota: POST /api/my-services/{id}/ssl/autossl
+func RunMyAutoSSLHandler(certificates *services.SSLCertificateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		if err := certificates.RunAutoSSL(userID, serviceID); err != nil {
+			writeSSLError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Verificação do AutoSSL iniciada. Os certificados emitidos aparecem em alguns minutos."})
+	}
+}
+
+// InstallMySSLCertificateHandler instala um certificado próprio (PEM) em um domínio da conta.
+// Rota: POST /api/my-services/{id}/ssl/certificates
+func InstallMySSLCertificateHandler(certificates *services.SSLCertificateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Usuário não autenticado", http.StatusUnauthorized)
+			return
+		}
+		serviceID, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		var req services.CustomCertificate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Domain == "" {
+			http.Error(w, "Informe domain, certificate e private_key", http.StatusBadRequest)
+			return
+		}
+
+		status, err := certificates.InstallCertificate(userID, serviceID, req)
+		if err != nil {
+			writeSSLError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+func writeSSLError(w http.ResponseWriter, err error) {
+	var whmErr *provisioning.WhmAPIError
+	switch {
+	case errors.Is(err, services.ErrServiceNotFound), errors.Is(err, services.ErrSSLDomainNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrServiceNotManageable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidCertificate):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &whmErr):
+		http.Error(w, "O servidor recusou a operação: "+whmErr.Reason, http.StatusBadGateway)
+	default:
+		http.Error(w, "Erro ao processar os certificados SSL", http.StatusInternalServerError)
+	}
+}
@@ -62,9 +62,29 @@ func OrderVpsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// 5. Criar o pedido de VPS (vps_order)
-		// A lógica para criar um vpsOrder e associá-lo à fatura deve ser implementada aqui.
-		// Por enquanto, vamos pular esta parte para focar na correção dos erros.
+		// 5. Criar o pedido de VPS (vps_order). A máquina é criada pela fila de provisionamento
+		// após o pagamento da fatura.
+		client, err := models.GetClientByUserID(db, userID)
+		if err != nil || client == nil {
+			tx.Rollback()
+			http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+			return
+		}
+		vpsOrder := models.VpsOrder{
+			ClientID:  client.ID,
+			InvoiceID: int64(invoice.ID),
+			PlanID:    req.PlanID,
+			Location:  req.Location,
+			Template:  req.Template,
+			Hostname:  req.Hostname,
+			Password:  req.Password,
+			Status:    "pending",
+		}
+		if _, err := vpsOrder.Create(tx); err != nil {
+			tx.Rollback()
+			http.Error(w, "Erro ao criar pedido de VPS", http.StatusInternalServerError)
+			return
+		}
 
 		// 6. Commit da transação
 		if err := tx.Commit(); err != nil {
@@ -73,7 +93,7 @@ func OrderVpsHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		// 7. Responder com o ID da fatura criada
-		response := map[string]int{"invoice_id": invoice.ID}
+		response := map[string]int{"invoice_id": invoice.ID, "vps_order_id": vpsOrder.ID}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(response)
@@ -1,29 +1,42 @@
 package domain
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
     "fmt"
 
+	"hosting-backend/internal/models"
 	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services"
+	"hosting-backend/internal/services/rdap"
 )
 
-// CheckDomainRequest é a estrutura para o corpo da requisição de verificação de domínio.
+// CheckDomainRequest é a estrutura para o corpo da requisição de verificação de domínio. Com
+// keyword, o nome é buscado em cada uma das TLDs (ou nas TLDs padrão), com sugestões opcionais.
 type CheckDomainRequest struct {
-	Domain string `json:"domain"`
+	Domain      string   `json:"domain"`
+	Keyword     string   `json:"keyword"`
+	TLDs        []string `json:"tlds"`
+	Suggestions bool     `json:"suggestions"`
 }
 
 // CheckDomainResponse é a estrutura para a resposta da verificação.
 type CheckDomainResponse struct {
-	Domain      string `json:"domain"`
-	Available   bool   `json:"available"`
-	Message     string `json:"message"`
+	Domain       string      `json:"domain"`
+	Available    bool        `json:"available"`
+	Status       rdap.Status `json:"status"`                  // available, registered ou unknown
+	Price        *float64    `json:"price"`                   // Preço de registro por um ano; nulo se a TLD não é vendida
+	RegularPrice *float64    `json:"regular_price,omitempty"` // Preço sem a promoção vigente, quando há uma
+	Message      string      `json:"message"`
 }
 
-// CheckDomainHandler lida com a verificação de disponibilidade de domínios via API da Hostinger.
-func CheckDomainHandler() http.HandlerFunc {
+// CheckDomainHandler lida com a verificação de disponibilidade de domínios via RDAP: um domínio
+// (domain) ou uma busca por palavra-chave em várias TLDs (keyword, tlds e suggestions).
+func CheckDomainHandler(db *sql.DB, search *services.DomainSearchService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CheckDomainRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -31,39 +44,63 @@ func CheckDomainHandler() http.HandlerFunc {
 			return
 		}
 
+		if strings.TrimSpace(req.Keyword) != "" {
+			searchDomains(w, search, req)
+			return
+		}
+
 		domain := strings.ToLower(strings.TrimSpace(req.Domain))
 		if domain == "" {
 			http.Error(w, "O campo 'domain' não pode ser vazio", http.StatusBadRequest)
 			return
 		}
 
-		// Inicializa o provisioner da Hostinger
-		hostinger, err := provisioning.NewHostingerProvisioner()
-		if err != nil {
-			log.Printf("Erro ao inicializar o provisioner da Hostinger: %v", err)
-			http.Error(w, "Erro interno do servidor - falha na configuração", http.StatusInternalServerError)
+		if _, _, ok := provisioning.SplitDomain(domain); !ok {
+			http.Error(w, "Domínio inválido", http.StatusBadRequest)
 			return
 		}
 
-		// Chama a API da Hostinger para verificar a disponibilidade
-		availability, err := hostinger.CheckDomainAvailability([]string{domain})
-		if err != nil {
-			log.Printf("Erro ao verificar disponibilidade do domínio '%s' na Hostinger: %v", domain, err)
-			http.Error(w, "Erro ao consultar serviço de domínios", http.StatusServiceUnavailable)
-			return
-		}
+		// Consulta o RDAP da TLD (com a Hostinger como alternativa quando o RDAP não responde)
+		result := services.CheckDomainAvailability(domain)
 
 		// Prepara a resposta
-		response := CheckDomainResponse{Domain: domain}
-		if result, ok := availability.Data[domain]; ok && result.Status == "available" {
+		_, tld, _ := strings.Cut(domain, ".")
+		response := CheckDomainResponse{Domain: domain, Status: result.Status}
+		quote, err := services.QuoteDomainPrice(db, tld, models.DomainPriceRegister, 1)
+		switch {
+		case err == nil:
+			response.Price = &quote.Price
+			if quote.PromotionID != nil {
+				response.RegularPrice = &quote.RegularPrice
+			}
+		case !errors.Is(err, services.ErrDomainPriceNotFound):
+			log.Printf("Falha ao buscar o preço de .%s: %v", tld, err)
+		}
+		switch result.Status {
+		case rdap.StatusAvailable:
 			response.Available = true
 			response.Message = fmt.Sprintf("Parabéns! O domínio %s está disponível para registro.", domain)
-		} else {
-			response.Available = false
+		case rdap.StatusRegistered:
 			response.Message = fmt.Sprintf("Que pena. O domínio %s não está disponível.", domain)
+		default:
+			log.Printf("Disponibilidade do domínio '%s' não confirmada: %s", domain, result.Reason)
+			response.Message = fmt.Sprintf("Não foi possível confirmar a disponibilidade do domínio %s agora. Tente novamente em instantes.", domain)
 		}
-        
+
         w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+// searchDomains responde a busca por palavra-chave com o resultado em cada TLD e as sugestões.
+func searchDomains(w http.ResponseWriter, search *services.DomainSearchService, req CheckDomainRequest) {
+	// Search só falha na validação da palavra-chave e das TLDs.
+	response, err := search.Search(req.Keyword, req.TLDs, req.Suggestions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
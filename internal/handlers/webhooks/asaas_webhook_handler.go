@@ -1,10 +1,15 @@
 package webhooks
 
 import (
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"log"
+
+	"hosting-backend/internal/config"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/orchestrator"
 )
 
 // WebhookEvent define a estrutura básica de um evento de webhook do Asaas.
@@ -16,9 +21,19 @@ type WebhookEvent struct {
 	} `json:"payment"`
 }
 
-// AsaasWebhookHandler processa os webhooks do Asaas.
+// asaasAccessTokenHeader é o cabeçalho em que o Asaas envia o token de autenticação do webhook.
+const asaasAccessTokenHeader = "asaas-access-token"
+
+// AsaasWebhookHandler processa os webhooks do Asaas. As requisições sem o token de autenticação
+// configurado em ASAAS_WEBHOOK_TOKEN são recusadas antes de qualquer leitura do corpo.
 func AsaasWebhookHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !validAsaasAccessToken(r.Header.Get(asaasAccessTokenHeader)) {
+			log.Printf("[Webhook Asaas] Requisição recusada: token de autenticação ausente ou inválido (origem %s)", r.RemoteAddr)
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
 		var event WebhookEvent
 		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
 			log.Printf("[Webhook Asaas] Erro ao decodificar o corpo da requisição: %v", err)
@@ -29,10 +44,10 @@ func AsaasWebhookHandler(db *sql.DB) http.HandlerFunc {
 		log.Printf("[Webhook Asaas] Evento recebido: %s, ID do Pagamento: %s, Status: %s", event.Event, event.Payment.ID, event.Payment.Status)
 
 		// Atualizar o status da fatura no banco de dados
-		var newStatus string
+		var newStatus models.InvoiceStatus
 		switch event.Event {
 		case "PAYMENT_CONFIRMED", "PAYMENT_RECEIVED":
-			newStatus = "paid"
+			newStatus = models.InvoiceStatusPaid
 		case "PAYMENT_OVERDUE":
 			newStatus = "overdue"
 		case "PAYMENT_CANCELED":
@@ -43,7 +58,7 @@ func AsaasWebhookHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		_, err := db.Exec("UPDATE invoices SET status = ? WHERE asaas_payment_id = ?", newStatus, event.Payment.ID)
+		invoiceIDs, err := models.UpdateInvoiceStatusByAsaasPaymentID(db, event.Payment.ID, newStatus)
 		if err != nil {
 			log.Printf("[Webhook Asaas] Erro ao atualizar o status da fatura: %v", err)
 			http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
@@ -51,6 +66,27 @@ func AsaasWebhookHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		log.Printf("[Webhook Asaas] Fatura com asaas_payment_id %s atualizada para o status '%s'", event.Payment.ID, newStatus)
+
+		// Fatura paga: inicia o provisionamento dos pedidos vinculados. Em caso de erro, o Asaas
+		// reenvia o evento, e o provisionamento não é duplicado.
+		if newStatus == models.InvoiceStatusPaid {
+			for _, invoiceID := range invoiceIDs {
+				if err := orchestrator.ProvisionPaidInvoice(db, invoiceID); err != nil {
+					log.Printf("[Webhook Asaas] Erro ao iniciar o provisionamento da fatura #%d: %v", invoiceID, err)
+					http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// validAsaasAccessToken compara, em tempo constante, o token recebido com o configurado. Sem token
+// configurado, todas as requisições são recusadas.
+func validAsaasAccessToken(token string) bool {
+	if config.AsaasWebhookToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(config.AsaasWebhookToken)) == 1
+}
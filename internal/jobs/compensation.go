@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"hosting-backend/internal/models"
+)
+
+// OpenStaffTask abre uma pendência na fila "precisa de atenção" para uma tarefa que foi para a fila
+// de mortas e cuja falha não pode ser compensada automaticamente. O erro da tarefa é anexado aos detalhes.
+func OpenStaffTask(db *sql.DB, job *models.Job, task *models.StaffTask, cause error) {
+	task.JobID = sql.NullInt64{Int64: job.ID, Valid: job.ID > 0}
+	details := fmt.Sprintf("Tarefa #%d (%s) falhou após %d tentativa(s): %v", job.ID, job.Type, job.Attempts, cause)
+	if task.Details.Valid && task.Details.String != "" {
+		details = task.Details.String + "\n\n" + details
+	}
+	task.Details = sql.NullString{String: details, Valid: true}
+
+	created, err := models.OpenStaffTask(db, task)
+	if err != nil {
+		log.Printf("[Job Queue] ERRO ao abrir pendência '%s' para %s #%s: %v", task.Kind, task.TargetType, task.TargetID, err)
+		return
+	}
+	if created {
+		log.Printf("[Job Queue] Pendência #%d aberta: %s", task.ID, task.Title)
+	}
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hosting-backend/internal/models"
+)
+
+const (
+	// Espera antes da primeira nova tentativa; dobra a cada falha até backoffMax.
+	backoffBase = 30 * time.Second
+	backoffMax  = 1 * time.Hour
+	// Tempo máximo de execução de uma tarefa. Enquanto o handler executa, o lock é renovado a cada
+	// lockRenewInterval, mesmo que ele ignore o cancelamento do contexto; tarefas com lock mais antigo
+	// que staleLockTimeout pertencem a uma instância que parou e voltam para a fila.
+	jobTimeout        = 10 * time.Minute
+	lockRenewInterval = 1 * time.Minute
+	staleLockTimeout  = 15 * time.Minute
+	// Limite padrão de tarefas simultâneas por provedor (JOB_CONCURRENCY_<PROVEDOR> sobrescreve).
+	defaultConcurrency = 2
+)
+
+// Spec descreve um tipo de tarefa: o provedor que ela acessa (para o limite de concorrência)
+// e o número máximo de tentativas antes de ir para a fila de mortas.
+type Spec struct {
+	Type        string
+	Provider    string
+	MaxAttempts int
+}
+
+// Handler executa uma tarefa. Um erro devolve a tarefa à fila com backoff exponencial; um erro
+// criado com Permanent a envia direto para a fila de mortas.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// DeadHandler é chamado quando a tarefa vai para a fila de mortas, para que o domínio da tarefa
+// registre a falha (ex: marcar o domínio como falho).
+type DeadHandler func(job *models.Job, err error)
+
+type registration struct {
+	spec   Spec
+	run    Handler
+	onDead DeadHandler
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marca um erro que não se resolve com novas tentativas (ex: payload inválido).
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Enqueue insere uma tarefa na fila. q pode ser uma transação, para que a tarefa só exista se o
+// registro que ela processa for gravado. Com dedupKey, uma tarefa idêntica já pendente não é duplicada.
+func Enqueue(q models.QueryRower, spec Spec, payload any, dedupKey string) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao serializar o payload da tarefa: %w", err)
+	}
+	job := &models.Job{
+		Type:        spec.Type,
+		Provider:    spec.Provider,
+		Payload:     data,
+		DedupKey:    sql.NullString{String: dedupKey, Valid: dedupKey != ""},
+		MaxAttempts: spec.MaxAttempts,
+	}
+	created, err := models.EnqueueJob(q, job)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao enfileirar a tarefa '%s': %w", spec.Type, err)
+	}
+	if !created {
+		log.Printf("[Job Queue] Tarefa '%s' (%s) já está na fila.", spec.Type, dedupKey)
+		return nil, nil
+	}
+	return job, nil
+}
+
+// Queue executa as tarefas da tabela `jobs`. Várias instâncias da API podem processar a mesma fila.
+type Queue struct {
+	db       *sql.DB
+	workerID string
+	handlers map[string]registration
+	limits   map[string]int
+	mu       sync.Mutex
+}
+
+// NewQueue cria uma nova instância de Queue.
+func NewQueue(db *sql.DB) *Queue {
+	hostname, _ := os.Hostname()
+	return &Queue{
+		db:       db,
+		workerID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		handlers: make(map[string]registration),
+		limits:   make(map[string]int),
+	}
+}
+
+// Register associa um tipo de tarefa ao seu handler. onDead é opcional.
+func (q *Queue) Register(spec Spec, run Handler, onDead DeadHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[spec.Type] = registration{spec: spec, run: run, onDead: onDead}
+}
+
+// concurrency retorna o limite de tarefas simultâneas do provedor.
+func (q *Queue) concurrency(provider string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit, ok := q.limits[provider]; ok {
+		return limit
+	}
+	limit := defaultConcurrency
+	if v, err := strconv.Atoi(os.Getenv("JOB_CONCURRENCY_" + strings.ToUpper(provider))); err == nil && v > 0 {
+		limit = v
+	}
+	q.limits[provider] = limit
+	return limit
+}
+
+// Poll devolve à fila as tarefas travadas e reserva as tarefas prontas de cada provedor, até o
+// limite de concorrência, executando-as em segundo plano.
+func (q *Queue) Poll() error {
+	if n, err := models.ReleaseStaleJobs(q.db, time.Now().Add(-staleLockTimeout)); err != nil {
+		log.Printf("[Job Queue] ERRO ao liberar tarefas travadas: %v", err)
+	} else if n > 0 {
+		log.Printf("[Job Queue] %d tarefa(s) interrompida(s) devolvida(s) à fila.", n)
+	}
+
+	providers, err := models.GetQueuedJobProviders(q.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar as tarefas pendentes: %w", err)
+	}
+	for _, provider := range providers {
+		limit := q.concurrency(provider)
+		for {
+			job, err := models.ClaimJob(q.db, provider, limit, q.workerID)
+			if err != nil {
+				log.Printf("[Job Queue] ERRO ao reservar tarefa do provedor '%s': %v", provider, err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			go q.execute(job)
+		}
+	}
+	return nil
+}
+
+func (q *Queue) execute(job *models.Job) {
+	q.mu.Lock()
+	reg, ok := q.handlers[job.Type]
+	q.mu.Unlock()
+	if !ok {
+		q.kill(job, reg, fmt.Errorf("nenhum handler registrado para o tipo '%s'", job.Type))
+		return
+	}
+	if job.Attempts > job.MaxAttempts {
+		q.kill(job, reg, fmt.Errorf("tentativas esgotadas (%d): %s", job.MaxAttempts, job.LastError.String))
+		return
+	}
+
+	err := q.run(reg, job)
+	if err == nil {
+		if err := models.CompleteJob(q.db, job.ID); err != nil {
+			log.Printf("[Job Queue] ERRO ao concluir a tarefa #%d: %v", job.ID, err)
+		}
+		log.Printf("[Job Queue] Tarefa #%d (%s) concluída.", job.ID, job.Type)
+		return
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		q.kill(job, reg, err)
+		return
+	}
+
+	runAt := time.Now().Add(backoff(job.Attempts))
+	log.Printf("[Job Queue] Tarefa #%d (%s) falhou na tentativa %d/%d: %v. Nova tentativa em %s.",
+		job.ID, job.Type, job.Attempts, job.MaxAttempts, err, runAt.Format(time.RFC3339))
+	if err := models.RescheduleJob(q.db, job.ID, runAt, err.Error()); err != nil {
+		log.Printf("[Job Queue] ERRO ao reagendar a tarefa #%d: %v", job.ID, err)
+	}
+}
+
+// run executa o handler com timeout, convertendo um panic em erro. O lock da tarefa é renovado até
+// o handler retornar, para que outra instância não a reserve enquanto ela ainda executa.
+func (q *Queue) run(reg registration, job *models.Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+	stop := q.renewLock(job)
+	defer stop()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic na execução da tarefa: %v", r)
+		}
+	}()
+	return reg.run(ctx, job)
+}
+
+// renewLock renova periodicamente o lock da tarefa. A função retornada encerra a renovação.
+func (q *Queue) renewLock(job *models.Job) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(lockRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := models.RenewJobLock(q.db, job.ID, q.workerID); err != nil {
+					log.Printf("[Job Queue] ERRO ao renovar o lock da tarefa #%d: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (q *Queue) kill(job *models.Job, reg registration, err error) {
+	log.Printf("[Job Queue] Tarefa #%d (%s) movida para a fila de mortas: %v", job.ID, job.Type, err)
+	if dbErr := models.KillJob(q.db, job.ID, err.Error()); dbErr != nil {
+		log.Printf("[Job Queue] ERRO ao mover a tarefa #%d para a fila de mortas: %v", job.ID, dbErr)
+	}
+	if reg.onDead != nil {
+		reg.onDead(job, err)
+	}
+}
+
+// backoff calcula a espera antes da próxima tentativa, com variação aleatória de até 20%
+// para que tarefas que falharam juntas não voltem todas ao mesmo tempo.
+func backoff(attempt int) time.Duration {
+	delay := backoffMax
+	if attempt < 20 {
+		delay = backoffBase << (attempt - 1)
+		if delay > backoffMax {
+			delay = backoffMax
+		}
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// DecodePayload decodifica o payload da tarefa. Um payload inválido é um erro permanente.
+func DecodePayload(job *models.Job, out any) error {
+	if err := json.Unmarshal(job.Payload, out); err != nil {
+		return Permanent(fmt.Errorf("payload inválido para a tarefa '%s': %w", job.Type, err))
+	}
+	return nil
+}
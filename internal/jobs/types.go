@@ -0,0 +1,17 @@
+package jobs
+
+// Tipos de tarefa da fila de provisionamento. O provedor agrupa as tarefas que disputam o mesmo
+// limite de concorrência (JOB_CONCURRENCY_HOSTINGER, JOB_CONCURRENCY_WHM, JOB_CONCURRENCY_HOSTING, JOB_CONCURRENCY_EMAIL).
+var (
+	// Etapas do provisionamento de domínio (ver orchestrator.DomainStep). Payload: {"domain_id", "single"}.
+	DomainRegister      = Spec{Type: "domain.register_domain", Provider: "hostinger", MaxAttempts: 8}
+	DomainCreateAccount = Spec{Type: "domain.create_account", Provider: "whm", MaxAttempts: 8}
+	DomainConfigureDNS  = Spec{Type: "domain.configure_dns", Provider: "hostinger", MaxAttempts: 8}
+	DomainWelcomeEmail  = Spec{Type: "domain.send_welcome_email", Provider: "email", MaxAttempts: 5}
+	// Envio ao registrador da renovação paga de um domínio. Payload: {"renewal_id"}.
+	DomainRenew = Spec{Type: "domain.renew", Provider: "hostinger", MaxAttempts: 8}
+	// Criação da conta de um serviço de hospedagem no servidor escolhido pelo plano (WHM ou Plesk).
+	HostingProvisionAccount = Spec{Type: "hosting.provision_account", Provider: "hosting", MaxAttempts: 6}
+	// Criação da máquina virtual de um pedido de VPS. Payload: {"vps_order_id"}.
+	VpsProvision = Spec{Type: "vps.provision", Provider: "hostinger", MaxAttempts: 6}
+)
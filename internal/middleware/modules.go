@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"hosting-backend/internal/modules"
+)
+
+// RequireModule bloqueia a rota enquanto o módulo informado estiver desativado.
+// O estado é consultado a cada requisição, então ativar ou desativar o módulo tem efeito sem reiniciar a API.
+func RequireModule(registry *modules.Registry, moduleName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !registry.IsEnabled(moduleName) {
+				http.Error(w, "Este recurso não está disponível no momento.", http.StatusNotFound)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
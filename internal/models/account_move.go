@@ -0,0 +1,178 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// AccountMoveStatus define o status de uma migração de conta entre servidores.
+type AccountMoveStatus string
+
+const (
+	AccountMoveStatusPending   AccountMoveStatus = "pending"
+	AccountMoveStatusRunning   AccountMoveStatus = "running"
+	AccountMoveStatusFailed    AccountMoveStatus = "failed"
+	AccountMoveStatusCompleted AccountMoveStatus = "completed"
+)
+
+// AccountMoveStep é um passo da migração. Os passos são executados nesta ordem e cada um pode ser
+// repetido com segurança, o que permite retomar uma migração a partir do passo que falhou.
+type AccountMoveStep string
+
+const (
+	AccountMoveStepTransfer     AccountMoveStep = "transfer"      // Cria a sessão do Transfer Tool no destino (pkgacct na origem e restore no destino)
+	AccountMoveStepWaitTransfer AccountMoveStep = "wait_transfer" // Aguarda a conclusão da sessão de transferência
+	AccountMoveStepVerify       AccountMoveStep = "verify"        // Confere a conta restaurada no destino
+	AccountMoveStepUpdateDNS    AccountMoveStep = "update_dns"    // Aponta os registros da zona na origem para o novo IP
+	AccountMoveStepSwitchServer AccountMoveStep = "switch_server" // Atualiza services.server_id
+	AccountMoveStepRemoveSource AccountMoveStep = "remove_source" // Remove a conta da origem
+	AccountMoveStepDone         AccountMoveStep = "done"
+)
+
+// AccountMoveOptions são as opções de uma migração, gravadas na coluna options.
+type AccountMoveOptions struct {
+	SSHUser              string `json:"ssh_user,omitempty"`
+	SSHPasswordEncrypted string `json:"ssh_password_encrypted,omitempty"` // utils.Encrypt
+	SSHKeyName           string `json:"ssh_key_name,omitempty"`           // Chave já instalada no servidor de destino
+	KeepSource           bool   `json:"keep_source"`                      // Não remove a conta da origem ao final
+}
+
+// AccountMove representa a migração de um serviço de hospedagem para outro servidor.
+type AccountMove struct {
+	ID                int64             `json:"id"`
+	ServiceID         int               `json:"service_id"`
+	SourceServerID    int               `json:"source_server_id"`
+	TargetServerID    int               `json:"target_server_id"`
+	Status            AccountMoveStatus `json:"status"`
+	CurrentStep       AccountMoveStep   `json:"current_step"`
+	TransferSessionID sql.NullString    `json:"transfer_session_id"`
+	SourceIP          sql.NullString    `json:"source_ip"`
+	TargetIP          sql.NullString    `json:"target_ip"`
+	Options           json.RawMessage   `json:"-"` // Contém a senha SSH criptografada
+	Attempts          int               `json:"attempts"`
+	LastError         sql.NullString    `json:"last_error"`
+	CreatedBy         sql.NullInt64     `json:"created_by"`
+	StartedAt         sql.NullTime      `json:"started_at"`
+	CompletedAt       sql.NullTime      `json:"completed_at"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// ParsedOptions decodifica as opções da migração.
+func (m *AccountMove) ParsedOptions() AccountMoveOptions {
+	var opts AccountMoveOptions
+	if len(m.Options) > 0 {
+		_ = json.Unmarshal(m.Options, &opts)
+	}
+	return opts
+}
+
+// AccountMoveEvent é um registro do histórico de execução de uma migração.
+type AccountMoveEvent struct {
+	ID        int64           `json:"id"`
+	MoveID    int64           `json:"move_id"`
+	Step      AccountMoveStep `json:"step"`
+	Result    string          `json:"result"`
+	Message   sql.NullString  `json:"message"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const accountMoveColumns = `id, service_id, source_server_id, target_server_id, status, current_step, transfer_session_id, source_ip, target_ip,
+	options, attempts, last_error, created_by, started_at, completed_at, created_at, updated_at`
+
+func scanAccountMove(row interface{ Scan(...any) error }) (*AccountMove, error) {
+	var m AccountMove
+	var options []byte
+	err := row.Scan(&m.ID, &m.ServiceID, &m.SourceServerID, &m.TargetServerID, &m.Status, &m.CurrentStep, &m.TransferSessionID, &m.SourceIP, &m.TargetIP,
+		&options, &m.Attempts, &m.LastError, &m.CreatedBy, &m.StartedAt, &m.CompletedAt, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	m.Options = options
+	return &m, nil
+}
+
+func queryAccountMoves(db *sql.DB, query string, args ...any) ([]AccountMove, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []AccountMove
+	for rows.Next() {
+		m, err := scanAccountMove(rows)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, *m)
+	}
+	return moves, rows.Err()
+}
+
+// CreateAccountMove registra uma nova migração pendente.
+func CreateAccountMove(db *sql.DB, m *AccountMove) error {
+	query := `INSERT INTO account_moves (service_id, source_server_id, target_server_id, status, current_step, options, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`
+	return db.QueryRow(query, m.ServiceID, m.SourceServerID, m.TargetServerID, AccountMoveStatusPending, AccountMoveStepTransfer,
+		nullableJSON(m.Options), m.CreatedBy).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+}
+
+// GetAccountMoveByID busca uma migração pelo ID.
+func GetAccountMoveByID(db *sql.DB, id int64) (*AccountMove, error) {
+	m, err := scanAccountMove(db.QueryRow(`SELECT `+accountMoveColumns+` FROM account_moves WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetAllAccountMoves lista as migrações, da mais recente para a mais antiga.
+func GetAllAccountMoves(db *sql.DB, limit int) ([]AccountMove, error) {
+	return queryAccountMoves(db, `SELECT `+accountMoveColumns+` FROM account_moves ORDER BY id DESC LIMIT $1`, limit)
+}
+
+// GetRunnableAccountMoves lista as migrações pendentes ou em andamento, na ordem de criação.
+func GetRunnableAccountMoves(db *sql.DB) ([]AccountMove, error) {
+	return queryAccountMoves(db, `SELECT `+accountMoveColumns+` FROM account_moves WHERE status IN ($1, $2) ORDER BY id ASC`,
+		AccountMoveStatusPending, AccountMoveStatusRunning)
+}
+
+// UpdateAccountMoveProgress grava o estado de uma migração após a execução de um passo.
+func UpdateAccountMoveProgress(db *sql.DB, m *AccountMove) error {
+	query := `UPDATE account_moves SET status = $1, current_step = $2, transfer_session_id = $3, source_ip = $4, target_ip = $5,
+		attempts = $6, last_error = $7, started_at = $8, completed_at = $9 WHERE id = $10`
+	_, err := db.Exec(query, m.Status, m.CurrentStep, m.TransferSessionID, m.SourceIP, m.TargetIP,
+		m.Attempts, m.LastError, m.StartedAt, m.CompletedAt, m.ID)
+	return err
+}
+
+// CreateAccountMoveEvent registra um evento no histórico da migração.
+func CreateAccountMoveEvent(db *sql.DB, moveID int64, step AccountMoveStep, result, message string) error {
+	_, err := db.Exec(`INSERT INTO account_move_events (move_id, step, result, message) VALUES ($1, $2, $3, $4)`,
+		moveID, step, result, sql.NullString{String: message, Valid: message != ""})
+	return err
+}
+
+// GetAccountMoveEvents lista o histórico de uma migração em ordem cronológica.
+func GetAccountMoveEvents(db *sql.DB, moveID int64) ([]AccountMoveEvent, error) {
+	rows, err := db.Query(`SELECT id, move_id, step, result, message, created_at FROM account_move_events WHERE move_id = $1 ORDER BY id ASC`, moveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []AccountMoveEvent
+	for rows.Next() {
+		var e AccountMoveEvent
+		if err := rows.Scan(&e.ID, &e.MoveID, &e.Step, &e.Result, &e.Message, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
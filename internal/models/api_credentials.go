@@ -30,7 +30,7 @@ type ApiCredential struct {
 
 // GetApiCredentialsForAdmin lista as credenciais para o painel admin (sem a chave).
 func GetApiCredentialsForAdmin(db *sql.DB) ([]ApiCredential, error) {
-	query := `SELECT provider, status, last_test_at, last_error, updated_at FROM api_credentials`
+	query := `SELECT provider, status, last_test_at, last_error, updated_at FROM api_credentials WHERE NOT server_scoped`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -56,7 +56,7 @@ func UpsertApiCredential(db *sql.DB, provider ApiProvider, apiKey string, status
 	}
 
 	query := `INSERT INTO api_credentials (provider, encrypted_key, status) VALUES ($1, $2, $3)
-			   ON CONFLICT (provider) DO UPDATE SET encrypted_key = EXCLUDED.encrypted_key, status = EXCLUDED.status`
+			   ON CONFLICT (provider) WHERE NOT server_scoped DO UPDATE SET encrypted_key = EXCLUDED.encrypted_key, status = EXCLUDED.status`
 	_, err = db.Exec(query, provider, encryptedKey, status)
 	return err
 }
@@ -64,7 +64,7 @@ func UpsertApiCredential(db *sql.DB, provider ApiProvider, apiKey string, status
 // GetDecryptedApiKey busca e descriptografa uma chave de API para uso interno.
 func GetDecryptedApiKey(db *sql.DB, provider ApiProvider) (string, error) {
 	var encryptedKey string
-	query := `SELECT encrypted_key FROM api_credentials WHERE provider = $1 AND status = 'active'`
+	query := `SELECT encrypted_key FROM api_credentials WHERE provider = $1 AND status = 'active' AND NOT server_scoped`
 	err := db.QueryRow(query, provider).Scan(&encryptedKey)
 	if err != nil {
 		return "", err
@@ -92,3 +92,41 @@ func UpdateApiTestResult(db *sql.DB, provider ApiProvider, testErr error) error
 	}
 	return err
 }
+
+// SaveServerCredential grava a credencial de um servidor. Se o servidor já tiver uma credencial
+// (apiKeyID válido), ela é substituída; caso contrário, uma nova é criada. Retorna o ID da credencial.
+func SaveServerCredential(db *sql.DB, apiKeyID sql.NullInt64, provider ApiProvider, username, apiKey string) (int64, error) {
+	encryptedKey, err := utils.Encrypt([]byte(apiKey))
+	if err != nil {
+		return 0, err
+	}
+
+	if apiKeyID.Valid {
+		query := `UPDATE api_credentials SET provider = $1, username = $2, encrypted_key = $3, status = 'active' WHERE id = $4 AND server_scoped`
+		if _, err := db.Exec(query, provider, username, encryptedKey, apiKeyID.Int64); err != nil {
+			return 0, err
+		}
+		return apiKeyID.Int64, nil
+	}
+
+	var id int64
+	query := `INSERT INTO api_credentials (provider, username, encrypted_key, status, server_scoped) VALUES ($1, $2, $3, 'active', TRUE) RETURNING id`
+	err = db.QueryRow(query, provider, username, encryptedKey).Scan(&id)
+	return id, err
+}
+
+// GetDecryptedServerCredential busca e descriptografa a credencial de um servidor pelo ID.
+func GetDecryptedServerCredential(db *sql.DB, apiKeyID int64) (username string, apiKey string, err error) {
+	var encryptedKey string
+	var user sql.NullString
+	query := `SELECT username, encrypted_key FROM api_credentials WHERE id = $1 AND status = 'active'`
+	if err := db.QueryRow(query, apiKeyID).Scan(&user, &encryptedKey); err != nil {
+		return "", "", err
+	}
+
+	decryptedKey, err := utils.Decrypt(encryptedKey)
+	if err != nil {
+		return "", "", err
+	}
+	return user.String, string(decryptedKey), nil
+}
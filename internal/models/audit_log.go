@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// AuditLogResult define o resultado registrado para uma ação auditada.
+type AuditLogResult string
+
+const (
+	AuditLogResultSuccess AuditLogResult = "success"
+	AuditLogResultFailure AuditLogResult = "failure"
+)
+
+// AuditLog representa a tabela audit_logs
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	StaffID    sql.NullInt64   `json:"staff_id"`
+	IPAddress  sql.NullString  `json:"ip_address"`
+	Action     string          `json:"action"`
+	TargetType sql.NullString  `json:"target_type"`
+	TargetID   sql.NullString  `json:"target_id"`
+	OldValue   json.RawMessage `json:"old_value"`
+	NewValue   json.RawMessage `json:"new_value"`
+	Result     AuditLogResult  `json:"result"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// CreateAuditLog registra uma ação no log de auditoria.
+// OldValue e NewValue podem ser nulos quando a ação não altera dados.
+func CreateAuditLog(db *sql.DB, entry *AuditLog) error {
+	query := `INSERT INTO audit_logs (staff_id, ip_address, action, target_type, target_id, old_value, new_value, result)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := db.Exec(query, entry.StaffID, entry.IPAddress, entry.Action, entry.TargetType, entry.TargetID,
+		nullableJSON(entry.OldValue), nullableJSON(entry.NewValue), entry.Result)
+	return err
+}
+
+// nullableJSON converte um JSON vazio em NULL para colunas JSONB opcionais.
+func nullableJSON(v json.RawMessage) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return []byte(v)
+}
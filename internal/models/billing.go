@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // BillingCycle define os ciclos de faturamento disponíveis.
 type BillingCycle string
 
@@ -13,3 +15,24 @@ const (
 	Biennially   BillingCycle = "biennially"
 	Triennially  BillingCycle = "triennially"
 )
+
+// NextDueDate calcula a próxima data de vencimento a partir de uma data, conforme o ciclo.
+// Ciclos sem recorrência (free e onetime) retornam a própria data.
+func (c BillingCycle) NextDueDate(from time.Time) time.Time {
+	switch c {
+	case Monthly:
+		return from.AddDate(0, 1, 0)
+	case Quarterly:
+		return from.AddDate(0, 3, 0)
+	case Semiannually:
+		return from.AddDate(0, 6, 0)
+	case Annually:
+		return from.AddDate(1, 0, 0)
+	case Biennially:
+		return from.AddDate(2, 0, 0)
+	case Triennially:
+		return from.AddDate(3, 0, 0)
+	default:
+		return from
+	}
+}
@@ -23,14 +23,13 @@ type Client struct {
 	CreatedAt       time.Time      `json:"created_at"`
 }
 
-// CreateClient insere um novo cliente no banco de dados.
-func CreateClient(db *sql.DB, client *Client) (int64, error) {
-	query := `INSERT INTO clients (user_id, company_name, contact_name, email, phone, address, city, state, zip, country) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	result, err := db.Exec(query, client.UserID, client.CompanyName, client.ContactName, client.Email, client.Phone, client.Address, client.City, client.State, client.Zip, client.Country)
-	if err != nil {
-		return 0, err
-	}
-	return result.LastInsertId()
+// CreateClient insere um novo cliente no banco de dados. Aceita *sql.DB ou *sql.Tx.
+func CreateClient(db QueryRower, client *Client) (int64, error) {
+	var id int64
+	query := `INSERT INTO clients (user_id, company_name, contact_name, email, phone, address, city, state, zip, country)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	err := db.QueryRow(query, client.UserID, client.CompanyName, client.ContactName, client.Email, client.Phone, client.Address, client.City, client.State, client.Zip, client.Country).Scan(&id)
+	return id, err
 }
 
 // GetAllClients retorna todos os clientes do banco de dados.
@@ -55,7 +54,7 @@ func GetAllClients(db *sql.DB) ([]Client, error) {
 
 // GetClientByID busca um cliente pelo seu ID de cliente.
 func GetClientByID(db *sql.DB, clientID int) (*Client, error) {
-	query := `SELECT id, user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj, asaas_customer_id, created_at FROM clients WHERE id = ?`
+	query := `SELECT id, user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj, asaas_customer_id, created_at FROM clients WHERE id = $1`
 	row := db.QueryRow(query, clientID)
 
 	client := &Client{}
@@ -88,7 +87,7 @@ func GetClientByID(db *sql.DB, clientID int) (*Client, error) {
 
 // GetClientByUserID busca um cliente pelo ID do usuário associado.
 func GetClientByUserID(db *sql.DB, userID int) (*Client, error) {
-	query := `SELECT id, user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj, asaas_customer_id, created_at FROM clients WHERE user_id = ?`
+	query := `SELECT id, user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj, asaas_customer_id, created_at FROM clients WHERE user_id = $1`
 	row := db.QueryRow(query, userID)
 
 	client := &Client{}
@@ -119,6 +118,54 @@ func GetClientByUserID(db *sql.DB, userID int) (*Client, error) {
 	return client, nil
 }
 
+// GetClientByEmail busca um cliente pelo e-mail de contato, sem diferenciar maiúsculas e minúsculas.
+func GetClientByEmail(db *sql.DB, email string) (*Client, error) {
+	query := `SELECT id, user_id, company_name, contact_name, email, phone, address, city, state, zip, country, cpf_cnpj, asaas_customer_id, created_at FROM clients WHERE LOWER(email) = LOWER($1) ORDER BY id ASC LIMIT 1`
+	row := db.QueryRow(query, email)
+
+	client := &Client{}
+	err := row.Scan(
+		&client.ID,
+		&client.UserID,
+		&client.CompanyName,
+		&client.ContactName,
+		&client.Email,
+		&client.Phone,
+		&client.Address,
+		&client.City,
+		&client.State,
+		&client.Zip,
+		&client.Country,
+		&client.CpfCnpj,
+		&client.AsaasCustomerID,
+		&client.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err // Outros erros
+	}
+
+	return client, nil
+}
+
+// FindClientUserIDByDomain retorna o ID de usuário do cliente dono de um domínio, procurando nos
+// domínios cadastrados e nos serviços de hospedagem. Retorna 0 se nenhum cliente for encontrado.
+func FindClientUserIDByDomain(db *sql.DB, domain string) (int, error) {
+	query := `SELECT c.user_id FROM domains d JOIN clients c ON c.id = d.client_id WHERE LOWER(d.domain_name) = LOWER($1)
+		UNION ALL
+		SELECT s.user_id FROM services s WHERE LOWER(s.domain) = LOWER($1)
+		LIMIT 1`
+	var userID int
+	err := db.QueryRow(query, domain).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
 // UpdateClient atualiza um cliente no banco de dados.
 func UpdateClient(db *sql.DB, client *Client) error {
 	query := `UPDATE clients SET company_name = ?, contact_name = ?, email = ?, phone = ?, address = ?, city = ?, state = ?, zip = ?, country = ? WHERE id = ?`
@@ -128,7 +175,7 @@ func UpdateClient(db *sql.DB, client *Client) error {
 
 // DeleteClient deleta um cliente do banco de dados.
 func DeleteClient(db *sql.DB, id int) error {
-	query := `DELETE FROM clients WHERE id = ?`
+	query := `DELETE FROM clients WHERE id = $1`
 	_, err := db.Exec(query, id)
 	return err
 }
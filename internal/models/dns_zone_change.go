@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Ações registradas no histórico de alterações de zonas DNS.
+const (
+	DNSZoneChangeAdd    = "add"
+	DNSZoneChangeEdit   = "edit"
+	DNSZoneChangeDelete = "delete"
+)
+
+// DNSZoneChange é uma alteração feita pelo cliente em um registro da zona DNS de um serviço.
+type DNSZoneChange struct {
+	ID         int64           `json:"id"`
+	ServiceID  int             `json:"service_id"`
+	Zone       string          `json:"zone"`
+	UserID     int             `json:"user_id"`
+	IPAddress  sql.NullString  `json:"ip_address"`
+	Action     string          `json:"action"`
+	Template   sql.NullString  `json:"template"` // Modelo aplicado (ex: google_workspace), se houver
+	RecordType string          `json:"record_type"`
+	RecordName string          `json:"record_name"`
+	OldRecord  json.RawMessage `json:"old_record"`
+	NewRecord  json.RawMessage `json:"new_record"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// CreateDNSZoneChange registra uma alteração no histórico da zona.
+func CreateDNSZoneChange(db *sql.DB, c *DNSZoneChange) error {
+	_, err := db.Exec(`INSERT INTO dns_zone_changes (service_id, zone, user_id, ip_address, action, template, record_type, record_name, old_record, new_record)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		c.ServiceID, c.Zone, c.UserID, c.IPAddress, c.Action, c.Template, c.RecordType, c.RecordName,
+		nullableJSON(c.OldRecord), nullableJSON(c.NewRecord))
+	return err
+}
+
+// GetDNSZoneChanges lista as alterações mais recentes da zona de um serviço.
+func GetDNSZoneChanges(db *sql.DB, serviceID int, zone string, limit int) ([]DNSZoneChange, error) {
+	rows, err := db.Query(`SELECT id, service_id, zone, user_id, ip_address, action, template, record_type, record_name, old_record, new_record, created_at
+		FROM dns_zone_changes WHERE service_id = $1 AND zone = $2 ORDER BY id DESC LIMIT $3`, serviceID, zone, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []DNSZoneChange{}
+	for rows.Next() {
+		var c DNSZoneChange
+		var oldRecord, newRecord []byte
+		if err := rows.Scan(&c.ID, &c.ServiceID, &c.Zone, &c.UserID, &c.IPAddress, &c.Action, &c.Template,
+			&c.RecordType, &c.RecordName, &oldRecord, &newRecord, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.OldRecord, c.NewRecord = oldRecord, newRecord
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
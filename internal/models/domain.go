@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -23,21 +24,29 @@ const (
 	StatusActive              DomainStatus = "active"
 	StatusFailed              DomainStatus = "failed"
 	StatusCancelled           DomainStatus = "cancelled"
+	StatusExpired             DomainStatus = "expired"    // Vencido sem renovação, ainda renovável
+	StatusRedemption          DomainStatus = "redemption" // Em período de resgate no registrador
 )
 
 // Domain representa um registro na tabela `domains`.
 type Domain struct {
-	ID                int            `json:"id"`
-	ClientID          int            `json:"client_id"`
-	ServiceID         int            `json:"service_id"`
-	DomainName        string         `json:"domain_name"`
-	Type              DomainType     `json:"type"`
-	Status            DomainStatus   `json:"status"`
-	Provider          sql.NullString `json:"provider"`
-	ProviderOrderID   sql.NullString `json:"provider_order_id"`
-	ExpiresAt         sql.NullTime   `json:"expires_at"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
+	ID                     int            `json:"id"`
+	ClientID               int            `json:"client_id"`
+	ServiceID              sql.NullInt64  `json:"service_id"` // Serviço de hospedagem vinculado, quando houver
+	DomainName             string         `json:"domain_name"`
+	Type                   DomainType     `json:"type"`
+	Status                 DomainStatus   `json:"status"`
+	Provider               sql.NullString `json:"provider"`
+	ProviderOrderID        sql.NullString `json:"provider_order_id"`
+	ProviderSubscriptionID sql.NullString `json:"provider_subscription_id"` // Assinatura no registrador, usada na renovação
+	ExpiresAt              sql.NullTime   `json:"expires_at"`
+	ExpirySyncedAt         sql.NullTime   `json:"expiry_synced_at"` // Última leitura do vencimento no registrador
+	Nameservers            sql.NullString `json:"nameservers"`      // Nameservers no registrador, separados por vírgula
+	RegistrarLocked        sql.NullBool   `json:"registrar_locked"` // Bloqueio de transferência no registrador
+	WhoisPrivacy           sql.NullBool   `json:"whois_privacy"`    // Proteção de privacidade do WHOIS
+	RegistrarSyncedAt      sql.NullTime   `json:"registrar_synced_at"`
+	CreatedAt              time.Time      `json:"created_at"`
+	UpdatedAt              time.Time      `json:"updated_at"`
 }
 
 // CreateDomain cria um novo registro de domínio e retorna o ID gerado.
@@ -67,3 +76,125 @@ func UpdateDomainProviderOrderID(db *sql.DB, domainID int, provider, providerOrd
 	_, err := db.Exec(query, provider, providerOrderID, domainID)
 	return err
 }
+
+const domainColumns = `id, client_id, service_id, domain_name, type, status, provider, provider_order_id, provider_subscription_id,
+	expires_at, expiry_synced_at, nameservers, registrar_locked, whois_privacy, registrar_synced_at, created_at, updated_at`
+
+func scanDomain(row interface{ Scan(...any) error }) (*Domain, error) {
+	var d Domain
+	err := row.Scan(&d.ID, &d.ClientID, &d.ServiceID, &d.DomainName, &d.Type, &d.Status, &d.Provider, &d.ProviderOrderID, &d.ProviderSubscriptionID,
+		&d.ExpiresAt, &d.ExpirySyncedAt, &d.Nameservers, &d.RegistrarLocked, &d.WhoisPrivacy, &d.RegistrarSyncedAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetDomainByID busca um domínio pelo ID.
+func GetDomainByID(db *sql.DB, id int) (*Domain, error) {
+	return scanDomain(db.QueryRow(`SELECT `+domainColumns+` FROM domains WHERE id = $1`, id))
+}
+
+// GetDomainsByClientID lista os domínios de um cliente em ordem alfabética.
+func GetDomainsByClientID(db *sql.DB, clientID int) ([]Domain, error) {
+	return queryDomains(db, `SELECT `+domainColumns+` FROM domains WHERE client_id = $1 ORDER BY domain_name`, clientID)
+}
+
+// GetDomainByNameTx busca um domínio pelo nome dentro de uma transação.
+func GetDomainByNameTx(tx *sql.Tx, domainName string) (*Domain, error) {
+	return scanDomain(tx.QueryRow(`SELECT `+domainColumns+` FROM domains WHERE domain_name = $1`, domainName))
+}
+
+// UpdateDomainProviderSubscriptionID grava a assinatura do domínio no registrador.
+func UpdateDomainProviderSubscriptionID(db *sql.DB, domainID int, subscriptionID string) error {
+	_, err := db.Exec(`UPDATE domains SET provider_subscription_id = $1 WHERE id = $2`, subscriptionID, domainID)
+	return err
+}
+
+// UpdateDomainExpiry grava o vencimento lido no registrador e a data da leitura.
+func UpdateDomainExpiry(db *sql.DB, domainID int, expiresAt time.Time) error {
+	_, err := db.Exec(`UPDATE domains SET expires_at = $1, expiry_synced_at = NOW() WHERE id = $2`, expiresAt, domainID)
+	return err
+}
+
+// TouchDomainExpirySync registra uma leitura do registrador sem vencimento, para que o domínio não
+// seja consultado novamente antes do próximo ciclo.
+func TouchDomainExpirySync(db *sql.DB, domainID int) error {
+	_, err := db.Exec(`UPDATE domains SET expiry_synced_at = NOW() WHERE id = $1`, domainID)
+	return err
+}
+
+// renewableDomainStatuses são os status de domínios registrados que ainda podem ser renovados.
+const renewableDomainStatuses = `('active', 'expired', 'redemption')`
+
+// GetDomainsForExpirySync lista os domínios do provedor cujo vencimento não foi lido desde syncedBefore,
+// começando pelos nunca lidos.
+func GetDomainsForExpirySync(db *sql.DB, provider string, syncedBefore time.Time, limit int) ([]Domain, error) {
+	return queryDomains(db, `SELECT `+domainColumns+` FROM domains
+		WHERE status IN `+renewableDomainStatuses+` AND type != 'existing' AND provider = $1
+		AND (expiry_synced_at IS NULL OR expiry_synced_at < $2)
+		ORDER BY expiry_synced_at NULLS FIRST, id LIMIT $3`, provider, syncedBefore, limit)
+}
+
+// GetDomainsExpiringBefore lista os domínios registrados por nós que vencem até a data informada,
+// incluindo os já vencidos e em redenção.
+func GetDomainsExpiringBefore(db *sql.DB, before time.Time) ([]Domain, error) {
+	return queryDomains(db, `SELECT `+domainColumns+` FROM domains
+		WHERE status IN `+renewableDomainStatuses+` AND type != 'existing' AND expires_at IS NOT NULL AND expires_at <= $1
+		ORDER BY expires_at, id`, before)
+}
+
+func queryDomains(db *sql.DB, query string, args ...any) ([]Domain, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []Domain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *d)
+	}
+	return domains, rows.Err()
+}
+
+// NameserverList retorna os nameservers em cache do domínio.
+func (d *Domain) NameserverList() []string {
+	if !d.Nameservers.Valid || d.Nameservers.String == "" {
+		return []string{}
+	}
+	return strings.Split(d.Nameservers.String, ",")
+}
+
+// UpdateDomainRegistrarState grava o estado do domínio lido no registrador: nameservers, bloqueio de
+// transferência e privacidade do WHOIS.
+func UpdateDomainRegistrarState(db *sql.DB, domainID int, nameservers []string, locked, privacy bool) error {
+	_, err := db.Exec(`UPDATE domains SET nameservers = $1, registrar_locked = $2, whois_privacy = $3, registrar_synced_at = NOW()
+		WHERE id = $4`, strings.Join(nameservers, ","), locked, privacy, domainID)
+	return err
+}
+
+// UpdateDomainNameservers grava os nameservers do domínio no registrador.
+func UpdateDomainNameservers(db *sql.DB, domainID int, nameservers []string) error {
+	_, err := db.Exec(`UPDATE domains SET nameservers = $1 WHERE id = $2`, strings.Join(nameservers, ","), domainID)
+	return err
+}
+
+// UpdateDomainRegistrarLock grava o bloqueio de transferência do domínio no registrador.
+func UpdateDomainRegistrarLock(db *sql.DB, domainID int, locked bool) error {
+	_, err := db.Exec(`UPDATE domains SET registrar_locked = $1 WHERE id = $2`, locked, domainID)
+	return err
+}
+
+// UpdateDomainWhoisPrivacy grava a proteção de privacidade do WHOIS do domínio.
+func UpdateDomainWhoisPrivacy(db *sql.DB, domainID int, enabled bool) error {
+	_, err := db.Exec(`UPDATE domains SET whois_privacy = $1 WHERE id = $2`, enabled, domainID)
+	return err
+}
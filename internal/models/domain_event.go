@@ -9,12 +9,12 @@ import (
 // DomainEvent representa um registro na tabela `domain_events`.
 // É usado para auditoria e rastreamento de estado.
 type DomainEvent struct {
-	ID        int64
-	DomainID  int
-	Type      string
-	Message   sql.NullString
-	RawData   sql.NullString // Armazenado como JSON string
-	CreatedAt time.Time
+	ID        int64          `json:"id"`
+	DomainID  int            `json:"domain_id"`
+	Type      string         `json:"type"`
+	Message   sql.NullString `json:"message"`
+	RawData   sql.NullString `json:"raw_data"` // Armazenado como JSON string
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 // LogDomainEvent registra um evento no ciclo de vida de um domínio.
@@ -39,7 +39,7 @@ func LogDomainEvent(db *sql.DB, domainID int, eventType, message string, data in
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(domainID, eventType, message, string(rawDataJSON))
+	_, err = stmt.Exec(domainID, eventType, message, nullableJSON(rawDataJSON)) // Sem dados, grava NULL (string vazia não é JSON válido)
 	return err
 }
 
@@ -54,3 +54,42 @@ func HasEventOccurred(db *sql.DB, domainID int, eventType string) (bool, error)
 	}
 	return count > 0, nil
 }
+
+// GetLatestDomainEventData decodifica em out os dados do evento mais recente do tipo informado.
+// Retorna false quando o evento ainda não ocorreu; eventos sem dados deixam out inalterado.
+func GetLatestDomainEventData(db *sql.DB, domainID int, eventType string, out any) (bool, error) {
+	var raw sql.NullString
+	query := "SELECT raw_data FROM domain_events WHERE domain_id = $1 AND type = $2 ORDER BY id DESC LIMIT 1"
+	err := db.QueryRow(query, domainID, eventType).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if raw.Valid && raw.String != "" {
+		if err := json.Unmarshal([]byte(raw.String), out); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// GetDomainEvents lista os eventos de um domínio em ordem cronológica.
+func GetDomainEvents(db *sql.DB, domainID int) ([]DomainEvent, error) {
+	rows, err := db.Query(`SELECT id, domain_id, type, message, raw_data, created_at FROM domain_events WHERE domain_id = $1 ORDER BY id ASC`, domainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []DomainEvent
+	for rows.Next() {
+		var e DomainEvent
+		if err := rows.Scan(&e.ID, &e.DomainID, &e.Type, &e.Message, &e.RawData, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
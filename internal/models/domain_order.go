@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DomainOrderStatus define o ciclo de vida de um pedido de domínio.
+type DomainOrderStatus string
+
+const (
+	DomainOrderStatusPendingPayment      DomainOrderStatus = "pending_payment"      // Cliente ainda não pagou
+	DomainOrderStatusPendingApproval     DomainOrderStatus = "pending_approval"     // Pago, aguardando aprovação manual
+	DomainOrderStatusPendingRegistration DomainOrderStatus = "pending_registration" // Pago, em provisionamento
+	DomainOrderStatusCompleted           DomainOrderStatus = "completed"
+	DomainOrderStatusFailed              DomainOrderStatus = "failed"
+	DomainOrderStatusCancelled           DomainOrderStatus = "cancelled"
+)
+
+// DomainOrder representa um registro na tabela `domain_orders`.
+type DomainOrder struct {
+	ID                int               `json:"id"`
+	ClientID          int               `json:"client_id"`
+	DomainName        string            `json:"domain_name"`
+	Document          string            `json:"document"`
+	Type              DomainType        `json:"type"`  // register ou transfer
+	Years             int               `json:"years"` // Período do registro
+	AuthCodeEncrypted sql.NullString    `json:"-"`     // Código EPP da transferência (utils.Encrypt)
+	Status            DomainOrderStatus `json:"status"`
+	InvoiceID         sql.NullInt64     `json:"invoice_id"`
+	ServiceID         sql.NullInt64     `json:"service_id"` // Serviço criado para o pedido no provisionamento
+	DomainID          sql.NullInt64     `json:"domain_id"`  // Domínio criado para o pedido no provisionamento
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+const domainOrderColumns = `id, client_id, domain_name, document, type, years, auth_code_encrypted, status, invoice_id, service_id, domain_id, created_at, updated_at`
+
+func scanDomainOrder(row interface{ Scan(...any) error }) (*DomainOrder, error) {
+	var o DomainOrder
+	err := row.Scan(&o.ID, &o.ClientID, &o.DomainName, &o.Document, &o.Type, &o.Years, &o.AuthCodeEncrypted, &o.Status, &o.InvoiceID, &o.ServiceID, &o.DomainID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// GetDomainOrderForUpdate busca um pedido de domínio e bloqueia a linha até o fim da transação.
+func GetDomainOrderForUpdate(tx *sql.Tx, id int) (*DomainOrder, error) {
+	o, err := scanDomainOrder(tx.QueryRow(`SELECT `+domainOrderColumns+` FROM domain_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetDomainOrderByDomainID busca o pedido mais recente vinculado a um domínio.
+func GetDomainOrderByDomainID(db *sql.DB, domainID int) (*DomainOrder, error) {
+	o, err := scanDomainOrder(db.QueryRow(`SELECT `+domainOrderColumns+` FROM domain_orders WHERE domain_id = $1 ORDER BY id DESC LIMIT 1`, domainID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetDomainOrdersByInvoiceID lista os pedidos de domínio vinculados a uma fatura.
+func GetDomainOrdersByInvoiceID(db *sql.DB, invoiceID int) ([]DomainOrder, error) {
+	rows, err := db.Query(`SELECT `+domainOrderColumns+` FROM domain_orders WHERE invoice_id = $1 ORDER BY id ASC`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []DomainOrder
+	for rows.Next() {
+		o, err := scanDomainOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *o)
+	}
+	return orders, rows.Err()
+}
+
+// UpdateDomainOrderStatus atualiza o status de um pedido de domínio.
+func UpdateDomainOrderStatus(db *sql.DB, id int, status DomainOrderStatus) error {
+	_, err := db.Exec(`UPDATE domain_orders SET status = $1 WHERE id = $2`, status, id)
+	return err
+}
+
+// SetDomainOrderService vincula ao pedido o serviço criado para ele.
+func SetDomainOrderService(tx *sql.Tx, id, serviceID int) error {
+	_, err := tx.Exec(`UPDATE domain_orders SET service_id = $1 WHERE id = $2`, serviceID, id)
+	return err
+}
+
+// SetDomainOrderDomain vincula ao pedido o domínio criado (ou retomado) para ele.
+func SetDomainOrderDomain(tx *sql.Tx, id, domainID int) error {
+	_, err := tx.Exec(`UPDATE domain_orders SET domain_id = $1 WHERE id = $2`, domainID, id)
+	return err
+}
+
+// UpdateDomainOrderAuthCode grava o código EPP criptografado da transferência do pedido.
+func UpdateDomainOrderAuthCode(db *sql.DB, id int, authCodeEncrypted string) error {
+	_, err := db.Exec(`UPDATE domain_orders SET auth_code_encrypted = $1 WHERE id = $2`, authCodeEncrypted, id)
+	return err
+}
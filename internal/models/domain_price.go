@@ -0,0 +1,291 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DomainPriceOperation define a operação cobrada no preço de um domínio.
+type DomainPriceOperation string
+
+const (
+	DomainPriceRegister DomainPriceOperation = "register"
+	DomainPriceTransfer DomainPriceOperation = "transfer"
+	DomainPriceRenew    DomainPriceOperation = "renew"
+)
+
+// Valid indica se a operação é conhecida.
+func (o DomainPriceOperation) Valid() bool {
+	switch o {
+	case DomainPriceRegister, DomainPriceTransfer, DomainPriceRenew:
+		return true
+	}
+	return false
+}
+
+// Limites do período, em anos, de registro, transferência e renovação.
+const (
+	DomainMinYears = 1
+	DomainMaxYears = 10
+)
+
+// DomainTLDPrice é o custo e o preço de venda de uma TLD em uma operação e período.
+type DomainTLDPrice struct {
+	ID        int                  `json:"id"`
+	TLD       string               `json:"tld"`
+	Operation DomainPriceOperation `json:"operation"`
+	Years     int                  `json:"years"`
+	CostPrice sql.NullFloat64      `json:"cost_price"` // Custo no registrador
+	Price     sql.NullFloat64      `json:"price"`      // Preço fixo; sem ele, vale o custo com a margem
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// DomainMarginRule é a margem aplicada sobre o custo. TLD ou operação nulas valem para todas.
+type DomainMarginRule struct {
+	ID            int            `json:"id"`
+	TLD           sql.NullString `json:"tld"`
+	Operation     sql.NullString `json:"operation"`
+	MarginPercent float64        `json:"margin_percent"`
+	MarginFixed   float64        `json:"margin_fixed"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// DomainPromotion é um preço promocional válido de StartsAt até EndsAt.
+type DomainPromotion struct {
+	ID          int                  `json:"id"`
+	TLD         string               `json:"tld"`
+	Operation   DomainPriceOperation `json:"operation"`
+	Years       int                  `json:"years"`
+	Price       float64              `json:"price"`
+	StartsAt    time.Time            `json:"starts_at"`
+	EndsAt      time.Time            `json:"ends_at"`
+	Description sql.NullString       `json:"description"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+const domainTLDPriceColumns = `id, tld, operation, years, cost_price, price, created_at, updated_at`
+
+func scanDomainTLDPrice(row interface{ Scan(...any) error }) (*DomainTLDPrice, error) {
+	var p DomainTLDPrice
+	err := row.Scan(&p.ID, &p.TLD, &p.Operation, &p.Years, &p.CostPrice, &p.Price, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetDomainTLDPrices lista a tabela de preços, de uma TLD ou de todas (tld vazia).
+func GetDomainTLDPrices(db *sql.DB, tld string) ([]DomainTLDPrice, error) {
+	rows, err := db.Query(`SELECT `+domainTLDPriceColumns+` FROM domain_tld_prices
+		WHERE $1 = '' OR tld = $1 ORDER BY tld, operation, years`, tld)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []DomainTLDPrice
+	for rows.Next() {
+		p, err := scanDomainTLDPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, *p)
+	}
+	return prices, rows.Err()
+}
+
+// GetDomainTLDPrice busca o preço de uma TLD em uma operação e período.
+func GetDomainTLDPrice(db *sql.DB, tld string, operation DomainPriceOperation, years int) (*DomainTLDPrice, error) {
+	p, err := scanDomainTLDPrice(db.QueryRow(`SELECT `+domainTLDPriceColumns+` FROM domain_tld_prices
+		WHERE tld = $1 AND operation = $2 AND years = $3`, tld, operation, years))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpsertDomainTLDPrice grava o custo e o preço de uma TLD em uma operação e período.
+func UpsertDomainTLDPrice(db QueryRower, p *DomainTLDPrice) error {
+	return db.QueryRow(`INSERT INTO domain_tld_prices (tld, operation, years, cost_price, price)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tld, operation, years) DO UPDATE SET cost_price = EXCLUDED.cost_price, price = EXCLUDED.price
+		RETURNING id, created_at, updated_at`,
+		p.TLD, p.Operation, p.Years, p.CostPrice, p.Price).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// SetDomainTLDCostPrice atualiza apenas o custo de uma TLD em uma operação e período, mantendo o
+// preço fixo, se houver. A linha é criada se ainda não existir.
+func SetDomainTLDCostPrice(db *sql.DB, tld string, operation DomainPriceOperation, years int, cost float64) error {
+	_, err := db.Exec(`INSERT INTO domain_tld_prices (tld, operation, years, cost_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tld, operation, years) DO UPDATE SET cost_price = EXCLUDED.cost_price`,
+		tld, operation, years, cost)
+	return err
+}
+
+// DeleteDomainTLDPrices remove todos os preços de uma TLD, que deixa de ser vendida.
+func DeleteDomainTLDPrices(db *sql.DB, tld string) (int64, error) {
+	res, err := db.Exec(`DELETE FROM domain_tld_prices WHERE tld = $1`, tld)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+const domainMarginRuleColumns = `id, tld, operation, margin_percent, margin_fixed, created_at, updated_at`
+
+func scanDomainMarginRule(row interface{ Scan(...any) error }) (*DomainMarginRule, error) {
+	var r DomainMarginRule
+	err := row.Scan(&r.ID, &r.TLD, &r.Operation, &r.MarginPercent, &r.MarginFixed, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetDomainMarginRules lista as regras de margem, das gerais para as mais específicas.
+func GetDomainMarginRules(db *sql.DB) ([]DomainMarginRule, error) {
+	rows, err := db.Query(`SELECT ` + domainMarginRuleColumns + ` FROM domain_margin_rules
+		ORDER BY tld NULLS FIRST, operation NULLS FIRST`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []DomainMarginRule
+	for rows.Next() {
+		r, err := scanDomainMarginRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// GetDomainMarginRuleByID busca uma regra de margem pelo ID.
+func GetDomainMarginRuleByID(db *sql.DB, id int) (*DomainMarginRule, error) {
+	r, err := scanDomainMarginRule(db.QueryRow(`SELECT `+domainMarginRuleColumns+` FROM domain_margin_rules WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// FindDomainMarginRule retorna a regra de margem mais específica para a TLD e a operação: a da TLD
+// tem precedência sobre a da operação, que tem precedência sobre a regra geral.
+func FindDomainMarginRule(db *sql.DB, tld string, operation DomainPriceOperation) (*DomainMarginRule, error) {
+	r, err := scanDomainMarginRule(db.QueryRow(`SELECT `+domainMarginRuleColumns+` FROM domain_margin_rules
+		WHERE (tld = $1 OR tld IS NULL) AND (operation = $2 OR operation IS NULL)
+		ORDER BY tld IS NULL, operation IS NULL LIMIT 1`, tld, operation))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpsertDomainMarginRule grava a regra de margem do escopo (TLD e operação), substituindo a existente.
+func UpsertDomainMarginRule(db *sql.DB, r *DomainMarginRule) error {
+	return db.QueryRow(`INSERT INTO domain_margin_rules (tld, operation, margin_percent, margin_fixed)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (COALESCE(tld, ''), COALESCE(operation, '')) DO UPDATE
+		SET margin_percent = EXCLUDED.margin_percent, margin_fixed = EXCLUDED.margin_fixed
+		RETURNING id, created_at, updated_at`,
+		r.TLD, r.Operation, r.MarginPercent, r.MarginFixed).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+}
+
+// DeleteDomainMarginRule remove uma regra de margem. Retorna false se ela não existe.
+func DeleteDomainMarginRule(db *sql.DB, id int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM domain_margin_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const domainPromotionColumns = `id, tld, operation, years, price, starts_at, ends_at, description, created_at, updated_at`
+
+func scanDomainPromotion(row interface{ Scan(...any) error }) (*DomainPromotion, error) {
+	var p DomainPromotion
+	err := row.Scan(&p.ID, &p.TLD, &p.Operation, &p.Years, &p.Price, &p.StartsAt, &p.EndsAt, &p.Description, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetDomainPromotions lista as promoções vigentes e futuras ou, com includeEnded, também as encerradas.
+func GetDomainPromotions(db *sql.DB, includeEnded bool) ([]DomainPromotion, error) {
+	rows, err := db.Query(`SELECT `+domainPromotionColumns+` FROM domain_price_promotions
+		WHERE $1 OR ends_at > NOW() ORDER BY starts_at DESC, id DESC`, includeEnded)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []DomainPromotion
+	for rows.Next() {
+		p, err := scanDomainPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+	return promotions, rows.Err()
+}
+
+// GetDomainPromotionByID busca uma promoção pelo ID.
+func GetDomainPromotionByID(db *sql.DB, id int) (*DomainPromotion, error) {
+	p, err := scanDomainPromotion(db.QueryRow(`SELECT `+domainPromotionColumns+` FROM domain_price_promotions WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetActiveDomainPromotion retorna a promoção de menor preço vigente em at para a TLD, operação e período.
+func GetActiveDomainPromotion(db *sql.DB, tld string, operation DomainPriceOperation, years int, at time.Time) (*DomainPromotion, error) {
+	p, err := scanDomainPromotion(db.QueryRow(`SELECT `+domainPromotionColumns+` FROM domain_price_promotions
+		WHERE tld = $1 AND operation = $2 AND years = $3 AND starts_at <= $4 AND ends_at > $4
+		ORDER BY price ASC LIMIT 1`, tld, operation, years, at))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// CreateDomainPromotion insere uma promoção.
+func CreateDomainPromotion(db *sql.DB, p *DomainPromotion) error {
+	return db.QueryRow(`INSERT INTO domain_price_promotions (tld, operation, years, price, starts_at, ends_at, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`,
+		p.TLD, p.Operation, p.Years, p.Price, p.StartsAt, p.EndsAt, p.Description).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// DeleteDomainPromotion remove uma promoção. Retorna false se ela não existe.
+func DeleteDomainPromotion(db *sql.DB, id int) (bool, error) {
+	res, err := db.Exec(`DELETE FROM domain_price_promotions WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DomainRenewalStatus define o ciclo de vida da renovação de um domínio.
+type DomainRenewalStatus string
+
+const (
+	DomainRenewalPendingPayment DomainRenewalStatus = "pending_payment" // Fatura emitida, aguardando pagamento
+	DomainRenewalPaid           DomainRenewalStatus = "paid"            // Paga, aguardando o envio ao registrador
+	DomainRenewalSubmitted      DomainRenewalStatus = "submitted"       // Enviada, aguardando o novo vencimento
+	DomainRenewalCompleted      DomainRenewalStatus = "completed"
+	DomainRenewalFailed         DomainRenewalStatus = "failed"
+	DomainRenewalCancelled      DomainRenewalStatus = "cancelled"
+)
+
+// DomainRenewal é a renovação de um domínio para um vencimento. Existe no máximo uma por vencimento.
+type DomainRenewal struct {
+	ID          int                 `json:"id"`
+	DomainID    int                 `json:"domain_id"`
+	InvoiceID   sql.NullInt64       `json:"invoice_id"`
+	ExpiresAt   time.Time           `json:"expires_at"` // Vencimento que a renovação estende
+	Years       int                 `json:"years"`
+	Price       float64             `json:"price"`
+	Status      DomainRenewalStatus `json:"status"`
+	SubmittedAt sql.NullTime        `json:"submitted_at"`
+	CompletedAt sql.NullTime        `json:"completed_at"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+const domainRenewalColumns = `id, domain_id, invoice_id, expires_at, years, price, status, submitted_at, completed_at, created_at, updated_at`
+
+func scanDomainRenewal(row interface{ Scan(...any) error }) (*DomainRenewal, error) {
+	var r DomainRenewal
+	err := row.Scan(&r.ID, &r.DomainID, &r.InvoiceID, &r.ExpiresAt, &r.Years, &r.Price, &r.Status, &r.SubmittedAt, &r.CompletedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func queryDomainRenewals(db *sql.DB, query string, args ...any) ([]DomainRenewal, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var renewals []DomainRenewal
+	for rows.Next() {
+		r, err := scanDomainRenewal(rows)
+		if err != nil {
+			return nil, err
+		}
+		renewals = append(renewals, *r)
+	}
+	return renewals, rows.Err()
+}
+
+// CreateDomainRenewal insere a renovação de um vencimento. Se já houver uma renovação para o mesmo
+// domínio e vencimento, nada é inserido e created retorna false.
+func CreateDomainRenewal(tx *sql.Tx, r *DomainRenewal) (created bool, err error) {
+	if r.Status == "" {
+		r.Status = DomainRenewalPendingPayment
+	}
+	err = tx.QueryRow(`INSERT INTO domain_renewals (domain_id, invoice_id, expires_at, years, price, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (domain_id, expires_at) DO NOTHING
+		RETURNING id, created_at, updated_at`,
+		r.DomainID, r.InvoiceID, r.ExpiresAt, r.Years, r.Price, r.Status).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetDomainRenewalByID busca uma renovação pelo ID.
+func GetDomainRenewalByID(db *sql.DB, id int) (*DomainRenewal, error) {
+	r, err := scanDomainRenewal(db.QueryRow(`SELECT `+domainRenewalColumns+` FROM domain_renewals WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetDomainRenewal busca a renovação de um domínio para um vencimento.
+func GetDomainRenewal(db *sql.DB, domainID int, expiresAt time.Time) (*DomainRenewal, error) {
+	r, err := scanDomainRenewal(db.QueryRow(`SELECT `+domainRenewalColumns+` FROM domain_renewals
+		WHERE domain_id = $1 AND expires_at = $2`, domainID, expiresAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetDomainRenewalsByInvoiceID lista as renovações cobradas em uma fatura.
+func GetDomainRenewalsByInvoiceID(db *sql.DB, invoiceID int) ([]DomainRenewal, error) {
+	return queryDomainRenewals(db, `SELECT `+domainRenewalColumns+` FROM domain_renewals WHERE invoice_id = $1 ORDER BY id`, invoiceID)
+}
+
+// GetDomainRenewalsByStatus lista as renovações em um status, das mais antigas para as mais recentes.
+func GetDomainRenewalsByStatus(db *sql.DB, status DomainRenewalStatus) ([]DomainRenewal, error) {
+	return queryDomainRenewals(db, `SELECT `+domainRenewalColumns+` FROM domain_renewals WHERE status = $1 ORDER BY id`, status)
+}
+
+// UpdateDomainRenewalStatus muda o status de uma renovação de from para to. Retorna false se a
+// renovação não estava em from.
+func UpdateDomainRenewalStatus(db *sql.DB, id int, from, to DomainRenewalStatus) (bool, error) {
+	res, err := db.Exec(`UPDATE domain_renewals SET status = $1,
+		submitted_at = CASE WHEN $1 = 'submitted' THEN NOW() ELSE submitted_at END,
+		completed_at = CASE WHEN $1 = 'completed' THEN NOW() ELSE completed_at END
+		WHERE id = $2 AND status = $3`, to, id, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClaimDomainRenewalReminder reserva o envio do lembrete de um vencimento com a antecedência
+// informada. Retorna false se o lembrete já foi enviado.
+func ClaimDomainRenewalReminder(db *sql.DB, domainID int, expiresAt time.Time, daysBefore int) (bool, error) {
+	res, err := db.Exec(`INSERT INTO domain_renewal_reminders (domain_id, expires_at, days_before)
+		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, domainID, expiresAt, daysBefore)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseDomainRenewalReminder desfaz a reserva de um lembrete que não pôde ser enviado.
+func ReleaseDomainRenewalReminder(db *sql.DB, domainID int, expiresAt time.Time, daysBefore int) error {
+	_, err := db.Exec(`DELETE FROM domain_renewal_reminders WHERE domain_id = $1 AND expires_at = $2 AND days_before = $3`,
+		domainID, expiresAt, daysBefore)
+	return err
+}
+
+// DomainRenewalSettings são as regras de renovação de domínios (system_settings).
+type DomainRenewalSettings struct {
+	InvoiceDaysBefore int   `json:"invoice_days_before"` // Antecedência da fatura de renovação
+	ReminderDays      []int `json:"reminder_days"`       // Dias antes do vencimento, em ordem decrescente
+	GraceDays         int   `json:"grace_days"`          // Dias como vencido antes da redenção
+	RedemptionDays    int   `json:"redemption_days"`     // Dias em redenção antes do cancelamento
+}
+
+// GetDomainRenewalSettings lê as regras de renovação de domínios.
+func GetDomainRenewalSettings(db *sql.DB) (*DomainRenewalSettings, error) {
+	var s DomainRenewalSettings
+	var reminderDays string
+	err := db.QueryRow(`SELECT domain_renewal_invoice_days, domain_renewal_reminder_days, domain_grace_days, domain_redemption_days
+		FROM system_settings WHERE id = 1`).Scan(&s.InvoiceDaysBefore, &reminderDays, &s.GraceDays, &s.RedemptionDays)
+	if err != nil {
+		return nil, err
+	}
+	s.ReminderDays = ParseReminderDays(reminderDays)
+	return &s, nil
+}
+
+// UpdateDomainRenewalSettings grava as regras de renovação de domínios.
+func UpdateDomainRenewalSettings(db *sql.DB, s *DomainRenewalSettings) error {
+	days := make([]string, len(s.ReminderDays))
+	for i, d := range s.ReminderDays {
+		days[i] = strconv.Itoa(d)
+	}
+	_, err := db.Exec(`UPDATE system_settings SET domain_renewal_invoice_days = $1, domain_renewal_reminder_days = $2,
+		domain_grace_days = $3, domain_redemption_days = $4 WHERE id = 1`,
+		s.InvoiceDaysBefore, strings.Join(days, ","), s.GraceDays, s.RedemptionDays)
+	return err
+}
+
+// ParseReminderDays interpreta a lista de antecedências dos lembretes (ex: "30,15,7,1"), ignorando
+// valores inválidos e repetidos, em ordem decrescente.
+func ParseReminderDays(value string) []int {
+	seen := make(map[int]bool)
+	days := []int{}
+	for _, part := range strings.Split(value, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || d <= 0 || seen[d] {
+			continue
+		}
+		seen[d] = true
+		days = append(days, d)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// GetDomainRenewalsByDomainID lista as renovações de um domínio, das mais recentes para as mais antigas.
+func GetDomainRenewalsByDomainID(db *sql.DB, domainID int) ([]DomainRenewal, error) {
+	return queryDomainRenewals(db, `SELECT `+domainRenewalColumns+` FROM domain_renewals WHERE domain_id = $1 ORDER BY expires_at DESC, id DESC`, domainID)
+}
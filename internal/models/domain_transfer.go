@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DomainTransferStatus define a situação da transferência de um domínio para o nosso registrador.
+type DomainTransferStatus string
+
+const (
+	DomainTransferPending        DomainTransferStatus = "pending"         // Enviada, aguardando o registrador
+	DomainTransferActionRequired DomainTransferStatus = "action_required" // Recusada; aguardando a correção do cliente
+	DomainTransferCompleted      DomainTransferStatus = "completed"
+	DomainTransferCancelled      DomainTransferStatus = "cancelled"
+)
+
+// DomainTransfer é a transferência de um domínio, no máximo uma por domínio.
+type DomainTransfer struct {
+	DomainID       int                  `json:"domain_id"`
+	Status         DomainTransferStatus `json:"status"`
+	ProviderStatus sql.NullString       `json:"provider_status"`
+	FailureReason  sql.NullString       `json:"failure_reason"` // Ex: invalid_auth_code, domain_locked
+	FailureMessage sql.NullString       `json:"failure_message"`
+	Attempts       int                  `json:"attempts"`
+	CheckedAt      sql.NullTime         `json:"checked_at"`
+	CompletedAt    sql.NullTime         `json:"completed_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+const domainTransferColumns = `domain_id, status, provider_status, failure_reason, failure_message, attempts, checked_at, completed_at, created_at, updated_at`
+
+func scanDomainTransfer(row interface{ Scan(...any) error }) (*DomainTransfer, error) {
+	var t DomainTransfer
+	err := row.Scan(&t.DomainID, &t.Status, &t.ProviderStatus, &t.FailureReason, &t.FailureMessage, &t.Attempts, &t.CheckedAt, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetDomainTransfer busca a transferência de um domínio.
+func GetDomainTransfer(db *sql.DB, domainID int) (*DomainTransfer, error) {
+	t, err := scanDomainTransfer(db.QueryRow(`SELECT `+domainTransferColumns+` FROM domain_transfers WHERE domain_id = $1`, domainID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetPendingDomainTransfers lista as transferências pendentes não consultadas desde checkedBefore,
+// começando pelas nunca consultadas.
+func GetPendingDomainTransfers(db *sql.DB, checkedBefore time.Time, limit int) ([]DomainTransfer, error) {
+	rows, err := db.Query(`SELECT `+domainTransferColumns+` FROM domain_transfers
+		WHERE status = $1 AND (checked_at IS NULL OR checked_at < $2)
+		ORDER BY checked_at NULLS FIRST, domain_id LIMIT $3`, DomainTransferPending, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []DomainTransfer
+	for rows.Next() {
+		t, err := scanDomainTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *t)
+	}
+	return transfers, rows.Err()
+}
+
+// MarkDomainTransferSubmitted registra um envio da transferência ao registrador: a transferência
+// volta a ficar pendente e a falha anterior é apagada.
+func MarkDomainTransferSubmitted(db *sql.DB, domainID int) error {
+	_, err := db.Exec(`INSERT INTO domain_transfers (domain_id, status, attempts) VALUES ($1, $2, 1)
+		ON CONFLICT (domain_id) DO UPDATE SET status = EXCLUDED.status, attempts = domain_transfers.attempts + 1,
+			failure_reason = NULL, failure_message = NULL, provider_status = NULL, checked_at = NULL`,
+		domainID, DomainTransferPending)
+	return err
+}
+
+// MarkDomainTransferActionRequired registra a recusa da transferência, que aguarda a correção do cliente.
+func MarkDomainTransferActionRequired(db *sql.DB, domainID int, providerStatus, reason, message string) error {
+	_, err := db.Exec(`INSERT INTO domain_transfers (domain_id, status, provider_status, failure_reason, failure_message, checked_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW())
+		ON CONFLICT (domain_id) DO UPDATE SET status = EXCLUDED.status, provider_status = EXCLUDED.provider_status,
+			failure_reason = EXCLUDED.failure_reason, failure_message = EXCLUDED.failure_message, checked_at = NOW()`,
+		domainID, DomainTransferActionRequired, providerStatus, reason, message)
+	return err
+}
+
+// UpdateDomainTransferCheck registra uma consulta ao registrador com a transferência ainda pendente.
+func UpdateDomainTransferCheck(db *sql.DB, domainID int, providerStatus string) error {
+	_, err := db.Exec(`UPDATE domain_transfers SET provider_status = NULLIF($1, ''), checked_at = NOW() WHERE domain_id = $2`,
+		providerStatus, domainID)
+	return err
+}
+
+// UpdateDomainTransferStatus muda o status da transferência de from para to. Retorna false se a
+// transferência não estava em from.
+func UpdateDomainTransferStatus(db *sql.DB, domainID int, from, to DomainTransferStatus) (bool, error) {
+	res, err := db.Exec(`UPDATE domain_transfers SET status = $1,
+		completed_at = CASE WHEN $1 = 'completed' THEN NOW() ELSE completed_at END
+		WHERE domain_id = $2 AND status = $3`, to, domainID, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
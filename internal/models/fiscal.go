@@ -5,29 +5,6 @@ import (
 	"time"
 )
 
-// SystemModule representa a tabela system_modules
-type SystemModule struct {
-	ID        int64          `json:"id"`
-	Name      string         `json:"name"`
-	Enabled   bool           `json:"enabled"`
-	EnabledAt sql.NullTime   `json:"enabled_at"`
-	EnabledBy sql.NullInt64  `json:"enabled_by"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-}
-
-// AuditLog representa a tabela audit_logs
-type AuditLog struct {
-	ID         int64          `json:"id"`
-	UserID     int64          `json:"user_id"`
-	Action     string         `json:"action"`
-	TargetType sql.NullString `json:"target_type"`
-	TargetID   sql.NullInt64  `json:"target_id"`
-	Details    sql.NullString `json:"details"`
-	IPAddress  sql.NullString `json:"ip_address"`
-	CreatedAt  time.Time      `json:"created_at"`
-}
-
 // FiscalSettings representa a tabela fiscal_settings
 type FiscalSettings struct {
 	ID                   int64           `json:"id"`
@@ -39,21 +16,245 @@ type FiscalSettings struct {
 	State                sql.NullString  `json:"state"`
 	ISSRate              sql.NullFloat64 `json:"iss_rate"`
 	Environment          sql.NullString  `json:"environment"`
+	MunicipalityCode     sql.NullString  `json:"municipality_code"` // Código IBGE do município emissor
+	SimplesNacional      bool            `json:"simples_nacional"`
+	ISSWithheldForCompanies bool         `json:"iss_withheld_for_companies"`
+	IRRFRate             float64         `json:"irrf_rate"`
+	PISRate              float64         `json:"pis_rate"`
+	COFINSRate           float64         `json:"cofins_rate"`
+	CSLLRate             float64         `json:"csll_rate"`
+	MinRetentionAmount   float64         `json:"min_retention_amount"`
 	CreatedAt            time.Time       `json:"created_at"`
 	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
+// FiscalDocumentStatus define os status possíveis de um documento fiscal.
+type FiscalDocumentStatus string
+
+const (
+	FiscalDocumentStatusPending    FiscalDocumentStatus = "pending"
+	FiscalDocumentStatusProcessing FiscalDocumentStatus = "processing"
+	FiscalDocumentStatusAuthorized FiscalDocumentStatus = "authorized"
+	FiscalDocumentStatusDenied     FiscalDocumentStatus = "denied"
+	FiscalDocumentStatusError      FiscalDocumentStatus = "error"
+	FiscalDocumentStatusCancelled  FiscalDocumentStatus = "cancelled"
+)
+
 // FiscalDocument representa a tabela fiscal_documents
 type FiscalDocument struct {
-	ID               int64          `json:"id"`
-	InvoiceID        int64          `json:"invoice_id"`
-	Provider         sql.NullString `json:"provider"`
-	NFNumber         sql.NullString `json:"nf_number"`
-	VerificationCode sql.NullString `json:"verification_code"`
-	Status           string         `json:"status"`
-	PDFURL           sql.NullString `json:"pdf_url"`
-	XMLURL           sql.NullString `json:"xml_url"`
-	ErrorMessage     sql.NullString `json:"error_message"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
+	ID                 int64                `json:"id"`
+	InvoiceID          int64                `json:"invoice_id"`
+	Provider           sql.NullString       `json:"provider"`
+	ProviderReference  sql.NullString       `json:"provider_reference"`
+	NFNumber           sql.NullString       `json:"nf_number"`
+	VerificationCode   sql.NullString       `json:"verification_code"`
+	Status             FiscalDocumentStatus `json:"status"`
+	PDFURL             sql.NullString       `json:"pdf_url"`
+	XMLURL             sql.NullString       `json:"xml_url"`
+	ErrorMessage       sql.NullString       `json:"error_message"`
+	AuthorizedAt       sql.NullTime         `json:"authorized_at"` // Momento em que a prefeitura autorizou a nota
+	ReplacesDocumentID sql.NullInt64        `json:"replaces_document_id"`
+	CancellationReason sql.NullString       `json:"cancellation_reason"`
+	CancelledAt        sql.NullTime         `json:"cancelled_at"`
+	CancelledBy        sql.NullInt64        `json:"cancelled_by"`
+	ClientNotifiedAt   sql.NullTime         `json:"client_notified_at"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+}
+
+// GetFiscalSettings busca as configurações fiscais da empresa.
+func GetFiscalSettings(db *sql.DB) (*FiscalSettings, error) {
+	var settings FiscalSettings
+	query := `SELECT id, provider, company_name, cnpj, municipal_registration, city, state, iss_rate, environment, municipality_code,
+		simples_nacional, iss_withheld_for_companies, irrf_rate, pis_rate, cofins_rate, csll_rate, min_retention_amount, created_at, updated_at
+		FROM fiscal_settings WHERE id = 1`
+	err := db.QueryRow(query).Scan(
+		&settings.ID, &settings.Provider, &settings.CompanyName, &settings.CNPJ,
+		&settings.MunicipalRegistration, &settings.City, &settings.State,
+		&settings.ISSRate, &settings.Environment, &settings.MunicipalityCode,
+		&settings.SimplesNacional, &settings.ISSWithheldForCompanies, &settings.IRRFRate, &settings.PISRate,
+		&settings.COFINSRate, &settings.CSLLRate, &settings.MinRetentionAmount, &settings.CreatedAt, &settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateFiscalSettings grava as configurações fiscais da empresa.
+func UpdateFiscalSettings(db *sql.DB, settings *FiscalSettings) error {
+	query := `UPDATE fiscal_settings SET
+		provider = $1, company_name = $2, cnpj = $3, municipal_registration = $4,
+		city = $5, state = $6, iss_rate = $7, environment = $8, municipality_code = $9,
+		simples_nacional = $10, iss_withheld_for_companies = $11, irrf_rate = $12, pis_rate = $13,
+		cofins_rate = $14, csll_rate = $15, min_retention_amount = $16
+		WHERE id = 1`
+	_, err := db.Exec(query, settings.Provider, settings.CompanyName, settings.CNPJ, settings.MunicipalRegistration,
+		settings.City, settings.State, settings.ISSRate, settings.Environment, settings.MunicipalityCode,
+		settings.SimplesNacional, settings.ISSWithheldForCompanies, settings.IRRFRate, settings.PISRate,
+		settings.COFINSRate, settings.CSLLRate, settings.MinRetentionAmount)
+	return err
+}
+
+// PlanFiscalCode representa a tabela plan_fiscal_codes
+type PlanFiscalCode struct {
+	PlanID      int             `json:"plan_id"`
+	ServiceCode string          `json:"service_code"` // Item da lista de serviços da LC 116
+	CNAECode    sql.NullString  `json:"cnae_code"`
+	ISSRate     sql.NullFloat64 `json:"iss_rate"` // Sobrescreve a alíquota padrão quando definida
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// GetPlanFiscalCode busca os códigos fiscais de um plano.
+func GetPlanFiscalCode(db *sql.DB, planID int) (*PlanFiscalCode, error) {
+	var c PlanFiscalCode
+	query := `SELECT plan_id, service_code, cnae_code, iss_rate, created_at, updated_at FROM plan_fiscal_codes WHERE plan_id = $1`
+	err := db.QueryRow(query, planID).Scan(&c.PlanID, &c.ServiceCode, &c.CNAECode, &c.ISSRate, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpsertPlanFiscalCode cria ou atualiza os códigos fiscais de um plano.
+func UpsertPlanFiscalCode(db *sql.DB, c *PlanFiscalCode) error {
+	query := `INSERT INTO plan_fiscal_codes (plan_id, service_code, cnae_code, iss_rate) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (plan_id) DO UPDATE SET service_code = EXCLUDED.service_code, cnae_code = EXCLUDED.cnae_code, iss_rate = EXCLUDED.iss_rate`
+	_, err := db.Exec(query, c.PlanID, c.ServiceCode, c.CNAECode, c.ISSRate)
+	return err
+}
+
+const fiscalDocumentColumns = `id, invoice_id, provider, provider_reference, nf_number, verification_code, status, pdf_url, xml_url,
+	error_message, authorized_at, replaces_document_id, cancellation_reason, cancelled_at, cancelled_by, client_notified_at, created_at, updated_at`
+
+func scanFiscalDocument(row interface{ Scan(...any) error }) (*FiscalDocument, error) {
+	var d FiscalDocument
+	err := row.Scan(
+		&d.ID, &d.InvoiceID, &d.Provider, &d.ProviderReference, &d.NFNumber, &d.VerificationCode, &d.Status, &d.PDFURL, &d.XMLURL,
+		&d.ErrorMessage, &d.AuthorizedAt, &d.ReplacesDocumentID, &d.CancellationReason, &d.CancelledAt, &d.CancelledBy, &d.ClientNotifiedAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetFiscalDocumentByID busca um documento fiscal pelo ID.
+func GetFiscalDocumentByID(db *sql.DB, id int64) (*FiscalDocument, error) {
+	doc, err := scanFiscalDocument(db.QueryRow(`SELECT `+fiscalDocumentColumns+` FROM fiscal_documents WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetFiscalDocumentsByInvoiceID lista todos os documentos fiscais de uma fatura, do mais recente ao mais antigo.
+func GetFiscalDocumentsByInvoiceID(db *sql.DB, invoiceID int64) ([]FiscalDocument, error) {
+	query := `SELECT ` + fiscalDocumentColumns + ` FROM fiscal_documents WHERE invoice_id = $1 ORDER BY created_at DESC`
+	return queryFiscalDocuments(db, query, invoiceID)
+}
+
+// GetFiscalDocumentsByUserID lista os documentos fiscais das faturas de um usuário, do mais recente ao mais antigo.
+func GetFiscalDocumentsByUserID(db *sql.DB, userID int) ([]FiscalDocument, error) {
+	query := `SELECT ` + fiscalDocumentColumns + ` FROM fiscal_documents
+		WHERE invoice_id IN (SELECT id FROM invoices WHERE user_id = $1)
+		ORDER BY created_at DESC`
+	return queryFiscalDocuments(db, query, userID)
+}
+
+// GetFiscalDocumentForUser busca um documento fiscal garantindo que a fatura pertença ao usuário.
+// Retorna nil, nil se o documento não existir ou for de outro usuário.
+func GetFiscalDocumentForUser(db *sql.DB, id int64, userID int) (*FiscalDocument, error) {
+	query := `SELECT ` + fiscalDocumentColumns + ` FROM fiscal_documents
+		WHERE id = $1 AND invoice_id IN (SELECT id FROM invoices WHERE user_id = $2)`
+	doc, err := scanFiscalDocument(db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetFiscalDocumentsByStatus lista os documentos fiscais em um determinado status, dos mais antigos aos mais recentes.
+func GetFiscalDocumentsByStatus(db *sql.DB, status FiscalDocumentStatus) ([]FiscalDocument, error) {
+	query := `SELECT ` + fiscalDocumentColumns + ` FROM fiscal_documents WHERE status = $1 ORDER BY created_at ASC`
+	return queryFiscalDocuments(db, query, status)
+}
+
+// GetUnnotifiedAuthorizedFiscalDocuments lista as notas autorizadas cujo e-mail ainda não foi enviado ao cliente.
+func GetUnnotifiedAuthorizedFiscalDocuments(db *sql.DB) ([]FiscalDocument, error) {
+	query := `SELECT ` + fiscalDocumentColumns + ` FROM fiscal_documents
+		WHERE status = $1 AND client_notified_at IS NULL ORDER BY created_at ASC`
+	return queryFiscalDocuments(db, query, FiscalDocumentStatusAuthorized)
+}
+
+// MarkFiscalDocumentClientNotified registra que o cliente foi avisado sobre a nota autorizada.
+func MarkFiscalDocumentClientNotified(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE fiscal_documents SET client_notified_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func queryFiscalDocuments(db *sql.DB, query string, args ...any) ([]FiscalDocument, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []FiscalDocument
+	for rows.Next() {
+		d, err := scanFiscalDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, *d)
+	}
+	return docs, rows.Err()
+}
+
+// CreateFiscalDocument insere um novo documento fiscal e retorna o ID gerado.
+func CreateFiscalDocument(db *sql.DB, d *FiscalDocument) (int64, error) {
+	var id int64
+	query := `INSERT INTO fiscal_documents (invoice_id, provider, provider_reference, status, replaces_document_id)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err := db.QueryRow(query, d.InvoiceID, d.Provider, d.ProviderReference, d.Status, d.ReplacesDocumentID).Scan(&id)
+	return id, err
+}
+
+// UpdateFiscalDocumentResult grava o retorno do provedor para um documento fiscal.
+func UpdateFiscalDocumentResult(db *sql.DB, d *FiscalDocument) error {
+	query := `UPDATE fiscal_documents SET status = $1, nf_number = $2, verification_code = $3, pdf_url = $4, xml_url = $5, error_message = $6,
+		authorized_at = $7
+		WHERE id = $8`
+	_, err := db.Exec(query, d.Status, d.NFNumber, d.VerificationCode, d.PDFURL, d.XMLURL, d.ErrorMessage, d.AuthorizedAt, d.ID)
+	return err
+}
+
+// MarkFiscalDocumentCancelled registra o cancelamento de um documento fiscal.
+func MarkFiscalDocumentCancelled(db *sql.DB, id int64, reason string, cancelledBy int) error {
+	query := `UPDATE fiscal_documents SET status = $1, cancellation_reason = $2, cancelled_at = NOW(), cancelled_by = $3 WHERE id = $4`
+	_, err := db.Exec(query, FiscalDocumentStatusCancelled, reason, cancelledBy, id)
+	return err
+}
+
+// GetCancellationWindowDays retorna o prazo de cancelamento (em dias) configurado para um município.
+// Retorna ok = false quando não há prazo configurado.
+func GetCancellationWindowDays(db *sql.DB, municipalityCode string) (days int, ok bool, err error) {
+	err = db.QueryRow(`SELECT window_days FROM nfse_cancellation_windows WHERE municipality_code = $1`, municipalityCode).Scan(&days)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return days, true, nil
 }
@@ -48,13 +48,72 @@ func GetOverdueInvoices(db *sql.DB) ([]Invoice, error) {
 	return invoices, nil
 }
 
-// CreateInvoice insere uma nova fatura no banco de dados.
+// CreateInvoice insere uma nova fatura no banco de dados e preenche o ID gerado.
 func CreateInvoice(db *sql.DB, invoice *Invoice) error {
-	query := `INSERT INTO invoices (user_id, service_id, issue_date, due_date, total_amount, status) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := db.Exec(query, invoice.UserID, invoice.ServiceID, invoice.IssueDate, invoice.DueDate, invoice.TotalAmount, invoice.Status)
+	query := `INSERT INTO invoices (user_id, service_id, issue_date, due_date, total_amount, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	return db.QueryRow(query, invoice.UserID, invoice.ServiceID, invoice.IssueDate, invoice.DueDate, invoice.TotalAmount, invoice.Status).Scan(&invoice.ID)
+}
+
+// UpdateInvoiceTotal atualiza o valor total de uma fatura.
+func UpdateInvoiceTotal(db *sql.DB, invoiceID int, total float64) error {
+	_, err := db.Exec(`UPDATE invoices SET total_amount = $1 WHERE id = $2`, total, invoiceID)
 	return err
 }
 
+// GetInvoiceByID busca uma fatura pelo seu ID.
+func GetInvoiceByID(db *sql.DB, id int) (*Invoice, error) {
+	query := `SELECT id, user_id, service_id, issue_date, due_date, total_amount, status, created_at FROM invoices WHERE id = $1`
+
+	var i Invoice
+	err := db.QueryRow(query, id).Scan(&i.ID, &i.UserID, &i.ServiceID, &i.IssueDate, &i.DueDate, &i.TotalAmount, &i.Status, &i.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return &i, nil
+}
+
+// MarkInvoicePaid marca uma fatura como paga. Retorna false se a fatura já estava paga.
+func MarkInvoicePaid(db *sql.DB, id int) (bool, error) {
+	res, err := db.Exec(`UPDATE invoices SET status = $1 WHERE id = $2 AND status != $1`, InvoiceStatusPaid, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// VoidUnpaidInvoice cancela uma fatura em aberto. Retorna false se a fatura não estava em aberto.
+func VoidUnpaidInvoice(db *sql.DB, id int) (bool, error) {
+	res, err := db.Exec(`UPDATE invoices SET status = $1 WHERE id = $2 AND status = $3`, InvoiceStatusVoid, id, InvoiceStatusUnpaid)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateInvoiceStatusByAsaasPaymentID atualiza o status das faturas da cobrança do Asaas e retorna os seus IDs.
+func UpdateInvoiceStatusByAsaasPaymentID(db *sql.DB, paymentID string, status InvoiceStatus) ([]int, error) {
+	rows, err := db.Query(`UPDATE invoices SET status = $1 WHERE asaas_payment_id = $2 RETURNING id`, status, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // GetInvoicesByUserID busca todas as faturas de um usuário específico, ordenadas pela data de emissão.
 func GetInvoicesByUserID(db *sql.DB, userID int) ([]Invoice, error) {
 	query := `SELECT id, user_id, service_id, issue_date, due_date, total_amount, status, created_at FROM invoices WHERE user_id = ? ORDER BY issue_date DESC`
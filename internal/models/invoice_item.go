@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// InvoiceItemType define os tipos de lançamento de uma fatura.
+type InvoiceItemType string
+
+const (
+	InvoiceItemTypeService   InvoiceItemType = "service"
+	InvoiceItemTypeTax       InvoiceItemType = "tax"       // Imposto informativo, já incluso no valor do serviço
+	InvoiceItemTypeRetention InvoiceItemType = "retention" // Imposto retido pelo tomador, abatido do total
+)
+
+// InvoiceItem representa um lançamento de uma fatura.
+type InvoiceItem struct {
+	ID          int64           `json:"id"`
+	InvoiceID   int             `json:"invoice_id"`
+	ServiceID   sql.NullInt64   `json:"service_id"`
+	Description string          `json:"description"`
+	Amount      float64         `json:"amount"`
+	Type        InvoiceItemType `json:"item_type"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// CreateInvoiceItem insere um novo item em uma fatura.
+func CreateInvoiceItem(db *sql.DB, item *InvoiceItem) error {
+	query := `INSERT INTO invoice_items (invoice_id, service_id, description, amount, item_type) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	return db.QueryRow(query, item.InvoiceID, item.ServiceID, item.Description, item.Amount, item.Type).Scan(&item.ID)
+}
+
+// GetInvoiceItemsByInvoiceID lista os itens de uma fatura na ordem em que foram lançados.
+func GetInvoiceItemsByInvoiceID(db *sql.DB, invoiceID int) ([]InvoiceItem, error) {
+	query := `SELECT id, invoice_id, service_id, description, amount, item_type, created_at FROM invoice_items WHERE invoice_id = $1 ORDER BY id ASC`
+	rows, err := db.Query(query, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []InvoiceItem
+	for rows.Next() {
+		var i InvoiceItem
+		var description sql.NullString
+		if err := rows.Scan(&i.ID, &i.InvoiceID, &i.ServiceID, &description, &i.Amount, &i.Type, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		i.Description = description.String
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
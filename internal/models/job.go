@@ -0,0 +1,229 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// JobStatus define o status de uma tarefa da fila de provisionamento.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusDead      JobStatus = "dead" // Esgotou as tentativas ou falhou de forma permanente
+)
+
+// Job representa uma tarefa da fila persistente (tabela `jobs`).
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Provider    string          `json:"provider"`
+	Payload     json.RawMessage `json:"payload"`
+	DedupKey    sql.NullString  `json:"dedup_key"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedBy    sql.NullString  `json:"locked_by"`
+	LockedAt    sql.NullTime    `json:"locked_at"`
+	LastError   sql.NullString  `json:"last_error"`
+	FinishedAt  sql.NullTime    `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// QueryRower é satisfeito por *sql.DB e *sql.Tx. Permite enfileirar uma tarefa na mesma
+// transação que grava o registro que ela vai processar.
+type QueryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+const jobColumns = `id, type, provider, payload, dedup_key, status, attempts, max_attempts, run_at, locked_by, locked_at,
+	last_error, finished_at, created_at, updated_at`
+
+func scanJob(row interface{ Scan(...any) error }) (*Job, error) {
+	var j Job
+	var payload []byte
+	err := row.Scan(&j.ID, &j.Type, &j.Provider, &payload, &j.DedupKey, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedBy, &j.LockedAt,
+		&j.LastError, &j.FinishedAt, &j.CreatedAt, &j.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	return &j, nil
+}
+
+func queryJobs(db *sql.DB, query string, args ...any) ([]Job, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// EnqueueJob insere uma tarefa na fila. Se já houver uma tarefa com a mesma dedup_key na fila ou em
+// execução, nada é inserido e created retorna false.
+func EnqueueJob(q QueryRower, j *Job) (created bool, err error) {
+	query := `INSERT INTO jobs (type, provider, payload, dedup_key, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (dedup_key) WHERE dedup_key IS NOT NULL AND status IN ('queued', 'running') DO NOTHING
+		RETURNING id, status, created_at, updated_at`
+	if j.RunAt.IsZero() {
+		j.RunAt = time.Now()
+	}
+	err = q.QueryRow(query, j.Type, j.Provider, nullableJSON(j.Payload), j.DedupKey, j.MaxAttempts, j.RunAt).
+		Scan(&j.ID, &j.Status, &j.CreatedAt, &j.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetJobByID busca uma tarefa pelo ID.
+func GetJobByID(db *sql.DB, id int64) (*Job, error) {
+	j, err := scanJob(db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return j, nil
+}
+
+// GetJobsByStatus lista as últimas tarefas com o status informado.
+func GetJobsByStatus(db *sql.DB, status JobStatus, limit int) ([]Job, error) {
+	return queryJobs(db, `SELECT `+jobColumns+` FROM jobs WHERE status = $1 ORDER BY id DESC LIMIT $2`, status, limit)
+}
+
+// GetQueuedJobProviders lista os provedores que têm tarefas prontas para execução.
+func GetQueuedJobProviders(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT provider FROM jobs WHERE status = $1 AND run_at <= NOW()`, JobStatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var providers []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, rows.Err()
+}
+
+// ClaimJob reserva a próxima tarefa pronta do provedor para a instância workerID, desde que o número de
+// tarefas do provedor em execução (em todas as instâncias) esteja abaixo de limit. Retorna nil quando
+// não há tarefa disponível ou o limite foi atingido.
+//
+// O lock consultivo serializa a contagem por provedor; FOR UPDATE SKIP LOCKED permite que várias
+// instâncias reservem tarefas ao mesmo tempo sem disputar a mesma linha.
+func ClaimJob(db *sql.DB, provider string, limit int, workerID string) (*Job, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('jobs:' || $1))`, provider); err != nil {
+		return nil, err
+	}
+
+	var running int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM jobs WHERE provider = $1 AND status = $2`, provider, JobStatusRunning).Scan(&running); err != nil {
+		return nil, err
+	}
+	if running >= limit {
+		return nil, nil
+	}
+
+	query := `UPDATE jobs SET status = $1, attempts = attempts + 1, locked_by = $2, locked_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE provider = $3 AND status = $4 AND run_at <= NOW()
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+	j, err := scanJob(tx.QueryRow(query, JobStatusRunning, workerID, provider, JobStatusQueued))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return j, tx.Commit()
+}
+
+// CompleteJob marca a tarefa como concluída.
+func CompleteJob(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE jobs SET status = $1, locked_by = NULL, locked_at = NULL, last_error = NULL, finished_at = NOW() WHERE id = $2`,
+		JobStatusSucceeded, id)
+	return err
+}
+
+// RescheduleJob devolve a tarefa à fila para uma nova tentativa em runAt.
+func RescheduleJob(db *sql.DB, id int64, runAt time.Time, lastError string) error {
+	_, err := db.Exec(`UPDATE jobs SET status = $1, run_at = $2, last_error = $3, locked_by = NULL, locked_at = NULL WHERE id = $4`,
+		JobStatusQueued, runAt, lastError, id)
+	return err
+}
+
+// KillJob move a tarefa para a fila de mortas (dead-letter), onde aguarda ação do administrador.
+func KillJob(db *sql.DB, id int64, lastError string) error {
+	_, err := db.Exec(`UPDATE jobs SET status = $1, last_error = $2, locked_by = NULL, locked_at = NULL, finished_at = NOW() WHERE id = $3`,
+		JobStatusDead, lastError, id)
+	return err
+}
+
+// RequeueDeadJob devolve uma tarefa morta à fila com as tentativas zeradas.
+// Retorna false se a tarefa não estiver morta.
+func RequeueDeadJob(db *sql.DB, id int64) (bool, error) {
+	res, err := db.Exec(`UPDATE jobs SET status = $1, attempts = 0, run_at = NOW(), finished_at = NULL WHERE id = $2 AND status = $3`,
+		JobStatusQueued, id, JobStatusDead)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RenewJobLock renova o lock de uma tarefa ainda em execução pela instância workerID.
+func RenewJobLock(db *sql.DB, id int64, workerID string) error {
+	_, err := db.Exec(`UPDATE jobs SET locked_at = NOW() WHERE id = $1 AND status = $2 AND locked_by = $3`,
+		id, JobStatusRunning, workerID)
+	return err
+}
+
+// ReleaseStaleJobs devolve à fila as tarefas em execução cujo lock é anterior a lockedBefore,
+// ou seja, tarefas de uma instância que foi reiniciada ou travou durante a execução.
+func ReleaseStaleJobs(db *sql.DB, lockedBefore time.Time) (int64, error) {
+	res, err := db.Exec(`UPDATE jobs SET status = $1, run_at = NOW(), locked_by = NULL, locked_at = NULL,
+		last_error = COALESCE(last_error, 'execução interrompida')
+		WHERE status = $2 AND locked_at < $3`,
+		JobStatusQueued, JobStatusRunning, lockedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,26 +17,85 @@ const (
 	PlanStatusArchived PlanStatus = "archived"
 )
 
+// PlacementPolicy define como o servidor de uma nova conta é escolhido.
+type PlacementPolicy string
+
+const (
+	PlacementPolicyDefault       PlacementPolicy = "default"        // Servidor marcado como padrão
+	PlacementPolicyLeastAccounts PlacementPolicy = "least_accounts" // Servidor com menos contas
+	PlacementPolicyMostFreeDisk  PlacementPolicy = "most_free_disk" // Servidor com mais disco livre
+	PlacementPolicyPinned        PlacementPolicy = "pinned"         // Servidor fixo do plano
+)
+
 // Plan representa um produto ou serviço vendável.
 type Plan struct {
-	ID             int             `json:"id"`
-	Name           string          `json:"name"`
-	Description    sql.NullString  `json:"description"`
-	Category       sql.NullString  `json:"category"`
-	Price          float64         `json:"price"`
-	BillingCycle   BillingCycle    `json:"billing_cycle"`
-	Features       json.RawMessage `json:"features"`
-	WhmPackageName sql.NullString  `json:"whm_package_name"`
-	Status         PlanStatus      `json:"status"`
-	DisplayOrder   int             `json:"display_order"`
-	IsFeatured     bool            `json:"is_featured"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID              int             `json:"id"`
+	Name            string          `json:"name"`
+	Description     sql.NullString  `json:"description"`
+	Category        sql.NullString  `json:"category"`
+	Price           float64         `json:"price"`
+	BillingCycle    BillingCycle    `json:"billing_cycle"`
+	Features        json.RawMessage `json:"features"`
+	WhmPackageName  sql.NullString  `json:"whm_package_name"` // Pacote do WHM ou plano de serviço do Plesk
+	ServerType      ServerType      `json:"server_type"`      // Tipo de painel dos servidores que recebem o plano
+	Status          PlanStatus      `json:"status"`
+	DisplayOrder    int             `json:"display_order"`
+	IsFeatured      bool            `json:"is_featured"`
+	PlacementPolicy PlacementPolicy `json:"placement_policy"`
+	PinnedServerID  sql.NullInt64   `json:"pinned_server_id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// Limites de e-mail em Features, aplicados na área do cliente. Um limite ausente não é verificado
+// pela aplicação (vale apenas o pacote do WHM); -1 ou "unlimited" é ilimitado e 0 desativa o recurso.
+const (
+	PlanFeatureEmailAccounts       = "email_accounts"       // Contas de e-mail
+	PlanFeatureEmailQuotaMB        = "email_quota_mb"       // Cota máxima de cada conta, em MB
+	PlanFeatureEmailForwarders     = "email_forwarders"     // Redirecionamentos
+	PlanFeatureEmailAutoresponders = "email_autoresponders" // Respostas automáticas
+)
+
+// FeatureLimit lê um limite numérico de Features. ok é false quando o plano não define o limite;
+// limit é -1 para ilimitado.
+func (p *Plan) FeatureLimit(key string) (limit int, ok bool) {
+	var features map[string]any
+	if len(p.Features) == 0 || json.Unmarshal(p.Features, &features) != nil {
+		return 0, false
+	}
+	switch v := features[key].(type) {
+	case float64:
+		if v < 0 {
+			return -1, true
+		}
+		return int(v), true
+	case string:
+		if strings.EqualFold(v, "unlimited") {
+			return -1, true
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			return max(n, -1), true
+		}
+	}
+	return 0, false
+}
+
+const planColumns = `id, name, description, category, price, billing_cycle, features, whm_package_name, server_type, status, display_order, is_featured,
+	placement_policy, pinned_server_id, created_at, updated_at`
+
+func scanPlan(row interface{ Scan(...any) error }) (*Plan, error) {
+	var p Plan
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.Price, &p.BillingCycle, &p.Features, &p.WhmPackageName, &p.ServerType, &p.Status,
+		&p.DisplayOrder, &p.IsFeatured, &p.PlacementPolicy, &p.PinnedServerID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
 // GetAllPlans busca todos os planos, ordenados para exibição.
 func GetAllPlans(db *sql.DB) ([]Plan, error) {
-	query := `SELECT id, name, description, category, price, billing_cycle, features, whm_package_name, status, display_order, is_featured, created_at, updated_at FROM plans WHERE status != 'archived' ORDER BY display_order ASC`
+	query := `SELECT ` + planColumns + ` FROM plans WHERE status != 'archived' ORDER BY display_order ASC`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -43,35 +104,75 @@ func GetAllPlans(db *sql.DB) ([]Plan, error) {
 
 	var plans []Plan
 	for rows.Next() {
-		var p Plan
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.Price, &p.BillingCycle, &p.Features, &p.WhmPackageName, &p.Status, &p.DisplayOrder, &p.IsFeatured, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		p, err := scanPlan(rows)
+		if err != nil {
 			return nil, err
 		}
-		plans = append(plans, p)
+		plans = append(plans, *p)
 	}
 	return plans, nil
 }
 
+// GetPlanByID busca um plano pelo ID.
+func GetPlanByID(db *sql.DB, id int) (*Plan, error) {
+	p, err := scanPlan(db.QueryRow(`SELECT `+planColumns+` FROM plans WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPlanByWhmPackageName busca o plano associado a um pacote do WHM.
+func GetPlanByWhmPackageName(db *sql.DB, packageName string) (*Plan, error) {
+	p, err := scanPlan(db.QueryRow(`SELECT `+planColumns+` FROM plans WHERE whm_package_name = $1 AND status != 'archived' ORDER BY id ASC LIMIT 1`, packageName))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
 // CreatePlan insere um novo plano no banco de dados.
 func CreatePlan(db *sql.DB, p *Plan) (int64, error) {
-	query := `INSERT INTO plans (name, description, category, price, billing_cycle, features, whm_package_name, status, display_order, is_featured) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := db.Exec(query, p.Name, p.Description, p.Category, p.Price, p.BillingCycle, p.Features, p.WhmPackageName, p.Status, p.DisplayOrder, p.IsFeatured)
-	if err != nil {
-		return 0, err
+	if p.PlacementPolicy == "" {
+		p.PlacementPolicy = PlacementPolicyDefault
+	}
+	if p.ServerType == "" {
+		p.ServerType = ServerTypeCPanel
 	}
-	return res.LastInsertId()
+
+	var id int64
+	query := `INSERT INTO plans (name, description, category, price, billing_cycle, features, whm_package_name, status, display_order, is_featured, placement_policy, pinned_server_id, server_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
+	err := db.QueryRow(query, p.Name, p.Description, p.Category, p.Price, p.BillingCycle, p.Features, p.WhmPackageName, p.Status, p.DisplayOrder, p.IsFeatured,
+		p.PlacementPolicy, p.PinnedServerID, p.ServerType).Scan(&id)
+	return id, err
 }
 
 // UpdatePlan atualiza um plano existente.
 func UpdatePlan(db *sql.DB, p *Plan) error {
-	query := `UPDATE plans SET name = ?, description = ?, category = ?, price = ?, billing_cycle = ?, features = ?, whm_package_name = ?, status = ?, display_order = ?, is_featured = ? WHERE id = ?`
-	_, err := db.Exec(query, p.Name, p.Description, p.Category, p.Price, p.BillingCycle, p.Features, p.WhmPackageName, p.Status, p.DisplayOrder, p.IsFeatured, p.ID)
+	if p.PlacementPolicy == "" {
+		p.PlacementPolicy = PlacementPolicyDefault
+	}
+	if p.ServerType == "" {
+		p.ServerType = ServerTypeCPanel
+	}
+
+	query := `UPDATE plans SET name = $1, description = $2, category = $3, price = $4, billing_cycle = $5, features = $6, whm_package_name = $7, status = $8,
+		display_order = $9, is_featured = $10, placement_policy = $11, pinned_server_id = $12, server_type = $13 WHERE id = $14`
+	_, err := db.Exec(query, p.Name, p.Description, p.Category, p.Price, p.BillingCycle, p.Features, p.WhmPackageName, p.Status, p.DisplayOrder, p.IsFeatured,
+		p.PlacementPolicy, p.PinnedServerID, p.ServerType, p.ID)
 	return err
 }
 
 // UpdatePlanStatus atualiza apenas o status de um plano.
 func UpdatePlanStatus(db *sql.DB, id int, status PlanStatus) error {
-	query := `UPDATE plans SET status = ? WHERE id = ?`
+	query := `UPDATE plans SET status = $1 WHERE id = $2`
 	_, err := db.Exec(query, status, id)
 	return err
 }
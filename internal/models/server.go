@@ -31,55 +31,214 @@ type Server struct {
 	Hostname      string          `json:"hostname"`
 	IPAddress     string          `json:"ip_address"`
 	ServerType    ServerType      `json:"server_type"`
-	APIKeyID      int             `json:"api_key_id"` // FK para api_credentials
+	APIKeyID      sql.NullInt64   `json:"api_key_id"` // FK para api_credentials
 	Status        ServerStatus    `json:"status"`
 	IsDefault     bool            `json:"is_default"`
-	Metrics       json.RawMessage `json:"metrics"` // Armazena dados como uso de disco, etc.
+	MaxAccounts   *int            `json:"max_accounts"` // Limite de contas do servidor; NULL desativa o alerta de contas
+	Metrics       json.RawMessage `json:"metrics"`      // Armazena dados como uso de disco, etc.
 	LastSyncAt    sql.NullTime    `json:"last_sync_at"`
+	SyncFailures  int             `json:"sync_failures"` // Falhas consecutivas de sincronização das métricas
+	LastSyncError sql.NullString  `json:"last_sync_error"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
+// ServerMetrics é o conteúdo da coluna metrics de um servidor e de cada ponto da série histórica.
+type ServerMetrics struct {
+	Load1           float64   `json:"load_1"`
+	Load5           float64   `json:"load_5"`
+	Load15          float64   `json:"load_15"`
+	DiskTotalMB     float64   `json:"disk_total_mb,omitempty"`
+	DiskUsedMB      float64   `json:"disk_used_mb,omitempty"`
+	DiskUsedPercent float64   `json:"disk_used_percent"`
+	DiskFreeMB      float64   `json:"disk_free_mb"`
+	AccountCount    int       `json:"account_count"`
+	Version         string    `json:"version,omitempty"`
+	CollectedAt     time.Time `json:"collected_at"`
+}
+
+// ParsedMetrics decodifica as métricas do servidor. Retorna nil se ainda não houver métricas.
+func (s *Server) ParsedMetrics() *ServerMetrics {
+	if len(s.Metrics) == 0 || string(s.Metrics) == "null" {
+		return nil
+	}
+	var m ServerMetrics
+	if err := json.Unmarshal(s.Metrics, &m); err != nil {
+		return nil
+	}
+	return &m
+}
+
+const serverColumns = `id, name, hostname, ip_address, server_type, api_key_id, status, is_default, max_accounts, metrics, last_sync_at, sync_failures, last_sync_error, created_at, updated_at`
+
+func scanServer(row interface{ Scan(...any) error }) (*Server, error) {
+	var s Server
+	var metrics []byte
+	if err := row.Scan(&s.ID, &s.Name, &s.Hostname, &s.IPAddress, &s.ServerType, &s.APIKeyID, &s.Status, &s.IsDefault, &s.MaxAccounts, &metrics, &s.LastSyncAt, &s.SyncFailures, &s.LastSyncError, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	s.Metrics = metrics
+	return &s, nil
+}
+
+func queryServers(db *sql.DB, query string, args ...any) ([]Server, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []Server
+	for rows.Next() {
+		s, err := scanServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, *s)
+	}
+	return servers, rows.Err()
+}
+
 // GetAllServers busca todos os servidores no banco de dados.
 func GetAllServers(db *sql.DB) ([]Server, error) {
-	query := `SELECT id, name, hostname, ip_address, server_type, api_key_id, status, is_default, metrics, last_sync_at, created_at, updated_at FROM servers`
-	rows, err := db.Query(query)
+	return queryServers(db, `SELECT `+serverColumns+` FROM servers ORDER BY id ASC`)
+}
+
+// GetActiveServersByType busca os servidores ativos de um tipo de painel.
+func GetActiveServersByType(db *sql.DB, serverType ServerType) ([]Server, error) {
+	return queryServers(db, `SELECT `+serverColumns+` FROM servers WHERE status = $1 AND server_type = $2 ORDER BY id ASC`, ServerStatusActive, serverType)
+}
+
+// GetServerByID busca um servidor pelo ID.
+func GetServerByID(db *sql.DB, id int) (*Server, error) {
+	s, err := scanServer(db.QueryRow(`SELECT `+serverColumns+` FROM servers WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// CountAccountsByServer retorna o número de serviços não encerrados em cada servidor.
+func CountAccountsByServer(db *sql.DB) (map[int]int, error) {
+	rows, err := db.Query(`SELECT server_id, COUNT(*) FROM services WHERE server_id IS NOT NULL AND status != $1 GROUP BY server_id`, ServiceStatusTerminated)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var servers []Server
+	counts := make(map[int]int)
 	for rows.Next() {
-		var s Server
-		if err := rows.Scan(&s.ID, &s.Name, &s.Hostname, &s.IPAddress, &s.ServerType, &s.APIKeyID, &s.Status, &s.IsDefault, &s.Metrics, &s.LastSyncAt, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		var serverID, count int
+		if err := rows.Scan(&serverID, &count); err != nil {
 			return nil, err
 		}
-		servers = append(servers, s)
+		counts[serverID] = count
 	}
-	return servers, nil
+	return counts, rows.Err()
 }
 
 // CreateServer insere um novo servidor no banco de dados.
 func CreateServer(db *sql.DB, s *Server) (int64, error) {
-	query := `INSERT INTO servers (name, hostname, ip_address, server_type, api_key_id, status, is_default, metrics) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := db.Exec(query, s.Name, s.Hostname, s.IPAddress, s.ServerType, s.APIKeyID, s.Status, s.IsDefault, s.Metrics)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+	var id int64
+	query := `INSERT INTO servers (name, hostname, ip_address, server_type, api_key_id, status, is_default, max_accounts, metrics)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	err := db.QueryRow(query, s.Name, s.Hostname, s.IPAddress, s.ServerType, s.APIKeyID, s.Status, s.IsDefault, s.MaxAccounts, nullableJSON(s.Metrics)).Scan(&id)
+	return id, err
 }
 
 // UpdateServer atualiza um servidor existente.
 func UpdateServer(db *sql.DB, s *Server) error {
-	query := `UPDATE servers SET name = ?, hostname = ?, ip_address = ?, server_type = ?, api_key_id = ?, status = ?, is_default = ?, metrics = ? WHERE id = ?`
-	_, err := db.Exec(query, s.Name, s.Hostname, s.IPAddress, s.ServerType, s.APIKeyID, s.Status, s.IsDefault, s.Metrics, s.ID)
+	query := `UPDATE servers SET name = $1, hostname = $2, ip_address = $3, server_type = $4, status = $5, is_default = $6, max_accounts = $7 WHERE id = $8`
+	_, err := db.Exec(query, s.Name, s.Hostname, s.IPAddress, s.ServerType, s.Status, s.IsDefault, s.MaxAccounts, s.ID)
+	return err
+}
+
+// SetServerAPIKey associa uma credencial de api_credentials ao servidor.
+func SetServerAPIKey(db *sql.DB, serverID int, apiKeyID int64) error {
+	_, err := db.Exec(`UPDATE servers SET api_key_id = $1 WHERE id = $2`, apiKeyID, serverID)
 	return err
 }
 
 // DeleteServer remove um servidor do banco de dados.
 func DeleteServer(db *sql.DB, id int) error {
-	query := `DELETE FROM servers WHERE id = ?`
+	query := `DELETE FROM servers WHERE id = $1`
 	_, err := db.Exec(query, id)
 	return err
 }
+
+// UpdateServerMetrics grava o último snapshot de métricas do servidor, zera o contador de falhas
+// e registra o ponto na série histórica.
+func UpdateServerMetrics(db *sql.DB, serverID int, status ServerStatus, m *ServerMetrics) error {
+	snapshot, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE servers SET metrics = $1, last_sync_at = $2, sync_failures = 0, last_sync_error = NULL, status = $3 WHERE id = $4`,
+		snapshot, m.CollectedAt, status, serverID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO server_metrics (server_id, load_1, load_5, load_15, disk_used_percent, disk_free_mb, account_count, collected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		serverID, m.Load1, m.Load5, m.Load15, m.DiskUsedPercent, m.DiskFreeMB, m.AccountCount, m.CollectedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RecordServerSyncFailure incrementa o contador de falhas de sincronização do servidor
+// e retorna o total de falhas consecutivas.
+func RecordServerSyncFailure(db *sql.DB, serverID int, syncErr string) (int, error) {
+	var failures int
+	err := db.QueryRow(`UPDATE servers SET sync_failures = sync_failures + 1, last_sync_error = $1 WHERE id = $2 RETURNING sync_failures`,
+		syncErr, serverID).Scan(&failures)
+	return failures, err
+}
+
+// SetServerStatus altera o status operacional de um servidor.
+func SetServerStatus(db *sql.DB, serverID int, status ServerStatus) error {
+	_, err := db.Exec(`UPDATE servers SET status = $1 WHERE id = $2`, status, serverID)
+	return err
+}
+
+// GetServerMetricHistory retorna os pontos de métricas de um servidor coletados desde a data informada.
+func GetServerMetricHistory(db *sql.DB, serverID int, since time.Time) ([]ServerMetrics, error) {
+	rows, err := db.Query(`SELECT load_1, load_5, load_15, disk_used_percent, disk_free_mb, account_count, collected_at
+		FROM server_metrics WHERE server_id = $1 AND collected_at >= $2 ORDER BY collected_at ASC`, serverID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []ServerMetrics
+	for rows.Next() {
+		var m ServerMetrics
+		if err := rows.Scan(&m.Load1, &m.Load5, &m.Load15, &m.DiskUsedPercent, &m.DiskFreeMB, &m.AccountCount, &m.CollectedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, m)
+	}
+	return points, rows.Err()
+}
+
+// DeleteServerMetricsOlderThan remove da série histórica os pontos anteriores à data informada.
+func DeleteServerMetricsOlderThan(db *sql.DB, before time.Time) (int64, error) {
+	res, err := db.Exec(`DELETE FROM server_metrics WHERE collected_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
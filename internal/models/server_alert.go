@@ -0,0 +1,142 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ServerAlertType identifica a regra que gerou um alerta de servidor.
+type ServerAlertType string
+
+const (
+	ServerAlertDiskUsage         ServerAlertType = "disk_usage"
+	ServerAlertLoad              ServerAlertType = "load"
+	ServerAlertAccountLimit      ServerAlertType = "account_limit"
+	ServerAlertCertificateExpiry ServerAlertType = "certificate_expiry"
+	ServerAlertUnreachable       ServerAlertType = "unreachable"
+)
+
+// ServerAlertStatus define o ciclo de vida de um alerta.
+type ServerAlertStatus string
+
+const (
+	ServerAlertStatusOpen         ServerAlertStatus = "open"
+	ServerAlertStatusAcknowledged ServerAlertStatus = "acknowledged"
+	ServerAlertStatusResolved     ServerAlertStatus = "resolved"
+)
+
+// ServerAlert é um alerta de capacidade ou saúde de um servidor do pool.
+// Enquanto não for resolvido, existe no máximo um alerta por servidor e tipo.
+type ServerAlert struct {
+	ID             int64             `json:"id"`
+	ServerID       int               `json:"server_id"`
+	ServerName     string            `json:"server_name"`
+	AlertType      ServerAlertType   `json:"alert_type"`
+	Status         ServerAlertStatus `json:"status"`
+	Message        string            `json:"message"`
+	Value          sql.NullFloat64   `json:"value"`
+	Threshold      sql.NullFloat64   `json:"threshold"`
+	OpenedAt       time.Time         `json:"opened_at"`
+	LastSeenAt     time.Time         `json:"last_seen_at"`
+	AcknowledgedAt sql.NullTime      `json:"acknowledged_at"`
+	AcknowledgedBy sql.NullInt64     `json:"acknowledged_by"`
+	ResolvedAt     sql.NullTime      `json:"resolved_at"`
+}
+
+const serverAlertColumns = `a.id, a.server_id, s.name, a.alert_type, a.status, a.message, a.value, a.threshold,
+	a.opened_at, a.last_seen_at, a.acknowledged_at, a.acknowledged_by, a.resolved_at`
+
+func scanServerAlert(row interface{ Scan(...any) error }) (*ServerAlert, error) {
+	var a ServerAlert
+	err := row.Scan(&a.ID, &a.ServerID, &a.ServerName, &a.AlertType, &a.Status, &a.Message, &a.Value, &a.Threshold,
+		&a.OpenedAt, &a.LastSeenAt, &a.AcknowledgedAt, &a.AcknowledgedBy, &a.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func queryServerAlerts(db *sql.DB, query string, args ...any) ([]ServerAlert, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []ServerAlert
+	for rows.Next() {
+		a, err := scanServerAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *a)
+	}
+	return alerts, rows.Err()
+}
+
+// GetUnresolvedServerAlerts retorna os alertas abertos ou reconhecidos, do mais recente ao mais antigo.
+func GetUnresolvedServerAlerts(db *sql.DB) ([]ServerAlert, error) {
+	return queryServerAlerts(db, `SELECT `+serverAlertColumns+` FROM server_alerts a JOIN servers s ON s.id = a.server_id
+		WHERE a.status IN ($1, $2) ORDER BY a.opened_at DESC`, ServerAlertStatusOpen, ServerAlertStatusAcknowledged)
+}
+
+// GetServerAlertsByStatus retorna os alertas com o status informado, do mais recente ao mais antigo.
+func GetServerAlertsByStatus(db *sql.DB, status ServerAlertStatus, limit int) ([]ServerAlert, error) {
+	return queryServerAlerts(db, `SELECT `+serverAlertColumns+` FROM server_alerts a JOIN servers s ON s.id = a.server_id
+		WHERE a.status = $1 ORDER BY a.opened_at DESC LIMIT $2`, status, limit)
+}
+
+// GetServerAlertByID busca um alerta pelo ID.
+func GetServerAlertByID(db *sql.DB, id int64) (*ServerAlert, error) {
+	a, err := scanServerAlert(db.QueryRow(`SELECT `+serverAlertColumns+` FROM server_alerts a JOIN servers s ON s.id = a.server_id
+		WHERE a.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// CreateServerAlert abre um novo alerta. Retorna false, sem erro, se já houver um alerta
+// não resolvido do mesmo tipo para o servidor.
+func CreateServerAlert(db *sql.DB, a *ServerAlert) (bool, error) {
+	err := db.QueryRow(`INSERT INTO server_alerts (server_id, alert_type, status, message, value, threshold)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (server_id, alert_type) WHERE status IN ('open', 'acknowledged') DO NOTHING
+		RETURNING id, opened_at, last_seen_at`,
+		a.ServerID, a.AlertType, ServerAlertStatusOpen, a.Message, a.Value, a.Threshold).Scan(&a.ID, &a.OpenedAt, &a.LastSeenAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	a.Status = ServerAlertStatusOpen
+	return true, nil
+}
+
+// TouchServerAlert atualiza a mensagem e o valor medido de um alerta que continua ativo.
+func TouchServerAlert(db *sql.DB, id int64, message string, value sql.NullFloat64) error {
+	_, err := db.Exec(`UPDATE server_alerts SET message = $1, value = $2, last_seen_at = NOW() WHERE id = $3`, message, value, id)
+	return err
+}
+
+// AcknowledgeServerAlert marca um alerta aberto como reconhecido pelo administrador.
+// Retorna false se o alerta não estiver aberto.
+func AcknowledgeServerAlert(db *sql.DB, id int64, adminID int) (bool, error) {
+	res, err := db.Exec(`UPDATE server_alerts SET status = $1, acknowledged_at = NOW(), acknowledged_by = $2 WHERE id = $3 AND status = $4`,
+		ServerAlertStatusAcknowledged, adminID, id, ServerAlertStatusOpen)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ResolveServerAlert encerra um alerta cuja condição deixou de ocorrer.
+func ResolveServerAlert(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE server_alerts SET status = $1, resolved_at = NOW() WHERE id = $2`, ServerAlertStatusResolved, id)
+	return err
+}
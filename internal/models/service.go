@@ -27,23 +27,23 @@ type Service struct {
 	Price        float64       `json:"price"`
 	BillingCycle BillingCycle  `json:"billing_cycle"`
 	NextDueDate  time.Time     `json:"next_due_date"`
+	ServerID     sql.NullInt64 `json:"server_id"` // Servidor em que a conta foi criada
 	CreatedAt    time.Time     `json:"created_at"`
 	UpdatedAt    time.Time     `json:"updated_at"`
 }
 
-// CreateService insere um novo serviço no banco de dados.
-func CreateService(db *sql.DB, s *Service) (int64, error) {
-	query := `INSERT INTO services (user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := db.Exec(query, s.UserID, s.ProductID, s.Domain, s.CpanelUser, s.Status, s.Price, s.BillingCycle, s.NextDueDate)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+// CreateService insere um novo serviço no banco de dados. Aceita uma transação.
+func CreateService(db QueryRower, s *Service) (int64, error) {
+	var id int64
+	query := `INSERT INTO services (user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	err := db.QueryRow(query, s.UserID, s.ProductID, s.Domain, s.CpanelUser, s.Status, s.Price, s.BillingCycle, s.NextDueDate, s.ServerID).Scan(&id)
+	return id, err
 }
 
 // GetServicesByUserID busca todos os serviços associados a um ID de cliente.
 func GetServicesByUserID(db *sql.DB, userID int) ([]Service, error) {
-	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, created_at, updated_at FROM services WHERE user_id = ?`
+	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id, created_at, updated_at FROM services WHERE user_id = $1`
 	rows, err := db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -53,7 +53,7 @@ func GetServicesByUserID(db *sql.DB, userID int) ([]Service, error) {
 	var services []Service
 	for rows.Next() {
 		var s Service
-		if err := rows.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.ServerID, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		services = append(services, s)
@@ -63,11 +63,26 @@ func GetServicesByUserID(db *sql.DB, userID int) ([]Service, error) {
 
 // GetServiceByID busca um serviço específico pelo seu ID e pelo ID do cliente.
 func GetServiceByID(db *sql.DB, serviceID int, userID int) (*Service, error) {
-	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, created_at, updated_at FROM services WHERE id = ? AND user_id = ?`
+	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id, created_at, updated_at FROM services WHERE id = $1 AND user_id = $2`
 	row := db.QueryRow(query, serviceID, userID)
 
 	var s Service
-	if err := row.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+	if err := row.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.ServerID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetServiceByIDAdmin busca um serviço pelo ID, sem restringir ao cliente. Uso exclusivo das rotas administrativas e workers.
+func GetServiceByIDAdmin(db *sql.DB, serviceID int) (*Service, error) {
+	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id, created_at, updated_at FROM services WHERE id = $1`
+	row := db.QueryRow(query, serviceID)
+
+	var s Service
+	if err := row.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.ServerID, &s.CreatedAt, &s.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Not found
 		}
@@ -76,16 +91,67 @@ func GetServiceByID(db *sql.DB, serviceID int, userID int) (*Service, error) {
 	return &s, nil
 }
 
+// GetActiveServiceByCpanelUser busca o serviço não encerrado com o usuário de cPanel informado.
+func GetActiveServiceByCpanelUser(db *sql.DB, username string) (*Service, error) {
+	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id, created_at, updated_at FROM services WHERE cpanel_user = $1 AND status != $2 ORDER BY id DESC LIMIT 1`
+	row := db.QueryRow(query, username, ServiceStatusTerminated)
+
+	var s Service
+	if err := row.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.ServerID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetServiceUsernamesByServer retorna os usuários de cPanel já cadastrados em serviços do servidor,
+// incluindo os serviços sem servidor definido (criados antes do pool de servidores).
+func GetServiceUsernamesByServer(db *sql.DB, serverID int) (map[string]int, error) {
+	query := `SELECT cpanel_user, id FROM services WHERE (server_id = $1 OR server_id IS NULL) AND cpanel_user != '' AND status != $2`
+	rows, err := db.Query(query, serverID, ServiceStatusTerminated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usernames := make(map[string]int)
+	for rows.Next() {
+		var username string
+		var id int
+		if err := rows.Scan(&username, &id); err != nil {
+			return nil, err
+		}
+		usernames[username] = id
+	}
+	return usernames, rows.Err()
+}
+
+// UpdateServiceServer move o serviço para outro servidor do pool.
+func UpdateServiceServer(db *sql.DB, serviceID, serverID int) error {
+	_, err := db.Exec(`UPDATE services SET server_id = $1, updated_at = NOW() WHERE id = $2`, serverID, serviceID)
+	return err
+}
+
+// ActivateServiceAccount registra o usuário de cPanel criado para o serviço e o servidor do pool em que
+// a conta foi criada (NULL para o servidor do ambiente), e ativa o serviço.
+func ActivateServiceAccount(db *sql.DB, serviceID int, cpanelUser string, serverID sql.NullInt64) error {
+	_, err := db.Exec(`UPDATE services SET cpanel_user = $1, server_id = $2, status = $3, updated_at = NOW() WHERE id = $4`,
+		cpanelUser, serverID, ServiceStatusActive, serviceID)
+	return err
+}
+
 // UpdateServiceStatus atualiza o status de um serviço específico.
 func UpdateServiceStatus(db *sql.DB, serviceID int, newStatus ServiceStatus) error {
-	query := `UPDATE services SET status = ?, updated_at = NOW() WHERE id = ?`
+	query := `UPDATE services SET status = $1, updated_at = NOW() WHERE id = $2`
 	_, err := db.Exec(query, newStatus, serviceID)
 	return err
 }
 
 // GetServicesDueForInvoicing busca serviços ativos cuja data de próxima fatura já passou.
 func GetServicesDueForInvoicing(db *sql.DB) ([]Service, error) {
-	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, created_at, updated_at FROM services WHERE status = ? AND next_due_date <= NOW()`
+	query := `SELECT id, user_id, product_id, domain, cpanel_user, status, price, billing_cycle, next_due_date, server_id, created_at, updated_at FROM services WHERE status = $1 AND next_due_date <= NOW()`
 	rows, err := db.Query(query, ServiceStatusActive)
 	if err != nil {
 		return nil, err
@@ -95,7 +161,7 @@ func GetServicesDueForInvoicing(db *sql.DB) ([]Service, error) {
 	var services []Service
 	for rows.Next() {
 		var s Service
-		if err := rows.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.UserID, &s.ProductID, &s.Domain, &s.CpanelUser, &s.Status, &s.Price, &s.BillingCycle, &s.NextDueDate, &s.ServerID, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		services = append(services, s)
@@ -105,7 +171,7 @@ func GetServicesDueForInvoicing(db *sql.DB) ([]Service, error) {
 
 // UpdateNextDueDate atualiza a data da próxima fatura de um serviço.
 func UpdateNextDueDate(db *sql.DB, serviceID int, newDueDate time.Time) error {
-	query := `UPDATE services SET next_due_date = ?, updated_at = NOW() WHERE id = ?`
+	query := `UPDATE services SET next_due_date = $1, updated_at = NOW() WHERE id = $2`
 	_, err := db.Exec(query, newDueDate, serviceID)
 	return err
 }
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// SSLCertificate é o certificado instalado em um virtual host da conta de um serviço, em cache.
+type SSLCertificate struct {
+	ServiceID       int            `json:"service_id"`
+	VirtualHost     string         `json:"virtual_host"`
+	Domains         string         `json:"domains"`         // Domínios do virtual host, separados por vírgula
+	CoveredDomains  sql.NullString `json:"covered_domains"` // Nomes cobertos pelo certificado, separados por vírgula
+	Issuer          sql.NullString `json:"issuer"`
+	NotBefore       sql.NullTime   `json:"not_before"`
+	NotAfter        sql.NullTime   `json:"not_after"` // NULL: domínio sem certificado
+	SelfSigned      bool           `json:"self_signed"`
+	AutoRenewed     bool           `json:"auto_renewed"`
+	ExpiryAlertDays sql.NullInt64  `json:"expiry_alert_days"`
+	SyncedAt        time.Time      `json:"synced_at"`
+}
+
+const sslCertificateColumns = `service_id, vhost, domains, covered_domains, issuer, not_before, not_after, self_signed, auto_renewed, expiry_alert_days, synced_at`
+
+func scanSSLCertificate(row interface{ Scan(...any) error }) (*SSLCertificate, error) {
+	var c SSLCertificate
+	err := row.Scan(&c.ServiceID, &c.VirtualHost, &c.Domains, &c.CoveredDomains, &c.Issuer, &c.NotBefore, &c.NotAfter,
+		&c.SelfSigned, &c.AutoRenewed, &c.ExpiryAlertDays, &c.SyncedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// DomainList retorna os domínios atendidos pelo virtual host.
+func (c *SSLCertificate) DomainList() []string {
+	if c.Domains == "" {
+		return []string{}
+	}
+	return strings.Split(c.Domains, ",")
+}
+
+// CoveredDomainList retorna os nomes cobertos pelo certificado.
+func (c *SSLCertificate) CoveredDomainList() []string {
+	if !c.CoveredDomains.Valid || c.CoveredDomains.String == "" {
+		return []string{}
+	}
+	return strings.Split(c.CoveredDomains.String, ",")
+}
+
+// UpsertSSLCertificate grava o certificado lido do servidor. Um novo vencimento reinicia os avisos.
+func UpsertSSLCertificate(db *sql.DB, c *SSLCertificate) error {
+	_, err := db.Exec(`INSERT INTO ssl_certificates (service_id, vhost, domains, covered_domains, issuer, not_before, not_after, self_signed, auto_renewed, synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (service_id, vhost) DO UPDATE SET domains = EXCLUDED.domains, covered_domains = EXCLUDED.covered_domains,
+			issuer = EXCLUDED.issuer, not_before = EXCLUDED.not_before, not_after = EXCLUDED.not_after,
+			self_signed = EXCLUDED.self_signed, auto_renewed = EXCLUDED.auto_renewed, synced_at = EXCLUDED.synced_at,
+			expiry_alert_days = CASE WHEN ssl_certificates.not_after IS DISTINCT FROM EXCLUDED.not_after
+				THEN NULL ELSE ssl_certificates.expiry_alert_days END`,
+		c.ServiceID, c.VirtualHost, c.Domains, c.CoveredDomains, c.Issuer, c.NotBefore, c.NotAfter, c.SelfSigned, c.AutoRenewed, c.SyncedAt)
+	return err
+}
+
+// DeleteSSLCertificatesSyncedBefore remove os virtual hosts do serviço que não apareceram na leitura
+// feita em syncedAt.
+func DeleteSSLCertificatesSyncedBefore(db *sql.DB, serviceID int, syncedAt time.Time) error {
+	_, err := db.Exec(`DELETE FROM ssl_certificates WHERE service_id = $1 AND synced_at < $2`, serviceID, syncedAt)
+	return err
+}
+
+// GetSSLCertificatesByService lista os certificados em cache da conta do serviço.
+func GetSSLCertificatesByService(db *sql.DB, serviceID int) ([]SSLCertificate, error) {
+	rows, err := db.Query(`SELECT `+sslCertificateColumns+` FROM ssl_certificates WHERE service_id = $1 ORDER BY vhost`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certificates []SSLCertificate
+	for rows.Next() {
+		c, err := scanSSLCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, *c)
+	}
+	return certificates, rows.Err()
+}
+
+// ClaimSSLExpiryAlert reserva o aviso de vencimento de days dias para o certificado. Retorna false se
+// o vencimento mudou ou se um aviso com a mesma ou menor antecedência já foi enviado.
+func ClaimSSLExpiryAlert(db *sql.DB, serviceID int, vhost string, notAfter time.Time, days int) (bool, error) {
+	res, err := db.Exec(`UPDATE ssl_certificates SET expiry_alert_days = $1
+		WHERE service_id = $2 AND vhost = $3 AND not_after = $4 AND (expiry_alert_days IS NULL OR expiry_alert_days > $1)`,
+		days, serviceID, vhost, notAfter)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseSSLExpiryAlert devolve o aviso de vencimento ao valor anterior após uma falha no envio.
+func ReleaseSSLExpiryAlert(db *sql.DB, serviceID int, vhost string, previous sql.NullInt64) error {
+	_, err := db.Exec(`UPDATE ssl_certificates SET expiry_alert_days = $1 WHERE service_id = $2 AND vhost = $3`,
+		previous, serviceID, vhost)
+	return err
+}
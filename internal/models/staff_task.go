@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// StaffTaskPriority define a urgência de uma pendência da equipe.
+type StaffTaskPriority string
+
+const (
+	StaffTaskPriorityLow    StaffTaskPriority = "low"
+	StaffTaskPriorityNormal StaffTaskPriority = "normal"
+	StaffTaskPriorityHigh   StaffTaskPriority = "high"
+)
+
+// StaffTaskStatus define o ciclo de vida de uma pendência.
+type StaffTaskStatus string
+
+const (
+	StaffTaskStatusOpen     StaffTaskStatus = "open"
+	StaffTaskStatusResolved StaffTaskStatus = "resolved"
+)
+
+// StaffTask é uma pendência da fila "precisa de atenção": uma falha que a compensação automática
+// não resolve e exige ação da equipe. Existe no máximo uma pendência aberta por alvo e tipo.
+type StaffTask struct {
+	ID         int64             `json:"id"`
+	Kind       string            `json:"kind"`
+	Priority   StaffTaskPriority `json:"priority"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	Title      string            `json:"title"`
+	Details    sql.NullString    `json:"details"`
+	JobID      sql.NullInt64     `json:"job_id"`
+	Status     StaffTaskStatus   `json:"status"`
+	Resolution sql.NullString    `json:"resolution"`
+	ResolvedBy sql.NullInt64     `json:"resolved_by"`
+	ResolvedAt sql.NullTime      `json:"resolved_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+const staffTaskColumns = `id, kind, priority, target_type, target_id, title, details, job_id, status, resolution,
+	resolved_by, resolved_at, created_at, updated_at`
+
+func scanStaffTask(row interface{ Scan(...any) error }) (*StaffTask, error) {
+	var t StaffTask
+	err := row.Scan(&t.ID, &t.Kind, &t.Priority, &t.TargetType, &t.TargetID, &t.Title, &t.Details, &t.JobID, &t.Status, &t.Resolution,
+		&t.ResolvedBy, &t.ResolvedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func queryStaffTasks(db *sql.DB, query string, args ...any) ([]StaffTask, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []StaffTask
+	for rows.Next() {
+		t, err := scanStaffTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// OpenStaffTask abre uma pendência. Se já houver uma pendência aberta para o mesmo alvo e tipo,
+// nada é inserido e created retorna false.
+func OpenStaffTask(db *sql.DB, t *StaffTask) (created bool, err error) {
+	if t.Priority == "" {
+		t.Priority = StaffTaskPriorityNormal
+	}
+	query := `INSERT INTO staff_tasks (kind, priority, target_type, target_id, title, details, job_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, target_type, target_id) WHERE status = 'open' DO NOTHING
+		RETURNING id, status, created_at, updated_at`
+	err = db.QueryRow(query, t.Kind, t.Priority, t.TargetType, t.TargetID, t.Title, t.Details, t.JobID).
+		Scan(&t.ID, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStaffTaskByID busca uma pendência pelo ID.
+func GetStaffTaskByID(db *sql.DB, id int64) (*StaffTask, error) {
+	t, err := scanStaffTask(db.QueryRow(`SELECT `+staffTaskColumns+` FROM staff_tasks WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetOpenStaffTasks lista as pendências abertas, das mais urgentes e antigas para as demais.
+func GetOpenStaffTasks(db *sql.DB) ([]StaffTask, error) {
+	return queryStaffTasks(db, `SELECT `+staffTaskColumns+` FROM staff_tasks WHERE status = $1
+		ORDER BY CASE priority WHEN 'high' THEN 0 WHEN 'normal' THEN 1 ELSE 2 END, created_at ASC`, StaffTaskStatusOpen)
+}
+
+// GetResolvedStaffTasks lista as últimas pendências resolvidas.
+func GetResolvedStaffTasks(db *sql.DB, limit int) ([]StaffTask, error) {
+	return queryStaffTasks(db, `SELECT `+staffTaskColumns+` FROM staff_tasks WHERE status = $1 ORDER BY resolved_at DESC LIMIT $2`,
+		StaffTaskStatusResolved, limit)
+}
+
+// ResolveStaffTask marca uma pendência aberta como resolvida. Retorna false se a pendência não
+// estava aberta.
+func ResolveStaffTask(db *sql.DB, id int64, resolvedBy int, resolution string) (bool, error) {
+	res, err := db.Exec(`UPDATE staff_tasks SET status = $1, resolution = $2, resolved_by = $3, resolved_at = NOW() WHERE id = $4 AND status = $5`,
+		StaffTaskStatusResolved, sql.NullString{String: resolution, Valid: resolution != ""}, resolvedBy, id, StaffTaskStatusOpen)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SystemModule representa a tabela system_modules
+type SystemModule struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Description sql.NullString `json:"description"`
+	Enabled     bool           `json:"enabled"`
+	EnabledAt   sql.NullTime   `json:"enabled_at"`
+	EnabledBy   sql.NullInt64  `json:"enabled_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+const systemModuleColumns = `id, name, description, enabled, enabled_at, enabled_by, created_at, updated_at`
+
+func scanSystemModule(row interface{ Scan(...any) error }) (*SystemModule, error) {
+	var m SystemModule
+	if err := row.Scan(&m.ID, &m.Name, &m.Description, &m.Enabled, &m.EnabledAt, &m.EnabledBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetAllSystemModules lista todos os módulos do sistema em ordem alfabética.
+func GetAllSystemModules(db *sql.DB) ([]SystemModule, error) {
+	rows, err := db.Query(`SELECT ` + systemModuleColumns + ` FROM system_modules ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var modules []SystemModule
+	for rows.Next() {
+		m, err := scanSystemModule(rows)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, *m)
+	}
+	return modules, rows.Err()
+}
+
+// GetSystemModuleByName busca um módulo pelo nome.
+func GetSystemModuleByName(db *sql.DB, name string) (*SystemModule, error) {
+	m, err := scanSystemModule(db.QueryRow(`SELECT `+systemModuleColumns+` FROM system_modules WHERE name = $1`, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// EnsureSystemModule cria o registro do módulo, desativado, caso ainda não exista,
+// e mantém a descrição atualizada.
+func EnsureSystemModule(db *sql.DB, name, description string) error {
+	query := `INSERT INTO system_modules (name, description, enabled) VALUES ($1, $2, FALSE)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`
+	_, err := db.Exec(query, name, description)
+	return err
+}
+
+// SetSystemModuleEnabled ativa ou desativa um módulo, registrando o admin responsável.
+func SetSystemModuleEnabled(db *sql.DB, name string, enabled bool, adminID int) error {
+	query := `UPDATE system_modules SET enabled = $1, enabled_by = $2,
+		enabled_at = CASE WHEN $1 THEN NOW() ELSE enabled_at END
+		WHERE name = $3`
+	_, err := db.Exec(query, enabled, adminID, name)
+	return err
+}
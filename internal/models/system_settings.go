@@ -51,3 +51,14 @@ func UpdateSystemSettings(db *sql.DB, settings map[string]string) error {
 	// Se tudo correu bem, confirma a transação
 	return tx.Commit()
 }
+
+// IsManualOrderApprovalEnabled informa se os pedidos pagos aguardam a aprovação de um administrador
+// antes do provisionamento (system_settings.manual_order_approval).
+func IsManualOrderApprovalEnabled(db *sql.DB) (bool, error) {
+	var enabled sql.NullBool
+	err := db.QueryRow(`SELECT manual_order_approval FROM system_settings WHERE id = 1`).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled.Bool, err
+}
@@ -49,7 +49,7 @@ func GetUserByEmail(db *sql.DB, email string) (*User, error) {
 func GetUserByID(db *sql.DB, id int) (*User, error) {
 	var user User
 	query := `SELECT id, first_name, last_name, email, is_active, created_at, updated_at
-			 FROM users WHERE id = ?`
+			 FROM users WHERE id = $1`
 
 	row := db.QueryRow(query, id)
 	if err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.IsActive, &user.CreatedAt, &user.UpdatedAt); err != nil {
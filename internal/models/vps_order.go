@@ -4,24 +4,27 @@ import (
 	"database/sql"
 	"time"
 
+	"hosting-backend/internal/utils"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
 // VpsOrder representa um pedido de servidor VPS no banco de dados.
 type VpsOrder struct {
-	ID            int            `json:"id"`
-	ClientID      int            `json:"client_id"`
-	InvoiceID     int64          `json:"invoice_id"`
-	VpsInstanceID sql.NullString `json:"vps_instance_id"`
-	PlanID        string         `json:"plan_id"`
-	Location      string         `json:"location"`
-	Template      string         `json:"template"`
-	Hostname      string         `json:"hostname"`
-	Password      string         `json:"-"` // Apenas para entrada, não para armazenamento
-	PasswordHash  string         `json:"-"`
-	Status        string         `json:"status"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
+	ID                int            `json:"id"`
+	ClientID          int            `json:"client_id"`
+	InvoiceID         int64          `json:"invoice_id"`
+	VpsInstanceID     sql.NullString `json:"vps_instance_id"`
+	PlanID            string         `json:"plan_id"`
+	Location          string         `json:"location"`
+	Template          string         `json:"template"`
+	Hostname          string         `json:"hostname"`
+	Password          string         `json:"-"` // Apenas para entrada, não para armazenamento
+	PasswordHash      string         `json:"-"`
+	PasswordEncrypted sql.NullString `json:"-"` // utils.Encrypt; usada no provisionamento assíncrono
+	Status            string         `json:"status"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
 }
 
 // Create insere um novo pedido de VPS no banco de dados dentro de uma transação.
@@ -32,29 +35,76 @@ func (vo *VpsOrder) Create(tx *sql.Tx) (int64, error) {
 	}
 	vo.PasswordHash = string(hashedPassword)
 
-	stmt, err := tx.Prepare(`INSERT INTO vps_orders (client_id, invoice_id, plan_id, location, template, hostname, password_hash, status) 
-						   VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	// A senha é necessária em claro na criação da máquina, que ocorre na fila de provisionamento.
+	encrypted, err := utils.Encrypt([]byte(vo.Password))
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
+	vo.PasswordEncrypted = sql.NullString{String: encrypted, Valid: true}
 
-	res, err := stmt.Exec(vo.ClientID, vo.InvoiceID, vo.PlanID, vo.Location, vo.Template, vo.Hostname, vo.PasswordHash, vo.Status)
+	var id int64
+	err = tx.QueryRow(`INSERT INTO vps_orders (client_id, invoice_id, plan_id, location, template, hostname, password_hash, password_encrypted, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		vo.ClientID, vo.InvoiceID, vo.PlanID, vo.Location, vo.Template, vo.Hostname, vo.PasswordHash, vo.PasswordEncrypted, vo.Status).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
+	vo.ID = int(id)
+	return id, nil
+}
 
-	return res.LastInsertId()
+// GetVpsOrderByID busca um pedido de VPS pelo ID.
+func GetVpsOrderByID(db *sql.DB, id int) (*VpsOrder, error) {
+	var vo VpsOrder
+	err := db.QueryRow(`SELECT id, client_id, invoice_id, vps_instance_id, plan_id, location, template, hostname, password_encrypted, status, created_at, updated_at
+		FROM vps_orders WHERE id = $1`, id).
+		Scan(&vo.ID, &vo.ClientID, &vo.InvoiceID, &vo.VpsInstanceID, &vo.PlanID, &vo.Location, &vo.Template, &vo.Hostname, &vo.PasswordEncrypted, &vo.Status, &vo.CreatedAt, &vo.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not Found
+		}
+		return nil, err
+	}
+	return &vo, nil
 }
 
-// UpdateVpsInstance atualiza o status e o ID da instância de um pedido de VPS.
-func UpdateVpsInstance(tx *sql.Tx, orderID int, vpsInstanceID, status string) error {
-	stmt, err := tx.Prepare("UPDATE vps_orders SET vps_instance_id = ?, status = ? WHERE id = ?")
+// GetVpsOrdersByInvoiceID lista os pedidos de VPS vinculados a uma fatura.
+func GetVpsOrdersByInvoiceID(db *sql.DB, invoiceID int) ([]VpsOrder, error) {
+	rows, err := db.Query(`SELECT id, client_id, invoice_id, vps_instance_id, plan_id, location, template, hostname, password_encrypted, status, created_at, updated_at
+		FROM vps_orders WHERE invoice_id = $1 ORDER BY id ASC`, invoiceID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []VpsOrder
+	for rows.Next() {
+		var vo VpsOrder
+		if err := rows.Scan(&vo.ID, &vo.ClientID, &vo.InvoiceID, &vo.VpsInstanceID, &vo.PlanID, &vo.Location, &vo.Template, &vo.Hostname, &vo.PasswordEncrypted, &vo.Status, &vo.CreatedAt, &vo.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, vo)
 	}
-	defer stmt.Close()
+	return orders, rows.Err()
+}
+
+// UpdateVpsInstance atualiza o status e o ID da instância de um pedido de VPS.
+// Com o provisionamento concluído, a senha criptografada deixa de ser necessária e é apagada.
+func UpdateVpsInstance(db *sql.DB, orderID int, vpsInstanceID, status string) error {
+	_, err := db.Exec(`UPDATE vps_orders SET vps_instance_id = $1, status = $2, password_encrypted = NULL WHERE id = $3`,
+		vpsInstanceID, status, orderID)
+	return err
+}
+
+// UpdateVpsOrderStatus atualiza o status de um pedido de VPS.
+func UpdateVpsOrderStatus(db *sql.DB, orderID int, status string) error {
+	_, err := db.Exec(`UPDATE vps_orders SET status = $1 WHERE id = $2`, status, orderID)
+	return err
+}
 
-	_, err = stmt.Exec(vpsInstanceID, status, orderID)
+// FailVpsOrder marca o pedido de VPS como falho. Sem novas tentativas de provisionamento, a senha
+// criptografada deixa de ser necessária e é apagada.
+func FailVpsOrder(db *sql.DB, orderID int) error {
+	_, err := db.Exec(`UPDATE vps_orders SET status = 'failed', password_encrypted = NULL WHERE id = $1`, orderID)
 	return err
 }
@@ -0,0 +1,204 @@
+package modules
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"hosting-backend/internal/models"
+)
+
+// Nomes dos módulos conhecidos pelo sistema, iguais aos registros da tabela system_modules.
+const (
+	FiscalNFSe  = "fiscal_nfse"
+	VPSSales    = "vps_sales"
+	DomainSales = "domain_sales"
+	Coupons     = "coupons"
+)
+
+// Por quanto tempo o estado lido do banco é reutilizado antes de uma nova consulta.
+// Alterações feitas por esta instância invalidam o cache imediatamente.
+const stateCacheTTL = 30 * time.Second
+
+// ErrUnknownModule indica que o módulo não foi registrado.
+var ErrUnknownModule = errors.New("módulo desconhecido")
+
+// Registry mantém os módulos registrados pelos subsistemas e o seu estado (ativado ou desativado).
+type Registry struct {
+	db *sql.DB
+
+	mu           sync.RWMutex
+	descriptions map[string]string
+	state        map[string]bool
+	loadedAt     time.Time
+}
+
+// NewRegistry cria um registro de módulos com os módulos padrão do sistema.
+func NewRegistry(db *sql.DB) *Registry {
+	r := &Registry{
+		db:           db,
+		descriptions: make(map[string]string),
+		state:        make(map[string]bool),
+	}
+
+	r.Register(FiscalNFSe, "Emissão, cancelamento e envio de NFS-e")
+	r.Register(VPSSales, "Venda e contratação de servidores VPS")
+	r.Register(DomainSales, "Consulta, registro e gestão de domínios")
+	r.Register(Coupons, "Cupons de desconto no checkout")
+	return r
+}
+
+// Register adiciona um módulo ao registro. Deve ser chamado antes de Sync.
+func (r *Registry) Register(name, description string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.descriptions[name] = description
+}
+
+// Sync garante que todos os módulos registrados existam na tabela system_modules
+// e carrega o estado atual de cada um.
+func (r *Registry) Sync() error {
+	r.mu.RLock()
+	descriptions := make(map[string]string, len(r.descriptions))
+	for name, description := range r.descriptions {
+		descriptions[name] = description
+	}
+	r.mu.RUnlock()
+
+	for name, description := range descriptions {
+		if err := models.EnsureSystemModule(r.db, name, description); err != nil {
+			return fmt.Errorf("falha ao registrar o módulo '%s': %w", name, err)
+		}
+	}
+	return r.reload()
+}
+
+// IsEnabled informa se um módulo está ativado. Módulos desconhecidos ou com erro
+// ao consultar o banco são tratados como desativados, a menos que já exista estado em cache.
+func (r *Registry) IsEnabled(name string) bool {
+	r.mu.RLock()
+	stale := time.Since(r.loadedAt) > stateCacheTTL
+	enabled := r.state[name]
+	r.mu.RUnlock()
+
+	if !stale {
+		return enabled
+	}
+
+	if err := r.reload(); err != nil {
+		log.Printf("[Modules] ERRO ao recarregar o estado dos módulos: %v", err)
+		return enabled
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state[name]
+}
+
+// List retorna os módulos registrados com o estado gravado no banco.
+func (r *Registry) List() ([]models.SystemModule, error) {
+	all, err := models.GetAllSystemModules(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modules := []models.SystemModule{}
+	for _, m := range all {
+		if _, ok := r.descriptions[m.Name]; ok {
+			modules = append(modules, m)
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return modules, nil
+}
+
+// SetEnabled ativa ou desativa um módulo, grava o admin responsável e registra a ação no log de auditoria.
+func (r *Registry) SetEnabled(name string, enabled bool, adminID int, ipAddress string) (*models.SystemModule, error) {
+	r.mu.RLock()
+	_, known := r.descriptions[name]
+	r.mu.RUnlock()
+	if !known {
+		return nil, ErrUnknownModule
+	}
+
+	previous, err := models.GetSystemModuleByName(r.db, name)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o módulo: %w", err)
+	}
+	if previous == nil {
+		return nil, ErrUnknownModule
+	}
+
+	action := "disable_module"
+	if enabled {
+		action = "enable_module"
+	}
+
+	if err := models.SetSystemModuleEnabled(r.db, name, enabled, adminID); err != nil {
+		r.audit(adminID, ipAddress, action, previous, nil, models.AuditLogResultFailure)
+		return nil, fmt.Errorf("falha ao atualizar o módulo: %w", err)
+	}
+
+	updated, err := models.GetSystemModuleByName(r.db, name)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o módulo atualizado: %w", err)
+	}
+	r.audit(adminID, ipAddress, action, previous, updated, models.AuditLogResultSuccess)
+
+	r.mu.Lock()
+	r.state[name] = enabled
+	r.mu.Unlock()
+
+	log.Printf("[Modules] Módulo '%s' alterado para enabled=%t pelo admin #%d.", name, enabled, adminID)
+	return updated, nil
+}
+
+func (r *Registry) reload() error {
+	all, err := models.GetAllSystemModules(r.db)
+	if err != nil {
+		return err
+	}
+
+	state := make(map[string]bool, len(all))
+	for _, m := range all {
+		state[m.Name] = m.Enabled
+	}
+
+	r.mu.Lock()
+	r.state = state
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Registry) audit(adminID int, ipAddress, action string, previous, updated *models.SystemModule, result models.AuditLogResult) {
+	entry := &models.AuditLog{
+		StaffID:    sql.NullInt64{Int64: int64(adminID), Valid: adminID > 0},
+		IPAddress:  sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		Action:     action,
+		TargetType: sql.NullString{String: "system_modules", Valid: true},
+		TargetID:   sql.NullString{String: strconv.FormatInt(previous.ID, 10), Valid: true},
+		Result:     result,
+	}
+	if data, err := json.Marshal(previous); err == nil {
+		entry.OldValue = data
+	}
+	if updated != nil {
+		if data, err := json.Marshal(updated); err == nil {
+			entry.NewValue = data
+		}
+	}
+
+	if err := models.CreateAuditLog(r.db, entry); err != nil {
+		log.Printf("[Modules] ERRO ao registrar a ação '%s' no log de auditoria: %v", action, err)
+	}
+}
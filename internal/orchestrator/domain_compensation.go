@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"hosting-backend/internal/jobs"
+	"hosting-backend/internal/models"
+)
+
+// compensationPolicy define o que acontece quando uma etapa esgota as novas tentativas automáticas
+// da fila (jobs.Spec.MaxAttempts). As ações são aplicadas nesta ordem:
+//   - rollback: desfaz o registro local que a etapa deixaria inconsistente;
+//   - staffTask: abre uma pendência na fila "precisa de atenção";
+//   - skip: a etapa não é essencial; o provisionamento segue para a próxima etapa em vez de falhar.
+type compensationPolicy struct {
+	rollback  func(db *sql.DB, domain *models.Domain) error
+	staffTask *staffTaskTemplate
+	skip      bool
+}
+
+// staffTaskTemplate descreve a pendência aberta para a etapa. O título recebe o nome do domínio.
+type staffTaskTemplate struct {
+	kind     string
+	priority models.StaffTaskPriority
+	title    string
+}
+
+// compensateDomainStep aplica a política de compensação da etapa que foi para a fila de mortas.
+func compensateDomainStep(db *sql.DB, step DomainStep, job *models.Job, cause error) {
+	var payload domainStepPayload
+	if jobs.DecodePayload(job, &payload) != nil || payload.DomainID == 0 {
+		return
+	}
+	domain, err := models.GetDomainByID(db, payload.DomainID)
+	if err != nil || domain == nil {
+		log.Printf("ERRO ao compensar a etapa '%s' do domínio #%d: %v", step, payload.DomainID, err)
+		return
+	}
+	policy := domainStepDefs[step].compensation
+
+	if policy.rollback != nil {
+		if err := policy.rollback(db, domain); err != nil {
+			log.Printf("ERRO ao desfazer o registro local do domínio #%d: %v", domain.ID, err)
+		} else {
+			models.LogDomainEvent(db, domain.ID, stepEvent(step, "rolled_back"), "Registro local desfeito após a falha da etapa.", nil)
+		}
+	}
+
+	if t := policy.staffTask; t != nil {
+		jobs.OpenStaffTask(db, job, &models.StaffTask{
+			Kind:       t.kind,
+			Priority:   t.priority,
+			TargetType: "domain",
+			TargetID:   strconv.Itoa(domain.ID),
+			Title:      fmt.Sprintf(t.title, domain.DomainName),
+		}, cause)
+	}
+
+	// Etapas não essenciais são puladas e o provisionamento continua.
+	if policy.skip && !payload.Single {
+		models.LogDomainEvent(db, domain.ID, stepEvent(step, "skipped"), cause.Error(), nil)
+		next, err := nextPendingStep(db, domain.ID)
+		if err == nil && next == "" {
+			err = completeDomainProvisioning(db, domain)
+		} else if err == nil {
+			err = enqueueDomainStep(db, domain.ID, next, false)
+		}
+		if err != nil {
+			finalizeAsFailed(db, domain.ID, fmt.Sprintf("Erro ao continuar o provisionamento após pular a etapa '%s'", step), err)
+		}
+		return
+	}
+	if payload.Single {
+		// Uma nova tentativa avulsa não altera o status do domínio.
+		models.LogDomainEvent(db, domain.ID, "provisioning.retry_failed", fmt.Sprintf("Nova tentativa da etapa '%s' falhou.", step), map[string]string{"error": cause.Error()})
+		return
+	}
+
+	finalizeAsFailed(db, domain.ID, fmt.Sprintf("Etapa '%s' falhou após esgotar as tentativas", step), cause)
+}
+
+// rollbackDomainOrder devolve o pedido do domínio ao estado de falha: nada foi criado no registrador,
+// então o pedido não deve continuar como pendente de registro.
+func rollbackDomainOrder(db *sql.DB, domain *models.Domain) error {
+	if domain.ProviderOrderID.Valid {
+		return fmt.Errorf("o pedido %s já existe no registrador; nada a desfazer", domain.ProviderOrderID.String)
+	}
+	_, err := db.Exec(`UPDATE domain_orders SET status = 'failed' WHERE domain_id = $1 AND status = 'pending_registration'`, domain.ID)
+	return err
+}
@@ -5,136 +5,221 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
+	"time"
 
+	"hosting-backend/internal/jobs"
 	"hosting-backend/internal/models"
-	"hosting-backend/internal/services/hostinger"
-	"hosting-backend/internal/services/whm"
+	"hosting-backend/internal/provisioning"
 )
 
-// DomainOrderData é uma estrutura para guardar os dados do pedido de domínio.
-type DomainOrderData struct {
-	ClientID   int
-	DomainName string
-	// O tipo (register/transfer) não está no pedido, então será fixo por enquanto.
-	DomainType models.DomainType
-	// O service ID não está disponível no pedido de domínio, precisará ser inferido ou adicionado posteriormente.
-	ServiceID int
+// HostingPlacement escolhe o servidor do pool e o provisionador das contas de hospedagem criadas
+// pelos pedidos de domínio. É implementado por services.ServerPlacement.
+type HostingPlacement interface {
+	PickServer(plan *models.Plan) (*models.Server, error)
+	ProvisionerFor(server *models.Server) (provisioning.HostingProvisioner, error)
 }
 
-// ProcessDomainProvisioning orquestra o provisionamento de um domínio a partir de um pedido.
-func ProcessDomainProvisioning(db *sql.DB, orderIDStr string) {
+// hostingPlacement é a política de alocação usada pela etapa de criação da conta (ver createHostingAccount).
+var hostingPlacement HostingPlacement
+
+// RegisterJobHandlers registra na fila os handlers das etapas de domínio, da renovação de domínios e
+// do provisionamento de VPS. placement define o servidor das contas de hospedagem dos pedidos.
+func RegisterJobHandlers(queue *jobs.Queue, db *sql.DB, placement HostingPlacement) {
+	hostingPlacement = placement
+	for _, step := range domainSteps {
+		queue.Register(domainStepDefs[step].spec, func(ctx context.Context, job *models.Job) error {
+			return runDomainStep(db, step, job)
+		}, func(job *models.Job, err error) {
+			compensateDomainStep(db, step, job, err)
+		})
+	}
+	queue.Register(jobs.DomainRenew, func(ctx context.Context, job *models.Job) error {
+		return submitDomainRenewal(db, job)
+	}, compensateDomainRenewal(db))
+	queue.Register(jobs.VpsProvision, func(ctx context.Context, job *models.Job) error {
+		return provisionVps(db, job)
+	}, compensateVpsOrder(db))
+}
+
+// ProcessDomainProvisioning cria o registro do domínio a partir de um pedido e enfileira o seu
+// provisionamento. O serviço de hospedagem do pedido, o domínio e a tarefa são gravados na mesma
+// transação. Chamar novamente para o mesmo pedido não duplica o domínio nem o serviço: um domínio
+// com falha do mesmo cliente é retomado a partir da etapa que falhou. Um nome já cadastrado para outro
+// cliente, ou cancelado ou vencido, faz o pedido falhar (ver failDomainOrder).
+func ProcessDomainProvisioning(db *sql.DB, orderIDStr string) (int, error) {
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil {
-		log.Printf("ERRO: orderID inválido: %s", orderIDStr)
-		return
+		return 0, fmt.Errorf("orderID inválido: %s", orderIDStr)
 	}
 
 	// 1. Iniciar transação e buscar dados do pedido
 	tx, err := db.BeginTx(context.Background(), nil)
 	if err != nil {
-		log.Printf("Erro ao iniciar transação para o pedido #%d: %v", orderID, err)
-		return
+		return 0, fmt.Errorf("erro ao iniciar transação para o pedido #%d: %w", orderID, err)
 	}
+	defer tx.Rollback()
 
-	var orderData DomainOrderData
-	// Simplificamos a query para refletir a estrutura real da tabela `domain_orders`
-	query := `SELECT client_id, domain_name FROM domain_orders WHERE id = $1`
-	err = tx.QueryRow(query, orderID).Scan(&orderData.ClientID, &orderData.DomainName)
+	order, err := models.GetDomainOrderForUpdate(tx, orderID)
 	if err != nil {
-		tx.Rollback()
-		log.Printf("Erro ao buscar dados do pedido #%d: %v", orderID, err)
-		return
+		return 0, fmt.Errorf("erro ao buscar dados do pedido #%d: %w", orderID, err)
+	}
+	if order == nil {
+		return 0, fmt.Errorf("pedido de domínio #%d não encontrado", orderID)
 	}
 
-	// === Lógica de Negócio Provisória ===
-	// Como o tipo não está no pedido, definimos como 'register'.
-	orderData.DomainType = models.DomainTypeRegister
-	// Como o service_id não está no pedido, usamos um valor provisório (ex: 1).
-	// Em um sistema real, isso deveria ser resolvido (ex: um serviço padrão para domínios).
-	orderData.ServiceID = 1 // TODO: Implementar uma forma de determinar o service_id correto
+	// 2. Criar o registro do domínio, ou reaproveitar o de uma execução anterior
+	existing, err := models.GetDomainByNameTx(tx, order.DomainName)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar o domínio do pedido #%d: %w", orderID, err)
+	}
+	switch {
+	case existing == nil:
+	case existing.ClientID == order.ClientID && existing.Status == models.StatusFailed:
+		if err := models.SetDomainOrderDomain(tx, order.ID, existing.ID); err != nil {
+			return 0, fmt.Errorf("erro ao vincular o domínio ao pedido #%d: %w", orderID, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return existing.ID, ResumeDomainProvisioning(db, existing.ID)
+	case order.DomainID.Valid && int(order.DomainID.Int64) == existing.ID &&
+		(existing.Status == models.StatusPendingProvisioning || existing.Status == models.StatusActive):
+		log.Printf("Domínio #%d do pedido #%d já está em provisionamento ou ativo.", existing.ID, orderID)
+		return existing.ID, nil
+	default:
+		// O nome já pertence a outro cliente, ou a um domínio cancelado, vencido ou de outro pedido.
+		if err := tx.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, failDomainOrder(db, order, existing)
+	}
 
-	// 2. Criar o registro do domínio
-	domainID, err := models.CreateDomain(tx, orderData.ClientID, orderData.ServiceID, orderData.DomainName, orderData.DomainType)
+	// 3. Criar o serviço de hospedagem do pedido. A conta é criada na etapa create_account.
+	serviceID := int(order.ServiceID.Int64)
+	if !order.ServiceID.Valid {
+		serviceID, err = createDomainOrderService(db, tx, order)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao criar o serviço do pedido #%d: %w", orderID, err)
+		}
+	}
+
+	domainID, err := models.CreateDomain(tx, order.ClientID, serviceID, order.DomainName, order.Type)
 	if err != nil {
-		tx.Rollback()
-		log.Printf("Erro ao criar registro de domínio para o pedido #%d: %v", orderID, err)
-		return
+		return 0, fmt.Errorf("erro ao criar registro de domínio para o pedido #%d: %w", orderID, err)
+	}
+	if err := models.SetDomainOrderDomain(tx, order.ID, domainID); err != nil {
+		return 0, fmt.Errorf("erro ao vincular o domínio ao pedido #%d: %w", orderID, err)
+	}
+
+	// 4. Enfileirar a primeira etapa; as seguintes são enfileiradas ao fim de cada etapa
+	if err := enqueueDomainStep(tx, domainID, domainSteps[0], false); err != nil {
+		return 0, err
 	}
 
-	// Commit da transação para salvar o novo domínio
 	if err := tx.Commit(); err != nil {
-		log.Printf("Erro ao commitar a criação do domínio para o pedido #%d: %v", orderID, err)
-		finalizeAsFailed(db, domainID, "Erro crítico de banco de dados", err)
-		return
+		return 0, fmt.Errorf("erro ao commitar a criação do domínio para o pedido #%d: %w", orderID, err)
 	}
 
-	log.Printf("Domínio #%d criado para o pedido #%d. Iniciando provisionamento.", domainID, orderID)
-	// Inicia o processo de provisionamento real
-	runProvisioningSteps(db, domainID, orderData)
+	log.Printf("Domínio #%d criado para o pedido #%d. Provisionamento enfileirado.", domainID, orderID)
+	return domainID, nil
 }
 
-// runProvisioningSteps contém a lógica de provisionamento.
-func runProvisioningSteps(db *sql.DB, domainID int, orderData DomainOrderData) {
-	hasStarted, err := models.HasEventOccurred(db, domainID, "provisioning.started")
-	if err != nil || hasStarted {
-		log.Printf("Provisionamento para o domínio %d já foi iniciado ou ocorreu um erro: %v", domainID, err)
-		return
+// failDomainOrder marca como falho um pedido cujo domínio já está cadastrado e não pode ser
+// reaproveitado, e abre uma pendência para a equipe resolver (reembolso ou migração manual).
+func failDomainOrder(db *sql.DB, order *models.DomainOrder, existing *models.Domain) error {
+	if err := models.UpdateDomainOrderStatus(db, order.ID, models.DomainOrderStatusFailed); err != nil {
+		return err
 	}
-	models.LogDomainEvent(db, domainID, "provisioning.started", "Orquestrador iniciou o processo de provisionamento.", nil)
+	models.OpenStaffTask(db, &models.StaffTask{
+		Kind:       "domain_order.domain_conflict",
+		Priority:   models.StaffTaskPriorityHigh,
+		TargetType: "domain_order",
+		TargetID:   strconv.Itoa(order.ID),
+		Title: fmt.Sprintf("Pedido #%d pago para %s, já cadastrado como domínio #%d (cliente #%d, status %s)",
+			order.ID, order.DomainName, existing.ID, existing.ClientID, existing.Status),
+	})
+	return fmt.Errorf("o domínio %s do pedido #%d já está cadastrado (domínio #%d, cliente #%d, status %s)",
+		order.DomainName, order.ID, existing.ID, existing.ClientID, existing.Status)
+}
 
-	// INICIAR CLIENTES DE SERVIÇO
-	hostingerClient, err := hostinger.NewClient()
+// createDomainOrderService cria, com status pendente, o serviço de hospedagem do plano configurado
+// em DOMAIN_ORDER_PLAN e o vincula ao pedido.
+func createDomainOrderService(db *sql.DB, tx *sql.Tx, order *models.DomainOrder) (int, error) {
+	plan, err := domainOrderPlan(db)
 	if err != nil {
-		finalizeAsFailed(db, domainID, "Erro ao iniciar cliente Hostinger", err)
-		return
+		return 0, err
 	}
-	whmClient, err := whm.NewClient()
+	client, err := models.GetClientByID(db, order.ClientID)
 	if err != nil {
-		finalizeAsFailed(db, domainID, "Erro ao iniciar cliente WHM", err)
-		return
-	}
-
-	// ETAPA 1: AÇÃO DE DOMÍNIO (HOSTINGER)
-	switch orderData.DomainType {
-	case models.DomainTypeRegister:
-		// Detalhes do cliente seriam buscados do banco de dados para um registro real
-		providerOrderID, actionErr := hostingerClient.RegisterDomain(orderData.DomainName, nil) // Usando nil por enquanto
-		if actionErr != nil {
-			finalizeAsFailed(db, domainID, "Falha na API da Hostinger (Register)", actionErr)
-			return
-		}
-		models.UpdateDomainProviderOrderID(db, domainID, "hostinger", providerOrderID)
-		models.LogDomainEvent(db, domainID, "hostinger.register.initiated", "Pedido de registro criado na Hostinger", map[string]string{"order_id": providerOrderID})
-	
-	case models.DomainTypeTransfer:
-        // A lógica de transferência permanece, caso seja usada no futuro
-        authCode := "" // Deveria vir do pedido
-        providerOrderID, actionErr := hostingerClient.TransferDomain(orderData.DomainName, authCode)
-        if actionErr != nil {
-            finalizeAsFailed(db, domainID, "Falha na API da Hostinger (Transfer)", actionErr)
-            return
-        }
-        models.UpdateDomainProviderOrderID(db, domainID, "hostinger", providerOrderID)
-        models.LogDomainEvent(db, domainID, "hostinger.transfer.initiated", "Pedido de transferência criado na Hostinger", map[string]string{"order_id": providerOrderID})
-    }
-
-	// ETAPA 2: CRIAÇÃO DA CONTA (WHM)
-	cpanelUsername := "user" + strconv.Itoa(domainID)
-	planName := "plano_default" // TODO: Deve vir do serviço associado
-	tempPassword := "aVeryComplexP@ssw0rd!2024" // TODO: Gerar senha segura
-
-	_, err = whmClient.CreateAccount(orderData.DomainName, cpanelUsername, tempPassword, planName)
+		return 0, err
+	}
+	if client == nil {
+		return 0, fmt.Errorf("cliente #%d não encontrado", order.ClientID)
+	}
+
+	id, err := models.CreateService(tx, &models.Service{
+		UserID:       client.UserID,
+		ProductID:    plan.ID,
+		Domain:       order.DomainName,
+		Status:       models.ServiceStatusPending,
+		Price:        plan.Price,
+		BillingCycle: plan.BillingCycle,
+		NextDueDate:  plan.BillingCycle.NextDueDate(time.Now()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := models.SetDomainOrderService(tx, order.ID, int(id)); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// domainOrderPlan retorna o plano de hospedagem dos pedidos de domínio (pacote do WHM em DOMAIN_ORDER_PLAN).
+func domainOrderPlan(db *sql.DB) (*models.Plan, error) {
+	packageName := os.Getenv("DOMAIN_ORDER_PLAN")
+	if packageName == "" {
+		return nil, fmt.Errorf("DOMAIN_ORDER_PLAN não configurado")
+	}
+	plan, err := models.GetPlanByWhmPackageName(db, packageName)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("nenhum plano cadastrado para o pacote do WHM '%s'", packageName)
+	}
+	return plan, nil
+}
+
+// ResumeDomainProvisioning retoma o provisionamento de um domínio com falha a partir da primeira
+// etapa sem checkpoint. As etapas já concluídas não são executadas novamente.
+func ResumeDomainProvisioning(db *sql.DB, domainID int) error {
+	step, err := nextPendingStep(db, domainID)
 	if err != nil {
-		finalizeAsFailed(db, domainID, "Falha na criação da conta WHM", err)
-		return
+		return err
 	}
-	models.LogDomainEvent(db, domainID, "whm.account.created", "Conta cPanel criada com sucesso.", map[string]string{"username": cpanelUsername})
+	if step == "" {
+		domain, err := models.GetDomainByID(db, domainID)
+		if err != nil || domain == nil {
+			return err
+		}
+		return completeDomainProvisioning(db, domain)
+	}
+
+	if err := models.UpdateDomainStatus(db, domainID, models.StatusPendingProvisioning); err != nil {
+		return err
+	}
+	models.LogDomainEvent(db, domainID, "provisioning.resumed", fmt.Sprintf("Provisionamento retomado a partir da etapa '%s'.", step), nil)
+	return enqueueDomainStep(db, domainID, step, false)
+}
 
-	// ETAPA 3: FINALIZAÇÃO
-	models.UpdateDomainStatus(db, domainID, models.StatusActive)
-	models.LogDomainEvent(db, domainID, "provisioning.completed", "Provisionamento concluído com sucesso.", nil)
-	log.Printf("Provisionamento concluído para o domínio: %s (ID: %d)", orderData.DomainName, domainID)
+// RetryDomainStep executa novamente uma única etapa, mesmo que já concluída (ex: reenviar o e-mail
+// de boas-vindas). Se a etapa completar o provisionamento, o domínio é ativado.
+func RetryDomainStep(db *sql.DB, domainID int, step DomainStep) error {
+	models.LogDomainEvent(db, domainID, stepEvent(step, "retry_requested"), "Nova tentativa da etapa solicitada pelo administrador.", nil)
+	return enqueueDomainStep(db, domainID, step, true)
 }
 
 // finalizeAsFailed padroniza o tratamento de falhas.
@@ -0,0 +1,192 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"hosting-backend/internal/jobs"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+// A Hostinger não tem uma chamada de renovação avulsa: o domínio é renovado pela renovação automática
+// da sua assinatura, cobrada da conta. Ela fica desativada enquanto a fatura de renovação do cliente
+// está em aberto e é ativada quando a fatura é paga. O novo vencimento é lido na sincronização.
+
+// renewalJobPayload é o payload da tarefa de renovação de domínio.
+type renewalJobPayload struct {
+	RenewalID int `json:"renewal_id"`
+}
+
+// EnqueueDomainRenewal enfileira o envio ao registrador de uma renovação paga.
+func EnqueueDomainRenewal(q models.QueryRower, renewalID int) error {
+	_, err := jobs.Enqueue(q, jobs.DomainRenew, renewalJobPayload{RenewalID: renewalID}, "domain.renew:"+strconv.Itoa(renewalID))
+	return err
+}
+
+// submitDomainRenewal ativa a renovação automática da assinatura do domínio de uma renovação paga.
+func submitDomainRenewal(db *sql.DB, job *models.Job) error {
+	var payload renewalJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+	renewal, err := models.GetDomainRenewalByID(db, payload.RenewalID)
+	if err != nil {
+		return err
+	}
+	if renewal == nil {
+		return jobs.Permanent(fmt.Errorf("renovação #%d não encontrada", payload.RenewalID))
+	}
+	if renewal.Status != models.DomainRenewalPaid {
+		return nil // Já enviada ou concluída
+	}
+	domain, err := models.GetDomainByID(db, renewal.DomainID)
+	if err != nil {
+		return err
+	}
+	if domain == nil {
+		return jobs.Permanent(fmt.Errorf("domínio #%d não encontrado", renewal.DomainID))
+	}
+
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	subscriptionID, err := domainSubscriptionID(db, provisioner, domain)
+	if err != nil {
+		return hostingerStepError(err)
+	}
+	if err := provisioner.SetSubscriptionAutoRenewal(subscriptionID, true); err != nil {
+		return hostingerStepError(err)
+	}
+
+	if _, err := models.UpdateDomainRenewalStatus(db, renewal.ID, models.DomainRenewalPaid, models.DomainRenewalSubmitted); err != nil {
+		return err
+	}
+	models.LogDomainEvent(db, domain.ID, "renewal.submitted", "Renovação automática ativada no registrador",
+		map[string]any{"renewal_id": renewal.ID, "subscription_id": subscriptionID, "expires_at": renewal.ExpiresAt.Format("2006-01-02")})
+	return nil
+}
+
+// compensateDomainRenewal marca como falha a renovação que não pôde ser enviada ao registrador e
+// abre uma pendência para a equipe: o cliente pagou e o domínio pode vencer.
+func compensateDomainRenewal(db *sql.DB) jobs.DeadHandler {
+	return func(job *models.Job, cause error) {
+		var payload renewalJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			log.Printf("[Domain Renewal] Payload inválido na tarefa #%d: %v", job.ID, err)
+			return
+		}
+		renewal, err := models.GetDomainRenewalByID(db, payload.RenewalID)
+		if err != nil || renewal == nil {
+			log.Printf("[Domain Renewal] Renovação #%d da tarefa #%d não encontrada: %v", payload.RenewalID, job.ID, err)
+			return
+		}
+		models.UpdateDomainRenewalStatus(db, renewal.ID, models.DomainRenewalPaid, models.DomainRenewalFailed)
+
+		domainName := strconv.Itoa(renewal.DomainID)
+		if domain, err := models.GetDomainByID(db, renewal.DomainID); err == nil && domain != nil {
+			domainName = domain.DomainName
+		}
+		models.LogDomainEvent(db, renewal.DomainID, "renewal.failed", "Falha ao enviar a renovação paga ao registrador",
+			map[string]any{"renewal_id": renewal.ID, "error": cause.Error()})
+		jobs.OpenStaffTask(db, job, &models.StaffTask{
+			Kind:       "domain.renewal_failed",
+			Priority:   models.StaffTaskPriorityHigh,
+			TargetType: "domain",
+			TargetID:   strconv.Itoa(renewal.DomainID),
+			Title:      fmt.Sprintf("Renovação paga de %s não enviada ao registrador (vence em %s)", domainName, renewal.ExpiresAt.Format("02/01/2006")),
+		}, cause)
+	}
+}
+
+// DisableDomainAutoRenewal desativa a renovação automática do domínio no registrador, para que ele
+// não seja renovado antes de o cliente pagar a fatura de renovação.
+func DisableDomainAutoRenewal(db *sql.DB, domain *models.Domain) error {
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return err
+	}
+	subscriptionID, err := domainSubscriptionID(db, provisioner, domain)
+	if err != nil {
+		return err
+	}
+	return provisioner.SetSubscriptionAutoRenewal(subscriptionID, false)
+}
+
+// domainSubscriptionID retorna a assinatura do domínio no registrador. Domínios registrados antes
+// de a assinatura ser gravada são localizados pelo nome, e a assinatura encontrada é gravada.
+func domainSubscriptionID(db *sql.DB, provisioner *provisioning.HostingerProvisioner, domain *models.Domain) (string, error) {
+	if domain.ProviderSubscriptionID.Valid && domain.ProviderSubscriptionID.String != "" {
+		return domain.ProviderSubscriptionID.String, nil
+	}
+	subscription, err := provisioner.FindDomainSubscription(domain.DomainName)
+	if err != nil {
+		return "", err
+	}
+	if subscription == nil {
+		return "", jobs.Permanent(fmt.Errorf("assinatura do domínio '%s' não encontrada na Hostinger", domain.DomainName))
+	}
+	if err := models.UpdateDomainProviderSubscriptionID(db, domain.ID, subscription.ID); err != nil {
+		return "", err
+	}
+	return subscription.ID, nil
+}
+
+// SyncDomainExpiry lê o vencimento do domínio no registrador. Se o vencimento avançou, as renovações
+// pagas do vencimento anterior são concluídas e o domínio vencido ou em redenção volta a ficar ativo.
+func SyncDomainExpiry(db *sql.DB, domain *models.Domain) error {
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return err
+	}
+	details, err := provisioner.GetDomainDetails(domain.DomainName)
+	if err != nil {
+		return err
+	}
+	if details == nil || details.ExpiresAt == nil {
+		// O registro ainda pode estar em andamento no registrador.
+		return models.TouchDomainExpirySync(db, domain.ID)
+	}
+
+	expiresAt := details.ExpiresAt.UTC().Truncate(24 * time.Hour)
+	if err := models.UpdateDomainExpiry(db, domain.ID, expiresAt); err != nil {
+		return err
+	}
+	if domain.ExpiresAt.Valid && !expiresAt.After(domain.ExpiresAt.Time) {
+		return nil
+	}
+	if domain.ExpiresAt.Valid {
+		models.LogDomainEvent(db, domain.ID, "expiry.updated", "Novo vencimento lido no registrador",
+			map[string]string{"previous": domain.ExpiresAt.Time.Format("2006-01-02"), "expires_at": expiresAt.Format("2006-01-02")})
+	}
+
+	renewals, err := models.GetDomainRenewalsByDomainID(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	for _, renewal := range renewals {
+		if !renewal.ExpiresAt.Before(expiresAt) {
+			continue
+		}
+		if renewal.Status != models.DomainRenewalPaid && renewal.Status != models.DomainRenewalSubmitted {
+			continue
+		}
+		if completed, err := models.UpdateDomainRenewalStatus(db, renewal.ID, renewal.Status, models.DomainRenewalCompleted); err != nil || !completed {
+			continue
+		}
+		models.LogDomainEvent(db, domain.ID, "renewal.completed", "Renovação confirmada pelo registrador",
+			map[string]any{"renewal_id": renewal.ID, "expires_at": expiresAt.Format("2006-01-02")})
+	}
+
+	if (domain.Status == models.StatusExpired || domain.Status == models.StatusRedemption) && expiresAt.After(time.Now()) {
+		if err := models.UpdateDomainStatus(db, domain.ID, models.StatusActive); err != nil {
+			return err
+		}
+		models.LogDomainEvent(db, domain.ID, "renewal.reactivated", "Domínio renovado voltou a ficar ativo", nil)
+	}
+	return nil
+}
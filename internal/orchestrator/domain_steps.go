@@ -0,0 +1,502 @@
+package orchestrator
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/jobs"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+// DomainStep é uma etapa do provisionamento de um domínio. Cada etapa é idempotente e, ao terminar,
+// grava o checkpoint "step.<etapa>.completed" em domain_events. Uma execução interrompida continua
+// a partir da primeira etapa sem checkpoint.
+type DomainStep string
+
+const (
+	StepRegisterDomain DomainStep = "register_domain"    // Registro ou transferência no registrador
+	StepCreateAccount  DomainStep = "create_account"     // Conta de hospedagem no servidor do pool
+	StepConfigureDNS   DomainStep = "configure_dns"      // Nameservers do domínio apontados para a hospedagem
+	StepWelcomeEmail   DomainStep = "send_welcome_email" // E-mail de boas-vindas ao cliente
+)
+
+// domainSteps define a ordem de execução das etapas.
+var domainSteps = []DomainStep{StepRegisterDomain, StepCreateAccount, StepConfigureDNS, StepWelcomeEmail}
+
+type domainStepDef struct {
+	spec         jobs.Spec
+	run          func(db *sql.DB, domain *models.Domain) error
+	compensation compensationPolicy // Aplicada quando a etapa esgota as tentativas (ver domain_compensation.go)
+}
+
+var domainStepDefs = map[DomainStep]domainStepDef{
+	StepRegisterDomain: {jobs.DomainRegister, registerDomain, compensationPolicy{
+		rollback:  rollbackDomainOrder,
+		staffTask: &staffTaskTemplate{"domain.registration_failed", models.StaffTaskPriorityNormal, "Registro do domínio %s falhou: reembolsar o cliente ou registrar manualmente"},
+	}},
+	StepCreateAccount: {jobs.DomainCreateAccount, createHostingAccount, compensationPolicy{
+		staffTask: &staffTaskTemplate{"domain.without_hosting", models.StaffTaskPriorityHigh, "Domínio %s registrado sem conta de hospedagem"},
+	}},
+	StepConfigureDNS: {jobs.DomainConfigureDNS, configureDNS, compensationPolicy{
+		staffTask: &staffTaskTemplate{"domain.dns_not_configured", models.StaffTaskPriorityNormal, "Nameservers do domínio %s não foram configurados"},
+		skip:      true,
+	}},
+	StepWelcomeEmail: {jobs.DomainWelcomeEmail, sendWelcomeEmail, compensationPolicy{
+		staffTask: &staffTaskTemplate{"domain.welcome_email_failed", models.StaffTaskPriorityLow, "E-mail de boas-vindas do domínio %s não foi enviado"},
+		skip:      true,
+	}},
+}
+
+// ParseDomainStep valida o nome de uma etapa.
+func ParseDomainStep(name string) (DomainStep, bool) {
+	step := DomainStep(name)
+	_, ok := domainStepDefs[step]
+	return step, ok
+}
+
+func stepEvent(step DomainStep, result string) string {
+	return "step." + string(step) + "." + result
+}
+
+// DomainStepState é a situação de uma etapa, derivada dos eventos do domínio.
+type DomainStepState struct {
+	Step        DomainStep `json:"step"`
+	Status      string     `json:"status"` // pending, running, waiting, failed, skipped, completed
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// GetDomainStepStates calcula a situação de cada etapa a partir dos eventos do domínio.
+// Os eventos são lidos em ordem, então uma nova tentativa bem-sucedida prevalece sobre a falha anterior.
+func GetDomainStepStates(events []models.DomainEvent) []DomainStepState {
+	states := make([]DomainStepState, len(domainSteps))
+	index := make(map[string]*DomainStepState)
+	for i, step := range domainSteps {
+		states[i] = DomainStepState{Step: step, Status: "pending"}
+		for _, result := range []string{"started", "waiting", "failed", "skipped", "completed"} {
+			index[stepEvent(step, result)] = &states[i]
+		}
+	}
+
+	for _, e := range events {
+		state, ok := index[e.Type]
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case stepEvent(state.Step, "started"):
+			state.Status = "running"
+			state.Attempts++
+		case stepEvent(state.Step, "waiting"):
+			state.Status = "waiting"
+			state.LastError = ""
+		case stepEvent(state.Step, "failed"):
+			state.Status = "failed"
+			state.LastError = e.Message.String
+		case stepEvent(state.Step, "skipped"):
+			state.Status = "skipped"
+		case stepEvent(state.Step, "completed"):
+			state.Status = "completed"
+			state.LastError = ""
+			completedAt := e.CreatedAt
+			state.CompletedAt = &completedAt
+		}
+	}
+	return states
+}
+
+// nextPendingStep retorna a primeira etapa sem checkpoint, ou "" se todas foram concluídas.
+// Etapas puladas pela política de compensação contam como concluídas.
+func nextPendingStep(db *sql.DB, domainID int) (DomainStep, error) {
+	for _, step := range domainSteps {
+		done, err := stepDone(db, domainID, step)
+		if err != nil {
+			return "", err
+		}
+		if !done {
+			return step, nil
+		}
+	}
+	return "", nil
+}
+
+func stepDone(db *sql.DB, domainID int, step DomainStep) (bool, error) {
+	done, err := models.HasEventOccurred(db, domainID, stepEvent(step, "completed"))
+	if err != nil || done {
+		return done, err
+	}
+	return models.HasEventOccurred(db, domainID, stepEvent(step, "skipped"))
+}
+
+// errStepWaiting indica que a etapa depende de um evento externo. A tarefa termina sem checkpoint e
+// a execução é retomada por quem acompanha o evento.
+var errStepWaiting = errors.New("etapa aguardando evento externo")
+
+// domainStepPayload é o payload das tarefas de etapa. Com Single, apenas a etapa é executada
+// (nova tentativa pedida pelo administrador); sem Single, a execução segue para a próxima etapa.
+type domainStepPayload struct {
+	DomainID int  `json:"domain_id"`
+	Single   bool `json:"single,omitempty"`
+}
+
+// enqueueDomainStep enfileira a execução de uma etapa.
+func enqueueDomainStep(q models.QueryRower, domainID int, step DomainStep, single bool) error {
+	def := domainStepDefs[step]
+	dedupKey := "domain:" + strconv.Itoa(domainID) + ":" + string(step)
+	_, err := jobs.Enqueue(q, def.spec, domainStepPayload{DomainID: domainID, Single: single}, dedupKey)
+	return err
+}
+
+// runDomainStep executa uma etapa a partir da tarefa da fila, gravando os eventos de início,
+// falha e conclusão, e enfileira a próxima etapa pendente.
+func runDomainStep(db *sql.DB, step DomainStep, job *models.Job) error {
+	var payload domainStepPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+	domain, err := models.GetDomainByID(db, payload.DomainID)
+	if err != nil {
+		return err
+	}
+	if domain == nil {
+		return jobs.Permanent(fmt.Errorf("domínio #%d não encontrado", payload.DomainID))
+	}
+	if domain.Status == models.StatusCancelled {
+		return nil
+	}
+
+	done, err := stepDone(db, domain.ID, step)
+	if err != nil {
+		return err
+	}
+	// Uma nova tentativa pedida pelo administrador executa a etapa mesmo que já concluída.
+	if !done || payload.Single {
+		models.LogDomainEvent(db, domain.ID, stepEvent(step, "started"), fmt.Sprintf("Tentativa %d de %d", job.Attempts, job.MaxAttempts), nil)
+		if err := domainStepDefs[step].run(db, domain); err != nil {
+			if errors.Is(err, errStepWaiting) {
+				// A etapa continua quando o evento externo acontecer (ex: transferência concluída).
+				models.LogDomainEvent(db, domain.ID, stepEvent(step, "waiting"), err.Error(), nil)
+				return nil
+			}
+			models.LogDomainEvent(db, domain.ID, stepEvent(step, "failed"), err.Error(), map[string]any{"attempt": job.Attempts})
+			return err
+		}
+		models.LogDomainEvent(db, domain.ID, stepEvent(step, "completed"), "", nil)
+	}
+
+	next, err := nextPendingStep(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	if next == "" {
+		return completeDomainProvisioning(db, domain)
+	}
+	if payload.Single {
+		return nil
+	}
+	return enqueueDomainStep(db, domain.ID, next, false)
+}
+
+// completeDomainProvisioning ativa o domínio quando todas as etapas têm checkpoint.
+func completeDomainProvisioning(db *sql.DB, domain *models.Domain) error {
+	if domain.Status == models.StatusActive {
+		return nil
+	}
+	if err := models.UpdateDomainStatus(db, domain.ID, models.StatusActive); err != nil {
+		return err
+	}
+	models.LogDomainEvent(db, domain.ID, "provisioning.completed", "Provisionamento concluído com sucesso.", nil)
+	return nil
+}
+
+// --- Etapas ---
+
+// registerDomain registra ou transfere o domínio na Hostinger.
+func registerDomain(db *sql.DB, domain *models.Domain) error {
+	// A transferência é acompanhada até a conclusão e pode ser reenviada após uma recusa.
+	if domain.Type == models.DomainTypeTransfer {
+		return transferDomain(db, domain)
+	}
+	// O pedido já foi criado no registrador em uma execução anterior.
+	if domain.ProviderOrderID.Valid || domain.Type != models.DomainTypeRegister {
+		return nil
+	}
+	return purchaseDomain(db, domain)
+}
+
+// purchaseDomain compra o registro do domínio na Hostinger pelo período do pedido, com o cliente como
+// titular (WHOIS).
+func purchaseDomain(db *sql.DB, domain *models.Domain) error {
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	// Uma execução anterior pode ter comprado o domínio e parado antes de gravar o pedido.
+	details, err := provisioner.GetDomainDetails(domain.DomainName)
+	if err != nil {
+		return hostingerStepError(err)
+	}
+	if details != nil {
+		if err := models.UpdateDomainProviderOrderID(db, domain.ID, "hostinger", details.Domain); err != nil {
+			return err
+		}
+		models.LogDomainEvent(db, domain.ID, "hostinger.register.recovered", "Domínio já consta no portfólio da Hostinger", details)
+		return nil
+	}
+
+	order, err := models.GetDomainOrderByDomainID(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	years := 1
+	if order != nil {
+		years = order.Years
+	}
+	whois, err := createWhoisProfile(db, provisioner, domain, order)
+	if err != nil {
+		return err
+	}
+	purchase, err := provisioner.RegisterDomain(domain.DomainName, years, whois.ID)
+	if err != nil {
+		return hostingerStepError(err)
+	}
+
+	providerOrderID := strconv.Itoa(purchase.OrderID)
+	if err := models.UpdateDomainProviderOrderID(db, domain.ID, "hostinger", providerOrderID); err != nil {
+		return err
+	}
+	if purchase.SubscriptionID != "" {
+		if err := models.UpdateDomainProviderSubscriptionID(db, domain.ID, purchase.SubscriptionID); err != nil {
+			return err
+		}
+	}
+	models.LogDomainEvent(db, domain.ID, "hostinger.register.initiated", "Pedido de registro criado na Hostinger",
+		map[string]any{"order_id": providerOrderID, "subscription_id": purchase.SubscriptionID, "whois_profile_id": whois.ID, "status": purchase.Status})
+	return nil
+}
+
+// createWhoisProfile cria na Hostinger o perfil WHOIS do titular do domínio, a partir do cadastro do
+// cliente e do documento informado no pedido.
+func createWhoisProfile(db *sql.DB, provisioner *provisioning.HostingerProvisioner, domain *models.Domain, order *models.DomainOrder) (*provisioning.WhoisProfileResponse, error) {
+	client, err := models.GetClientByID(db, domain.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, jobs.Permanent(fmt.Errorf("cliente #%d não encontrado", domain.ClientID))
+	}
+	document := ""
+	if order != nil {
+		document = order.Document
+	}
+	_, tld, ok := provisioning.SplitDomain(domain.DomainName)
+	if !ok {
+		return nil, jobs.Permanent(fmt.Errorf("domínio inválido: %s", domain.DomainName))
+	}
+
+	whois, err := provisioner.CreateWhoisProfile(provisioning.WhoisProfileFromClient(client, tld, document))
+	if err != nil {
+		return nil, hostingerStepError(err)
+	}
+	return whois, nil
+}
+
+// hostingerStepError classifica um erro da API da Hostinger: limites de requisições e falhas do
+// provedor são repetidos pela fila; erros de validação e de autenticação não se resolvem sozinhos.
+func hostingerStepError(err error) error {
+	var apiErr *provisioning.HostingerAPIError
+	if errors.As(err, &apiErr) && !apiErr.Temporary() {
+		return jobs.Permanent(err)
+	}
+	if errors.Is(err, provisioning.ErrDomainCatalogItemNotFound) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// hostingAccountCreated são os dados gravados no evento "whm.account.created". Eventos anteriores
+// ao pool de servidores não têm server_id: a conta foi criada no servidor do ambiente.
+type hostingAccountCreated struct {
+	Username string `json:"username"`
+	ServerID *int   `json:"server_id,omitempty"`
+}
+
+// createHostingAccount cria a conta de hospedagem do domínio no servidor escolhido pela política do
+// plano (ver services.ServerPlacement) e ativa o serviço vinculado com o servidor da conta. Os dados
+// de acesso são enviados ao cliente pelo próprio painel, então a senha gerada não é armazenada.
+func createHostingAccount(db *sql.DB, domain *models.Domain) error {
+	account := hostingAccountCreated{Username: "user" + strconv.Itoa(domain.ID)}
+
+	// A conta pode ter sido criada por uma execução anterior que parou antes do checkpoint.
+	found, err := models.GetLatestDomainEventData(db, domain.ID, "whm.account.created", &account)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if hostingPlacement == nil {
+			return jobs.Permanent(fmt.Errorf("política de alocação de servidores não configurada"))
+		}
+		plan, err := hostingPlan(db, domain)
+		if err != nil {
+			return jobs.Permanent(err)
+		}
+		client, err := models.GetClientByID(db, domain.ClientID)
+		if err != nil {
+			return err
+		}
+		if client == nil {
+			return jobs.Permanent(fmt.Errorf("cliente #%d não encontrado", domain.ClientID))
+		}
+
+		server, err := hostingPlacement.PickServer(plan)
+		if err != nil {
+			return fmt.Errorf("falha ao escolher o servidor: %w", err)
+		}
+		provisioner, err := hostingPlacement.ProvisionerFor(server)
+		if err != nil {
+			return jobs.Permanent(err)
+		}
+		password, err := generatePassword(20)
+		if err != nil {
+			return err
+		}
+
+		if err := provisioner.CreateAccount(account.Username, domain.DomainName, plan.WhmPackageName.String, password, client.Email); err != nil {
+			return fmt.Errorf("falha na criação da conta em %s: %w", provisioner.Host(), err)
+		}
+		if server != nil {
+			account.ServerID = &server.ID
+		}
+		models.LogDomainEvent(db, domain.ID, "whm.account.created", "Conta de hospedagem criada em "+provisioner.Host(), account)
+	}
+
+	if !domain.ServiceID.Valid {
+		return nil
+	}
+	var serverID sql.NullInt64
+	if account.ServerID != nil {
+		serverID = sql.NullInt64{Int64: int64(*account.ServerID), Valid: true}
+	}
+	return models.ActivateServiceAccount(db, int(domain.ServiceID.Int64), account.Username, serverID)
+}
+
+// hostingPlan retorna o plano do serviço vinculado ao domínio. Domínios sem serviço (ou com um
+// plano sem pacote) usam o plano dos pedidos de domínio (DOMAIN_ORDER_PLAN).
+func hostingPlan(db *sql.DB, domain *models.Domain) (*models.Plan, error) {
+	if domain.ServiceID.Valid {
+		service, err := models.GetServiceByIDAdmin(db, int(domain.ServiceID.Int64))
+		if err != nil {
+			return nil, err
+		}
+		if service != nil {
+			plan, err := models.GetPlanByID(db, service.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if plan != nil && plan.WhmPackageName.Valid {
+				return plan, nil
+			}
+		}
+	}
+	return domainOrderPlan(db)
+}
+
+// configureDNS aponta os nameservers do domínio para os servidores de hospedagem (HOSTING_NAMESERVERS).
+func configureDNS(db *sql.DB, domain *models.Domain) error {
+	nameservers := hostingNameservers()
+	if len(nameservers) < 2 {
+		return jobs.Permanent(fmt.Errorf("HOSTING_NAMESERVERS deve conter ao menos dois nameservers"))
+	}
+	// Em domínios já existentes, o cliente aponta os nameservers por conta própria.
+	if domain.Type == models.DomainTypeExisting {
+		return nil
+	}
+
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := provisioner.UpdateNameservers(domain.DomainName, nameservers); err != nil {
+		return hostingerStepError(fmt.Errorf("falha ao atualizar os nameservers na Hostinger: %w", err))
+	}
+	if err := models.UpdateDomainNameservers(db, domain.ID, nameservers); err != nil {
+		return err
+	}
+	models.LogDomainEvent(db, domain.ID, "dns.nameservers.updated", "Nameservers apontados para a hospedagem", map[string]any{"nameservers": nameservers})
+	return nil
+}
+
+// sendWelcomeEmail envia o e-mail de boas-vindas com as instruções de DNS.
+func sendWelcomeEmail(db *sql.DB, domain *models.Domain) error {
+	client, err := models.GetClientByID(db, domain.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return jobs.Permanent(fmt.Errorf("cliente #%d não encontrado", domain.ClientID))
+	}
+
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("erro ao iniciar o serviço de e-mail: %w", err))
+	}
+
+	clientName := client.Email
+	if client.ContactName.Valid && client.ContactName.String != "" {
+		clientName = client.ContactName.String
+	} else if client.CompanyName.Valid && client.CompanyName.String != "" {
+		clientName = client.CompanyName.String
+	}
+	nameservers := append(hostingNameservers(), "", "")
+
+	return emailService.Send(email.EmailData{
+		ToName:   clientName,
+		ToEmail:  client.Email,
+		Subject:  "Seu serviço de hospedagem para " + domain.DomainName + " foi ativado",
+		Template: "welcome.html",
+		TemplateData: map[string]string{
+			"ClientName":  clientName,
+			"Domain":      domain.DomainName,
+			"Nameserver1": nameservers[0],
+			"Nameserver2": nameservers[1],
+		},
+	})
+}
+
+// hostingNameservers lê os nameservers da hospedagem de HOSTING_NAMESERVERS (separados por vírgula).
+func hostingNameservers() []string {
+	var nameservers []string
+	for _, ns := range strings.Split(os.Getenv("HOSTING_NAMESERVERS"), ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			nameservers = append(nameservers, ns)
+		}
+	}
+	return nameservers
+}
+
+const passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#%*-_"
+
+// generatePassword gera uma senha aleatória para a conta cPanel.
+func generatePassword(length int) (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("falha ao gerar a senha: %w", err)
+		}
+		sb.WriteByte(passwordAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/jobs"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/utils"
+)
+
+// Uma transferência leva dias para ser aprovada pelo registrador atual. A etapa register_domain envia
+// a transferência e termina aguardando (errStepWaiting); PollDomainTransfer acompanha o registrador e
+// continua o provisionamento quando ela é concluída. Recusas corrigíveis pelo cliente (código EPP
+// incorreto, domínio bloqueado) deixam a transferência em action_required até a correção.
+
+// domainTransferMissingAuthCode é o motivo de espera de uma transferência sem código EPP no pedido.
+const domainTransferMissingAuthCode = "missing_auth_code"
+
+// transferDomain envia a transferência do domínio ao registrador, ou a reenvia após uma recusa.
+func transferDomain(db *sql.DB, domain *models.Domain) error {
+	transfer, err := models.GetDomainTransfer(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	if transfer != nil {
+		switch transfer.Status {
+		case models.DomainTransferCompleted:
+			return nil
+		case models.DomainTransferPending:
+			return fmt.Errorf("%w: transferência em andamento no registrador", errStepWaiting)
+		case models.DomainTransferCancelled:
+			return jobs.Permanent(fmt.Errorf("a transferência de %s foi cancelada", domain.DomainName))
+		}
+	}
+
+	order, err := models.GetDomainOrderByDomainID(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	if order == nil || !order.AuthCodeEncrypted.Valid || order.AuthCodeEncrypted.String == "" {
+		if err := models.MarkDomainTransferActionRequired(db, domain.ID, "", domainTransferMissingAuthCode, "Informe o código de transferência (EPP) do domínio."); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: código de transferência não informado", errStepWaiting)
+	}
+	authCode, err := utils.Decrypt(order.AuthCodeEncrypted.String)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("erro ao descriptografar o código de transferência do pedido #%d: %w", order.ID, err))
+	}
+
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	if transferSubmitted(transfer) {
+		// Recusada: o cliente corrigiu o código ou desbloqueou o domínio no registrador atual.
+		if err := provisioner.ResubmitDomainTransfer(domain.DomainName, string(authCode)); err != nil {
+			return hostingerStepError(err)
+		}
+		models.LogDomainEvent(db, domain.ID, "hostinger.transfer.resubmitted", "Transferência reenviada à Hostinger", map[string]int{"attempt": transfer.Attempts + 1})
+	} else {
+		// Primeiro envio, inclusive quando o pedido aguardava o código EPP. Uma execução anterior pode
+		// ter enviado a transferência e parado antes de gravá-la.
+		existing, err := provisioner.GetDomainTransfer(domain.DomainName)
+		if err != nil {
+			return hostingerStepError(err)
+		}
+		if existing == nil {
+			whois, err := createWhoisProfile(db, provisioner, domain, order)
+			if err != nil {
+				return err
+			}
+			purchase, err := provisioner.TransferDomain(domain.DomainName, string(authCode), whois.ID)
+			if err != nil {
+				return hostingerStepError(err)
+			}
+			providerOrderID := strconv.Itoa(purchase.OrderID)
+			if err := models.UpdateDomainProviderOrderID(db, domain.ID, "hostinger", providerOrderID); err != nil {
+				return err
+			}
+			if purchase.SubscriptionID != "" {
+				if err := models.UpdateDomainProviderSubscriptionID(db, domain.ID, purchase.SubscriptionID); err != nil {
+					return err
+				}
+			}
+			models.LogDomainEvent(db, domain.ID, "hostinger.transfer.initiated", "Pedido de transferência criado na Hostinger",
+				map[string]any{"order_id": providerOrderID, "subscription_id": purchase.SubscriptionID, "whois_profile_id": whois.ID, "status": purchase.Status})
+		}
+	}
+
+	if err := models.MarkDomainTransferSubmitted(db, domain.ID); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: transferência enviada ao registrador", errStepWaiting)
+}
+
+// transferSubmitted informa se a transferência já foi enviada ao registrador. A espera pelo código EPP
+// do pedido também grava a transferência, mas sem nenhum envio: a próxima execução é o envio inicial.
+func transferSubmitted(transfer *models.DomainTransfer) bool {
+	return transfer != nil && transfer.Attempts > 0 && transfer.FailureReason.String != domainTransferMissingAuthCode
+}
+
+// PollDomainTransfer consulta no registrador uma transferência pendente. Concluída, o provisionamento
+// continua a partir da etapa seguinte; recusada, o cliente é avisado para corrigir o código EPP ou
+// desbloquear o domínio.
+func PollDomainTransfer(db *sql.DB, domain *models.Domain, transfer *models.DomainTransfer) error {
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return err
+	}
+	state, err := provisioner.GetDomainTransfer(domain.DomainName)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		// Sem transferência em andamento: o domínio já pode ter chegado ao portfólio.
+		details, err := provisioner.GetDomainDetails(domain.DomainName)
+		if err != nil {
+			return err
+		}
+		if details == nil {
+			return models.UpdateDomainTransferCheck(db, domain.ID, transfer.ProviderStatus.String)
+		}
+		state = &provisioning.DomainTransfer{Domain: domain.DomainName, Status: provisioning.DomainTransferStateCompleted}
+	}
+
+	switch state.Status {
+	case provisioning.DomainTransferStateCompleted:
+		return completeDomainTransfer(db, domain)
+
+	case provisioning.DomainTransferStateFailed:
+		if err := models.MarkDomainTransferActionRequired(db, domain.ID, state.Status, state.Reason, state.Message); err != nil {
+			return err
+		}
+		models.LogDomainEvent(db, domain.ID, "transfer.action_required", "Transferência recusada pelo registrador", state)
+		// Outros motivos não se resolvem com o código EPP ou o desbloqueio: a equipe acompanha.
+		if state.Reason != provisioning.DomainTransferReasonInvalidAuthCode && state.Reason != provisioning.DomainTransferReasonDomainLocked {
+			models.OpenStaffTask(db, &models.StaffTask{
+				Kind:       "domain.transfer_rejected",
+				Priority:   models.StaffTaskPriorityNormal,
+				TargetType: "domain",
+				TargetID:   strconv.Itoa(domain.ID),
+				Title:      fmt.Sprintf("Transferência de %s recusada: %s", domain.DomainName, state.Message),
+			})
+		}
+		if err := sendTransferActionRequiredEmail(db, domain, state); err != nil {
+			log.Printf("[Domain Transfer] AVISO: falha ao avisar o cliente sobre a transferência de %s: %v", domain.DomainName, err)
+		}
+		return nil
+
+	default:
+		if state.Status != transfer.ProviderStatus.String {
+			models.LogDomainEvent(db, domain.ID, "transfer.status", "Situação da transferência no registrador: "+state.Status, state)
+		}
+		return models.UpdateDomainTransferCheck(db, domain.ID, state.Status)
+	}
+}
+
+// completeDomainTransfer registra a conclusão da transferência e continua o provisionamento.
+func completeDomainTransfer(db *sql.DB, domain *models.Domain) error {
+	completed, err := models.UpdateDomainTransferStatus(db, domain.ID, models.DomainTransferPending, models.DomainTransferCompleted)
+	if err != nil || !completed {
+		return err
+	}
+	models.LogDomainEvent(db, domain.ID, "transfer.completed", "Transferência concluída: o domínio está na conta da Hostinger", nil)
+	models.LogDomainEvent(db, domain.ID, stepEvent(StepRegisterDomain, "completed"), "", nil)
+
+	next, err := nextPendingStep(db, domain.ID)
+	if err != nil {
+		return err
+	}
+	if next == "" {
+		return completeDomainProvisioning(db, domain)
+	}
+	return enqueueDomainStep(db, domain.ID, next, false)
+}
+
+// ResubmitDomainTransfer envia novamente ao registrador uma transferência que aguarda a correção do
+// cliente. O novo código EPP, quando houver, já deve estar gravado no pedido.
+func ResubmitDomainTransfer(db *sql.DB, domainID int) error {
+	models.LogDomainEvent(db, domainID, "transfer.correction_submitted", "Cliente corrigiu os dados da transferência.", nil)
+	return enqueueDomainStep(db, domainID, StepRegisterDomain, false)
+}
+
+// sendTransferActionRequiredEmail avisa o cliente que a transferência foi recusada e como corrigi-la.
+func sendTransferActionRequiredEmail(db *sql.DB, domain *models.Domain, state *provisioning.DomainTransfer) error {
+	client, err := models.GetClientByID(db, domain.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("cliente #%d não encontrado", domain.ClientID)
+	}
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar o serviço de e-mail: %w", err)
+	}
+
+	clientName := client.Email
+	if client.ContactName.Valid && client.ContactName.String != "" {
+		clientName = client.ContactName.String
+	} else if client.CompanyName.Valid && client.CompanyName.String != "" {
+		clientName = client.CompanyName.String
+	}
+
+	return emailService.Send(email.EmailData{
+		ToName:   clientName,
+		ToEmail:  client.Email,
+		Subject:  "A transferência do domínio " + domain.DomainName + " precisa da sua atenção",
+		Template: "domain_transfer_action_required.html",
+		TemplateData: map[string]any{
+			"ClientName":      clientName,
+			"Domain":          domain.DomainName,
+			"Message":         state.Message,
+			"InvalidAuthCode": state.Reason == provisioning.DomainTransferReasonInvalidAuthCode,
+			"DomainLocked":    state.Reason == provisioning.DomainTransferReasonDomainLocked,
+		},
+	})
+}
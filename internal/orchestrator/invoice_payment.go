@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"hosting-backend/internal/models"
+)
+
+// ProvisionPaidInvoice inicia o provisionamento dos pedidos e o envio das renovações de domínio
+// vinculados a uma fatura paga, seja pelo webhook do gateway ou por um administrador. Com a aprovação manual de pedidos ativa
+// (system_settings.manual_order_approval), os pedidos de domínio aguardam a aprovação de um
+// administrador. Chamar novamente para a mesma fatura não duplica o provisionamento.
+func ProvisionPaidInvoice(db *sql.DB, invoiceID int) error {
+	manualApproval, err := models.IsManualOrderApprovalEnabled(db)
+	if err != nil {
+		return fmt.Errorf("erro ao ler as configurações do sistema: %w", err)
+	}
+
+	domainOrders, err := models.GetDomainOrdersByInvoiceID(db, invoiceID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar os pedidos de domínio da fatura #%d: %w", invoiceID, err)
+	}
+	var errs []error
+	for _, order := range domainOrders {
+		// Um pedido já em registro é reprocessado: a criação do domínio é idempotente.
+		if order.Status != models.DomainOrderStatusPendingPayment && order.Status != models.DomainOrderStatusPendingRegistration {
+			continue
+		}
+		if manualApproval && order.Status == models.DomainOrderStatusPendingPayment {
+			if err := models.UpdateDomainOrderStatus(db, order.ID, models.DomainOrderStatusPendingApproval); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			log.Printf("### [ADMIN] Pedido de domínio #%d (%s) pago e aguardando aprovação manual ###", order.ID, order.DomainName)
+			continue
+		}
+
+		if err := models.UpdateDomainOrderStatus(db, order.ID, models.DomainOrderStatusPendingRegistration); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := ProcessDomainProvisioning(db, strconv.Itoa(order.ID)); err != nil {
+			errs = append(errs, fmt.Errorf("pedido de domínio #%d: %w", order.ID, err))
+		}
+	}
+
+	// Renovações pagas são enviadas ao registrador, mesmo com o domínio vencido ou em redenção.
+	renewals, err := models.GetDomainRenewalsByInvoiceID(db, invoiceID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar as renovações de domínio da fatura #%d: %w", invoiceID, err)
+	}
+	for _, renewal := range renewals {
+		if renewal.Status == models.DomainRenewalPendingPayment {
+			if _, err := models.UpdateDomainRenewalStatus(db, renewal.ID, models.DomainRenewalPendingPayment, models.DomainRenewalPaid); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			models.LogDomainEvent(db, renewal.DomainID, "renewal.paid", fmt.Sprintf("Fatura de renovação #%d paga", invoiceID), map[string]int{"renewal_id": renewal.ID})
+		} else if renewal.Status != models.DomainRenewalPaid {
+			continue
+		}
+		if err := EnqueueDomainRenewal(db, renewal.ID); err != nil {
+			errs = append(errs, fmt.Errorf("renovação de domínio #%d: %w", renewal.ID, err))
+		}
+	}
+
+	// Pedidos de VPS não passam por aprovação manual: a máquina é criada assim que a fatura é paga.
+	vpsOrders, err := models.GetVpsOrdersByInvoiceID(db, invoiceID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar os pedidos de VPS da fatura #%d: %w", invoiceID, err)
+	}
+	for _, order := range vpsOrders {
+		if order.Status != "pending" {
+			continue
+		}
+		if err := EnqueueVpsProvisioning(db, order.ID); err != nil {
+			errs = append(errs, fmt.Errorf("pedido de VPS #%d: %w", order.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"hosting-backend/internal/jobs"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/utils"
+)
+
+// vpsJobPayload é o payload da tarefa de provisionamento de VPS.
+type vpsJobPayload struct {
+	VpsOrderID int `json:"vps_order_id"`
+}
+
+// EnqueueVpsProvisioning enfileira a criação da máquina virtual de um pedido de VPS.
+func EnqueueVpsProvisioning(q models.QueryRower, vpsOrderID int) error {
+	_, err := jobs.Enqueue(q, jobs.VpsProvision, vpsJobPayload{VpsOrderID: vpsOrderID}, "vps.provision:"+strconv.Itoa(vpsOrderID))
+	return err
+}
+
+// provisionVps cria a máquina virtual do pedido na Hostinger.
+func provisionVps(db *sql.DB, job *models.Job) error {
+	var payload vpsJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return err
+	}
+	order, err := models.GetVpsOrderByID(db, payload.VpsOrderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return jobs.Permanent(fmt.Errorf("pedido de VPS #%d não encontrado", payload.VpsOrderID))
+	}
+	if order.VpsInstanceID.Valid {
+		return nil // Já provisionado
+	}
+	if !order.PasswordEncrypted.Valid {
+		return jobs.Permanent(fmt.Errorf("pedido de VPS #%d sem senha para o provisionamento", order.ID))
+	}
+
+	password, err := utils.Decrypt(order.PasswordEncrypted.String)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("falha ao descriptografar a senha do VPS: %w", err))
+	}
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	models.UpdateVpsOrderStatus(db, order.ID, "processing")
+	vm, err := provisioner.PurchaseVps(provisioning.VpsPurchaseRequest{
+		PlanID:   order.PlanID,
+		Location: order.Location,
+		Template: order.Template,
+		Hostname: order.Hostname,
+		Password: string(password),
+	})
+	if err != nil {
+		return err
+	}
+
+	// A máquina já foi comprada: repetir a tarefa compraria outra. Se o registro falhar, a tarefa
+	// vai para a fila de mortas com o ID da máquina para conciliação manual.
+	if err := models.UpdateVpsInstance(db, order.ID, vm.VmID, "active"); err != nil {
+		return jobs.Permanent(fmt.Errorf("%w (instância %s, pedido #%d): %v", errVpsNotRecorded, vm.VmID, order.ID, err))
+	}
+	log.Printf("VPS %s provisionado para o pedido #%d.", vm.VmID, order.ID)
+	return nil
+}
+
+// errVpsNotRecorded indica que a máquina foi criada na Hostinger, mas o pedido não foi atualizado.
+var errVpsNotRecorded = errors.New("VPS criado, mas o pedido não foi atualizado")
+
+// compensateVpsOrder é a política de compensação do provisionamento de VPS: o pedido é marcado como
+// falho (e a senha root apagada) e uma pendência é aberta para a equipe reembolsar o cliente ou concluir o provisionamento.
+// Uma máquina criada sem registro no pedido é uma pendência de prioridade alta (cobrança do provedor).
+func compensateVpsOrder(db *sql.DB) jobs.DeadHandler {
+	return func(job *models.Job, err error) {
+		var payload vpsJobPayload
+		if jobs.DecodePayload(job, &payload) != nil || payload.VpsOrderID == 0 {
+			return
+		}
+		log.Printf("ERRO no provisionamento do pedido de VPS #%d: %v", payload.VpsOrderID, err)
+		if err := models.FailVpsOrder(db, payload.VpsOrderID); err != nil {
+			log.Printf("ERRO ao marcar o pedido de VPS #%d como falho: %v", payload.VpsOrderID, err)
+		}
+
+		task := &models.StaffTask{
+			Kind:       "vps.provision_failed",
+			Priority:   models.StaffTaskPriorityNormal,
+			TargetType: "vps_order",
+			TargetID:   strconv.Itoa(payload.VpsOrderID),
+			Title:      fmt.Sprintf("Pedido de VPS #%d não foi provisionado: reembolsar o cliente ou provisionar manualmente", payload.VpsOrderID),
+		}
+		if errors.Is(err, errVpsNotRecorded) {
+			task.Kind = "vps.unrecorded_instance"
+			task.Priority = models.StaffTaskPriorityHigh
+			task.Title = fmt.Sprintf("VPS criado na Hostinger sem registro no pedido #%d", payload.VpsOrderID)
+		}
+		jobs.OpenStaffTask(db, job, task, err)
+	}
+}
@@ -0,0 +1,26 @@
+package provisioning
+
+import "fmt"
+
+// DomainProvider é a gestão de um domínio registrado, comum aos registradores suportados. O
+// provedor gravado no domínio (models.Domain.Provider) define qual implementação o atende.
+type DomainProvider interface {
+	// GetDomainDetails busca o domínio no registrador. Retorna nil se o domínio não está na conta.
+	GetDomainDetails(domain string) (*DomainDetails, error)
+	// UpdateNameservers aponta o domínio para os nameservers informados (de 2 a 4).
+	UpdateNameservers(domain string, nameservers []string) error
+	SetDomainLock(domain string, locked bool) error
+	SetPrivacyProtection(domain string, enabled bool) error
+}
+
+var _ DomainProvider = (*HostingerProvisioner)(nil)
+
+// NewDomainProvider retorna a implementação do registrador informado.
+func NewDomainProvider(provider string) (DomainProvider, error) {
+	switch provider {
+	case "hostinger":
+		return NewHostingerProvisioner()
+	default:
+		return nil, fmt.Errorf("registrador '%s' não suportado", provider)
+	}
+}
@@ -0,0 +1,168 @@
+package provisioning
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Erros do FakeProvisioner, equivalentes às recusas dos painéis reais.
+var (
+	ErrFakeAccountExists   = errors.New("a conta já existe no servidor")
+	ErrFakeAccountNotFound = errors.New("a conta não existe no servidor")
+)
+
+// FakeAccount é o estado de uma conta mantida pelo FakeProvisioner.
+type FakeAccount struct {
+	Username  string
+	Domain    string
+	Plan      string
+	Password  string
+	Email     string
+	Suspended bool
+	// SuspendReason guarda o último motivo informado; o Plesk não o registra, mas o fake sim.
+	SuspendReason string
+}
+
+// FakeProvisioner é um HostingProvisioner em memória, usado nos testes dos serviços que provisionam
+// contas e como estado de referência dos servidores simulados dos testes de contrato do WHM e do Plesk.
+// Segue as mesmas regras dos painéis: usuário e domínio são únicos e operações em contas inexistentes falham.
+type FakeProvisioner struct {
+	host string
+
+	mu       sync.Mutex
+	accounts map[string]*FakeAccount
+	// FailNext, quando definido, é retornado (e consumido) pela próxima chamada de qualquer operação.
+	FailNext error
+}
+
+// NewFakeProvisioner cria um FakeProvisioner vazio que se apresenta com o hostname informado.
+func NewFakeProvisioner(host string) *FakeProvisioner {
+	return &FakeProvisioner{host: host, accounts: make(map[string]*FakeAccount)}
+}
+
+// Host retorna o hostname informado na criação.
+func (p *FakeProvisioner) Host() string {
+	return p.host
+}
+
+// Account retorna uma cópia do estado da conta, localizada pelo usuário.
+func (p *FakeProvisioner) Account(username string) (FakeAccount, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	acct, ok := p.accounts[username]
+	if !ok {
+		return FakeAccount{}, false
+	}
+	return *acct, true
+}
+
+// Accounts retorna as contas existentes, ordenadas pelo usuário.
+func (p *FakeProvisioner) Accounts() []FakeAccount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	accounts := make([]FakeAccount, 0, len(p.accounts))
+	for _, acct := range p.accounts {
+		accounts = append(accounts, *acct)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Username < accounts[j].Username })
+	return accounts
+}
+
+// CreateAccount registra a conta. Usuário ou domínio repetidos são recusados.
+func (p *FakeProvisioner) CreateAccount(username, domain, plan, password, email string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return err
+	}
+
+	if _, ok := p.accounts[username]; ok {
+		return fmt.Errorf("%w: %s", ErrFakeAccountExists, username)
+	}
+	for _, acct := range p.accounts {
+		if acct.Domain == domain {
+			return fmt.Errorf("%w: domínio %s", ErrFakeAccountExists, domain)
+		}
+	}
+
+	p.accounts[username] = &FakeAccount{Username: username, Domain: domain, Plan: plan, Password: password, Email: email}
+	return nil
+}
+
+// SuspendAccount marca a conta como suspensa.
+func (p *FakeProvisioner) SuspendAccount(account AccountRef, reason string) error {
+	return p.update(account, func(acct *FakeAccount) {
+		acct.Suspended = true
+		acct.SuspendReason = reason
+	})
+}
+
+// UnsuspendAccount reativa a conta.
+func (p *FakeProvisioner) UnsuspendAccount(account AccountRef) error {
+	return p.update(account, func(acct *FakeAccount) {
+		acct.Suspended = false
+		acct.SuspendReason = ""
+	})
+}
+
+// TerminateAccount remove a conta.
+func (p *FakeProvisioner) TerminateAccount(account AccountRef) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return err
+	}
+
+	acct := p.find(account)
+	if acct == nil {
+		return fmt.Errorf("%w: %s", ErrFakeAccountNotFound, account.Username)
+	}
+	delete(p.accounts, acct.Username)
+	return nil
+}
+
+// ChangePlan troca o pacote da conta.
+func (p *FakeProvisioner) ChangePlan(account AccountRef, plan string) error {
+	return p.update(account, func(acct *FakeAccount) { acct.Plan = plan })
+}
+
+// ChangePassword troca a senha da conta.
+func (p *FakeProvisioner) ChangePassword(account AccountRef, password string) error {
+	return p.update(account, func(acct *FakeAccount) { acct.Password = password })
+}
+
+func (p *FakeProvisioner) update(account AccountRef, apply func(*FakeAccount)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.takeFailure(); err != nil {
+		return err
+	}
+
+	acct := p.find(account)
+	if acct == nil {
+		return fmt.Errorf("%w: %s", ErrFakeAccountNotFound, account.Username)
+	}
+	apply(acct)
+	return nil
+}
+
+// find localiza a conta pelo usuário (como o cPanel) ou, sem usuário, pelo domínio (como a assinatura do Plesk).
+func (p *FakeProvisioner) find(account AccountRef) *FakeAccount {
+	if account.Username != "" {
+		return p.accounts[account.Username]
+	}
+	for _, acct := range p.accounts {
+		if acct.Domain == account.Domain {
+			return acct
+		}
+	}
+	return nil
+}
+
+func (p *FakeProvisioner) takeFailure() error {
+	err := p.FailNext
+	p.FailNext = nil
+	return err
+}
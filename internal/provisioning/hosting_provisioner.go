@@ -0,0 +1,29 @@
+package provisioning
+
+// AccountRef identifica uma conta de hospedagem no painel. O cPanel localiza a conta pelo usuário;
+// o Plesk, pelo login do cliente e pelo domínio da assinatura.
+type AccountRef struct {
+	Username string
+	Domain   string
+}
+
+// HostingProvisioner é o ciclo de vida de uma conta de hospedagem, comum a todos os painéis suportados.
+// O tipo do servidor (models.ServerType) define qual implementação atende cada serviço.
+type HostingProvisioner interface {
+	// Host retorna o hostname do servidor atendido pelo provisionador.
+	Host() string
+	// CreateAccount cria a conta com o pacote (cPanel) ou plano de serviço (Plesk) informado.
+	CreateAccount(username, domain, plan, password, email string) error
+	SuspendAccount(account AccountRef, reason string) error
+	UnsuspendAccount(account AccountRef) error
+	// TerminateAccount remove a conta e todos os seus dados do servidor.
+	TerminateAccount(account AccountRef) error
+	ChangePlan(account AccountRef, plan string) error
+	ChangePassword(account AccountRef, password string) error
+}
+
+var (
+	_ HostingProvisioner = (*WhmProvisioner)(nil)
+	_ HostingProvisioner = (*PleskProvisioner)(nil)
+	_ HostingProvisioner = (*FakeProvisioner)(nil)
+)
@@ -0,0 +1,348 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Os cenários de contrato rodam contra o FakeProvisioner e contra os adaptadores do WHM e do Plesk,
+// cada um falando com um servidor httptest que simula a API do painel sobre um FakeProvisioner.
+// Assim o fake e os adaptadores reais ficam presos ao mesmo comportamento observável.
+
+type hostingBackend struct {
+	name string
+	// start retorna o provisionador testado e o estado de referência das contas no "servidor".
+	start func(t *testing.T) (HostingProvisioner, *FakeProvisioner)
+}
+
+var hostingBackends = []hostingBackend{
+	{name: "fake", start: func(t *testing.T) (HostingProvisioner, *FakeProvisioner) {
+		p := NewFakeProvisioner("fake.test")
+		return p, p
+	}},
+	{name: "whm", start: startWhmBackend},
+	{name: "plesk", start: startPleskBackend},
+}
+
+func TestHostingProvisionerContract(t *testing.T) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, p HostingProvisioner, state *FakeProvisioner)
+	}{
+		{"create", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			mustCreate(t, p)
+			acct, ok := state.Account("cliente1")
+			if !ok {
+				t.Fatal("conta não foi criada no servidor")
+			}
+			if acct.Domain != "cliente1.com.br" || acct.Plan != "basico" || acct.Email != "cliente1@example.com" {
+				t.Errorf("conta criada com dados inesperados: %+v", acct)
+			}
+			if acct.Suspended {
+				t.Error("conta nova não deveria estar suspensa")
+			}
+		}},
+		{"create duplicated username", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			mustCreate(t, p)
+			if err := p.CreateAccount("cliente1", "outro.com.br", "basico", "Senha#Forte1", "x@example.com"); err == nil {
+				t.Fatal("esperava erro ao criar usuário repetido")
+			}
+			if n := len(state.Accounts()); n != 1 {
+				t.Errorf("esperava 1 conta no servidor, encontrou %d", n)
+			}
+		}},
+		{"suspend and unsuspend", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			mustCreate(t, p)
+			if err := p.SuspendAccount(testAccount, "Fatura em atraso"); err != nil {
+				t.Fatalf("SuspendAccount: %v", err)
+			}
+			if acct, _ := state.Account("cliente1"); !acct.Suspended {
+				t.Fatal("conta deveria estar suspensa")
+			}
+			if err := p.UnsuspendAccount(testAccount); err != nil {
+				t.Fatalf("UnsuspendAccount: %v", err)
+			}
+			if acct, _ := state.Account("cliente1"); acct.Suspended {
+				t.Fatal("conta deveria estar ativa")
+			}
+		}},
+		{"change package", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			mustCreate(t, p)
+			if err := p.ChangePlan(testAccount, "profissional"); err != nil {
+				t.Fatalf("ChangePlan: %v", err)
+			}
+			if acct, _ := state.Account("cliente1"); acct.Plan != "profissional" {
+				t.Errorf("plano = %q, esperava %q", acct.Plan, "profissional")
+			}
+		}},
+		{"terminate", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			mustCreate(t, p)
+			if err := p.TerminateAccount(testAccount); err != nil {
+				t.Fatalf("TerminateAccount: %v", err)
+			}
+			if _, ok := state.Account("cliente1"); ok {
+				t.Fatal("conta deveria ter sido removida")
+			}
+			if err := p.TerminateAccount(testAccount); err == nil {
+				t.Error("esperava erro ao remover a conta pela segunda vez")
+			}
+		}},
+		{"missing account", func(t *testing.T, p HostingProvisioner, state *FakeProvisioner) {
+			if err := p.SuspendAccount(testAccount, "teste"); err == nil {
+				t.Error("SuspendAccount: esperava erro para conta inexistente")
+			}
+			if err := p.UnsuspendAccount(testAccount); err == nil {
+				t.Error("UnsuspendAccount: esperava erro para conta inexistente")
+			}
+			if err := p.ChangePlan(testAccount, "profissional"); err == nil {
+				t.Error("ChangePlan: esperava erro para conta inexistente")
+			}
+			if err := p.TerminateAccount(testAccount); err == nil {
+				t.Error("TerminateAccount: esperava erro para conta inexistente")
+			}
+		}},
+	}
+
+	for _, backend := range hostingBackends {
+		for _, sc := range scenarios {
+			t.Run(backend.name+"/"+sc.name, func(t *testing.T) {
+				p, state := backend.start(t)
+				sc.run(t, p, state)
+			})
+		}
+	}
+}
+
+func TestPleskCreateAccountRemovesClientWhenSubscriptionFails(t *testing.T) {
+	p, state := startPleskBackend(t)
+	if err := p.CreateAccount("cliente1", "cliente1.com.br", pleskMissingPlan, "Senha#Forte1", "cliente1@example.com"); err == nil {
+		t.Fatal("esperava erro com plano inexistente")
+	}
+	if n := len(state.Accounts()); n != 0 {
+		t.Errorf("esperava nenhuma conta no servidor, encontrou %d", n)
+	}
+	// O cliente órfão foi removido, então o mesmo login pode ser usado de novo.
+	if err := p.CreateAccount("cliente1", "cliente1.com.br", "basico", "Senha#Forte1", "cliente1@example.com"); err != nil {
+		t.Fatalf("CreateAccount após rollback: %v", err)
+	}
+}
+
+var testAccount = AccountRef{Username: "cliente1", Domain: "cliente1.com.br"}
+
+func mustCreate(t *testing.T, p HostingProvisioner) {
+	t.Helper()
+	if err := p.CreateAccount("cliente1", "cliente1.com.br", "basico", "Senha#Forte1", "cliente1@example.com"); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+}
+
+// redirectClient devolve um cliente HTTP que envia qualquer requisição para o servidor de teste,
+// independentemente do host e da porta da URL montada pelo adaptador.
+func redirectClient(srv *httptest.Server) *http.Client {
+	addr := srv.Listener.Addr().String()
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+// --- Servidor WHM simulado ---
+
+func startWhmBackend(t *testing.T) (HostingProvisioner, *FakeProvisioner) {
+	state := NewFakeProvisioner("whm.test")
+	srv := httptest.NewTLSServer(whmEmulator(t, state))
+	t.Cleanup(srv.Close)
+
+	p := NewWhmProvisionerWithCredentials("whm.test", "root", "token-whm")
+	p.httpClient = redirectClient(srv)
+	return p, state
+}
+
+func whmEmulator(t *testing.T, state *FakeProvisioner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "whm root:token-whm" {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		switch function := strings.TrimPrefix(r.URL.Path, "/json-api/"); function {
+		case "createacct":
+			err = state.CreateAccount(r.Form.Get("username"), r.Form.Get("domain"), r.Form.Get("plan"), r.Form.Get("password"), r.Form.Get("contactemail"))
+		case "suspendacct":
+			err = state.SuspendAccount(AccountRef{Username: r.Form.Get("user")}, r.Form.Get("reason"))
+		case "unsuspendacct":
+			err = state.UnsuspendAccount(AccountRef{Username: r.Form.Get("user")})
+		case "removeacct":
+			err = state.TerminateAccount(AccountRef{Username: r.Form.Get("username")})
+		case "changepackage":
+			err = state.ChangePlan(AccountRef{Username: r.Form.Get("user")}, r.Form.Get("pkg"))
+		case "passwd":
+			err = state.ChangePassword(AccountRef{Username: r.Form.Get("user")}, r.Form.Get("password"))
+		default:
+			t.Errorf("função do WHM inesperada: %s", function)
+			err = errors.New("Unknown app requested")
+		}
+
+		// O WHM responde 200 mesmo nas recusas; o resultado vai em metadata.result.
+		resp := map[string]any{"metadata": map[string]any{"result": 1, "reason": "OK"}}
+		if err != nil {
+			resp["metadata"] = map[string]any{"result": 0, "reason": err.Error()}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// --- Servidor Plesk simulado ---
+
+// pleskMissingPlan é um plano de serviço que o Plesk simulado não conhece.
+const pleskMissingPlan = "inexistente"
+
+func startPleskBackend(t *testing.T) (HostingProvisioner, *FakeProvisioner) {
+	state := NewFakeProvisioner("plesk.test")
+	srv := httptest.NewTLSServer(newPleskEmulator(t, state))
+	t.Cleanup(srv.Close)
+
+	p := NewPleskProvisionerWithCredentials("plesk.test", "", "chave-plesk")
+	p.httpClient = redirectClient(srv)
+	return p, state
+}
+
+// pleskEmulator guarda os clientes do Plesk que ainda não têm assinatura; a conta só passa a existir
+// no estado de referência quando a assinatura do domínio é criada.
+type pleskEmulator struct {
+	t     *testing.T
+	state *FakeProvisioner
+
+	mu      sync.Mutex
+	clients map[string]pleskClientRequest
+}
+
+func newPleskEmulator(t *testing.T, state *FakeProvisioner) *pleskEmulator {
+	return &pleskEmulator{t: t, state: state, clients: make(map[string]pleskClientRequest)}
+}
+
+func (e *pleskEmulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != "chave-plesk" {
+		writePleskError(w, http.StatusUnauthorized, "Authentication failed")
+		return
+	}
+
+	switch path := strings.TrimPrefix(r.URL.Path, "/api/v2"); {
+	case r.Method == http.MethodPost && path == "/clients":
+		var req pleskClientRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.clients[req.Login]; ok {
+			writePleskError(w, http.StatusConflict, "Client with login "+req.Login+" already exists")
+			return
+		}
+		if _, ok := e.state.Account(req.Login); ok {
+			writePleskError(w, http.StatusConflict, "Client with login "+req.Login+" already exists")
+			return
+		}
+		e.clients[req.Login] = req
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"id": len(e.clients), "guid": req.Login})
+
+	case r.Method == http.MethodPost && path == "/domains":
+		var req pleskDomainRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		client, ok := e.clients[req.OwnerClient.Login]
+		if !ok {
+			writePleskError(w, http.StatusBadRequest, "Owner client not found")
+			return
+		}
+		if req.Plan.Name == pleskMissingPlan {
+			writePleskError(w, http.StatusBadRequest, "Service plan "+req.Plan.Name+" does not exist")
+			return
+		}
+		if err := e.state.CreateAccount(client.Login, req.Name, req.Plan.Name, client.Password, client.Email); err != nil {
+			writePleskError(w, http.StatusConflict, err.Error())
+			return
+		}
+		delete(e.clients, client.Login)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"id": 1, "name": req.Name})
+
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/cli/") && strings.HasSuffix(path, "/call"):
+		var req pleskCLIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		command := strings.TrimSuffix(strings.TrimPrefix(path, "/cli/"), "/call")
+		resp := pleskCLIResponse{Stdout: "SUCCESS"}
+		if err := e.runCLI(command, req.Params); err != nil {
+			resp = pleskCLIResponse{Code: 1, Stderr: err.Error()}
+		}
+		json.NewEncoder(w).Encode(resp)
+
+	default:
+		e.t.Errorf("chamada do Plesk inesperada: %s %s", r.Method, r.URL.Path)
+		writePleskError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (e *pleskEmulator) runCLI(command string, params []string) error {
+	if len(params) < 2 {
+		return errors.New("parâmetros insuficientes")
+	}
+	action, target := params[0], params[1]
+	customer := AccountRef{Username: target}
+	subscription := AccountRef{Domain: target}
+
+	switch command + " " + action {
+	case "customer --remove":
+		e.mu.Lock()
+		_, pending := e.clients[target]
+		delete(e.clients, target)
+		e.mu.Unlock()
+		if pending {
+			return nil
+		}
+		return e.state.TerminateAccount(customer)
+	case "customer --update":
+		return e.state.ChangePassword(customer, cliOption(params, "-passwd"))
+	case "subscription --webspace-off":
+		return e.state.SuspendAccount(subscription, "")
+	case "subscription --webspace-on":
+		return e.state.UnsuspendAccount(subscription)
+	case "subscription --switch-subscription":
+		return e.state.ChangePlan(subscription, cliOption(params, "-service-plan"))
+	case "subscription --update":
+		return e.state.ChangePassword(subscription, cliOption(params, "-passwd"))
+	}
+	e.t.Errorf("comando do Plesk inesperado: %s %v", command, params)
+	return errors.New("unknown command")
+}
+
+func cliOption(params []string, name string) string {
+	for i := 0; i+1 < len(params); i++ {
+		if params[i] == name {
+			return params[i+1]
+		}
+	}
+	return ""
+}
+
+func writePleskError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(pleskErrorResponse{Code: status, Message: message})
+}
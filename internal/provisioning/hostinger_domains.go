@@ -0,0 +1,472 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/utils"
+)
+
+// --- Estruturas de Domínio ---
+
+// DomainAvailability é o resultado da consulta de disponibilidade de um domínio.
+type DomainAvailability struct {
+	Domain        string  `json:"domain"`
+	IsAvailable   bool    `json:"is_available"`
+	IsAlternative bool    `json:"is_alternative"`
+	Restriction   *string `json:"restriction"`
+}
+
+type domainAvailabilityRequest struct {
+	Domain           string   `json:"domain"`
+	TLDs             []string `json:"tlds"`
+	WithAlternatives bool     `json:"with_alternatives"`
+}
+
+// WhoisEntityType define se o titular do domínio é uma pessoa física ou jurídica.
+type WhoisEntityType string
+
+const (
+	WhoisEntityIndividual   WhoisEntityType = "individual"
+	WhoisEntityOrganization WhoisEntityType = "organization"
+)
+
+// WhoisProfile é o perfil de contato (WHOIS) usado no registro de domínios de uma TLD.
+type WhoisProfile struct {
+	TLD          string            `json:"tld"`
+	Country      string            `json:"country"`
+	EntityType   WhoisEntityType   `json:"entity_type"`
+	WhoisDetails map[string]string `json:"whois_details"`
+	TldDetails   map[string]string `json:"tld_details,omitempty"`
+}
+
+type WhoisProfileResponse struct {
+	ID int `json:"id"`
+}
+
+type DomainContacts struct {
+	OwnerID   int `json:"owner_id"`
+	AdminID   int `json:"admin_id,omitempty"`
+	BillingID int `json:"billing_id,omitempty"`
+	TechID    int `json:"tech_id,omitempty"`
+}
+
+type DomainPurchaseRequest struct {
+	Domain         string         `json:"domain"`
+	ItemID         string         `json:"item_id"`
+	DomainContacts DomainContacts `json:"domain_contacts"`
+	AuthCode       string         `json:"auth_code,omitempty"` // Código EPP, apenas em transferências
+}
+
+type DomainPurchaseResponse struct {
+	OrderID        int    `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	Status         string `json:"status"`
+}
+
+// DomainDetails é a situação de um domínio do portfólio da conta na Hostinger.
+type DomainDetails struct {
+	Domain                     string            `json:"domain"`
+	Status                     string            `json:"status"`
+	IsPrivacyProtectionAllowed bool              `json:"is_privacy_protection_allowed"`
+	IsPrivacyProtected         bool              `json:"is_privacy_protected"`
+	IsLockable                 bool              `json:"is_lockable"`
+	IsLocked                   bool              `json:"is_locked"`
+	NameServers                map[string]string `json:"name_servers"`
+	DomainContacts             DomainContacts    `json:"domain_contacts"`
+	RegisteredAt               *time.Time        `json:"registered_at"`
+	ExpiresAt                  *time.Time        `json:"expires_at"`
+}
+
+// NameserverList retorna os nameservers do domínio na ordem ns1, ns2, ...
+func (d *DomainDetails) NameserverList() []string {
+	nameservers := []string{}
+	for i := 1; i <= len(d.NameServers); i++ {
+		if ns := d.NameServers[fmt.Sprintf("ns%d", i)]; ns != "" {
+			nameservers = append(nameservers, ns)
+		}
+	}
+	return nameservers
+}
+
+// Situações de uma transferência de domínio na Hostinger.
+const (
+	DomainTransferStatePending   = "pending"
+	DomainTransferStateCompleted = "completed"
+	DomainTransferStateFailed    = "failed"
+)
+
+// Motivos de recusa de uma transferência que o cliente pode corrigir.
+const (
+	DomainTransferReasonInvalidAuthCode = "invalid_auth_code" // Código EPP incorreto ou expirado
+	DomainTransferReasonDomainLocked    = "domain_locked"     // Bloqueio de transferência ativo no registrador atual
+)
+
+// DomainTransfer é a situação da transferência de um domínio para a conta na Hostinger.
+type DomainTransfer struct {
+	Domain  string `json:"domain"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`  // Motivo da recusa, quando Status é failed
+	Message string `json:"message"` // Mensagem do registrador
+}
+
+// Subscription é uma assinatura da conta na Hostinger. A renovação de um domínio é feita pela
+// renovação automática da sua assinatura, cobrada do meio de pagamento padrão da conta.
+type Subscription struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	IsAutoRenewed bool       `json:"is_auto_renewed"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	NextBillingAt *time.Time `json:"next_billing_at"`
+}
+
+// ErrDomainCatalogItemNotFound indica que a Hostinger não vende a TLD no período solicitado.
+var ErrDomainCatalogItemNotFound = errors.New("item de catálogo do domínio não encontrado")
+
+// SplitDomain separa o nome e a TLD de um domínio (ex: "exemplo.com.br" → "exemplo", "com.br").
+func SplitDomain(domain string) (name, tld string, ok bool) {
+	name, tld, ok = strings.Cut(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if !ok || name == "" || tld == "" {
+		return "", "", false
+	}
+	return name, tld, true
+}
+
+// --- Métodos de Domínio ---
+
+// CheckDomainAvailability consulta a disponibilidade do nome em cada uma das TLDs informadas.
+func (p *HostingerProvisioner) CheckDomainAvailability(name string, tlds []string) ([]DomainAvailability, error) {
+	respBody, err := p.makeHostingerAPIRequest("POST", "/domains/v1/availability", domainAvailabilityRequest{
+		Domain: name,
+		TLDs:   tlds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar a disponibilidade de '%s': %w", name, err)
+	}
+	var results []DomainAvailability
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar a disponibilidade de '%s': %w", name, err)
+	}
+	return results, nil
+}
+
+// CreateWhoisProfile cria o perfil de contato do titular para a TLD.
+func (p *HostingerProvisioner) CreateWhoisProfile(profile WhoisProfile) (*WhoisProfileResponse, error) {
+	respBody, err := p.makeHostingerAPIRequest("POST", "/domains/v1/whois", profile)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar o perfil WHOIS: %w", err)
+	}
+	var response WhoisProfileResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar o perfil WHOIS: %w", err)
+	}
+	if response.ID == 0 {
+		return nil, fmt.Errorf("a Hostinger não retornou o ID do perfil WHOIS")
+	}
+	return &response, nil
+}
+
+// RegisterDomain compra o registro do domínio por period anos, com o perfil WHOIS informado como
+// titular e demais contatos. A compra é cobrada do meio de pagamento padrão da conta na Hostinger.
+func (p *HostingerProvisioner) RegisterDomain(domain string, period int, whoisID int) (*DomainPurchaseResponse, error) {
+	_, tld, ok := SplitDomain(domain)
+	if !ok {
+		return nil, fmt.Errorf("domínio inválido: %s", domain)
+	}
+	itemID, err := p.domainCatalogItemID(tld, period)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Hostinger Provisioner] Registrando o domínio '%s' por %d ano(s) (item '%s').", domain, period, itemID)
+	respBody, err := p.makeHostingerAPIRequest("POST", "/domains/v1/portfolio", DomainPurchaseRequest{
+		Domain: domain,
+		ItemID: itemID,
+		DomainContacts: DomainContacts{
+			OwnerID:   whoisID,
+			AdminID:   whoisID,
+			BillingID: whoisID,
+			TechID:    whoisID,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao registrar o domínio '%s': %w", domain, err)
+	}
+	var response DomainPurchaseResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar o pedido de registro de '%s': %w", domain, err)
+	}
+	if response.OrderID == 0 {
+		return nil, fmt.Errorf("a Hostinger não retornou o pedido de registro de '%s'", domain)
+	}
+	return &response, nil
+}
+
+// TransferDomain compra a transferência do domínio para a conta com o código EPP, com o perfil WHOIS
+// informado como titular e demais contatos. A transferência acrescenta um ano ao vencimento atual.
+func (p *HostingerProvisioner) TransferDomain(domain, authCode string, whoisID int) (*DomainPurchaseResponse, error) {
+	_, tld, ok := SplitDomain(domain)
+	if !ok {
+		return nil, fmt.Errorf("domínio inválido: %s", domain)
+	}
+	itemID, err := p.domainCatalogItemID(tld, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Hostinger Provisioner] Transferindo o domínio '%s' (item '%s').", domain, itemID)
+	respBody, err := p.makeHostingerAPIRequest("POST", "/domains/v1/portfolio", DomainPurchaseRequest{
+		Domain: domain,
+		ItemID: itemID,
+		DomainContacts: DomainContacts{
+			OwnerID:   whoisID,
+			AdminID:   whoisID,
+			BillingID: whoisID,
+			TechID:    whoisID,
+		},
+		AuthCode: authCode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao transferir o domínio '%s': %w", domain, err)
+	}
+	var response DomainPurchaseResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar o pedido de transferência de '%s': %w", domain, err)
+	}
+	if response.OrderID == 0 {
+		return nil, fmt.Errorf("a Hostinger não retornou o pedido de transferência de '%s'", domain)
+	}
+	return &response, nil
+}
+
+// GetDomainTransfer busca a situação da transferência de um domínio. Retorna nil se não houver
+// transferência para o domínio.
+func (p *HostingerProvisioner) GetDomainTransfer(domain string) (*DomainTransfer, error) {
+	respBody, err := p.makeHostingerAPIRequest("GET", "/domains/v1/portfolio/"+url.PathEscape(domain)+"/transfer", nil)
+	if err != nil {
+		var apiErr *HostingerAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil // Not Found
+		}
+		return nil, fmt.Errorf("falha ao buscar a transferência de '%s': %w", domain, err)
+	}
+	var transfer DomainTransfer
+	if err := json.Unmarshal(respBody, &transfer); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar a transferência de '%s': %w", domain, err)
+	}
+	return &transfer, nil
+}
+
+// ResubmitDomainTransfer envia novamente uma transferência recusada, com o código EPP corrigido.
+func (p *HostingerProvisioner) ResubmitDomainTransfer(domain, authCode string) error {
+	body := map[string]string{"auth_code": authCode}
+	if _, err := p.makeHostingerAPIRequest("POST", "/domains/v1/portfolio/"+url.PathEscape(domain)+"/transfer", body); err != nil {
+		return fmt.Errorf("falha ao reenviar a transferência de '%s': %w", domain, err)
+	}
+	return nil
+}
+
+// GetDomainDetails busca um domínio do portfólio da conta. Retorna nil se o domínio não está na conta.
+func (p *HostingerProvisioner) GetDomainDetails(domain string) (*DomainDetails, error) {
+	respBody, err := p.makeHostingerAPIRequest("GET", "/domains/v1/portfolio/"+url.PathEscape(domain), nil)
+	if err != nil {
+		var apiErr *HostingerAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil // Not Found
+		}
+		return nil, fmt.Errorf("falha ao buscar o domínio '%s': %w", domain, err)
+	}
+	var details DomainDetails
+	if err := json.Unmarshal(respBody, &details); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar o domínio '%s': %w", domain, err)
+	}
+	return &details, nil
+}
+
+// UpdateNameservers aponta o domínio para os nameservers informados (de 2 a 4).
+func (p *HostingerProvisioner) UpdateNameservers(domain string, nameservers []string) error {
+	if len(nameservers) < 2 || len(nameservers) > 4 {
+		return fmt.Errorf("informe de 2 a 4 nameservers")
+	}
+	body := make(map[string]string, len(nameservers))
+	for i, ns := range nameservers {
+		body[fmt.Sprintf("ns%d", i+1)] = ns
+	}
+	if _, err := p.makeHostingerAPIRequest("PUT", "/domains/v1/portfolio/"+url.PathEscape(domain)+"/nameservers", body); err != nil {
+		return fmt.Errorf("falha ao atualizar os nameservers de '%s': %w", domain, err)
+	}
+	return nil
+}
+
+// SetDomainLock ativa ou desativa o bloqueio de transferência do domínio.
+func (p *HostingerProvisioner) SetDomainLock(domain string, locked bool) error {
+	method := "DELETE"
+	if locked {
+		method = "PUT"
+	}
+	if _, err := p.makeHostingerAPIRequest(method, "/domains/v1/portfolio/"+url.PathEscape(domain)+"/domain-lock", nil); err != nil {
+		return fmt.Errorf("falha ao alterar o bloqueio de transferência de '%s': %w", domain, err)
+	}
+	return nil
+}
+
+// SetPrivacyProtection ativa ou desativa a proteção de privacidade do WHOIS do domínio.
+func (p *HostingerProvisioner) SetPrivacyProtection(domain string, enabled bool) error {
+	method := "DELETE"
+	if enabled {
+		method = "PUT"
+	}
+	if _, err := p.makeHostingerAPIRequest(method, "/domains/v1/portfolio/"+url.PathEscape(domain)+"/privacy-protection", nil); err != nil {
+		return fmt.Errorf("falha ao alterar a privacidade do WHOIS de '%s': %w", domain, err)
+	}
+	return nil
+}
+
+// GetDomainCatalog busca os itens de domínio do catálogo, um por TLD (ex: ".COM"), com o preço em
+// centavos de cada período.
+func (p *HostingerProvisioner) GetDomainCatalog() ([]CatalogItem, error) {
+	respBody, err := p.makeHostingerAPIRequest("GET", "/billing/v1/catalog?category=DOMAIN", nil)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o catálogo de domínios: %w", err)
+	}
+	var catalog CatalogResponse
+	if err := json.Unmarshal(respBody, &catalog); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar o catálogo de domínios: %w", err)
+	}
+	return catalog.Data, nil
+}
+
+// GetSubscriptions lista as assinaturas da conta.
+func (p *HostingerProvisioner) GetSubscriptions() ([]Subscription, error) {
+	respBody, err := p.makeHostingerAPIRequest("GET", "/billing/v1/subscriptions", nil)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar as assinaturas: %w", err)
+	}
+	var subscriptions []Subscription
+	if err := json.Unmarshal(respBody, &subscriptions); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar as assinaturas: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// FindDomainSubscription busca a assinatura de um domínio pelo nome. Retorna nil se não houver.
+func (p *HostingerProvisioner) FindDomainSubscription(domain string) (*Subscription, error) {
+	subscriptions, err := p.GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		if strings.EqualFold(subscriptions[i].Name, domain) {
+			return &subscriptions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SetSubscriptionAutoRenewal ativa ou desativa a renovação automática de uma assinatura.
+func (p *HostingerProvisioner) SetSubscriptionAutoRenewal(subscriptionID string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	path := "/billing/v1/subscriptions/" + url.PathEscape(subscriptionID) + "/auto-renewal/" + action
+	if _, err := p.makeHostingerAPIRequest("PATCH", path, nil); err != nil {
+		return fmt.Errorf("falha ao alterar a renovação automática da assinatura '%s': %w", subscriptionID, err)
+	}
+	return nil
+}
+
+// domainCatalogItemID busca no catálogo o item de registro da TLD pelo período em anos.
+func (p *HostingerProvisioner) domainCatalogItemID(tld string, period int) (string, error) {
+	query := url.Values{"category": {"DOMAIN"}, "name": {"." + strings.ToUpper(tld)}}
+	respBody, err := p.makeHostingerAPIRequest("GET", "/billing/v1/catalog?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("falha ao buscar o catálogo de domínios: %w", err)
+	}
+	var catalog CatalogResponse
+	if err := json.Unmarshal(respBody, &catalog); err != nil {
+		return "", fmt.Errorf("falha ao decodificar o catálogo de domínios: %w", err)
+	}
+	for _, item := range catalog.Data {
+		for _, price := range item.Pricing {
+			if price.ID != "" && price.Period == period && price.PeriodType == "year" {
+				return price.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: .%s por %d ano(s)", ErrDomainCatalogItemNotFound, tld, period)
+}
+
+// WhoisProfileFromClient monta o perfil WHOIS do titular a partir do cadastro do cliente. O documento
+// (CPF ou CNPJ) informado no pedido tem precedência sobre o do cadastro; com CNPJ, o titular é a empresa.
+func WhoisProfileFromClient(client *models.Client, tld, document string) WhoisProfile {
+	if document == "" {
+		document = client.CpfCnpj.String
+	}
+	document = utils.OnlyDigits(document)
+
+	country := strings.ToUpper(client.Country.String)
+	if len(country) != 2 {
+		country = "BR"
+	}
+	firstName, lastName, _ := strings.Cut(strings.TrimSpace(client.ContactName.String), " ")
+	if lastName == "" {
+		lastName = firstName
+	}
+
+	details := map[string]string{
+		"first_name":   firstName,
+		"last_name":    strings.TrimSpace(lastName),
+		"email":        client.Email,
+		"phone":        whoisPhone(client.Phone.String, country),
+		"address":      client.Address.String,
+		"city":         client.City.String,
+		"state":        client.State.String,
+		"zip":          utils.OnlyDigits(client.Zip.String),
+		"country_code": country,
+	}
+	entityType := WhoisEntityIndividual
+	if len(document) == 14 { // CNPJ
+		entityType = WhoisEntityOrganization
+		details["company_name"] = client.CompanyName.String
+	}
+
+	profile := WhoisProfile{
+		TLD:          tld,
+		Country:      country,
+		EntityType:   entityType,
+		WhoisDetails: details,
+	}
+	// O registro.br exige o CPF ou CNPJ do titular.
+	if document != "" && (tld == "br" || strings.HasSuffix(tld, ".br")) {
+		profile.TldDetails = map[string]string{"document": document}
+	}
+	return profile
+}
+
+// whoisPhone formata o telefone no padrão dos registradores: +DDI.número (ex: +55.11999998888).
+func whoisPhone(phone, country string) string {
+	digits := utils.OnlyDigits(phone)
+	if digits == "" {
+		return ""
+	}
+	if strings.HasPrefix(strings.TrimSpace(phone), "+") {
+		// O DDI do Brasil tem 2 dígitos; nos demais países, o número é enviado sem separação.
+		if country == "BR" && strings.HasPrefix(digits, "55") {
+			return "+55." + digits[2:]
+		}
+		return "+" + digits
+	}
+	if country == "BR" {
+		return "+55." + digits
+	}
+	return "+" + digits
+}
@@ -0,0 +1,412 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Os testes de domínio da Hostinger rodam contra um servidor httptest que devolve respostas gravadas
+// da API (testdata/hostinger). Cada caso define, por rota, o status e o arquivo da resposta.
+
+type recordedResponse struct {
+	status  int
+	fixture string
+}
+
+type recordedRequest struct {
+	method string
+	path   string
+	query  string
+	body   []byte
+}
+
+// hostingerFake replica as respostas gravadas e guarda as requisições recebidas.
+type hostingerFake struct {
+	t      *testing.T
+	routes map[string]recordedResponse // "MÉTODO /caminho"
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+const hostingerTestToken = "token-de-teste"
+
+func newHostingerFake(t *testing.T, routes map[string]recordedResponse) (*HostingerProvisioner, *hostingerFake) {
+	fake := &hostingerFake{t: t, routes: routes}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	// O caminho /api reproduz a URL base da API (hostingerAPIBaseURL).
+	return NewHostingerProvisionerWithURL(hostingerTestToken, srv.URL+"/api"), fake
+}
+
+func (f *hostingerFake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.requests = append(f.requests, recordedRequest{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, body: body})
+	f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+hostingerTestToken {
+		f.reply(w, recordedResponse{http.StatusUnauthorized, "error_401.json"})
+		return
+	}
+	resp, ok := f.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		f.t.Errorf("chamada inesperada à Hostinger: %s %s", r.Method, r.URL.Path)
+		f.reply(w, recordedResponse{http.StatusNotFound, "error_404.json"})
+		return
+	}
+	f.reply(w, resp)
+}
+
+func (f *hostingerFake) reply(w http.ResponseWriter, resp recordedResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	if resp.fixture == "" {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "hostinger", resp.fixture))
+	if err != nil {
+		f.t.Fatalf("resposta gravada %s: %v", resp.fixture, err)
+	}
+	w.Write(data)
+}
+
+// lastRequest retorna a última requisição recebida para o método e o caminho informados.
+func (f *hostingerFake) lastRequest(method, path string) (recordedRequest, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.requests) - 1; i >= 0; i-- {
+		if f.requests[i].method == method && f.requests[i].path == path {
+			return f.requests[i], true
+		}
+	}
+	return recordedRequest{}, false
+}
+
+// wantErr descreve o erro esperado de uma chamada: o status da API (com a classificação de erro
+// temporário usada pela fila) ou um erro de negócio. O valor zero significa sucesso.
+type wantErr struct {
+	status    int
+	temporary bool
+	is        error
+	any       bool // Qualquer erro, para respostas sem status de erro
+}
+
+func checkErr(t *testing.T, err error, want wantErr) {
+	t.Helper()
+	switch {
+	case want.status != 0:
+		var apiErr *HostingerAPIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("esperava HostingerAPIError com status %d, recebeu %v", want.status, err)
+		}
+		if apiErr.StatusCode != want.status {
+			t.Errorf("status = %d, esperava %d", apiErr.StatusCode, want.status)
+		}
+		if apiErr.Temporary() != want.temporary {
+			t.Errorf("Temporary() = %v, esperava %v", apiErr.Temporary(), want.temporary)
+		}
+	case want.is != nil:
+		if !errors.Is(err, want.is) {
+			t.Fatalf("esperava %v, recebeu %v", want.is, err)
+		}
+	case want.any:
+		if err == nil {
+			t.Fatal("esperava erro, recebeu nil")
+		}
+	default:
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+	}
+}
+
+var (
+	errUnauthorized    = wantErr{status: http.StatusUnauthorized}
+	errValidation      = wantErr{status: http.StatusUnprocessableEntity}
+	errRateLimited     = wantErr{status: http.StatusTooManyRequests, temporary: true}
+	respUnauthorized   = recordedResponse{http.StatusUnauthorized, "error_401.json"}
+	respValidation     = recordedResponse{http.StatusUnprocessableEntity, "error_422.json"}
+	respRateLimited    = recordedResponse{http.StatusTooManyRequests, "error_429.json"}
+	respNotInPortfolio = recordedResponse{http.StatusNotFound, "error_404.json"}
+	respCatalogComBr   = recordedResponse{http.StatusOK, "catalog_domain_com_br.json"}
+	respCatalogEmpty   = recordedResponse{http.StatusOK, "catalog_empty.json"}
+)
+
+func TestCheckDomainAvailability(t *testing.T) {
+	const route = "POST /api/domains/v1/availability"
+	tests := []struct {
+		name string
+		resp recordedResponse
+		want wantErr
+	}{
+		{"success", recordedResponse{http.StatusOK, "availability.json"}, wantErr{}},
+		{"unauthorized", respUnauthorized, errUnauthorized},
+		{"validation error", respValidation, errValidation},
+		{"rate limited", respRateLimited, errRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newHostingerFake(t, map[string]recordedResponse{route: tt.resp})
+
+			results, err := p.CheckDomainAvailability("minhaempresa", []string{"com", "com.br", "ai"})
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if len(results) != 3 {
+				t.Fatalf("esperava 3 resultados, recebeu %d", len(results))
+			}
+			if !results[0].IsAvailable || results[1].IsAvailable {
+				t.Errorf("disponibilidade inesperada: %+v", results)
+			}
+			if results[2].Restriction == nil {
+				t.Error("esperava a restrição da TLD .ai")
+			}
+
+			req, _ := fake.lastRequest("POST", "/api/domains/v1/availability")
+			var sent domainAvailabilityRequest
+			json.Unmarshal(req.body, &sent)
+			if sent.Domain != "minhaempresa" || len(sent.TLDs) != 3 {
+				t.Errorf("requisição inesperada: %s", req.body)
+			}
+		})
+	}
+}
+
+func TestCreateWhoisProfile(t *testing.T) {
+	const route = "POST /api/domains/v1/whois"
+	tests := []struct {
+		name string
+		resp recordedResponse
+		want wantErr
+	}{
+		{"success", recordedResponse{http.StatusOK, "whois_profile.json"}, wantErr{}},
+		{"response without id", recordedResponse{http.StatusOK, "whois_profile_without_id.json"}, wantErr{any: true}},
+		{"unauthorized", respUnauthorized, errUnauthorized},
+		{"validation error", respValidation, errValidation},
+		{"rate limited", respRateLimited, errRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newHostingerFake(t, map[string]recordedResponse{route: tt.resp})
+
+			profile := WhoisProfile{
+				TLD:          "com.br",
+				Country:      "BR",
+				EntityType:   WhoisEntityIndividual,
+				WhoisDetails: map[string]string{"first_name": "Maria", "last_name": "Silva"},
+				TldDetails:   map[string]string{"document": "12345678909"},
+			}
+			resp, err := p.CreateWhoisProfile(profile)
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if resp.ID != 481516 {
+				t.Errorf("ID = %d, esperava 481516", resp.ID)
+			}
+			req, _ := fake.lastRequest("POST", "/api/domains/v1/whois")
+			var sent WhoisProfile
+			json.Unmarshal(req.body, &sent)
+			if sent.TldDetails["document"] != "12345678909" {
+				t.Errorf("documento do titular não enviado: %s", req.body)
+			}
+		})
+	}
+}
+
+func TestRegisterDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		catalog  recordedResponse
+		purchase recordedResponse
+		want     wantErr
+	}{
+		{"success", respCatalogComBr, recordedResponse{http.StatusOK, "purchase.json"}, wantErr{}},
+		{"tld not in catalog", respCatalogEmpty, recordedResponse{}, wantErr{is: ErrDomainCatalogItemNotFound}},
+		{"catalog rate limited", respRateLimited, recordedResponse{}, errRateLimited},
+		{"response without order", respCatalogComBr, recordedResponse{http.StatusOK, "purchase_without_order.json"}, wantErr{any: true}},
+		{"unauthorized", respCatalogComBr, respUnauthorized, errUnauthorized},
+		{"validation error", respCatalogComBr, respValidation, errValidation},
+		{"rate limited", respCatalogComBr, respRateLimited, errRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := map[string]recordedResponse{"GET /api/billing/v1/catalog": tt.catalog}
+			if tt.purchase.status != 0 {
+				routes["POST /api/domains/v1/portfolio"] = tt.purchase
+			}
+			p, fake := newHostingerFake(t, routes)
+
+			resp, err := p.RegisterDomain("minhaempresa.com.br", 2, 481516)
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if resp.OrderID != 2957086 || resp.SubscriptionID != "Azz353Uhl1xC54pR0" {
+				t.Errorf("pedido inesperado: %+v", resp)
+			}
+			catalogReq, _ := fake.lastRequest("GET", "/api/billing/v1/catalog")
+			if catalogReq.query != "category=DOMAIN&name=.COM.BR" {
+				t.Errorf("consulta do catálogo = %q", catalogReq.query)
+			}
+			req, _ := fake.lastRequest("POST", "/api/domains/v1/portfolio")
+			var sent DomainPurchaseRequest
+			json.Unmarshal(req.body, &sent)
+			if sent.ItemID != "hostingercombr-domain-registration-2y" {
+				t.Errorf("item_id = %q, esperava o registro por 2 anos", sent.ItemID)
+			}
+			if sent.DomainContacts.OwnerID != 481516 || sent.DomainContacts.TechID != 481516 {
+				t.Errorf("contatos inesperados: %+v", sent.DomainContacts)
+			}
+			if sent.AuthCode != "" {
+				t.Error("o registro não deveria enviar código EPP")
+			}
+		})
+	}
+}
+
+func TestTransferDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		catalog  recordedResponse
+		purchase recordedResponse
+		want     wantErr
+	}{
+		{"success", respCatalogComBr, recordedResponse{http.StatusOK, "purchase.json"}, wantErr{}},
+		{"tld not in catalog", respCatalogEmpty, recordedResponse{}, wantErr{is: ErrDomainCatalogItemNotFound}},
+		{"unauthorized", respCatalogComBr, respUnauthorized, errUnauthorized},
+		{"invalid auth code", respCatalogComBr, respValidation, errValidation},
+		{"rate limited", respCatalogComBr, respRateLimited, errRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := map[string]recordedResponse{"GET /api/billing/v1/catalog": tt.catalog}
+			if tt.purchase.status != 0 {
+				routes["POST /api/domains/v1/portfolio"] = tt.purchase
+			}
+			p, fake := newHostingerFake(t, routes)
+
+			resp, err := p.TransferDomain("minhaempresa.com.br", "Xy7#kP2q", 481516)
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if resp.OrderID != 2957086 {
+				t.Errorf("pedido inesperado: %+v", resp)
+			}
+			req, _ := fake.lastRequest("POST", "/api/domains/v1/portfolio")
+			var sent DomainPurchaseRequest
+			json.Unmarshal(req.body, &sent)
+			if sent.AuthCode != "Xy7#kP2q" {
+				t.Errorf("auth_code = %q", sent.AuthCode)
+			}
+			if sent.ItemID != "hostingercombr-domain-registration-1y" {
+				t.Errorf("item_id = %q, esperava o item de 1 ano", sent.ItemID)
+			}
+		})
+	}
+}
+
+func TestGetDomainTransfer(t *testing.T) {
+	const route = "GET /api/domains/v1/portfolio/minhaempresa.com/transfer"
+	tests := []struct {
+		name    string
+		resp    recordedResponse
+		want    wantErr
+		wantNil bool
+	}{
+		{"failed transfer", recordedResponse{http.StatusOK, "transfer_failed.json"}, wantErr{}, false},
+		{"not in portfolio", respNotInPortfolio, wantErr{}, true},
+		{"unauthorized", respUnauthorized, errUnauthorized, false},
+		{"rate limited", respRateLimited, errRateLimited, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newHostingerFake(t, map[string]recordedResponse{route: tt.resp})
+
+			transfer, err := p.GetDomainTransfer("minhaempresa.com")
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if tt.wantNil {
+				if transfer != nil {
+					t.Errorf("esperava nil, recebeu %+v", transfer)
+				}
+				return
+			}
+			if transfer.Status != DomainTransferStateFailed || transfer.Reason != DomainTransferReasonInvalidAuthCode {
+				t.Errorf("transferência inesperada: %+v", transfer)
+			}
+		})
+	}
+}
+
+func TestGetDomainDetails(t *testing.T) {
+	const route = "GET /api/domains/v1/portfolio/minhaempresa.com.br"
+	tests := []struct {
+		name    string
+		resp    recordedResponse
+		want    wantErr
+		wantNil bool
+	}{
+		{"success", recordedResponse{http.StatusOK, "domain_details.json"}, wantErr{}, false},
+		{"not in portfolio", respNotInPortfolio, wantErr{}, true},
+		{"unauthorized", respUnauthorized, errUnauthorized, false},
+		{"validation error", respValidation, errValidation, false},
+		{"rate limited", respRateLimited, errRateLimited, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newHostingerFake(t, map[string]recordedResponse{route: tt.resp})
+
+			details, err := p.GetDomainDetails("minhaempresa.com.br")
+			checkErr(t, err, tt.want)
+			if err != nil {
+				return
+			}
+
+			if tt.wantNil {
+				if details != nil {
+					t.Errorf("esperava nil, recebeu %+v", details)
+				}
+				return
+			}
+			if details.Status != "active" || !details.IsLocked {
+				t.Errorf("domínio inesperado: %+v", details)
+			}
+			if ns := details.NameserverList(); len(ns) != 2 || ns[0] != "ns1.dresbach.com.br" {
+				t.Errorf("nameservers = %v", ns)
+			}
+			if details.ExpiresAt == nil || details.ExpiresAt.Year() != 2027 {
+				t.Errorf("vencimento = %v", details.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestHostingerRejectsWrongToken(t *testing.T) {
+	fake := &hostingerFake{t: t, routes: map[string]recordedResponse{
+		"POST /api/domains/v1/availability": {http.StatusOK, "availability.json"},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := NewHostingerProvisionerWithURL("token-errado", srv.URL+"/api")
+	_, err := p.CheckDomainAvailability("minhaempresa", []string{"com"})
+	checkErr(t, err, errUnauthorized)
+}
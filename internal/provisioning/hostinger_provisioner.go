@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 const hostingerAPIBaseURL = "https://developers.hostinger.com/api"
@@ -16,49 +17,29 @@ const hostingerAPIBaseURL = "https://developers.hostinger.com/api"
 // HostingerProvisioner implementa a comunicação com a API da Hostinger.
 type HostingerProvisioner struct {
 	apiToken   string
+	baseURL    string
 	httpClient *http.Client
 }
 
-// --- Estruturas Comuns e de Domínio ---
-type HostingerAvailabilityRequest struct {
-	Domains []string `json:"domains"`
+// HostingerAPIError é uma resposta de erro da API da Hostinger.
+type HostingerAPIError struct {
+	StatusCode int
+	Body       string
 }
 
-type HostingerAvailabilityResponse struct {
-	Data map[string]struct {
-		Status string `json:"status"`
-	} `json:"data"`
+func (e *HostingerAPIError) Error() string {
+	return fmt.Sprintf("API retornou status não-OK: %d - %s", e.StatusCode, e.Body)
 }
 
-type WhoisProfile struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Country  string `json:"country"`
-	State    string `json:"state"`
-	City     string `json:"city"`
-	Address  string `json:"address"`
-	Postcode string `json:"postcode"`
-	Phone    string `json:"phone"`
-	TaxID    string `json:"tax_id,omitempty"`
-}
-
-type WhoisProfileResponse struct {
-	ID int `json:"id"`
-}
-
-type DomainPurchaseRequest struct {
-	Domain         string `json:"domain"`
-	WhoisProfileID int    `json:"whois_profile_id"`
-	Period         int    `json:"period"`
-}
-
-type DomainPurchaseResponse struct {
-	OrderID string `json:"order_id"`
+// Temporary informa se a requisição pode ser repetida (limite de requisições ou falha da Hostinger).
+func (e *HostingerAPIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
 }
 
 // --- Estruturas de Catálogo e VPS ---
 
 type CatalogItemPrice struct {
+	ID string `json:"id"` // Item usado na compra (ex: o registro de um domínio por um ano)
 	Period int `json:"period"`
 	PeriodType string `json:"period_type"`
 	Price int `json:"price"`
@@ -106,11 +87,25 @@ func NewHostingerProvisioner() (*HostingerProvisioner, error) {
 	if apiToken == "" {
 		return nil, fmt.Errorf("variável de ambiente HOSTINGER_API_TOKEN não definida")
 	}
-	return &HostingerProvisioner{apiToken: apiToken, httpClient: &http.Client{}}, nil
+	// HOSTINGER_API_URL permite apontar para outro ambiente (ex: um servidor de testes).
+	baseURL := os.Getenv("HOSTINGER_API_URL")
+	if baseURL == "" {
+		baseURL = hostingerAPIBaseURL
+	}
+	return NewHostingerProvisionerWithURL(apiToken, baseURL), nil
+}
+
+// NewHostingerProvisionerWithURL cria o provisionador para a API no endereço informado.
+func NewHostingerProvisionerWithURL(apiToken, baseURL string) *HostingerProvisioner {
+	return &HostingerProvisioner{
+		apiToken:   apiToken,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
 }
 
 func (p *HostingerProvisioner) makeHostingerAPIRequest(method, path string, body interface{}) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", hostingerAPIBaseURL, path)
+	url := fmt.Sprintf("%s%s", p.baseURL, path)
 	var reqBody []byte
 	var err error
 	if body != nil {
@@ -125,6 +120,7 @@ func (p *HostingerProvisioner) makeHostingerAPIRequest(method, path string, body
 	}
 	req.Header.Set("Authorization", "Bearer "+p.apiToken)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("falha ao enviar requisição: %w", err)
@@ -135,16 +131,12 @@ func (p *HostingerProvisioner) makeHostingerAPIRequest(method, path string, body
 		return nil, fmt.Errorf("falha ao ler resposta: %w", err)
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API retornou status não-OK: %d - %s", resp.StatusCode, string(respBody))
+		return nil, &HostingerAPIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 	log.Printf("[Hostinger Provisioner] Resposta da API para '%s %s' recebida.", method, path)
 	return respBody, nil
 }
 
-func (p *HostingerProvisioner) CheckDomainAvailability(domains []string) (*HostingerAvailabilityResponse, error) { /* ... */ return nil, nil }
-func (p *HostingerProvisioner) CreateWhoisProfile(profile WhoisProfile) (*WhoisProfileResponse, error) { /* ... */ return nil, nil }
-func (p *HostingerProvisioner) RegisterDomain(domain string, period int, whoisID int) (*DomainPurchaseResponse, error) { /* ... */ return nil, nil }
-
 func (p *HostingerProvisioner) GetVpsCatalog() ([]VpsPlan, error) {
 	log.Println("[Hostinger Provisioner] Buscando catálogo de produtos VPS...")
 	respBody, err := p.makeHostingerAPIRequest("GET", "/billing/v1/catalog", nil)
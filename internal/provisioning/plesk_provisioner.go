@@ -0,0 +1,216 @@
+package provisioning
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Tempo máximo de uma chamada à API REST do Plesk.
+const pleskRequestTimeout = 2 * time.Minute
+
+// PleskProvisioner implementa o ciclo de vida de contas através da API REST do Plesk (porta 8443).
+// Cada conta corresponde a um cliente do Plesk com uma assinatura no domínio principal.
+type PleskProvisioner struct {
+	host       string
+	login      string // Vazio quando a autenticação é feita por chave de API
+	secret     string // Senha do login ou chave de API
+	httpClient *http.Client
+}
+
+// --- Estruturas da API REST do Plesk ---
+
+type pleskClientRequest struct {
+	Name     string `json:"name"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+	Type     string `json:"type"`
+}
+
+type pleskHostingSettings struct {
+	FTPLogin    string `json:"ftp_login"`
+	FTPPassword string `json:"ftp_password"`
+}
+
+type pleskNamedRef struct {
+	Name  string `json:"name,omitempty"`
+	Login string `json:"login,omitempty"`
+}
+
+type pleskDomainRequest struct {
+	Name            string               `json:"name"`
+	HostingType     string               `json:"hosting_type"`
+	HostingSettings pleskHostingSettings `json:"hosting_settings"`
+	OwnerClient     pleskNamedRef        `json:"owner_client"`
+	Plan            pleskNamedRef        `json:"plan"`
+}
+
+type pleskCLIRequest struct {
+	Params []string `json:"params"`
+}
+
+type pleskCLIResponse struct {
+	Code   int    `json:"code"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+}
+
+type pleskErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// --- Métodos do Provisioner ---
+
+// NewPleskProvisionerWithCredentials cria um PleskProvisioner para um servidor do pool. Com login vazio,
+// o segredo é enviado como chave de API (X-API-Key); caso contrário, é usada autenticação básica.
+func NewPleskProvisionerWithCredentials(host, login, secret string) *PleskProvisioner {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // Cuidado em produção
+	}
+
+	return &PleskProvisioner{
+		host:       host,
+		login:      login,
+		secret:     secret,
+		httpClient: &http.Client{Transport: tr, Timeout: pleskRequestTimeout},
+	}
+}
+
+// Host retorna o hostname do servidor Plesk atendido por este provisionador.
+func (p *PleskProvisioner) Host() string {
+	return p.host
+}
+
+// makePleskAPIRequest constrói e executa uma chamada para a API REST do Plesk e decodifica a resposta em out.
+func (p *PleskProvisioner) makePleskAPIRequest(method, path string, body, out any) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return fmt.Errorf("falha ao encodar o corpo da requisição: %w", err)
+		}
+	}
+
+	apiURL := fmt.Sprintf("https://%s:8443/api/v2%s", p.host, path)
+	req, err := http.NewRequest(method, apiURL, &buf)
+	if err != nil {
+		return fmt.Errorf("falha ao criar requisição para o Plesk: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if p.login == "" {
+		req.Header.Set("X-API-Key", p.secret)
+	} else {
+		req.SetBasicAuth(p.login, p.secret)
+	}
+
+	log.Printf("[Plesk Provisioner] Executando %s %s", method, path)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("falha ao enviar requisição para o Plesk: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("falha ao ler resposta do Plesk: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr pleskErrorResponse
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API do Plesk retornou um erro (status %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API do Plesk retornou status não-OK: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("falha ao decodificar resposta do Plesk: %w", err)
+		}
+	}
+	return nil
+}
+
+// runCLI executa um utilitário de linha de comando do Plesk através do gateway /cli da API REST.
+// Operações sem endpoint REST próprio (suspensão, troca de plano, senha) usam este caminho.
+func (p *PleskProvisioner) runCLI(command string, params ...string) error {
+	var resp pleskCLIResponse
+	if err := p.makePleskAPIRequest("POST", "/cli/"+command+"/call", pleskCLIRequest{Params: params}, &resp); err != nil {
+		return err
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("comando '%s' do Plesk falhou (código %d): %s", command, resp.Code, resp.Stderr)
+	}
+	return nil
+}
+
+// CreateAccount cria o cliente no Plesk e a assinatura do domínio com o plano de serviço informado.
+// Se a assinatura não puder ser criada, o cliente recém-criado é removido.
+func (p *PleskProvisioner) CreateAccount(username, domain, plan, password, email string) error {
+	log.Printf("[Plesk Provisioner] Enviando solicitação para criar conta '%s' com domínio '%s' no plano '%s'", username, domain, plan)
+
+	err := p.makePleskAPIRequest("POST", "/clients", pleskClientRequest{
+		Name:     domain,
+		Login:    username,
+		Password: password,
+		Email:    email,
+		Type:     "customer",
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao criar o cliente no Plesk: %w", err)
+	}
+
+	err = p.makePleskAPIRequest("POST", "/domains", pleskDomainRequest{
+		Name:            domain,
+		HostingType:     "virtual",
+		HostingSettings: pleskHostingSettings{FTPLogin: username, FTPPassword: password},
+		OwnerClient:     pleskNamedRef{Login: username},
+		Plan:            pleskNamedRef{Name: plan},
+	}, nil)
+	if err != nil {
+		if rmErr := p.runCLI("customer", "--remove", username); rmErr != nil {
+			log.Printf("[Plesk Provisioner] ERRO ao remover o cliente '%s' após falha na assinatura: %v", username, rmErr)
+		}
+		return fmt.Errorf("falha ao criar a assinatura no Plesk: %w", err)
+	}
+
+	log.Printf("[Plesk Provisioner] Conta '%s' criada com sucesso no Plesk.", username)
+	return nil
+}
+
+// SuspendAccount suspende a assinatura do domínio. O Plesk não registra o motivo da suspensão.
+func (p *PleskProvisioner) SuspendAccount(account AccountRef, reason string) error {
+	log.Printf("[Plesk Provisioner] Suspendendo a assinatura '%s': %s", account.Domain, reason)
+	return p.runCLI("subscription", "--webspace-off", account.Domain)
+}
+
+// UnsuspendAccount reativa a assinatura do domínio.
+func (p *PleskProvisioner) UnsuspendAccount(account AccountRef) error {
+	return p.runCLI("subscription", "--webspace-on", account.Domain)
+}
+
+// TerminateAccount remove o cliente do Plesk, junto com todas as suas assinaturas.
+func (p *PleskProvisioner) TerminateAccount(account AccountRef) error {
+	return p.runCLI("customer", "--remove", account.Username)
+}
+
+// ChangePlan vincula a assinatura a outro plano de serviço.
+func (p *PleskProvisioner) ChangePlan(account AccountRef, plan string) error {
+	return p.runCLI("subscription", "--switch-subscription", account.Domain, "-service-plan", plan)
+}
+
+// ChangePassword altera a senha do painel do cliente e do usuário de sistema (FTP/SSH) da assinatura.
+func (p *PleskProvisioner) ChangePassword(account AccountRef, password string) error {
+	if err := p.runCLI("customer", "--update", account.Username, "-passwd", password); err != nil {
+		return err
+	}
+	return p.runCLI("subscription", "--update", account.Domain, "-passwd", password)
+}
@@ -0,0 +1,158 @@
+package provisioning
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// whmZoneRecord é um registro retornado pelo dumpzone. Os campos preenchidos dependem do tipo.
+type whmZoneRecord struct {
+	Line       int    `json:"Line"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	TTL        int    `json:"ttl"`
+	Address    string `json:"address"`    // A, AAAA
+	CName      string `json:"cname"`      // CNAME
+	Exchange   string `json:"exchange"`   // MX
+	Preference int    `json:"preference"` // MX
+	TxtData    string `json:"txtdata"`    // TXT
+	Priority   int    `json:"priority"`   // SRV
+	Weight     int    `json:"weight"`     // SRV
+	Port       int    `json:"port"`       // SRV
+	Target     string `json:"target"`     // SRV
+	Flag       int    `json:"flag"`       // CAA
+	Tag        string `json:"tag"`        // CAA
+	Value      string `json:"value"`      // CAA
+	NSDName    string `json:"nsdname"`    // NS
+}
+
+// ZoneRecord é um registro de uma zona DNS do servidor, no formato usado pela API.
+type ZoneRecord struct {
+	Line     int    `json:"line"` // Linha no arquivo da zona; muda quando registros anteriores são removidos
+	Type     string `json:"type"`
+	Name     string `json:"name"` // Nome completo, sem o ponto final
+	TTL      int    `json:"ttl"`
+	Value    string `json:"value"`    // Endereço (A/AAAA), destino (CNAME/MX/SRV/NS), texto (TXT) ou valor (CAA)
+	Priority int    `json:"priority"` // MX e SRV
+	Weight   int    `json:"weight"`   // SRV
+	Port     int    `json:"port"`     // SRV
+	Flag     int    `json:"flag"`     // CAA
+	Tag      string `json:"tag"`      // CAA: issue, issuewild ou iodef
+}
+
+func (r whmZoneRecord) toZoneRecord() ZoneRecord {
+	record := ZoneRecord{
+		Line: r.Line,
+		Type: r.Type,
+		Name: strings.TrimSuffix(r.Name, "."),
+		TTL:  r.TTL,
+	}
+	switch r.Type {
+	case "A", "AAAA":
+		record.Value = r.Address
+	case "CNAME":
+		record.Value = strings.TrimSuffix(r.CName, ".")
+	case "MX":
+		record.Value = strings.TrimSuffix(r.Exchange, ".")
+		record.Priority = r.Preference
+	case "TXT":
+		record.Value = r.TxtData
+	case "SRV":
+		record.Value = strings.TrimSuffix(r.Target, ".")
+		record.Priority, record.Weight, record.Port = r.Priority, r.Weight, r.Port
+	case "CAA":
+		record.Value, record.Flag, record.Tag = r.Value, r.Flag, r.Tag
+	case "NS":
+		record.Value = strings.TrimSuffix(r.NSDName, ".")
+	}
+	return record
+}
+
+// dumpZone lê os registros da zona do domínio (função dumpzone).
+func (p *WhmProvisioner) dumpZone(domain string) ([]whmZoneRecord, error) {
+	params := url.Values{}
+	params.Set("domain", domain)
+
+	var data struct {
+		Zone []struct {
+			Record []whmZoneRecord `json:"record"`
+		} `json:"zone"`
+	}
+	if err := p.callWhmAPI("dumpzone", params, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Zone) == 0 {
+		return nil, nil
+	}
+	return data.Zone[0].Record, nil
+}
+
+// ListZoneRecords lista os registros da zona do domínio. Linhas de controle do arquivo ($TTL,
+// comentários) são omitidas.
+func (p *WhmProvisioner) ListZoneRecords(domain string) ([]ZoneRecord, error) {
+	raw, err := p.dumpZone(domain)
+	if err != nil {
+		return nil, err
+	}
+	records := []ZoneRecord{}
+	for _, r := range raw {
+		if r.Name == "" || r.Type == "" || strings.HasPrefix(r.Type, ":") || strings.HasPrefix(r.Type, "$") {
+			continue
+		}
+		records = append(records, r.toZoneRecord())
+	}
+	return records, nil
+}
+
+// zoneRecordParams monta os parâmetros do addzonerecord/editzonerecord para o registro.
+func zoneRecordParams(domain string, record ZoneRecord) url.Values {
+	params := url.Values{}
+	params.Set("domain", domain)
+	params.Set("name", record.Name+".")
+	params.Set("class", "IN")
+	params.Set("type", record.Type)
+	params.Set("ttl", strconv.Itoa(record.TTL))
+
+	switch record.Type {
+	case "A", "AAAA":
+		params.Set("address", record.Value)
+	case "CNAME":
+		params.Set("cname", record.Value+".")
+	case "MX":
+		params.Set("exchange", record.Value+".")
+		params.Set("preference", strconv.Itoa(record.Priority))
+	case "TXT":
+		params.Set("txtdata", record.Value)
+	case "SRV":
+		params.Set("target", record.Value+".")
+		params.Set("priority", strconv.Itoa(record.Priority))
+		params.Set("weight", strconv.Itoa(record.Weight))
+		params.Set("port", strconv.Itoa(record.Port))
+	case "CAA":
+		params.Set("flag", strconv.Itoa(record.Flag))
+		params.Set("tag", record.Tag)
+		params.Set("value", record.Value)
+	}
+	return params
+}
+
+// AddZoneRecord adiciona um registro à zona do domínio (função addzonerecord).
+func (p *WhmProvisioner) AddZoneRecord(domain string, record ZoneRecord) error {
+	return p.callWhmAPI("addzonerecord", zoneRecordParams(domain, record), nil)
+}
+
+// EditZoneRecord substitui o registro da linha informada (função editzonerecord).
+func (p *WhmProvisioner) EditZoneRecord(domain string, line int, record ZoneRecord) error {
+	params := zoneRecordParams(domain, record)
+	params.Set("line", strconv.Itoa(line))
+	return p.callWhmAPI("editzonerecord", params, nil)
+}
+
+// RemoveZoneRecord remove o registro da linha informada (função removezonerecord).
+func (p *WhmProvisioner) RemoveZoneRecord(domain string, line int) error {
+	params := url.Values{}
+	params.Set("zone", domain)
+	params.Set("line", strconv.Itoa(line))
+	return p.callWhmAPI("removezonerecord", params, nil)
+}
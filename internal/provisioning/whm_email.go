@@ -0,0 +1,255 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uapiNumber é um número da UAPI, que pode vir como número ou string ("unlimited" vira zero).
+type uapiNumber float64
+
+func (n *uapiNumber) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*n = uapiNumber(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		*n = uapiNumber(f)
+	}
+	return nil
+}
+
+// Mailbox é uma conta de e-mail de uma conta cPanel.
+type Mailbox struct {
+	Email             string  `json:"email"`
+	Domain            string  `json:"domain"`
+	DiskUsedMB        float64 `json:"disk_used_mb"`
+	QuotaMB           int     `json:"quota_mb"` // 0: ilimitada
+	SuspendedLogin    bool    `json:"suspended_login"`
+	SuspendedIncoming bool    `json:"suspended_incoming"`
+}
+
+// ListMailboxes lista as contas de e-mail da conta cPanel (UAPI Email::list_pops_with_disk).
+func (p *WhmProvisioner) ListMailboxes(username string) ([]Mailbox, error) {
+	var data []struct {
+		Email             string     `json:"email"`
+		Domain            string     `json:"domain"`
+		DiskUsed          uapiNumber `json:"diskused"`  // MB
+		DiskQuota         uapiNumber `json:"diskquota"` // MB; "unlimited" sem limite
+		SuspendedLogin    uapiNumber `json:"suspended_login"`
+		SuspendedIncoming uapiNumber `json:"suspended_incoming"`
+	}
+	if err := p.callCpanelUAPI(username, "Email", "list_pops_with_disk", nil, &data); err != nil {
+		return nil, err
+	}
+
+	mailboxes := make([]Mailbox, 0, len(data))
+	for _, m := range data {
+		mailboxes = append(mailboxes, Mailbox{
+			Email:             m.Email,
+			Domain:            m.Domain,
+			DiskUsedMB:        float64(m.DiskUsed),
+			QuotaMB:           int(m.DiskQuota),
+			SuspendedLogin:    m.SuspendedLogin == 1,
+			SuspendedIncoming: m.SuspendedIncoming == 1,
+		})
+	}
+	return mailboxes, nil
+}
+
+// splitAddress separa o endereço de e-mail em usuário e domínio.
+func splitAddress(address string) (string, string) {
+	local, domain, _ := strings.Cut(address, "@")
+	return local, domain
+}
+
+// CreateMailbox cria a conta de e-mail com a cota em MB; zero cria a conta sem limite (UAPI Email::add_pop).
+func (p *WhmProvisioner) CreateMailbox(username, address, password string, quotaMB int) error {
+	local, domain := splitAddress(address)
+	params := url.Values{}
+	params.Set("email", local)
+	params.Set("domain", domain)
+	params.Set("password", password)
+	params.Set("quota", strconv.Itoa(quotaMB))
+	return p.postCpanelUAPI(username, "Email", "add_pop", params, nil)
+}
+
+// DeleteMailbox remove a conta de e-mail e as mensagens dela (UAPI Email::delete_pop).
+func (p *WhmProvisioner) DeleteMailbox(username, address string) error {
+	local, domain := splitAddress(address)
+	params := url.Values{}
+	params.Set("email", local)
+	params.Set("domain", domain)
+	return p.callCpanelUAPI(username, "Email", "delete_pop", params, nil)
+}
+
+// ChangeMailboxPassword altera a senha da conta de e-mail (UAPI Email::passwd_pop).
+func (p *WhmProvisioner) ChangeMailboxPassword(username, address, password string) error {
+	local, domain := splitAddress(address)
+	params := url.Values{}
+	params.Set("email", local)
+	params.Set("domain", domain)
+	params.Set("password", password)
+	return p.postCpanelUAPI(username, "Email", "passwd_pop", params, nil)
+}
+
+// ChangeMailboxQuota altera a cota da conta de e-mail em MB; zero remove o limite (UAPI Email::edit_pop_quota).
+func (p *WhmProvisioner) ChangeMailboxQuota(username, address string, quotaMB int) error {
+	local, domain := splitAddress(address)
+	params := url.Values{}
+	params.Set("email", local)
+	params.Set("domain", domain)
+	params.Set("quota", strconv.Itoa(quotaMB))
+	return p.callCpanelUAPI(username, "Email", "edit_pop_quota", params, nil)
+}
+
+// EmailForwarder é um redirecionamento de um endereço para outro.
+type EmailForwarder struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// ListForwarders lista os redirecionamentos de todos os domínios da conta (UAPI Email::list_forwarders).
+func (p *WhmProvisioner) ListForwarders(username string) ([]EmailForwarder, error) {
+	var data []struct {
+		Dest    string `json:"dest"`    // Endereço redirecionado
+		Forward string `json:"forward"` // Destino
+	}
+	if err := p.callCpanelUAPI(username, "Email", "list_forwarders", nil, &data); err != nil {
+		return nil, err
+	}
+
+	forwarders := make([]EmailForwarder, 0, len(data))
+	for _, f := range data {
+		forwarders = append(forwarders, EmailForwarder{Source: f.Dest, Destination: f.Forward})
+	}
+	return forwarders, nil
+}
+
+// AddForwarder redireciona as mensagens de source para destination (UAPI Email::add_forwarder).
+func (p *WhmProvisioner) AddForwarder(username, source, destination string) error {
+	_, domain := splitAddress(source)
+	params := url.Values{}
+	params.Set("domain", domain)
+	params.Set("email", source)
+	params.Set("fwdopt", "fwd")
+	params.Set("fwdemail", destination)
+	return p.callCpanelUAPI(username, "Email", "add_forwarder", params, nil)
+}
+
+// DeleteForwarder remove o redirecionamento de source para destination (UAPI Email::delete_forwarder).
+func (p *WhmProvisioner) DeleteForwarder(username, source, destination string) error {
+	params := url.Values{}
+	params.Set("address", source)
+	params.Set("forwarder", destination)
+	return p.callCpanelUAPI(username, "Email", "delete_forwarder", params, nil)
+}
+
+// Autoresponder é a resposta automática de um endereço de e-mail.
+type Autoresponder struct {
+	Email         string     `json:"email"`
+	From          string     `json:"from"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body,omitempty"`
+	IsHTML        bool       `json:"is_html"`
+	IntervalHours int        `json:"interval_hours"` // Intervalo mínimo entre respostas ao mesmo remetente
+	Start         *time.Time `json:"start,omitempty"`
+	Stop          *time.Time `json:"stop,omitempty"`
+}
+
+// ListAutoresponders lista as respostas automáticas de um domínio da conta (UAPI Email::list_auto_responders).
+// A lista traz apenas o endereço e o assunto.
+func (p *WhmProvisioner) ListAutoresponders(username, domain string) ([]Autoresponder, error) {
+	params := url.Values{}
+	params.Set("domain", domain)
+
+	var data []struct {
+		Email   string `json:"email"`
+		Subject string `json:"subject"`
+	}
+	if err := p.callCpanelUAPI(username, "Email", "list_auto_responders", params, &data); err != nil {
+		return nil, err
+	}
+
+	autoresponders := make([]Autoresponder, 0, len(data))
+	for _, a := range data {
+		autoresponders = append(autoresponders, Autoresponder{Email: a.Email, Subject: a.Subject})
+	}
+	return autoresponders, nil
+}
+
+// GetAutoresponder busca a resposta automática completa do endereço (UAPI Email::get_auto_responder).
+func (p *WhmProvisioner) GetAutoresponder(username, address string) (*Autoresponder, error) {
+	params := url.Values{}
+	params.Set("email", address)
+
+	var data struct {
+		From     string     `json:"from"`
+		Subject  string     `json:"subject"`
+		Body     string     `json:"body"`
+		IsHTML   uapiNumber `json:"is_html"`
+		Interval uapiNumber `json:"interval"`
+		Start    uapiNumber `json:"start"`
+		Stop     uapiNumber `json:"stop"`
+	}
+	if err := p.callCpanelUAPI(username, "Email", "get_auto_responder", params, &data); err != nil {
+		return nil, err
+	}
+
+	autoresponder := &Autoresponder{
+		Email:         address,
+		From:          data.From,
+		Subject:       data.Subject,
+		Body:          data.Body,
+		IsHTML:        data.IsHTML == 1,
+		IntervalHours: int(data.Interval),
+	}
+	if data.Start > 0 {
+		start := time.Unix(int64(data.Start), 0)
+		autoresponder.Start = &start
+	}
+	if data.Stop > 0 {
+		stop := time.Unix(int64(data.Stop), 0)
+		autoresponder.Stop = &stop
+	}
+	return autoresponder, nil
+}
+
+// SaveAutoresponder cria ou substitui a resposta automática do endereço (UAPI Email::add_auto_responder).
+func (p *WhmProvisioner) SaveAutoresponder(username string, a Autoresponder) error {
+	local, domain := splitAddress(a.Email)
+	params := url.Values{}
+	params.Set("email", local)
+	params.Set("domain", domain)
+	params.Set("from", a.From)
+	params.Set("subject", a.Subject)
+	params.Set("body", a.Body)
+	params.Set("charset", "utf-8")
+	params.Set("interval", strconv.Itoa(a.IntervalHours))
+	if a.IsHTML {
+		params.Set("is_html", "1")
+	} else {
+		params.Set("is_html", "0")
+	}
+	if a.Start != nil {
+		params.Set("start", strconv.FormatInt(a.Start.Unix(), 10))
+	}
+	if a.Stop != nil {
+		params.Set("stop", strconv.FormatInt(a.Stop.Unix(), 10))
+	}
+	return p.postCpanelUAPI(username, "Email", "add_auto_responder", params, nil)
+}
+
+// DeleteAutoresponder remove a resposta automática do endereço (UAPI Email::delete_auto_responder).
+func (p *WhmProvisioner) DeleteAutoresponder(username, address string) error {
+	params := url.Values{}
+	params.Set("email", address)
+	return p.callCpanelUAPI(username, "Email", "delete_auto_responder", params, nil)
+}
@@ -4,13 +4,19 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
+// Tempo máximo de uma chamada à API do WHM. A criação de contas pode levar mais de um minuto.
+const whmRequestTimeout = 2 * time.Minute
+
 // WhmProvisioner implementa a comunicação com a API do WHM/cPanel.
 type WhmProvisioner struct {
 	whmHost    string
@@ -41,6 +47,12 @@ func NewWhmProvisioner() (*WhmProvisioner, error) {
 		return nil, fmt.Errorf("as variáveis de ambiente WHM_HOST, WHM_USER e WHM_API_TOKEN devem estar definidas")
 	}
 
+	return NewWhmProvisionerWithCredentials(whmHost, whmUser, apiToken), nil
+}
+
+// NewWhmProvisionerWithCredentials cria um WhmProvisioner para um servidor específico do pool,
+// com as credenciais lidas de api_credentials.
+func NewWhmProvisionerWithCredentials(whmHost, whmUser, apiToken string) *WhmProvisioner {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // Cuidado em produção
 	}
@@ -49,18 +61,38 @@ func NewWhmProvisioner() (*WhmProvisioner, error) {
 		whmHost:    whmHost,
 		whmUser:    whmUser,
 		apiToken:   apiToken,
-		httpClient: &http.Client{Transport: tr},
-	}, nil
+		httpClient: &http.Client{Transport: tr, Timeout: whmRequestTimeout},
+	}
+}
+
+// Host retorna o hostname do servidor WHM atendido por este provisionador.
+func (p *WhmProvisioner) Host() string {
+	return p.whmHost
 }
 
 // makeWhmAPIRequest constrói e executa uma chamada para a API do WHM.
 func (p *WhmProvisioner) makeWhmAPIRequest(function string, params url.Values) ([]byte, error) {
-	apiURL := fmt.Sprintf("https://%s:2087/json-api/%s?api.version=1&%s", p.whmHost, function, params.Encode())
+	return p.sendWhmAPIRequest(http.MethodGet, function, params)
+}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+// sendWhmAPIRequest envia a chamada com o método informado. No POST, os parâmetros vão no corpo do
+// formulário, o que permite valores grandes como certificados e chaves privadas.
+func (p *WhmProvisioner) sendWhmAPIRequest(method, function string, params url.Values) ([]byte, error) {
+	apiURL := fmt.Sprintf("https://%s:2087/json-api/%s?api.version=1", p.whmHost, function)
+	var body io.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(params.Encode())
+	} else {
+		apiURL += "&" + params.Encode()
+	}
+
+	req, err := http.NewRequest(method, apiURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("falha ao criar requisição para o WHM: %w", err)
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
 
 	authHeader := fmt.Sprintf("whm %s:%s", p.whmUser, p.apiToken)
 	req.Header.Set("Authorization", authHeader)
@@ -87,7 +119,7 @@ func (p *WhmProvisioner) makeWhmAPIRequest(function string, params url.Values) (
 }
 
 // CreateAccount cria uma nova conta de hospedagem no servidor cPanel via API do WHM.
-func (p *WhmProvisioner) CreateAccount(username, domain, plan, password, email string) (*CreateAccountResponse, error) {
+func (p *WhmProvisioner) CreateAccount(username, domain, plan, password, email string) error {
 	params := url.Values{}
 	params.Set("username", username)
 	params.Set("domain", domain)
@@ -99,20 +131,58 @@ func (p *WhmProvisioner) CreateAccount(username, domain, plan, password, email s
 
 	respBody, err := p.makeWhmAPIRequest("createacct", params)
 	if err != nil {
-		return nil, fmt.Errorf("falha na chamada da API para criar conta: %w", err)
+		return fmt.Errorf("falha na chamada da API para criar conta: %w", err)
 	}
 
 	var response CreateAccountResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta da criação da conta: %w - Resposta: %s", err, string(respBody))
+		return fmt.Errorf("falha ao decodificar resposta da criação da conta: %w - Resposta: %s", err, string(respBody))
 	}
 
 	// A API do WHM retorna 'result: 1' em caso de sucesso.
 	if response.Metadata.Result != 1 {
 		log.Printf("[WHM Provisioner] Erro ao criar conta '%s': %s", username, response.Metadata.Reason)
-		return nil, fmt.Errorf("WHM API retornou um erro: %s", response.Metadata.Reason)
+		return fmt.Errorf("WHM API retornou um erro: %s", response.Metadata.Reason)
 	}
 
 	log.Printf("[WHM Provisioner] Conta '%s' criada com sucesso no cPanel.", username)
-	return &response, nil
+	return nil
+}
+
+// SuspendAccount suspende a conta no cPanel (função suspendacct).
+func (p *WhmProvisioner) SuspendAccount(account AccountRef, reason string) error {
+	params := url.Values{}
+	params.Set("user", account.Username)
+	params.Set("reason", reason)
+	return p.callWhmAPI("suspendacct", params, nil)
+}
+
+// UnsuspendAccount reativa uma conta suspensa (função unsuspendacct).
+func (p *WhmProvisioner) UnsuspendAccount(account AccountRef) error {
+	params := url.Values{}
+	params.Set("user", account.Username)
+	return p.callWhmAPI("unsuspendacct", params, nil)
+}
+
+// TerminateAccount remove a conta do servidor (função removeacct).
+func (p *WhmProvisioner) TerminateAccount(account AccountRef) error {
+	params := url.Values{}
+	params.Set("username", account.Username)
+	return p.callWhmAPI("removeacct", params, nil)
+}
+
+// ChangePlan altera o pacote da conta (função changepackage).
+func (p *WhmProvisioner) ChangePlan(account AccountRef, plan string) error {
+	params := url.Values{}
+	params.Set("user", account.Username)
+	params.Set("pkg", plan)
+	return p.callWhmAPI("changepackage", params, nil)
+}
+
+// ChangePassword altera a senha da conta (função passwd).
+func (p *WhmProvisioner) ChangePassword(account AccountRef, password string) error {
+	params := url.Values{}
+	params.Set("user", account.Username)
+	params.Set("password", password)
+	return p.callWhmAPI("passwd", params, nil)
 }
@@ -0,0 +1,145 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// --- Estruturas da API WHM para consulta do servidor ---
+
+// whmMetadata é o bloco de metadados presente em todas as respostas da API 1 do WHM.
+type whmMetadata struct {
+	Result  int    `json:"result"`
+	Reason  string `json:"reason"`
+	Command string `json:"command"`
+}
+
+// WhmLoadAverage é a carga média do servidor em 1, 5 e 15 minutos.
+type WhmLoadAverage struct {
+	One     float64
+	Five    float64
+	Fifteen float64
+}
+
+// WhmDiskPartition é uma partição retornada pelo getdiskusage. Os tamanhos estão em blocos de 1 KiB.
+type WhmDiskPartition struct {
+	Filesystem string `json:"filesystem"`
+	Mount      string `json:"mount"`
+	Total      int64  `json:"total"`
+	Used       int64  `json:"used"`
+	Available  int64  `json:"available"`
+	Percentage int    `json:"percentage"`
+}
+
+// WhmAccount é uma conta de hospedagem retornada pelo listaccts.
+type WhmAccount struct {
+	User      string `json:"user"`
+	Domain    string `json:"domain"`
+	Email     string `json:"email"`
+	Plan      string `json:"plan"`
+	IP        string `json:"ip"`
+	Owner     string `json:"owner"`
+	Suspended int    `json:"suspended"`
+	DiskUsed  string `json:"diskused"`
+	StartDate string `json:"startdate"`
+	UnixStart int64  `json:"unix_startdate"`
+}
+
+// WhmAPIError é uma resposta da API do WHM com result diferente de 1, com o motivo informado pelo servidor.
+type WhmAPIError struct {
+	Function string
+	Reason   string
+}
+
+func (e *WhmAPIError) Error() string {
+	return fmt.Sprintf("WHM API retornou um erro em '%s': %s", e.Function, e.Reason)
+}
+
+// callWhmAPI executa uma função da API 1 do WHM, verifica o resultado e decodifica o bloco "data" em out.
+func (p *WhmProvisioner) callWhmAPI(function string, params url.Values, out any) error {
+	return p.doWhmAPI(http.MethodGet, function, params, out)
+}
+
+// postWhmAPI é o callWhmAPI com os parâmetros enviados por POST.
+func (p *WhmProvisioner) postWhmAPI(function string, params url.Values, out any) error {
+	return p.doWhmAPI(http.MethodPost, function, params, out)
+}
+
+func (p *WhmProvisioner) doWhmAPI(method, function string, params url.Values, out any) error {
+	respBody, err := p.sendWhmAPIRequest(method, function, params)
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Metadata whmMetadata     `json:"metadata"`
+		Data     json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("falha ao decodificar resposta de '%s': %w", function, err)
+	}
+	if envelope.Metadata.Result != 1 {
+		return &WhmAPIError{Function: function, Reason: envelope.Metadata.Reason}
+	}
+
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("falha ao decodificar os dados de '%s': %w", function, err)
+		}
+	}
+	return nil
+}
+
+// LoadAverage consulta a carga média do servidor (função loadavg).
+func (p *WhmProvisioner) LoadAverage() (*WhmLoadAverage, error) {
+	var data struct {
+		One     string `json:"one"`
+		Five    string `json:"five"`
+		Fifteen string `json:"fifteen"`
+	}
+	if err := p.callWhmAPI("loadavg", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+
+	// O WHM retorna os valores como texto.
+	one, _ := strconv.ParseFloat(data.One, 64)
+	five, _ := strconv.ParseFloat(data.Five, 64)
+	fifteen, _ := strconv.ParseFloat(data.Fifteen, 64)
+	return &WhmLoadAverage{One: one, Five: five, Fifteen: fifteen}, nil
+}
+
+// DiskUsage consulta o uso das partições do servidor (função getdiskusage).
+func (p *WhmProvisioner) DiskUsage() ([]WhmDiskPartition, error) {
+	var data struct {
+		Partition []WhmDiskPartition `json:"partition"`
+	}
+	if err := p.callWhmAPI("getdiskusage", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+	return data.Partition, nil
+}
+
+// ListAccounts lista as contas de hospedagem do servidor (função listaccts).
+func (p *WhmProvisioner) ListAccounts() ([]WhmAccount, error) {
+	var data struct {
+		Acct []WhmAccount `json:"acct"`
+	}
+	if err := p.callWhmAPI("listaccts", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+	return data.Acct, nil
+}
+
+// Version consulta a versão do cPanel & WHM instalada no servidor (função version).
+func (p *WhmProvisioner) Version() (string, error) {
+	var data struct {
+		Version string `json:"version"`
+	}
+	if err := p.callWhmAPI("version", url.Values{}, &data); err != nil {
+		return "", err
+	}
+	return data.Version, nil
+}
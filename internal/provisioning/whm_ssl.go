@@ -0,0 +1,135 @@
+package provisioning
+
+import (
+	"net/url"
+	"time"
+)
+
+// whmSSLVhost é um virtual host com SSL retornado pelo fetch_ssl_vhosts.
+type whmSSLVhost struct {
+	ServerName string   `json:"servername"`
+	User       string   `json:"user"`
+	Domains    []string `json:"domains"`
+	Crt        *struct {
+		Domains            []string `json:"domains"`
+		IssuerOrganization string   `json:"issuer.organizationName"`
+		IssuerCommonName   string   `json:"issuer.commonName"`
+		NotBefore          int64    `json:"not_before"`
+		NotAfter           int64    `json:"not_after"`
+		IsSelfSigned       int      `json:"is_self_signed"`
+	} `json:"crt"`
+}
+
+// SSLVhost é um virtual host do servidor e o certificado instalado nele.
+type SSLVhost struct {
+	ServerName  string
+	User        string
+	Domains     []string // Domínios atendidos pelo virtual host
+	Certificate *SSLCertificateInfo
+}
+
+// SSLCertificateInfo são os dados de um certificado instalado.
+type SSLCertificateInfo struct {
+	Domains            []string // Nomes cobertos (SAN), podendo incluir curingas
+	IssuerOrganization string
+	IssuerCommonName   string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	SelfSigned         bool
+}
+
+// Issuer retorna o nome de exibição do emissor do certificado.
+func (c *SSLCertificateInfo) Issuer() string {
+	if c.IssuerOrganization != "" {
+		return c.IssuerOrganization
+	}
+	return c.IssuerCommonName
+}
+
+// FetchSSLVhosts lista os virtual hosts com SSL de todas as contas do servidor (função fetch_ssl_vhosts).
+func (p *WhmProvisioner) FetchSSLVhosts() ([]SSLVhost, error) {
+	var data struct {
+		Vhosts []whmSSLVhost `json:"vhosts"`
+	}
+	if err := p.callWhmAPI("fetch_ssl_vhosts", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+
+	vhosts := make([]SSLVhost, 0, len(data.Vhosts))
+	for _, v := range data.Vhosts {
+		vhost := SSLVhost{ServerName: v.ServerName, User: v.User, Domains: v.Domains}
+		if v.Crt != nil && v.Crt.NotAfter > 0 {
+			vhost.Certificate = &SSLCertificateInfo{
+				Domains:            v.Crt.Domains,
+				IssuerOrganization: v.Crt.IssuerOrganization,
+				IssuerCommonName:   v.Crt.IssuerCommonName,
+				NotBefore:          time.Unix(v.Crt.NotBefore, 0),
+				NotAfter:           time.Unix(v.Crt.NotAfter, 0),
+				SelfSigned:         v.Crt.IsSelfSigned == 1,
+			}
+		}
+		vhosts = append(vhosts, vhost)
+	}
+	return vhosts, nil
+}
+
+// AutoSSLProblem é um problema registrado pelo AutoSSL na última verificação de um domínio.
+type AutoSSLProblem struct {
+	Domain  string `json:"domain"`
+	Problem string `json:"problem"`
+	Time    string `json:"time"`
+}
+
+// AutoSSLProblems lista os problemas registrados nos logs do AutoSSL para a conta
+// (função get_autossl_problems_for_user).
+func (p *WhmProvisioner) AutoSSLProblems(username string) ([]AutoSSLProblem, error) {
+	params := url.Values{}
+	params.Set("username", username)
+
+	var data struct {
+		Payload []AutoSSLProblem `json:"payload"`
+	}
+	if err := p.callWhmAPI("get_autossl_problems_for_user", params, &data); err != nil {
+		return nil, err
+	}
+	return data.Payload, nil
+}
+
+// StartAutoSSL inicia em segundo plano uma verificação do AutoSSL para a conta
+// (função start_autossl_check_for_one_user).
+func (p *WhmProvisioner) StartAutoSSL(username string) error {
+	params := url.Values{}
+	params.Set("username", username)
+	return p.callWhmAPI("start_autossl_check_for_one_user", params, nil)
+}
+
+// InstallSSL instala um certificado no domínio da conta (UAPI SSL::install_ssl). O certificado
+// substitui o do virtual host do domínio, inclusive um emitido pelo AutoSSL.
+func (p *WhmProvisioner) InstallSSL(username, domain, certificate, privateKey, caBundle string) error {
+	params := url.Values{}
+	params.Set("domain", domain)
+	params.Set("cert", certificate)
+	params.Set("key", privateKey)
+	if caBundle != "" {
+		params.Set("cabundle", caBundle)
+	}
+	return p.postCpanelUAPI(username, "SSL", "install_ssl", params, nil)
+}
+
+// WhmDomain é um domínio de uma conta do servidor retornado pelo get_domain_info.
+type WhmDomain struct {
+	Domain     string `json:"domain"`
+	User       string `json:"user"`
+	DomainType string `json:"domain_type"` // main, addon, parked ou sub
+}
+
+// ListServerDomains lista os domínios de todas as contas do servidor (função get_domain_info).
+func (p *WhmProvisioner) ListServerDomains() ([]WhmDomain, error) {
+	var data struct {
+		Domains []WhmDomain `json:"domains"`
+	}
+	if err := p.callWhmAPI("get_domain_info", url.Values{}, &data); err != nil {
+		return nil, err
+	}
+	return data.Domains, nil
+}
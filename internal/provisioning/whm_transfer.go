@@ -0,0 +1,146 @@
+package provisioning
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Estados de uma sessão do Transfer Tool do WHM (get_transfer_session_state).
+const (
+	WhmTransferStateCompleted = "COMPLETED"
+	WhmTransferStateFailed    = "FAILED"
+	WhmTransferStateAborted   = "ABORTED"
+)
+
+// WhmRemoteAccess são as credenciais SSH do servidor de origem usadas pelo Transfer Tool.
+// Informe a senha ou o nome de uma chave SSH já instalada no servidor de destino.
+type WhmRemoteAccess struct {
+	Host       string
+	User       string
+	Password   string
+	SSHKeyName string
+}
+
+// CreateRemoteTransferSession cria, neste servidor (destino), uma sessão do Transfer Tool que copia
+// contas do servidor remoto (função create_remote_root_transfer_session).
+func (p *WhmProvisioner) CreateRemoteTransferSession(remote WhmRemoteAccess) (string, error) {
+	params := url.Values{}
+	params.Set("remote_server_type", "auto")
+	params.Set("host", remote.Host)
+	params.Set("port", "22")
+	params.Set("user", remote.User)
+	if remote.SSHKeyName != "" {
+		params.Set("sshkey_name", remote.SSHKeyName)
+	} else {
+		params.Set("password", remote.Password)
+	}
+
+	var data struct {
+		TransferSessionID string `json:"transfer_session_id"`
+	}
+	if err := p.callWhmAPI("create_remote_root_transfer_session", params, &data); err != nil {
+		return "", err
+	}
+	if data.TransferSessionID == "" {
+		return "", fmt.Errorf("o WHM não retornou o ID da sessão de transferência")
+	}
+	return data.TransferSessionID, nil
+}
+
+// EnqueueAccountTransfer adiciona uma conta à sessão de transferência. O módulo AccountRemoteRoot
+// empacota a conta na origem (pkgacct), copia o pacote e o restaura neste servidor.
+func (p *WhmProvisioner) EnqueueAccountTransfer(sessionID, username, domain string) error {
+	params := url.Values{}
+	params.Set("transfer_session_id", sessionID)
+	params.Set("module", "AccountRemoteRoot")
+	params.Set("user", username)
+	params.Set("localuser", username)
+	params.Set("domain", domain)
+	return p.callWhmAPI("enqueue_transfer_item", params, nil)
+}
+
+// StartTransferSession inicia a execução de uma sessão de transferência.
+func (p *WhmProvisioner) StartTransferSession(sessionID string) error {
+	params := url.Values{}
+	params.Set("transfer_session_id", sessionID)
+	return p.callWhmAPI("start_transfer_session", params, nil)
+}
+
+// AbortTransferSession interrompe uma sessão de transferência (função abort_transfer_session).
+func (p *WhmProvisioner) AbortTransferSession(sessionID string) error {
+	params := url.Values{}
+	params.Set("transfer_session_id", sessionID)
+	return p.callWhmAPI("abort_transfer_session", params, nil)
+}
+
+// TransferSessionState consulta o estado de uma sessão de transferência (ex: RUNNING, COMPLETED, FAILED).
+func (p *WhmProvisioner) TransferSessionState(sessionID string) (string, error) {
+	params := url.Values{}
+	params.Set("transfer_session_id", sessionID)
+
+	var data struct {
+		State string `json:"state"`
+	}
+	if err := p.callWhmAPI("get_transfer_session_state", params, &data); err != nil {
+		return "", err
+	}
+	return data.State, nil
+}
+
+// AccountSummary consulta os dados de uma conta (função accountsummary). Retorna nil se a conta não existir.
+func (p *WhmProvisioner) AccountSummary(username string) (*WhmAccount, error) {
+	params := url.Values{}
+	params.Set("user", username)
+
+	var data struct {
+		Acct []WhmAccount `json:"acct"`
+	}
+	if err := p.callWhmAPI("accountsummary", params, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Acct) == 0 {
+		return nil, nil
+	}
+	return &data.Acct[0], nil
+}
+
+// ZoneExists informa se o servidor tem a zona DNS do domínio. O WHM recusa o dumpzone de zonas
+// inexistentes (result 0), o que também é tratado como ausência da zona.
+func (p *WhmProvisioner) ZoneExists(domain string) (bool, error) {
+	records, err := p.dumpZone(domain)
+	if err != nil {
+		var apiErr *WhmAPIError
+		if errors.As(err, &apiErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(records) > 0, nil
+}
+
+// SwapZoneAddress altera para newIP todos os registros A da zona que apontam para oldIP
+// e retorna a quantidade de registros alterados.
+func (p *WhmProvisioner) SwapZoneAddress(domain, oldIP, newIP string) (int, error) {
+	records, err := p.dumpZone(domain)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, record := range records {
+		if record.Type != "A" || record.Address != oldIP {
+			continue
+		}
+		edit := url.Values{}
+		edit.Set("domain", domain)
+		edit.Set("line", strconv.Itoa(record.Line))
+		edit.Set("address", newIP)
+		if err := p.callWhmAPI("editzonerecord", edit, nil); err != nil {
+			return changed, fmt.Errorf("falha ao alterar o registro '%s' (linha %d): %w", record.Name, record.Line, err)
+		}
+		changed++
+	}
+	return changed, nil
+}
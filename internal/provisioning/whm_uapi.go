@@ -0,0 +1,85 @@
+package provisioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// callCpanelUAPI executa uma função da UAPI do cPanel em nome da conta username, através do WHM
+// (função uapi_cpanel), e decodifica o bloco "data" da UAPI em out.
+func (p *WhmProvisioner) callCpanelUAPI(username, module, function string, params url.Values, out any) error {
+	return p.doCpanelUAPI(p.callWhmAPI, username, module, function, params, out)
+}
+
+// postCpanelUAPI é o callCpanelUAPI com os parâmetros enviados por POST.
+func (p *WhmProvisioner) postCpanelUAPI(username, module, function string, params url.Values, out any) error {
+	return p.doCpanelUAPI(p.postWhmAPI, username, module, function, params, out)
+}
+
+func (p *WhmProvisioner) doCpanelUAPI(call func(string, url.Values, any) error, username, module, function string, params url.Values, out any) error {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("cpanel.user", username)
+	query.Set("cpanel.module", module)
+	query.Set("cpanel.function", function)
+
+	var data struct {
+		UAPI struct {
+			Status int             `json:"status"`
+			Errors []string        `json:"errors"`
+			Data   json.RawMessage `json:"data"`
+		} `json:"uapi"`
+	}
+	if err := call("uapi_cpanel", query, &data); err != nil {
+		return err
+	}
+
+	name := module + "::" + function
+	if data.UAPI.Status != 1 {
+		reason := strings.Join(data.UAPI.Errors, "; ")
+		if reason == "" {
+			reason = "falha sem motivo informado"
+		}
+		return &WhmAPIError{Function: name, Reason: reason}
+	}
+	if out != nil && len(data.UAPI.Data) > 0 && string(data.UAPI.Data) != "null" {
+		if err := json.Unmarshal(data.UAPI.Data, out); err != nil {
+			return fmt.Errorf("falha ao decodificar os dados de '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CpanelAccountDomains são os domínios de uma conta cPanel (DomainInfo::list_domains).
+type CpanelAccountDomains struct {
+	MainDomain    string   `json:"main_domain"`
+	AddonDomains  []string `json:"addon_domains"`
+	ParkedDomains []string `json:"parked_domains"`
+	SubDomains    []string `json:"sub_domains"`
+}
+
+// Zones retorna os domínios com zona DNS própria: o principal, os adicionais e os estacionados.
+// Os subdomínios ficam na zona do domínio pai.
+func (d *CpanelAccountDomains) Zones() []string {
+	zones := []string{d.MainDomain}
+	zones = append(zones, d.AddonDomains...)
+	return append(zones, d.ParkedDomains...)
+}
+
+// All retorna todos os domínios da conta, incluindo os subdomínios.
+func (d *CpanelAccountDomains) All() []string {
+	return append(d.Zones(), d.SubDomains...)
+}
+
+// ListAccountDomains lista os domínios da conta cPanel.
+func (p *WhmProvisioner) ListAccountDomains(username string) (*CpanelAccountDomains, error) {
+	var domains CpanelAccountDomains
+	if err := p.callCpanelUAPI(username, "DomainInfo", "list_domains", nil, &domains); err != nil {
+		return nil, err
+	}
+	return &domains, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+// ErrServerNotFound indica que o servidor informado não existe.
+var ErrServerNotFound = errors.New("servidor não encontrado")
+
+// ErrImportNotSupported indica que o servidor não é um servidor cPanel/WHM.
+var ErrImportNotSupported = errors.New("a importação de contas está disponível apenas para servidores cPanel")
+
+// Ações possíveis para cada conta encontrada no servidor durante a importação.
+const (
+	ImportActionCreate    = "create"    // Serviço criado (ou a ser criado, no modo simulação)
+	ImportActionSkip      = "skip"      // A conta já possui serviço cadastrado
+	ImportActionUnmatched = "unmatched" // Nenhum cliente ou plano correspondente
+	ImportActionError     = "error"     // Falha ao gravar o serviço
+)
+
+// AccountImportItem descreve o resultado da importação de uma conta do WHM.
+type AccountImportItem struct {
+	Username  string               `json:"username"`
+	Domain    string               `json:"domain"`
+	Email     string               `json:"email"`
+	Package   string               `json:"package"`
+	Suspended bool                 `json:"suspended"`
+	Action    string               `json:"action"`
+	MatchedBy string               `json:"matched_by,omitempty"` // email ou domain
+	UserID    int                  `json:"user_id,omitempty"`
+	PlanID    int                  `json:"plan_id,omitempty"`
+	PlanName  string               `json:"plan_name,omitempty"`
+	Status    models.ServiceStatus `json:"status,omitempty"`
+	ServiceID int                  `json:"service_id,omitempty"`
+	Reason    string               `json:"reason,omitempty"`
+}
+
+// AccountImportReport é o resultado de uma importação. No modo simulação (DryRun), os itens com
+// ação "create" formam o diff do que seria gravado, sem nenhuma alteração no banco.
+type AccountImportReport struct {
+	ServerID  int                 `json:"server_id"`
+	DryRun    bool                `json:"dry_run"`
+	Total     int                 `json:"total"`
+	Created   int                 `json:"created"`
+	Skipped   int                 `json:"skipped"`
+	Unmatched int                 `json:"unmatched"`
+	Failed    int                 `json:"failed"`
+	Items     []AccountImportItem `json:"items"`
+}
+
+// AccountImporter importa para a plataforma as contas cPanel criadas diretamente no WHM.
+type AccountImporter struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewAccountImporter cria uma nova instância de AccountImporter.
+func NewAccountImporter(db *sql.DB) *AccountImporter {
+	return &AccountImporter{db: db, placement: NewServerPlacement(db, nil)}
+}
+
+// Import lista as contas do servidor (listaccts) e cria um serviço para cada conta ainda não cadastrada,
+// associando-a ao cliente pelo e-mail de contato ou pelo domínio e ao plano pelo nome do pacote.
+func (i *AccountImporter) Import(serverID int, dryRun bool, adminID int, ipAddress string) (*AccountImportReport, error) {
+	server, err := models.GetServerByID(i.db, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o servidor: %w", err)
+	}
+	if server == nil {
+		return nil, ErrServerNotFound
+	}
+
+	provisioner, err := i.placement.ProvisionerFor(server)
+	if err != nil {
+		return nil, err
+	}
+	whm, ok := provisioner.(*provisioning.WhmProvisioner)
+	if !ok {
+		return nil, ErrImportNotSupported
+	}
+
+	accounts, err := whm.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar as contas do servidor '%s': %w", server.Name, err)
+	}
+
+	existing, err := models.GetServiceUsernamesByServer(i.db, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar os serviços já cadastrados: %w", err)
+	}
+
+	report := &AccountImportReport{ServerID: serverID, DryRun: dryRun, Total: len(accounts)}
+	plans := make(map[string]*models.Plan)
+
+	for _, account := range accounts {
+		item := i.importAccount(server, account, existing, plans, dryRun)
+		switch item.Action {
+		case ImportActionCreate:
+			report.Created++
+		case ImportActionSkip:
+			report.Skipped++
+		case ImportActionUnmatched:
+			report.Unmatched++
+		case ImportActionError:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	if !dryRun {
+		log.Printf("[Account Import] Servidor '%s': %d contas, %d importadas, %d já cadastradas, %d sem correspondência, %d falhas.",
+			server.Name, report.Total, report.Created, report.Skipped, report.Unmatched, report.Failed)
+		recordAuditLog(i.db, adminID, ipAddress, "server.import_accounts", "server", strconv.Itoa(serverID), nil,
+			map[string]any{"total": report.Total, "created": report.Created, "skipped": report.Skipped, "unmatched": report.Unmatched, "failed": report.Failed},
+			models.AuditLogResultSuccess)
+	}
+
+	return report, nil
+}
+
+func (i *AccountImporter) importAccount(server *models.Server, account provisioning.WhmAccount, existing map[string]int, plans map[string]*models.Plan, dryRun bool) AccountImportItem {
+	item := AccountImportItem{
+		Username:  account.User,
+		Domain:    account.Domain,
+		Email:     account.Email,
+		Package:   account.Plan,
+		Suspended: account.Suspended == 1,
+	}
+
+	if serviceID, ok := existing[account.User]; ok {
+		item.Action = ImportActionSkip
+		item.ServiceID = serviceID
+		item.Reason = "a conta já possui serviço cadastrado"
+		return item
+	}
+
+	userID, matchedBy, err := i.matchClient(account)
+	if err != nil {
+		item.Action = ImportActionError
+		item.Reason = err.Error()
+		return item
+	}
+	if userID == 0 {
+		item.Action = ImportActionUnmatched
+		item.Reason = "nenhum cliente com o e-mail de contato ou o domínio da conta"
+		return item
+	}
+	item.UserID = userID
+	item.MatchedBy = matchedBy
+
+	plan, err := i.planForPackage(account.Plan, plans)
+	if err != nil {
+		item.Action = ImportActionError
+		item.Reason = err.Error()
+		return item
+	}
+	if plan == nil {
+		item.Action = ImportActionUnmatched
+		item.Reason = fmt.Sprintf("nenhum plano cadastrado para o pacote '%s'", account.Plan)
+		return item
+	}
+	item.PlanID = plan.ID
+	item.PlanName = plan.Name
+
+	item.Status = models.ServiceStatusActive
+	if item.Suspended {
+		item.Status = models.ServiceStatusSuspended
+	}
+	item.Action = ImportActionCreate
+
+	if dryRun {
+		return item
+	}
+
+	service := &models.Service{
+		UserID:       userID,
+		ProductID:    plan.ID,
+		Domain:       account.Domain,
+		CpanelUser:   account.User,
+		Status:       item.Status,
+		Price:        plan.Price,
+		BillingCycle: plan.BillingCycle,
+		NextDueDate:  plan.BillingCycle.NextDueDate(time.Now()),
+		ServerID:     sql.NullInt64{Int64: int64(server.ID), Valid: true},
+	}
+	id, err := models.CreateService(i.db, service)
+	if err != nil {
+		item.Action = ImportActionError
+		item.Reason = fmt.Sprintf("falha ao registrar o serviço: %v", err)
+		return item
+	}
+	item.ServiceID = int(id)
+	existing[account.User] = int(id)
+	return item
+}
+
+// matchClient associa a conta a um cliente, primeiro pelo e-mail de contato e depois pelo domínio.
+func (i *AccountImporter) matchClient(account provisioning.WhmAccount) (int, string, error) {
+	if email := strings.TrimSpace(account.Email); email != "" && email != "*unknown*" {
+		client, err := models.GetClientByEmail(i.db, email)
+		if err != nil {
+			return 0, "", fmt.Errorf("falha ao buscar o cliente pelo e-mail: %w", err)
+		}
+		if client != nil {
+			return client.UserID, "email", nil
+		}
+	}
+
+	userID, err := models.FindClientUserIDByDomain(i.db, account.Domain)
+	if err != nil {
+		return 0, "", fmt.Errorf("falha ao buscar o cliente pelo domínio: %w", err)
+	}
+	if userID != 0 {
+		return userID, "domain", nil
+	}
+	return 0, "", nil
+}
+
+// planForPackage busca o plano do pacote do WHM, usando o cache da importação em andamento.
+func (i *AccountImporter) planForPackage(packageName string, plans map[string]*models.Plan) (*models.Plan, error) {
+	if plan, ok := plans[packageName]; ok {
+		return plan, nil
+	}
+	plan, err := models.GetPlanByWhmPackageName(i.db, packageName)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o plano do pacote '%s': %w", packageName, err)
+	}
+	plans[packageName] = plan
+	return plan, nil
+}
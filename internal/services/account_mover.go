@@ -0,0 +1,424 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/utils"
+)
+
+var (
+	ErrAccountMoveNotFound     = errors.New("migração não encontrada")
+	ErrAccountMoveNotResumable = errors.New("apenas migrações com falha podem ser retomadas")
+	ErrInvalidAccountMove      = errors.New("migração de conta inválida")
+)
+
+// Número de tentativas de um passo antes de a migração ser marcada como falha.
+const accountMoveMaxAttempts = 5
+
+// errPermanent marca erros que não se resolvem com novas tentativas.
+type errPermanent struct{ err error }
+
+func (e errPermanent) Error() string { return e.err.Error() }
+
+// AccountMoveRequest são os dados para migrar a conta de um serviço para outro servidor.
+type AccountMoveRequest struct {
+	TargetServerID int    `json:"target_server_id"`
+	SSHUser        string `json:"ssh_user"`
+	SSHPassword    string `json:"ssh_password"`
+	SSHKeyName     string `json:"ssh_key_name"`
+	KeepSource     bool   `json:"keep_source"`
+}
+
+// AccountMover migra contas cPanel entre servidores do pool. Cada migração avança passo a passo
+// (ver models.AccountMoveStep) e pode ser retomada a partir do passo que falhou.
+type AccountMover struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewAccountMover cria uma nova instância de AccountMover.
+func NewAccountMover(db *sql.DB) *AccountMover {
+	return &AccountMover{db: db, placement: NewServerPlacement(db, nil)}
+}
+
+// Start valida e registra a migração da conta do serviço para o servidor de destino.
+// A execução dos passos é feita pelo AccountMoveWorker.
+func (a *AccountMover) Start(serviceID int, req AccountMoveRequest, adminID int, ipAddress string) (*models.AccountMove, error) {
+	service, err := models.GetServiceByIDAdmin(a.db, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o serviço: %w", err)
+	}
+	if service == nil {
+		return nil, ErrServiceNotFound
+	}
+	if !service.ServerID.Valid {
+		return nil, fmt.Errorf("%w: o serviço não está associado a um servidor do pool", ErrInvalidAccountMove)
+	}
+	if int(service.ServerID.Int64) == req.TargetServerID {
+		return nil, fmt.Errorf("%w: o serviço já está no servidor de destino", ErrInvalidAccountMove)
+	}
+	if req.SSHKeyName == "" && req.SSHPassword == "" {
+		return nil, fmt.Errorf("%w: informe a senha SSH ou a chave SSH do servidor de origem", ErrInvalidAccountMove)
+	}
+
+	target, err := models.GetServerByID(a.db, req.TargetServerID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o servidor de destino: %w", err)
+	}
+	if target == nil || target.ServerType != models.ServerTypeCPanel || target.Status != models.ServerStatusActive {
+		return nil, fmt.Errorf("%w: o servidor de destino deve ser um servidor cPanel ativo", ErrInvalidAccountMove)
+	}
+
+	opts := models.AccountMoveOptions{SSHUser: req.SSHUser, SSHKeyName: req.SSHKeyName, KeepSource: req.KeepSource}
+	if opts.SSHUser == "" {
+		opts.SSHUser = "root"
+	}
+	if req.SSHPassword != "" {
+		opts.SSHPasswordEncrypted, err = utils.Encrypt([]byte(req.SSHPassword))
+		if err != nil {
+			return nil, fmt.Errorf("falha ao criptografar a senha SSH: %w", err)
+		}
+	}
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	move := &models.AccountMove{
+		ServiceID:      serviceID,
+		SourceServerID: int(service.ServerID.Int64),
+		TargetServerID: req.TargetServerID,
+		Options:        options,
+		CreatedBy:      sql.NullInt64{Int64: int64(adminID), Valid: adminID > 0},
+	}
+	if err := models.CreateAccountMove(a.db, move); err != nil {
+		return nil, fmt.Errorf("falha ao registrar a migração (já existe uma migração em andamento para o serviço?): %w", err)
+	}
+	move.Status = models.AccountMoveStatusPending
+	move.CurrentStep = models.AccountMoveStepTransfer
+
+	recordAuditLog(a.db, adminID, ipAddress, "service.move", "service", strconv.Itoa(serviceID),
+		map[string]any{"server_id": move.SourceServerID},
+		map[string]any{"server_id": move.TargetServerID, "move_id": move.ID, "keep_source": opts.KeepSource},
+		models.AuditLogResultSuccess)
+
+	return move, nil
+}
+
+// Resume retoma uma migração com falha a partir do passo em que ela parou.
+func (a *AccountMover) Resume(moveID int64, adminID int, ipAddress string) (*models.AccountMove, error) {
+	move, err := models.GetAccountMoveByID(a.db, moveID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar a migração: %w", err)
+	}
+	if move == nil {
+		return nil, ErrAccountMoveNotFound
+	}
+	if move.Status != models.AccountMoveStatusFailed {
+		return nil, ErrAccountMoveNotResumable
+	}
+
+	move.Status = models.AccountMoveStatusRunning
+	move.Attempts = 0
+	move.LastError = sql.NullString{}
+	if err := models.UpdateAccountMoveProgress(a.db, move); err != nil {
+		return nil, fmt.Errorf("falha ao retomar a migração: %w", err)
+	}
+	a.logEvent(move, "resumed", fmt.Sprintf("Migração retomada pelo admin #%d", adminID))
+
+	recordAuditLog(a.db, adminID, ipAddress, "account_move.resume", "account_move", strconv.FormatInt(moveID, 10),
+		nil, map[string]any{"step": move.CurrentStep}, models.AuditLogResultSuccess)
+
+	return move, nil
+}
+
+// ProcessPending avança todas as migrações pendentes ou em andamento.
+func (a *AccountMover) ProcessPending() error {
+	moves, err := models.GetRunnableAccountMoves(a.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar as migrações em andamento: %w", err)
+	}
+	for i := range moves {
+		if err := a.Advance(&moves[i]); err != nil {
+			log.Printf("[Account Mover] ERRO na migração #%d: %v", moves[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Advance executa os passos da migração até que um passo precise aguardar, falhe ou a migração termine.
+func (a *AccountMover) Advance(move *models.AccountMove) error {
+	if move.Status == models.AccountMoveStatusPending {
+		move.Status = models.AccountMoveStatusRunning
+		move.StartedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	env, err := a.loadEnv(move)
+	if err != nil {
+		return a.stepFailed(move, errPermanent{err})
+	}
+
+	for move.CurrentStep != models.AccountMoveStepDone {
+		step := move.CurrentStep
+		advanced, err := a.runStep(move, env)
+		if err != nil {
+			return a.stepFailed(move, err)
+		}
+		if !advanced {
+			a.logEvent(move, "waiting", "")
+			return models.UpdateAccountMoveProgress(a.db, move)
+		}
+		a.logEvent(move, "succeeded", "")
+		log.Printf("[Account Mover] Migração #%d: passo '%s' concluído.", move.ID, step)
+
+		move.CurrentStep = nextAccountMoveStep(step)
+		move.Attempts = 0
+		move.LastError = sql.NullString{}
+		if err := models.UpdateAccountMoveProgress(a.db, move); err != nil {
+			return err
+		}
+	}
+
+	move.Status = models.AccountMoveStatusCompleted
+	move.CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	log.Printf("[Account Mover] Migração #%d do serviço #%d concluída.", move.ID, move.ServiceID)
+	return models.UpdateAccountMoveProgress(a.db, move)
+}
+
+// accountMoveEnv reúne os dados necessários para executar os passos de uma migração.
+type accountMoveEnv struct {
+	service *models.Service
+	source  *models.Server
+	target  *models.Server
+	srcWhm  *provisioning.WhmProvisioner
+	dstWhm  *provisioning.WhmProvisioner
+	opts    models.AccountMoveOptions
+}
+
+func (a *AccountMover) loadEnv(move *models.AccountMove) (*accountMoveEnv, error) {
+	env := &accountMoveEnv{opts: move.ParsedOptions()}
+
+	var err error
+	if env.service, err = models.GetServiceByIDAdmin(a.db, move.ServiceID); err != nil || env.service == nil {
+		return nil, fmt.Errorf("serviço #%d não encontrado: %v", move.ServiceID, err)
+	}
+	if env.source, env.srcWhm, err = a.whmFor(move.SourceServerID); err != nil {
+		return nil, err
+	}
+	if env.target, env.dstWhm, err = a.whmFor(move.TargetServerID); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func (a *AccountMover) whmFor(serverID int) (*models.Server, *provisioning.WhmProvisioner, error) {
+	server, err := models.GetServerByID(a.db, serverID)
+	if err != nil || server == nil {
+		return nil, nil, fmt.Errorf("servidor #%d não encontrado: %v", serverID, err)
+	}
+	provisioner, err := a.placement.ProvisionerFor(server)
+	if err != nil {
+		return nil, nil, err
+	}
+	whm, ok := provisioner.(*provisioning.WhmProvisioner)
+	if !ok {
+		return nil, nil, fmt.Errorf("o servidor '%s' não é um servidor cPanel", server.Name)
+	}
+	return server, whm, nil
+}
+
+// abortTransferSession interrompe no destino uma sessão de transferência que não chegou a ser iniciada.
+func abortTransferSession(whm *provisioning.WhmProvisioner, move *models.AccountMove, sessionID string) {
+	if err := whm.AbortTransferSession(sessionID); err != nil {
+		log.Printf("[Account Mover] Migração #%d: AVISO: falha ao interromper a sessão de transferência %s: %v", move.ID, sessionID, err)
+	}
+}
+
+// runStep executa o passo atual. Retorna false, sem erro, quando o passo precisa aguardar.
+func (a *AccountMover) runStep(move *models.AccountMove, env *accountMoveEnv) (bool, error) {
+	account := provisioning.AccountRef{Username: env.service.CpanelUser, Domain: env.service.Domain}
+
+	switch move.CurrentStep {
+	case models.AccountMoveStepTransfer:
+		// A sessão só é gravada depois de iniciada. Se não for possível iniciá-la, ela é interrompida
+		// no destino e a próxima tentativa cria uma nova sessão.
+		if move.TransferSessionID.Valid {
+			return true, nil
+		}
+		summary, err := env.srcWhm.AccountSummary(account.Username)
+		if err != nil {
+			return false, fmt.Errorf("falha ao consultar a conta na origem: %w", err)
+		}
+		if summary == nil {
+			return false, errPermanent{fmt.Errorf("a conta '%s' não existe no servidor de origem", account.Username)}
+		}
+		move.SourceIP = sql.NullString{String: summary.IP, Valid: summary.IP != ""}
+
+		remote := provisioning.WhmRemoteAccess{Host: env.source.Hostname, User: env.opts.SSHUser, SSHKeyName: env.opts.SSHKeyName}
+		if env.opts.SSHPasswordEncrypted != "" {
+			password, err := utils.Decrypt(env.opts.SSHPasswordEncrypted)
+			if err != nil {
+				return false, errPermanent{fmt.Errorf("falha ao descriptografar a senha SSH: %w", err)}
+			}
+			remote.Password = string(password)
+		}
+
+		sessionID, err := env.dstWhm.CreateRemoteTransferSession(remote)
+		if err != nil {
+			return false, fmt.Errorf("falha ao criar a sessão de transferência: %w", err)
+		}
+		if err := env.dstWhm.EnqueueAccountTransfer(sessionID, account.Username, account.Domain); err != nil {
+			abortTransferSession(env.dstWhm, move, sessionID)
+			return false, fmt.Errorf("falha ao adicionar a conta à sessão de transferência: %w", err)
+		}
+		if err := env.dstWhm.StartTransferSession(sessionID); err != nil {
+			abortTransferSession(env.dstWhm, move, sessionID)
+			return false, fmt.Errorf("falha ao iniciar a sessão de transferência: %w", err)
+		}
+		move.TransferSessionID = sql.NullString{String: sessionID, Valid: true}
+		return true, nil
+
+	case models.AccountMoveStepWaitTransfer:
+		state, err := env.dstWhm.TransferSessionState(move.TransferSessionID.String)
+		if err != nil {
+			return false, fmt.Errorf("falha ao consultar a sessão de transferência: %w", err)
+		}
+		switch state {
+		case provisioning.WhmTransferStateCompleted:
+			return true, nil
+		case provisioning.WhmTransferStateFailed, provisioning.WhmTransferStateAborted:
+			// Uma nova tentativa precisa de uma nova sessão: a migração volta ao passo de transferência.
+			move.CurrentStep = models.AccountMoveStepTransfer
+			move.TransferSessionID = sql.NullString{}
+			return false, errPermanent{fmt.Errorf("a sessão de transferência terminou com o estado %s; consulte o Transfer Tool no WHM de destino", state)}
+		default:
+			return false, nil
+		}
+
+	case models.AccountMoveStepVerify:
+		summary, err := env.dstWhm.AccountSummary(account.Username)
+		if err != nil {
+			return false, fmt.Errorf("falha ao consultar a conta no destino: %w", err)
+		}
+		if summary == nil {
+			return false, errPermanent{fmt.Errorf("a conta '%s' não foi encontrada no servidor de destino", account.Username)}
+		}
+		if summary.Domain != account.Domain {
+			return false, errPermanent{fmt.Errorf("a conta restaurada tem o domínio '%s', esperado '%s'", summary.Domain, account.Domain)}
+		}
+		move.TargetIP = sql.NullString{String: summary.IP, Valid: summary.IP != ""}
+		return true, nil
+
+	case models.AccountMoveStepUpdateDNS:
+		// A zona que passa a valer é a do destino: o removeacct da origem apaga a zona de lá
+		// (e de todo o cluster DNS, quando houver). Sem a zona no destino, a migração não avança.
+		exists, err := env.dstWhm.ZoneExists(account.Domain)
+		if err != nil {
+			return false, fmt.Errorf("falha ao consultar a zona DNS no destino: %w", err)
+		}
+		if !exists {
+			return false, errPermanent{fmt.Errorf("a zona DNS de '%s' não existe no servidor de destino; a origem não será removida", account.Domain)}
+		}
+		if !move.SourceIP.Valid || !move.TargetIP.Valid || move.SourceIP.String == move.TargetIP.String {
+			return true, nil
+		}
+		changed, err := env.dstWhm.SwapZoneAddress(account.Domain, move.SourceIP.String, move.TargetIP.String)
+		if err != nil {
+			return false, fmt.Errorf("falha ao atualizar a zona DNS no destino: %w", err)
+		}
+		a.logEvent(move, "info", fmt.Sprintf("%d registros A apontados para %s na zona do destino", changed, move.TargetIP.String))
+
+		// Com a origem mantida, a zona de lá continua respondendo e também precisa do novo endereço.
+		if env.opts.KeepSource {
+			changed, err := env.srcWhm.SwapZoneAddress(account.Domain, move.SourceIP.String, move.TargetIP.String)
+			if err != nil {
+				return false, fmt.Errorf("falha ao atualizar a zona DNS na origem: %w", err)
+			}
+			a.logEvent(move, "info", fmt.Sprintf("%d registros A apontados para %s na zona da origem", changed, move.TargetIP.String))
+		}
+		return true, nil
+
+	case models.AccountMoveStepSwitchServer:
+		if err := models.UpdateServiceServer(a.db, env.service.ID, env.target.ID); err != nil {
+			return false, fmt.Errorf("falha ao atualizar o servidor do serviço: %w", err)
+		}
+		return true, nil
+
+	case models.AccountMoveStepRemoveSource:
+		if env.opts.KeepSource {
+			return true, nil
+		}
+		// Confere novamente o destino antes de apagar a conta da origem.
+		summary, err := env.dstWhm.AccountSummary(account.Username)
+		if err != nil {
+			return false, fmt.Errorf("falha ao conferir a conta no destino: %w", err)
+		}
+		if summary == nil {
+			return false, errPermanent{fmt.Errorf("a conta '%s' não está no destino; a origem não foi removida", account.Username)}
+		}
+		existing, err := env.srcWhm.AccountSummary(account.Username)
+		if err != nil {
+			return false, fmt.Errorf("falha ao consultar a conta na origem: %w", err)
+		}
+		if existing == nil {
+			return true, nil // Já removida em uma execução anterior
+		}
+		if err := env.srcWhm.TerminateAccount(account); err != nil {
+			return false, fmt.Errorf("falha ao remover a conta da origem: %w", err)
+		}
+		return true, nil
+
+	default:
+		return false, errPermanent{fmt.Errorf("passo desconhecido: %s", move.CurrentStep)}
+	}
+}
+
+func nextAccountMoveStep(step models.AccountMoveStep) models.AccountMoveStep {
+	switch step {
+	case models.AccountMoveStepTransfer:
+		return models.AccountMoveStepWaitTransfer
+	case models.AccountMoveStepWaitTransfer:
+		return models.AccountMoveStepVerify
+	case models.AccountMoveStepVerify:
+		return models.AccountMoveStepUpdateDNS
+	case models.AccountMoveStepUpdateDNS:
+		return models.AccountMoveStepSwitchServer
+	case models.AccountMoveStepSwitchServer:
+		return models.AccountMoveStepRemoveSource
+	default:
+		return models.AccountMoveStepDone
+	}
+}
+
+// stepFailed registra a falha do passo atual. Erros transitórios são repetidos no próximo ciclo do
+// worker até accountMoveMaxAttempts; depois disso, ou em erros permanentes, a migração fica com falha
+// e aguarda ser retomada pelo administrador.
+func (a *AccountMover) stepFailed(move *models.AccountMove, stepErr error) error {
+	move.Attempts++
+	move.LastError = sql.NullString{String: stepErr.Error(), Valid: true}
+
+	var permanent errPermanent
+	if errors.As(stepErr, &permanent) || move.Attempts >= accountMoveMaxAttempts {
+		move.Status = models.AccountMoveStatusFailed
+	}
+	a.logEvent(move, "failed", stepErr.Error())
+
+	if err := models.UpdateAccountMoveProgress(a.db, move); err != nil {
+		return fmt.Errorf("%v (falha ao gravar o estado: %v)", stepErr, err)
+	}
+	return stepErr
+}
+
+func (a *AccountMover) logEvent(move *models.AccountMove, result, message string) {
+	if err := models.CreateAccountMoveEvent(a.db, move.ID, move.CurrentStep, result, message); err != nil {
+		log.Printf("[Account Mover] ERRO ao registrar evento da migração #%d: %v", move.ID, err)
+	}
+}
@@ -1,17 +1,32 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"hosting-backend/internal/jobs"
 	"hosting-backend/internal/models"
+	"hosting-backend/internal/orchestrator"
 	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/utils"
 	"log"
+	"strconv"
+	"time"
 )
 
+// ErrServiceNotFound indica que o serviço informado não existe.
+var ErrServiceNotFound = errors.New("serviço não encontrado")
+
+// ErrServiceNotRecorded indica que a conta foi criada no servidor, mas o serviço não foi registrado
+// e precisa ser cadastrado manualmente. Uma nova tentativa criaria a conta novamente.
+var ErrServiceNotRecorded = errors.New("conta criada no servidor, mas falhou ao registrar o serviço")
+
 // AdminService encapsula a lógica de negócios para administração.
 type AdminService struct {
 	db             *sql.DB
 	whmProvisioner *provisioning.WhmProvisioner
+	placement      *ServerPlacement
 }
 
 // NewAdminService cria uma nova instância de AdminService.
@@ -19,33 +34,273 @@ func NewAdminService(db *sql.DB, whmProvisioner *provisioning.WhmProvisioner) *A
 	return &AdminService{
 		db:             db,
 		whmProvisioner: whmProvisioner,
+		placement:      NewServerPlacement(db, whmProvisioner),
 	}
 }
 
-// CreateClientAndProvisionAccount cria um novo cliente no banco de dados e provisiona a conta no WHM.
-func (s *AdminService) CreateClientAndProvisionAccount(client *models.Client, domain, username, plan, password string) (int64, error) {
-	// Passo 1: Inserir o cliente no banco de dados.
+// CreateClientAndProvisionAccount cria um novo cliente no banco de dados e enfileira o provisionamento
+// da conta no servidor escolhido pelo plano. Retorna o ID do cliente e a tarefa de provisionamento.
+func (s *AdminService) CreateClientAndProvisionAccount(client *models.Client, domain, username, plan, password string) (int64, *models.Job, error) {
+	// Passo 0: O plano define em qual servidor do pool a conta será criada.
+	planRecord, err := models.GetPlanByWhmPackageName(s.db, plan)
+	if err != nil {
+		return 0, nil, fmt.Errorf("falha ao buscar o plano: %w", err)
+	}
+	if planRecord == nil {
+		return 0, nil, fmt.Errorf("nenhum plano cadastrado para o pacote do WHM '%s'", plan)
+	}
+
+	payload, err := newProvisionAccountPayload(client.UserID, planRecord, domain, username, password, client.Email)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Passo 1: Inserir o cliente e enfileirar o provisionamento da conta na mesma transação, para que
+	// não exista cliente sem tarefa (nem tarefa sem cliente). Se o provisionamento falhar em definitivo,
+	// a compensação da tarefa remove o cliente recém-criado.
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("falha ao iniciar a transação: %w", err)
+	}
+	defer tx.Rollback()
+
 	log.Printf("Iniciando a criação do cliente '%s' no banco de dados.", client.Email)
-	clientID, err := models.CreateClient(s.db, client)
+	clientID, err := models.CreateClient(tx, client)
 	if err != nil {
-		return 0, fmt.Errorf("falha ao criar cliente no banco de dados: %w", err)
+		return 0, nil, fmt.Errorf("falha ao criar cliente no banco de dados: %w", err)
+	}
+
+	payload.RollbackClientID = clientID
+	job, err := s.enqueueProvisionAccount(tx, payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("falha ao enfileirar o provisionamento: %w", err)
+	}
+	if job == nil {
+		return 0, nil, fmt.Errorf("já existe um provisionamento pendente para o usuário '%s'", username)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("falha ao confirmar o cadastro do cliente: %w", err)
 	}
 	log.Printf("Cliente '%s' criado com sucesso no banco de dados com ID: %d.", client.Email, clientID)
 
-	// Passo 2: Provisionar a conta no cPanel/WHM.
-	log.Printf("Iniciando o provisionamento da conta no WHM para o domínio '%s'.", domain)
-	_, err = s.whmProvisioner.CreateAccount(username, domain, plan, password, client.Email)
+	return clientID, job, nil
+}
+
+// provisionAccountPayload é o payload da tarefa de provisionamento de conta de hospedagem.
+type provisionAccountPayload struct {
+	UserID            int    `json:"user_id"`
+	PlanID            int    `json:"plan_id"`
+	Domain            string `json:"domain"`
+	Username          string `json:"username"`
+	PasswordEncrypted string `json:"password_encrypted"` // utils.Encrypt
+	Email             string `json:"email"`
+	// RollbackClientID é o cliente criado junto com a conta; ele é removido se o provisionamento falhar.
+	RollbackClientID int64 `json:"rollback_client_id,omitempty"`
+}
+
+func newProvisionAccountPayload(userID int, plan *models.Plan, domain, username, password, email string) (provisionAccountPayload, error) {
+	encrypted, err := utils.Encrypt([]byte(password))
+	if err != nil {
+		return provisionAccountPayload{}, fmt.Errorf("falha ao criptografar a senha da conta: %w", err)
+	}
+	return provisionAccountPayload{
+		UserID:            userID,
+		PlanID:            plan.ID,
+		Domain:            domain,
+		Username:          username,
+		PasswordEncrypted: encrypted,
+		Email:             email,
+	}, nil
+}
+
+// EnqueueServiceAccount enfileira a criação da conta de hospedagem e o registro do serviço.
+// Retorna nil se já houver uma tarefa pendente para o mesmo usuário de cPanel.
+func (s *AdminService) EnqueueServiceAccount(userID int, plan *models.Plan, domain, username, password, email string) (*models.Job, error) {
+	payload, err := newProvisionAccountPayload(userID, plan, domain, username, password, email)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueueProvisionAccount(s.db, payload)
+}
+
+// enqueueProvisionAccount grava a tarefa com q, que pode ser a transação que cria o cliente.
+func (s *AdminService) enqueueProvisionAccount(q models.QueryRower, payload provisionAccountPayload) (*models.Job, error) {
+	return jobs.Enqueue(q, jobs.HostingProvisionAccount, payload, "hosting.provision_account:"+payload.Username)
+}
+
+// RegisterJobHandlers registra na fila o handler de provisionamento de contas de hospedagem.
+func (s *AdminService) RegisterJobHandlers(queue *jobs.Queue) {
+	queue.Register(jobs.HostingProvisionAccount, func(ctx context.Context, job *models.Job) error {
+		var payload provisionAccountPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+
+		// Uma execução anterior já registrou o serviço.
+		existing, err := models.GetActiveServiceByCpanelUser(s.db, payload.Username)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return nil
+		}
+
+		plan, err := models.GetPlanByID(s.db, payload.PlanID)
+		if err != nil {
+			return err
+		}
+		if plan == nil {
+			return jobs.Permanent(fmt.Errorf("plano #%d não encontrado", payload.PlanID))
+		}
+		password, err := utils.Decrypt(payload.PasswordEncrypted)
+		if err != nil {
+			return jobs.Permanent(fmt.Errorf("falha ao descriptografar a senha da conta: %w", err))
+		}
+
+		_, err = s.ProvisionServiceAccount(payload.UserID, plan, payload.Domain, payload.Username, string(password), payload.Email)
+		if errors.Is(err, ErrServiceNotRecorded) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}, s.compensateServiceAccount)
+}
+
+// compensateServiceAccount é a política de compensação do provisionamento de hospedagem. Uma conta
+// criada no painel sem serviço registrado exige conciliação manual; nos demais casos, o cliente
+// criado junto com a conta é removido para não ficar órfão, e a equipe é avisada da falha.
+func (s *AdminService) compensateServiceAccount(job *models.Job, cause error) {
+	var payload provisionAccountPayload
+	if jobs.DecodePayload(job, &payload) != nil || payload.Username == "" {
+		return
+	}
+	log.Printf("ERRO no provisionamento da conta '%s': %v", payload.Username, cause)
+
+	if errors.Is(cause, ErrServiceNotRecorded) {
+		jobs.OpenStaffTask(s.db, job, &models.StaffTask{
+			Kind:       "hosting.orphan_account",
+			Priority:   models.StaffTaskPriorityHigh,
+			TargetType: "cpanel_user",
+			TargetID:   payload.Username,
+			Title:      fmt.Sprintf("Conta '%s' criada no painel sem serviço registrado", payload.Username),
+		}, cause)
+		return
+	}
+
+	if payload.RollbackClientID > 0 {
+		services, err := models.GetServicesByUserID(s.db, payload.UserID)
+		if err == nil && len(services) == 0 {
+			err = models.DeleteClient(s.db, int(payload.RollbackClientID))
+			if err == nil {
+				log.Printf("Cliente ID %d removido após a falha do provisionamento da conta '%s'.", payload.RollbackClientID, payload.Username)
+			}
+		}
+		if err != nil {
+			jobs.OpenStaffTask(s.db, job, &models.StaffTask{
+				Kind:       "client.orphan",
+				Priority:   models.StaffTaskPriorityNormal,
+				TargetType: "client",
+				TargetID:   strconv.FormatInt(payload.RollbackClientID, 10),
+				Title:      fmt.Sprintf("Cliente ID %d ficou sem conta de hospedagem e não pôde ser removido", payload.RollbackClientID),
+			}, fmt.Errorf("%v (remoção do cliente: %v)", cause, err))
+			return
+		}
+	}
+
+	jobs.OpenStaffTask(s.db, job, &models.StaffTask{
+		Kind:       "hosting.provision_failed",
+		Priority:   models.StaffTaskPriorityNormal,
+		TargetType: "cpanel_user",
+		TargetID:   payload.Username,
+		Title:      fmt.Sprintf("Conta de hospedagem '%s' (%s) não foi provisionada", payload.Username, payload.Domain),
+	}, cause)
+}
+
+// ProvisionServiceAccount escolhe o servidor conforme a política do plano, cria a conta no painel
+// desse servidor (WHM ou Plesk) e registra o serviço com o server_id escolhido.
+func (s *AdminService) ProvisionServiceAccount(userID int, plan *models.Plan, domain, username, password, email string) (*models.Service, error) {
+	server, err := s.placement.PickServer(plan)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao escolher o servidor: %w", err)
+	}
+	provisioner, err := s.placement.ProvisionerFor(server)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Iniciando o provisionamento da conta em %s para o domínio '%s'.", provisioner.Host(), domain)
+	if err := provisioner.CreateAccount(username, domain, plan.WhmPackageName.String, password, email); err != nil {
+		return nil, err
+	}
+	log.Printf("Conta para o domínio '%s' provisionada com sucesso em %s.", domain, provisioner.Host())
+
+	now := time.Now()
+	service := &models.Service{
+		UserID:       userID,
+		ProductID:    plan.ID,
+		Domain:       domain,
+		CpanelUser:   username,
+		Status:       models.ServiceStatusActive,
+		Price:        plan.Price,
+		BillingCycle: plan.BillingCycle,
+		NextDueDate:  plan.BillingCycle.NextDueDate(now),
+	}
+	if server != nil {
+		service.ServerID = sql.NullInt64{Int64: int64(server.ID), Valid: true}
+	}
+
+	id, err := models.CreateService(s.db, service)
 	if err != nil {
-		// Opcional: Implementar lógica de rollback.
-		// Por exemplo, deletar o cliente do banco de dados se o provisionamento falhar.
-		log.Printf("ERRO CRÍTICO: Falha ao provisionar a conta no WHM para o cliente ID %d: %v", clientID, err)
-		// Neste ponto, você pode querer retornar um erro específico para que o chamador possa tratar.
-		// Por enquanto, vamos apenas retornar o erro do provisionador.
-		return clientID, fmt.Errorf("o cliente foi criado no banco de dados (ID: %d), mas falhou ao provisionar no WHM: %w", clientID, err)
+		// A conta já existe no servidor; o serviço precisa ser registrado manualmente.
+		return nil, fmt.Errorf("%w (%s): %v", ErrServiceNotRecorded, provisioner.Host(), err)
 	}
+	service.ID = int(id)
+
+	return service, nil
+}
+
+// SuspendService suspende a conta do serviço no painel do seu servidor e marca o serviço como suspenso.
+func (s *AdminService) SuspendService(serviceID int, reason string) error {
+	service, provisioner, err := s.serviceProvisioner(serviceID)
+	if err != nil {
+		return err
+	}
+
+	account := provisioning.AccountRef{Username: service.CpanelUser, Domain: service.Domain}
+	if err := provisioner.SuspendAccount(account, reason); err != nil {
+		return fmt.Errorf("falha ao suspender a conta em %s: %w", provisioner.Host(), err)
+	}
+	return models.UpdateServiceStatus(s.db, serviceID, models.ServiceStatusSuspended)
+}
 
-	log.Printf("Conta para o domínio '%s' provisionada com sucesso no WHM.", domain)
-	return clientID, nil
+// UnsuspendService reativa a conta do serviço no painel do seu servidor e marca o serviço como ativo.
+func (s *AdminService) UnsuspendService(serviceID int) error {
+	service, provisioner, err := s.serviceProvisioner(serviceID)
+	if err != nil {
+		return err
+	}
+
+	account := provisioning.AccountRef{Username: service.CpanelUser, Domain: service.Domain}
+	if err := provisioner.UnsuspendAccount(account); err != nil {
+		return fmt.Errorf("falha ao reativar a conta em %s: %w", provisioner.Host(), err)
+	}
+	return models.UpdateServiceStatus(s.db, serviceID, models.ServiceStatusActive)
+}
+
+func (s *AdminService) serviceProvisioner(serviceID int) (*models.Service, provisioning.HostingProvisioner, error) {
+	service, err := models.GetServiceByIDAdmin(s.db, serviceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao buscar o serviço: %w", err)
+	}
+	if service == nil {
+		return nil, nil, ErrServiceNotFound
+	}
+
+	provisioner, err := s.placement.ProvisionerForService(service)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service, provisioner, nil
 }
 
 // CreateClient cria um novo cliente (sem provisionamento).
@@ -72,3 +327,66 @@ func (s *AdminService) UpdateClient(client *models.Client) error {
 func (s *AdminService) DeleteClient(id int) error {
 	return models.DeleteClient(s.db, id)
 }
+
+var (
+	ErrJobNotFound = errors.New("tarefa não encontrada")
+	ErrJobNotDead  = errors.New("apenas tarefas na fila de mortas podem ser reenfileiradas")
+)
+
+// RetryJob devolve uma tarefa da fila de mortas para a fila, com as tentativas zeradas.
+func (s *AdminService) RetryJob(jobID int64, adminID int, ipAddress string) (*models.Job, error) {
+	job, err := models.GetJobByID(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	requeued, err := models.RequeueDeadJob(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, ErrJobNotDead
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "job.retry", "job", strconv.FormatInt(jobID, 10),
+		map[string]any{"status": job.Status, "attempts": job.Attempts, "last_error": job.LastError.String},
+		map[string]any{"status": models.JobStatusQueued},
+		models.AuditLogResultSuccess)
+
+	return models.GetJobByID(s.db, jobID)
+}
+
+var (
+	ErrInvoiceNotFound    = errors.New("fatura não encontrada")
+	ErrInvoiceAlreadyPaid = errors.New("a fatura já está paga")
+)
+
+// MarkInvoicePaid registra o pagamento de uma fatura recebido fora do gateway (ex: transferência) e
+// inicia o provisionamento dos pedidos vinculados a ela.
+func (s *AdminService) MarkInvoicePaid(invoiceID, adminID int, ipAddress string) error {
+	invoice, err := models.GetInvoiceByID(s.db, invoiceID)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar a fatura: %w", err)
+	}
+	if invoice == nil {
+		return ErrInvoiceNotFound
+	}
+	updated, err := models.MarkInvoicePaid(s.db, invoiceID)
+	if err != nil {
+		return fmt.Errorf("falha ao marcar a fatura como paga: %w", err)
+	}
+	if !updated {
+		return ErrInvoiceAlreadyPaid
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "invoice.mark_paid", "invoice", strconv.Itoa(invoiceID),
+		map[string]any{"status": invoice.Status}, map[string]any{"status": models.InvoiceStatusPaid},
+		models.AuditLogResultSuccess)
+
+	if err := orchestrator.ProvisionPaidInvoice(s.db, invoiceID); err != nil {
+		return fmt.Errorf("a fatura foi marcada como paga, mas falhou ao iniciar o provisionamento: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"hosting-backend/internal/models"
+)
+
+// recordAuditLog grava uma ação administrativa no log de auditoria.
+// Falhas ao auditar são apenas registradas em log para não desfazer a ação já concluída.
+func recordAuditLog(db *sql.DB, adminID int, ipAddress, action, targetType, targetID string, oldValue, newValue any, result models.AuditLogResult) {
+	entry := &models.AuditLog{
+		StaffID:    sql.NullInt64{Int64: int64(adminID), Valid: adminID > 0},
+		IPAddress:  sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		Action:     action,
+		TargetType: sql.NullString{String: targetType, Valid: targetType != ""},
+		TargetID:   sql.NullString{String: targetID, Valid: targetID != ""},
+		Result:     result,
+	}
+
+	if oldValue != nil {
+		if data, err := json.Marshal(oldValue); err == nil {
+			entry.OldValue = data
+		}
+	}
+	if newValue != nil {
+		if data, err := json.Marshal(newValue); err == nil {
+			entry.NewValue = data
+		}
+	}
+
+	if err := models.CreateAuditLog(db, entry); err != nil {
+		log.Printf("[Audit] ERRO ao registrar a ação '%s' do admin #%d: %v", action, adminID, err)
+	}
+}
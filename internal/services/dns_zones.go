@@ -0,0 +1,601 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+var (
+	ErrServiceNotManageable = errors.New("o serviço não pode ser gerenciado pela área do cliente")
+	ErrDNSZoneNotFound      = errors.New("zona DNS não encontrada na conta do serviço")
+	ErrDNSRecordNotFound    = errors.New("registro DNS não encontrado")
+	ErrDNSRecordChanged     = errors.New("o registro foi alterado desde a última consulta; recarregue a zona")
+	ErrInvalidDNSRecord     = errors.New("registro DNS inválido")
+	ErrDNSTemplateNotFound  = errors.New("modelo de DNS não encontrado")
+)
+
+const (
+	dnsDefaultTTL      = 14400
+	dnsMinTTL          = 300
+	dnsMaxTTL          = 604800
+	dnsMaxTXTLength    = 4096
+	dnsMaxCAAValue     = 255
+	dnsZoneHistorySize = 100
+)
+
+// dnsEditableTypes são os tipos de registro que o cliente pode criar, alterar e remover. Os demais
+// (SOA, NS) são exibidos apenas para consulta.
+var dnsEditableTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "TXT": true, "SRV": true, "CAA": true}
+
+// dnsNamePattern aceita nomes com sublinhado (_dmarc, _sip._tls) e o curinga na primeira posição.
+var dnsNamePattern = regexp.MustCompile(`^(\*\.)?([a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?\.)+[a-z0-9-]{2,63}$`)
+
+// DNSZoneRecord é um registro da zona exibido ao cliente.
+type DNSZoneRecord struct {
+	provisioning.ZoneRecord
+	Editable bool `json:"editable"`
+}
+
+// DNSZone é a zona DNS de um domínio do serviço.
+type DNSZone struct {
+	Zone    string          `json:"zone"`
+	Records []DNSZoneRecord `json:"records"`
+}
+
+// dnsTemplate é um conjunto de registros aplicado de uma vez, como os de um provedor de e-mail.
+type dnsTemplate struct {
+	ID          string
+	Name        string
+	Description string
+	Records     func(zone string) []provisioning.ZoneRecord // Nomes relativos à zona ("@" para o domínio)
+}
+
+var dnsTemplates = []dnsTemplate{
+	{
+		ID:          "google_workspace",
+		Name:        "Google Workspace",
+		Description: "Entrega os e-mails do domínio ao Gmail do Google Workspace e autoriza o envio pelo Google (SPF).",
+		Records: func(zone string) []provisioning.ZoneRecord {
+			return []provisioning.ZoneRecord{
+				{Type: "MX", Name: "@", Value: "smtp.google.com", Priority: 1},
+				{Type: "TXT", Name: "@", Value: "v=spf1 include:_spf.google.com ~all"},
+			}
+		},
+	},
+	{
+		ID:          "microsoft_365",
+		Name:        "Microsoft 365",
+		Description: "Entrega os e-mails do domínio ao Exchange Online, autoriza o envio pela Microsoft (SPF) e configura o Autodiscover do Outlook.",
+		Records: func(zone string) []provisioning.ZoneRecord {
+			return []provisioning.ZoneRecord{
+				{Type: "MX", Name: "@", Value: strings.ReplaceAll(zone, ".", "-") + ".mail.protection.outlook.com", Priority: 0},
+				{Type: "TXT", Name: "@", Value: "v=spf1 include:spf.protection.outlook.com -all"},
+				{Type: "CNAME", Name: "autodiscover", Value: "autodiscover.outlook.com"},
+			}
+		},
+	},
+}
+
+// DNSTemplate é um modelo de registros exibido ao cliente, já montado para a zona.
+type DNSTemplate struct {
+	ID          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Records     []provisioning.ZoneRecord `json:"records"`
+}
+
+// DNSZoneService edita, pelo WHM, as zonas DNS dos domínios das contas cPanel dos clientes e
+// registra o histórico das alterações.
+type DNSZoneService struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewDNSZoneService cria o serviço de zonas DNS. whmProvisioner atende os serviços sem servidor cadastrado.
+func NewDNSZoneService(db *sql.DB, whmProvisioner *provisioning.WhmProvisioner) *DNSZoneService {
+	return &DNSZoneService{db: db, placement: NewServerPlacement(db, whmProvisioner)}
+}
+
+// ListZones lista os domínios da conta do serviço que têm zona DNS própria.
+func (s *DNSZoneService) ListZones(userID, serviceID int) ([]string, error) {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := whm.ListAccountDomains(service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	return domains.Zones(), nil
+}
+
+// GetZone retorna os registros da zona de um domínio do serviço.
+func (s *DNSZoneService) GetZone(userID, serviceID int, zone string) (*DNSZone, error) {
+	_, whm, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	return s.readZone(whm, zone)
+}
+
+// AddRecord valida e adiciona um registro à zona.
+func (s *DNSZoneService) AddRecord(userID, serviceID int, zone string, record provisioning.ZoneRecord, ipAddress string) (*DNSZone, error) {
+	service, whm, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	record, err = ValidateDNSRecord(zone, record)
+	if err != nil {
+		return nil, err
+	}
+	records, err := whm.ListZoneRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDNSRecordConflicts(records, record, 0); err != nil {
+		return nil, err
+	}
+
+	if err := whm.AddZoneRecord(zone, record); err != nil {
+		return nil, err
+	}
+	s.recordChange(service.ID, zone, userID, ipAddress, models.DNSZoneChangeAdd, "", nil, &record)
+	return s.readZone(whm, zone)
+}
+
+// UpdateRecord substitui o registro da linha informada. O tipo do registro não pode ser alterado.
+func (s *DNSZoneService) UpdateRecord(userID, serviceID int, zone string, line int, record provisioning.ZoneRecord, ipAddress string) (*DNSZone, error) {
+	service, whm, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	record, err = ValidateDNSRecord(zone, record)
+	if err != nil {
+		return nil, err
+	}
+	records, err := whm.ListZoneRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+	current, err := editableRecordAt(records, line)
+	if err != nil {
+		return nil, err
+	}
+	if current.Type != record.Type {
+		return nil, fmt.Errorf("%w: o tipo de um registro não pode ser alterado; remova o registro %s e crie um novo", ErrInvalidDNSRecord, current.Type)
+	}
+	if err := checkDNSRecordConflicts(records, record, line); err != nil {
+		return nil, err
+	}
+
+	if err := whm.EditZoneRecord(zone, line, record); err != nil {
+		return nil, err
+	}
+	s.recordChange(service.ID, zone, userID, ipAddress, models.DNSZoneChangeEdit, "", current, &record)
+	return s.readZone(whm, zone)
+}
+
+// DeleteRecord remove o registro da linha informada. Com expectedType e expectedName, a remoção é
+// recusada se a linha não contiver mais o registro exibido ao cliente.
+func (s *DNSZoneService) DeleteRecord(userID, serviceID int, zone string, line int, expectedType, expectedName, ipAddress string) (*DNSZone, error) {
+	service, whm, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	records, err := whm.ListZoneRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+	current, err := editableRecordAt(records, line)
+	if err != nil {
+		return nil, err
+	}
+	if (expectedType != "" && !strings.EqualFold(expectedType, current.Type)) ||
+		(expectedName != "" && !strings.EqualFold(strings.TrimSuffix(expectedName, "."), current.Name)) {
+		return nil, ErrDNSRecordChanged
+	}
+
+	if err := whm.RemoveZoneRecord(zone, line); err != nil {
+		return nil, err
+	}
+	s.recordChange(service.ID, zone, userID, ipAddress, models.DNSZoneChangeDelete, "", current, nil)
+	return s.readZone(whm, zone)
+}
+
+// ListTemplates lista os modelos de registros montados para a zona.
+func (s *DNSZoneService) ListTemplates(userID, serviceID int, zone string) ([]DNSTemplate, error) {
+	_, _, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]DNSTemplate, 0, len(dnsTemplates))
+	for _, t := range dnsTemplates {
+		records, err := templateRecords(t, zone)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, DNSTemplate{ID: t.ID, Name: t.Name, Description: t.Description, Records: records})
+	}
+	return templates, nil
+}
+
+// ApplyTemplate aplica um modelo à zona. Registros que conflitam com os do modelo são removidos:
+// os MX do mesmo nome, o SPF anterior e qualquer registro no nome de um CNAME do modelo. Registros
+// do modelo já existentes são mantidos.
+func (s *DNSZoneService) ApplyTemplate(userID, serviceID int, zone, templateID, ipAddress string) (*DNSZone, error) {
+	var template *dnsTemplate
+	for i := range dnsTemplates {
+		if dnsTemplates[i].ID == templateID {
+			template = &dnsTemplates[i]
+		}
+	}
+	if template == nil {
+		return nil, ErrDNSTemplateNotFound
+	}
+
+	service, whm, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	wanted, err := templateRecords(*template, zone)
+	if err != nil {
+		return nil, err
+	}
+	records, err := whm.ListZoneRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var removals []provisioning.ZoneRecord
+	var additions []provisioning.ZoneRecord
+	for _, record := range wanted {
+		exists := false
+		for _, existing := range records {
+			if sameDNSRecord(existing, record) {
+				exists = true
+			}
+		}
+		if !exists {
+			additions = append(additions, record)
+		}
+	}
+	for _, existing := range records {
+		for _, record := range wanted {
+			if dnsEditableTypes[existing.Type] && templateReplaces(record, existing) && !sameDNSRecord(existing, record) {
+				removals = append(removals, existing)
+				break
+			}
+		}
+	}
+
+	// Remover uma linha desloca as seguintes: as remoções vão da última linha para a primeira.
+	sort.Slice(removals, func(i, j int) bool { return removals[i].Line > removals[j].Line })
+	for i := range removals {
+		if err := whm.RemoveZoneRecord(zone, removals[i].Line); err != nil {
+			return nil, fmt.Errorf("falha ao remover o registro %s %s: %w", removals[i].Type, removals[i].Name, err)
+		}
+		s.recordChange(service.ID, zone, userID, ipAddress, models.DNSZoneChangeDelete, template.ID, &removals[i], nil)
+	}
+	for i := range additions {
+		if err := whm.AddZoneRecord(zone, additions[i]); err != nil {
+			return nil, fmt.Errorf("falha ao adicionar o registro %s %s: %w", additions[i].Type, additions[i].Name, err)
+		}
+		s.recordChange(service.ID, zone, userID, ipAddress, models.DNSZoneChangeAdd, template.ID, nil, &additions[i])
+	}
+	return s.readZone(whm, zone)
+}
+
+// History lista as alterações mais recentes da zona.
+func (s *DNSZoneService) History(userID, serviceID int, zone string) ([]models.DNSZoneChange, error) {
+	service, _, zone, err := s.clientZone(userID, serviceID, zone)
+	if err != nil {
+		return nil, err
+	}
+	return models.GetDNSZoneChanges(s.db, service.ID, zone, dnsZoneHistorySize)
+}
+
+// clientZone busca o serviço do cliente e confirma que a zona pertence à conta cPanel.
+func (s *DNSZoneService) clientZone(userID, serviceID int, zone string) (*models.Service, *provisioning.WhmProvisioner, string, error) {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+	domains, err := whm.ListAccountDomains(service.CpanelUser)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	for _, z := range domains.Zones() {
+		if strings.EqualFold(z, zone) {
+			return service, whm, zone, nil
+		}
+	}
+	return nil, nil, "", ErrDNSZoneNotFound
+}
+
+func (s *DNSZoneService) readZone(whm *provisioning.WhmProvisioner, zone string) (*DNSZone, error) {
+	records, err := whm.ListZoneRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+	view := &DNSZone{Zone: zone, Records: make([]DNSZoneRecord, 0, len(records))}
+	for _, r := range records {
+		view.Records = append(view.Records, DNSZoneRecord{ZoneRecord: r, Editable: dnsEditableTypes[r.Type]})
+	}
+	return view, nil
+}
+
+// recordChange grava a alteração no histórico. A zona já foi alterada no servidor, então uma falha
+// ao gravar é apenas registrada em log.
+func (s *DNSZoneService) recordChange(serviceID int, zone string, userID int, ipAddress, action, template string, oldRecord, newRecord *provisioning.ZoneRecord) {
+	change := &models.DNSZoneChange{
+		ServiceID: serviceID,
+		Zone:      zone,
+		UserID:    userID,
+		IPAddress: sql.NullString{String: ipAddress, Valid: ipAddress != ""},
+		Action:    action,
+		Template:  sql.NullString{String: template, Valid: template != ""},
+	}
+	for _, r := range []*provisioning.ZoneRecord{newRecord, oldRecord} {
+		if r != nil {
+			change.RecordType, change.RecordName = r.Type, r.Name
+		}
+	}
+	if oldRecord != nil {
+		change.OldRecord, _ = json.Marshal(oldRecord)
+	}
+	if newRecord != nil {
+		change.NewRecord, _ = json.Marshal(newRecord)
+	}
+	if err := models.CreateDNSZoneChange(s.db, change); err != nil {
+		log.Printf("[DNS Zone] ERRO ao registrar a alteração %s em %s (serviço #%d): %v", action, zone, serviceID, err)
+	}
+}
+
+// ValidateDNSRecord normaliza e valida um registro para a zona. O nome pode ser relativo à zona
+// ("www", "@" para o próprio domínio) ou completo; o TTL padrão é de 4 horas.
+func ValidateDNSRecord(zone string, record provisioning.ZoneRecord) (provisioning.ZoneRecord, error) {
+	r := provisioning.ZoneRecord{Type: strings.ToUpper(strings.TrimSpace(record.Type)), TTL: record.TTL}
+	if !dnsEditableTypes[r.Type] {
+		return r, fmt.Errorf("%w: tipo '%s' não suportado (use A, AAAA, CNAME, MX, TXT, SRV ou CAA)", ErrInvalidDNSRecord, record.Type)
+	}
+
+	name, err := normalizeDNSName(zone, record.Name)
+	if err != nil {
+		return r, err
+	}
+	r.Name = name
+
+	if r.TTL == 0 {
+		r.TTL = dnsDefaultTTL
+	}
+	if r.TTL < dnsMinTTL || r.TTL > dnsMaxTTL {
+		return r, fmt.Errorf("%w: o TTL deve estar entre %d e %d segundos", ErrInvalidDNSRecord, dnsMinTTL, dnsMaxTTL)
+	}
+
+	value := strings.TrimSpace(record.Value)
+	switch r.Type {
+	case "A", "AAAA":
+		ip := net.ParseIP(value)
+		if r.Type == "A" && (ip == nil || ip.To4() == nil) {
+			return r, fmt.Errorf("%w: '%s' não é um endereço IPv4 válido", ErrInvalidDNSRecord, value)
+		}
+		if r.Type == "AAAA" && (ip == nil || ip.To4() != nil) {
+			return r, fmt.Errorf("%w: '%s' não é um endereço IPv6 válido", ErrInvalidDNSRecord, value)
+		}
+		r.Value = ip.String()
+
+	case "CNAME":
+		if r.Name == zone {
+			return r, fmt.Errorf("%w: o domínio principal da zona não pode ter um CNAME", ErrInvalidDNSRecord)
+		}
+		if r.Value, err = normalizeDNSTarget(value); err != nil {
+			return r, err
+		}
+		if r.Value == r.Name {
+			return r, fmt.Errorf("%w: o CNAME não pode apontar para o próprio nome", ErrInvalidDNSRecord)
+		}
+
+	case "MX":
+		if err := checkDNSUint16("prioridade", record.Priority); err != nil {
+			return r, err
+		}
+		r.Priority = record.Priority
+		if r.Value, err = normalizeDNSTarget(value); err != nil {
+			return r, err
+		}
+
+	case "TXT":
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+		if value == "" || len(value) > dnsMaxTXTLength {
+			return r, fmt.Errorf("%w: o texto deve ter de 1 a %d caracteres", ErrInvalidDNSRecord, dnsMaxTXTLength)
+		}
+		for _, c := range value {
+			if c < ' ' || c > '~' || c == '"' {
+				return r, fmt.Errorf("%w: o texto contém caracteres não permitidos", ErrInvalidDNSRecord)
+			}
+		}
+		r.Value = value
+
+	case "SRV":
+		labels := strings.Split(r.Name, ".")
+		if len(labels) < 3 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return r, fmt.Errorf("%w: o nome de um SRV deve seguir o formato _serviço._protocolo (ex: _sip._tls)", ErrInvalidDNSRecord)
+		}
+		if err := checkDNSUint16("prioridade", record.Priority); err != nil {
+			return r, err
+		}
+		if err := checkDNSUint16("peso", record.Weight); err != nil {
+			return r, err
+		}
+		if err := checkDNSUint16("porta", record.Port); err != nil {
+			return r, err
+		}
+		r.Priority, r.Weight, r.Port = record.Priority, record.Weight, record.Port
+		if r.Value, err = normalizeDNSTarget(value); err != nil {
+			return r, err
+		}
+
+	case "CAA":
+		if record.Flag != 0 && record.Flag != 128 {
+			return r, fmt.Errorf("%w: a flag de um CAA deve ser 0 ou 128", ErrInvalidDNSRecord)
+		}
+		r.Flag = record.Flag
+		r.Tag = strings.ToLower(strings.TrimSpace(record.Tag))
+		switch r.Tag {
+		case "issue", "issuewild":
+		case "iodef":
+			if !strings.HasPrefix(value, "mailto:") && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+				return r, fmt.Errorf("%w: o valor de um CAA iodef deve ser um endereço mailto: ou http(s)://", ErrInvalidDNSRecord)
+			}
+		default:
+			return r, fmt.Errorf("%w: a tag de um CAA deve ser issue, issuewild ou iodef", ErrInvalidDNSRecord)
+		}
+		if value == "" || len(value) > dnsMaxCAAValue || strings.ContainsAny(value, "\"\r\n") {
+			return r, fmt.Errorf("%w: o valor do CAA deve ter de 1 a %d caracteres, sem aspas", ErrInvalidDNSRecord, dnsMaxCAAValue)
+		}
+		r.Value = value
+	}
+	return r, nil
+}
+
+// normalizeDNSName converte o nome do registro em um nome completo dentro da zona.
+func normalizeDNSName(zone, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "" || name == "@":
+		return zone, nil
+	case strings.HasSuffix(name, "."):
+		name = strings.TrimSuffix(name, ".")
+	case name == zone || strings.HasSuffix(name, "."+zone):
+	default:
+		name = name + "." + zone
+	}
+	if len(name) > 253 || !dnsNamePattern.MatchString(name) {
+		return "", fmt.Errorf("%w: '%s' não é um nome válido", ErrInvalidDNSRecord, name)
+	}
+	if name != zone && !strings.HasSuffix(name, "."+zone) {
+		return "", fmt.Errorf("%w: o nome %s está fora da zona %s", ErrInvalidDNSRecord, name, zone)
+	}
+	return name, nil
+}
+
+// normalizeDNSTarget valida o hostname de destino de um CNAME, MX ou SRV.
+func normalizeDNSTarget(target string) (string, error) {
+	target = strings.TrimSuffix(strings.ToLower(target), ".")
+	if net.ParseIP(target) != nil {
+		return "", fmt.Errorf("%w: o destino deve ser um hostname, não um endereço IP", ErrInvalidDNSRecord)
+	}
+	if len(target) > 253 || strings.HasPrefix(target, "*") || !dnsNamePattern.MatchString(target) {
+		return "", fmt.Errorf("%w: '%s' não é um hostname válido", ErrInvalidDNSRecord, target)
+	}
+	return target, nil
+}
+
+func checkDNSUint16(field string, v int) error {
+	if v < 0 || v > 65535 {
+		return fmt.Errorf("%w: %s deve estar entre 0 e 65535", ErrInvalidDNSRecord, field)
+	}
+	return nil
+}
+
+// checkDNSRecordConflicts recusa registros duplicados e CNAMEs que dividem o nome com outros
+// registros. skipLine é a linha do registro sendo alterado.
+func checkDNSRecordConflicts(records []provisioning.ZoneRecord, record provisioning.ZoneRecord, skipLine int) error {
+	for _, existing := range records {
+		if existing.Line == skipLine || existing.Name != record.Name {
+			continue
+		}
+		if sameDNSRecord(existing, record) {
+			return fmt.Errorf("%w: o registro %s %s já existe", ErrInvalidDNSRecord, record.Type, record.Name)
+		}
+		if record.Type == "CNAME" || existing.Type == "CNAME" {
+			return fmt.Errorf("%w: %s já tem um registro %s, e um CNAME não pode dividir o nome com outros registros", ErrInvalidDNSRecord, record.Name, existing.Type)
+		}
+	}
+	return nil
+}
+
+// editableRecordAt busca o registro editável da linha informada.
+func editableRecordAt(records []provisioning.ZoneRecord, line int) (*provisioning.ZoneRecord, error) {
+	for i := range records {
+		if records[i].Line == line {
+			if !dnsEditableTypes[records[i].Type] {
+				return nil, fmt.Errorf("%w: registros %s não podem ser alterados", ErrInvalidDNSRecord, records[i].Type)
+			}
+			return &records[i], nil
+		}
+	}
+	return nil, ErrDNSRecordNotFound
+}
+
+// sameDNSRecord compara dois registros ignorando a linha e o TTL.
+func sameDNSRecord(a, b provisioning.ZoneRecord) bool {
+	a.Line, b.Line, a.TTL, b.TTL = 0, 0, 0, 0
+	return a == b
+}
+
+// templateReplaces informa se o registro do modelo substitui o registro existente.
+func templateReplaces(record, existing provisioning.ZoneRecord) bool {
+	if record.Name != existing.Name {
+		return false
+	}
+	switch {
+	case record.Type == "CNAME" || existing.Type == "CNAME":
+		return true
+	case record.Type == "MX":
+		return existing.Type == "MX"
+	case record.Type == "TXT" && strings.HasPrefix(record.Value, "v=spf1"):
+		return existing.Type == "TXT" && strings.HasPrefix(existing.Value, "v=spf1")
+	}
+	return false
+}
+
+func templateRecords(t dnsTemplate, zone string) ([]provisioning.ZoneRecord, error) {
+	records := t.Records(zone)
+	for i := range records {
+		record, err := ValidateDNSRecord(zone, records[i])
+		if err != nil {
+			return nil, fmt.Errorf("modelo %s inválido para %s: %w", t.ID, zone, err)
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// clientCpanelService busca um serviço ativo do cliente autenticado hospedado em um servidor cPanel
+// e o provisionador do servidor. Serviços de outros clientes resultam em ErrServiceNotFound.
+func clientCpanelService(db *sql.DB, placement *ServerPlacement, userID, serviceID int) (*models.Service, *provisioning.WhmProvisioner, error) {
+	service, err := models.GetServiceByID(db, serviceID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao buscar o serviço: %w", err)
+	}
+	if service == nil {
+		return nil, nil, ErrServiceNotFound
+	}
+	if service.Status != models.ServiceStatusActive || service.CpanelUser == "" {
+		return nil, nil, fmt.Errorf("%w: o serviço não está ativo", ErrServiceNotManageable)
+	}
+
+	provisioner, err := placement.ProvisionerForService(service)
+	if err != nil {
+		return nil, nil, err
+	}
+	whm, ok := provisioner.(*provisioning.WhmProvisioner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: disponível apenas para serviços em servidores cPanel", ErrServiceNotManageable)
+	}
+	return service, whm, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"log"
+
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/services/rdap"
+)
+
+// CheckDomainAvailability consulta a disponibilidade do domínio no servidor RDAP da sua TLD. Quando o
+// RDAP não é conclusivo (servidor fora do ar, limite de requisições ou TLD sem RDAP), a consulta é
+// feita na Hostinger, se a API estiver configurada.
+func CheckDomainAvailability(domain string) rdap.Result {
+	result := rdap.Default().Check(domain)
+	if result.Status != rdap.StatusUnknown {
+		return result
+	}
+
+	name, tld, ok := provisioning.SplitDomain(domain)
+	if !ok {
+		return result
+	}
+	hostinger, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return result
+	}
+	availability, err := hostinger.CheckDomainAvailability(name, []string{tld})
+	if err != nil {
+		log.Printf("Erro ao consultar a disponibilidade de '%s' na Hostinger após falha do RDAP (%s): %v", domain, result.Reason, err)
+		return result
+	}
+	for _, a := range availability {
+		if a.Domain != result.Domain || a.IsAlternative {
+			continue
+		}
+		result.Status = rdap.StatusRegistered
+		if a.IsAvailable {
+			result.Status = rdap.StatusAvailable
+		}
+		result.Server = "hostinger"
+		result.Reason = ""
+		break
+	}
+	return result
+}
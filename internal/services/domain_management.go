@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+var (
+	ErrInvalidNameservers       = errors.New("nameservers inválidos")
+	ErrDomainNotManageable      = errors.New("o domínio não pode ser gerenciado no registrador")
+	ErrDomainFeatureUnavailable = errors.New("recurso não disponível para este domínio no registrador")
+)
+
+const (
+	domainMinNameservers = 2
+	domainMaxNameservers = 4
+	// Tempo máximo da consulta DNS de cada nameserver na validação.
+	nameserverLookupTimeout = 5 * time.Second
+)
+
+var hostnamePattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// lookupNameserver resolve o endereço de um nameserver. É uma variável para permitir outro resolvedor.
+var lookupNameserver = net.DefaultResolver.LookupHost
+
+// ValidateNameservers normaliza e valida os nameservers de um domínio: de 2 a 4 hostnames distintos,
+// que resolvam para um endereço IP. Nameservers dentro do próprio domínio (ex: ns1.exemplo.com.br
+// para exemplo.com.br) só resolvem com registros glue no registrador.
+func ValidateNameservers(domain string, nameservers []string) ([]string, error) {
+	if len(nameservers) < domainMinNameservers || len(nameservers) > domainMaxNameservers {
+		return nil, fmt.Errorf("%w: informe de %d a %d nameservers", ErrInvalidNameservers, domainMinNameservers, domainMaxNameservers)
+	}
+
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(nameservers))
+	for _, ns := range nameservers {
+		ns = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(ns)), ".")
+		if len(ns) > 253 || !hostnamePattern.MatchString(ns) {
+			return nil, fmt.Errorf("%w: '%s' não é um hostname válido", ErrInvalidNameservers, ns)
+		}
+		if seen[ns] {
+			return nil, fmt.Errorf("%w: o nameserver %s foi informado mais de uma vez", ErrInvalidNameservers, ns)
+		}
+		seen[ns] = true
+		normalized = append(normalized, ns)
+	}
+
+	for _, ns := range normalized {
+		ctx, cancel := context.WithTimeout(context.Background(), nameserverLookupTimeout)
+		addrs, err := lookupNameserver(ctx, ns)
+		cancel()
+
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			// Falha temporária de DNS: o registrador valida os nameservers ao recebê-los.
+			log.Printf("[Domain Management] AVISO: não foi possível resolver o nameserver %s: %v", ns, err)
+			continue
+		}
+		if len(addrs) > 0 {
+			continue
+		}
+		if ns == domain || strings.HasSuffix(ns, "."+domain) {
+			return nil, fmt.Errorf("%w: o nameserver %s pertence ao próprio domínio e não tem registro glue no registrador", ErrInvalidNameservers, ns)
+		}
+		return nil, fmt.Errorf("%w: o nameserver %s não resolve para nenhum endereço IP", ErrInvalidNameservers, ns)
+	}
+	return normalized, nil
+}
+
+// ClientDomain é um domínio exibido na área do cliente, com o estado em cache no registrador.
+type ClientDomain struct {
+	ID                int                 `json:"id"`
+	Domain            string              `json:"domain"`
+	Type              models.DomainType   `json:"type"`
+	Status            models.DomainStatus `json:"status"`
+	ExpiresAt         *time.Time          `json:"expires_at,omitempty"`
+	Nameservers       []string            `json:"nameservers"`
+	RegistrarLocked   *bool               `json:"registrar_locked"`
+	WhoisPrivacy      *bool               `json:"whois_privacy"`
+	Manageable        bool                `json:"manageable"` // Nameservers, bloqueio e privacidade podem ser alterados
+	RegistrarSyncedAt *time.Time          `json:"registrar_synced_at,omitempty"`
+}
+
+func newClientDomain(d *models.Domain) ClientDomain {
+	view := ClientDomain{
+		ID:          d.ID,
+		Domain:      d.DomainName,
+		Type:        d.Type,
+		Status:      d.Status,
+		Nameservers: d.NameserverList(),
+		Manageable:  domainManageable(d),
+	}
+	if d.ExpiresAt.Valid {
+		view.ExpiresAt = &d.ExpiresAt.Time
+	}
+	if d.RegistrarLocked.Valid {
+		view.RegistrarLocked = &d.RegistrarLocked.Bool
+	}
+	if d.WhoisPrivacy.Valid {
+		view.WhoisPrivacy = &d.WhoisPrivacy.Bool
+	}
+	if d.RegistrarSyncedAt.Valid {
+		view.RegistrarSyncedAt = &d.RegistrarSyncedAt.Time
+	}
+	return view
+}
+
+// domainManageable informa se o domínio foi registrado ou transferido por nós e está ativo.
+func domainManageable(d *models.Domain) bool {
+	return d.Type != models.DomainTypeExisting && d.Status == models.StatusActive && d.Provider.Valid
+}
+
+// DomainManagementService altera no registrador os nameservers, o bloqueio de transferência e a
+// privacidade do WHOIS dos domínios dos clientes.
+type DomainManagementService struct {
+	db *sql.DB
+}
+
+// NewDomainManagementService cria o serviço de gestão de domínios.
+func NewDomainManagementService(db *sql.DB) *DomainManagementService {
+	return &DomainManagementService{db: db}
+}
+
+// ListForClient lista os domínios do cliente autenticado.
+func (s *DomainManagementService) ListForClient(userID int) ([]ClientDomain, error) {
+	client, err := models.GetClientByUserID(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	views := []ClientDomain{}
+	if client == nil {
+		return views, nil
+	}
+	domains, err := models.GetDomainsByClientID(s.db, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range domains {
+		views = append(views, newClientDomain(&domains[i]))
+	}
+	return views, nil
+}
+
+// GetForClient retorna um domínio do cliente. O estado é lido no registrador na primeira consulta ou
+// com refresh; nas demais, vem do cache.
+func (s *DomainManagementService) GetForClient(userID, domainID int, refresh bool) (*ClientDomain, error) {
+	domain, err := clientDomain(s.db, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if domainManageable(domain) && (refresh || !domain.RegistrarSyncedAt.Valid) {
+		if domain, err = s.syncRegistrarState(domain); err != nil {
+			return nil, err
+		}
+	}
+	view := newClientDomain(domain)
+	return &view, nil
+}
+
+// UpdateNameservers valida e aponta o domínio para os nameservers informados.
+func (s *DomainManagementService) UpdateNameservers(userID, domainID int, nameservers []string) (*ClientDomain, error) {
+	domain, provider, err := s.manageableDomain(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	nameservers, err = ValidateNameservers(domain.DomainName, nameservers)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := domain.NameserverList()
+	if err := provider.UpdateNameservers(domain.DomainName, nameservers); err != nil {
+		return nil, err
+	}
+	if err := models.UpdateDomainNameservers(s.db, domain.ID, nameservers); err != nil {
+		return nil, err
+	}
+	models.LogDomainEvent(s.db, domain.ID, "registrar.nameservers.updated", "Nameservers alterados pelo cliente",
+		map[string][]string{"from": previous, "to": nameservers})
+	return s.GetForClient(userID, domainID, false)
+}
+
+// SetLock ativa ou desativa o bloqueio de transferência do domínio.
+func (s *DomainManagementService) SetLock(userID, domainID int, locked bool) (*ClientDomain, error) {
+	domain, provider, err := s.manageableDomain(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.providerDetails(domain, provider)
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsLockable {
+		return nil, fmt.Errorf("%w: a extensão não permite o bloqueio de transferência", ErrDomainFeatureUnavailable)
+	}
+
+	if details.IsLocked != locked {
+		if err := provider.SetDomainLock(domain.DomainName, locked); err != nil {
+			return nil, err
+		}
+		message := "Bloqueio de transferência desativado pelo cliente"
+		if locked {
+			message = "Bloqueio de transferência ativado pelo cliente"
+		}
+		models.LogDomainEvent(s.db, domain.ID, "registrar.lock.updated", message, map[string]bool{"locked": locked})
+	}
+	if err := models.UpdateDomainRegistrarLock(s.db, domain.ID, locked); err != nil {
+		return nil, err
+	}
+	return s.GetForClient(userID, domainID, false)
+}
+
+// SetPrivacy ativa ou desativa a proteção de privacidade do WHOIS do domínio.
+func (s *DomainManagementService) SetPrivacy(userID, domainID int, enabled bool) (*ClientDomain, error) {
+	domain, provider, err := s.manageableDomain(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.providerDetails(domain, provider)
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsPrivacyProtectionAllowed {
+		return nil, fmt.Errorf("%w: a extensão não permite a privacidade do WHOIS", ErrDomainFeatureUnavailable)
+	}
+
+	if details.IsPrivacyProtected != enabled {
+		if err := provider.SetPrivacyProtection(domain.DomainName, enabled); err != nil {
+			return nil, err
+		}
+		message := "Privacidade do WHOIS desativada pelo cliente"
+		if enabled {
+			message = "Privacidade do WHOIS ativada pelo cliente"
+		}
+		models.LogDomainEvent(s.db, domain.ID, "registrar.privacy.updated", message, map[string]bool{"enabled": enabled})
+	}
+	if err := models.UpdateDomainWhoisPrivacy(s.db, domain.ID, enabled); err != nil {
+		return nil, err
+	}
+	return s.GetForClient(userID, domainID, false)
+}
+
+// manageableDomain busca um domínio do cliente que pode ser alterado no registrador.
+func (s *DomainManagementService) manageableDomain(userID, domainID int) (*models.Domain, provisioning.DomainProvider, error) {
+	domain, err := clientDomain(s.db, userID, domainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !domainManageable(domain) {
+		return nil, nil, ErrDomainNotManageable
+	}
+	provider, err := provisioning.NewDomainProvider(domain.Provider.String)
+	if err != nil {
+		return nil, nil, err
+	}
+	return domain, provider, nil
+}
+
+// providerDetails busca o domínio no registrador. Um domínio fora da conta não pode ser gerenciado.
+func (s *DomainManagementService) providerDetails(domain *models.Domain, provider provisioning.DomainProvider) (*provisioning.DomainDetails, error) {
+	details, err := provider.GetDomainDetails(domain.DomainName)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, fmt.Errorf("%w: %s não consta na conta do registrador", ErrDomainNotManageable, domain.DomainName)
+	}
+	return details, nil
+}
+
+// syncRegistrarState lê no registrador e grava em cache os nameservers, o bloqueio e a privacidade.
+func (s *DomainManagementService) syncRegistrarState(domain *models.Domain) (*models.Domain, error) {
+	provider, err := provisioning.NewDomainProvider(domain.Provider.String)
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.providerDetails(domain, provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.UpdateDomainRegistrarState(s.db, domain.ID, details.NameserverList(), details.IsLocked, details.IsPrivacyProtected); err != nil {
+		return nil, err
+	}
+	return models.GetDomainByID(s.db, domain.ID)
+}
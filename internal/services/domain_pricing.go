@@ -0,0 +1,396 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+var (
+	ErrDomainPriceNotFound      = errors.New("TLD não disponível para venda neste período")
+	ErrDomainMarginRuleNotFound = errors.New("regra de margem não encontrada")
+	ErrDomainPromotionNotFound  = errors.New("promoção não encontrada")
+	ErrInvalidDomainPricing     = errors.New("preço de domínio inválido")
+)
+
+// domainCostCurrency é a moeda dos custos importados do catálogo do registrador.
+const domainCostCurrency = "BRL"
+
+// DomainPriceQuote é o preço de um domínio para uma operação e período.
+type DomainPriceQuote struct {
+	TLD             string                      `json:"tld"`
+	Operation       models.DomainPriceOperation `json:"operation"`
+	Years           int                         `json:"years"`
+	Price           float64                     `json:"price"`         // Preço a cobrar, com a promoção vigente
+	RegularPrice    float64                     `json:"regular_price"` // Preço sem promoção
+	PromotionID     *int                        `json:"promotion_id,omitempty"`
+	PromotionEndsAt *time.Time                  `json:"promotion_ends_at,omitempty"`
+}
+
+// QuoteDomainPrice calcula o preço de uma TLD para a operação e o período em anos.
+//
+// O preço regular é o preço fixo da tabela ou, sem ele, o custo no registrador com a regra de margem
+// mais específica. Se a tabela não tem o período, vale o preço de um ano multiplicado pelos anos.
+// Uma promoção vigente substitui o preço regular quando é menor. TLDs sem preço, e custos sem regra
+// de margem, resultam em ErrDomainPriceNotFound: a TLD não é vendida.
+func QuoteDomainPrice(db *sql.DB, tld string, operation models.DomainPriceOperation, years int) (*DomainPriceQuote, error) {
+	tld = normalizeTLD(tld)
+	if !operation.Valid() || years < models.DomainMinYears || years > models.DomainMaxYears {
+		return nil, fmt.Errorf("%w: operação '%s' por %d ano(s)", ErrInvalidDomainPricing, operation, years)
+	}
+
+	row, err := models.GetDomainTLDPrice(db, tld, operation, years)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o preço de .%s: %w", tld, err)
+	}
+	multiplier := 1.0
+	if row == nil && years > 1 {
+		row, err = models.GetDomainTLDPrice(db, tld, operation, 1)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao buscar o preço de .%s: %w", tld, err)
+		}
+		multiplier = float64(years)
+	}
+	if row == nil {
+		return nil, fmt.Errorf("%w: .%s (%s, %d ano(s))", ErrDomainPriceNotFound, tld, operation, years)
+	}
+
+	regular, err := domainRegularPrice(db, row)
+	if err != nil {
+		return nil, err
+	}
+	quote := &DomainPriceQuote{
+		TLD:          tld,
+		Operation:    operation,
+		Years:        years,
+		Price:        roundCents(regular * multiplier),
+		RegularPrice: roundCents(regular * multiplier),
+	}
+
+	promotion, err := models.GetActiveDomainPromotion(db, tld, operation, years, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar as promoções de .%s: %w", tld, err)
+	}
+	if promotion != nil && promotion.Price < quote.RegularPrice {
+		quote.Price = promotion.Price
+		quote.PromotionID = &promotion.ID
+		quote.PromotionEndsAt = &promotion.EndsAt
+	}
+	return quote, nil
+}
+
+// domainRegularPrice retorna o preço fixo da linha ou o custo com a margem.
+func domainRegularPrice(db *sql.DB, row *models.DomainTLDPrice) (float64, error) {
+	if row.Price.Valid {
+		return row.Price.Float64, nil
+	}
+	if !row.CostPrice.Valid {
+		return 0, fmt.Errorf("%w: .%s sem preço nem custo (%s, %d ano(s))", ErrDomainPriceNotFound, row.TLD, row.Operation, row.Years)
+	}
+	rule, err := models.FindDomainMarginRule(db, row.TLD, row.Operation)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao buscar a margem de .%s: %w", row.TLD, err)
+	}
+	if rule == nil {
+		// Vender pelo custo zeraria a margem; a TLD fica indisponível até haver uma regra.
+		log.Printf("[Domain Pricing] .%s tem custo mas nenhuma regra de margem se aplica (%s).", row.TLD, row.Operation)
+		return 0, fmt.Errorf("%w: .%s sem regra de margem", ErrDomainPriceNotFound, row.TLD)
+	}
+	return roundCents(row.CostPrice.Float64*(1+rule.MarginPercent/100) + rule.MarginFixed), nil
+}
+
+// DomainPricingService mantém a tabela de preços de domínios: preços e custos por TLD, regras de
+// margem e promoções. Todas as alterações ficam no log de auditoria.
+type DomainPricingService struct {
+	db *sql.DB
+}
+
+// NewDomainPricingService cria uma nova instância de DomainPricingService.
+func NewDomainPricingService(db *sql.DB) *DomainPricingService {
+	return &DomainPricingService{db: db}
+}
+
+// Quote calcula o preço de uma TLD, como no checkout.
+func (s *DomainPricingService) Quote(tld string, operation models.DomainPriceOperation, years int) (*DomainPriceQuote, error) {
+	return QuoteDomainPrice(s.db, tld, operation, years)
+}
+
+// ListPrices lista a tabela de preços de uma TLD ou de todas (tld vazia).
+func (s *DomainPricingService) ListPrices(tld string) ([]models.DomainTLDPrice, error) {
+	prices, err := models.GetDomainTLDPrices(s.db, normalizeTLD(tld))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar a tabela de preços: %w", err)
+	}
+	if prices == nil {
+		prices = []models.DomainTLDPrice{}
+	}
+	return prices, nil
+}
+
+// DomainTLDPriceInput é uma linha da tabela de preços enviada pelo administrador. Preço ou custo
+// nulos apagam o valor anterior.
+type DomainTLDPriceInput struct {
+	Operation models.DomainPriceOperation `json:"operation"`
+	Years     int                         `json:"years"`
+	CostPrice *float64                    `json:"cost_price"`
+	Price     *float64                    `json:"price"`
+}
+
+// SetTLDPrices grava as linhas informadas da tabela de preços de uma TLD, em uma única transação.
+// Linhas não informadas permanecem como estão.
+func (s *DomainPricingService) SetTLDPrices(tld string, entries []DomainTLDPriceInput, adminID int, ipAddress string) ([]models.DomainTLDPrice, error) {
+	tld = normalizeTLD(tld)
+	if !validTLD(tld) {
+		return nil, fmt.Errorf("%w: TLD '%s'", ErrInvalidDomainPricing, tld)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: informe ao menos um preço", ErrInvalidDomainPricing)
+	}
+	rows := make([]models.DomainTLDPrice, 0, len(entries))
+	for _, e := range entries {
+		if !e.Operation.Valid() {
+			return nil, fmt.Errorf("%w: operação '%s'", ErrInvalidDomainPricing, e.Operation)
+		}
+		if e.Years < models.DomainMinYears || e.Years > models.DomainMaxYears {
+			return nil, fmt.Errorf("%w: o período deve ser de %d a %d anos", ErrInvalidDomainPricing, models.DomainMinYears, models.DomainMaxYears)
+		}
+		if e.CostPrice != nil && *e.CostPrice < 0 || e.Price != nil && *e.Price < 0 {
+			return nil, fmt.Errorf("%w: preços e custos não podem ser negativos", ErrInvalidDomainPricing)
+		}
+		row := models.DomainTLDPrice{TLD: tld, Operation: e.Operation, Years: e.Years}
+		if e.CostPrice != nil {
+			row.CostPrice = sql.NullFloat64{Float64: roundCents(*e.CostPrice), Valid: true}
+		}
+		if e.Price != nil {
+			row.Price = sql.NullFloat64{Float64: roundCents(*e.Price), Valid: true}
+		}
+		rows = append(rows, row)
+	}
+
+	previous, err := models.GetDomainTLDPrices(s.db, tld)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar a tabela de preços: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for i := range rows {
+		if err := models.UpsertDomainTLDPrice(tx, &rows[i]); err != nil {
+			return nil, fmt.Errorf("falha ao gravar o preço de .%s: %w", tld, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "domain_price.update", "domain_tld", tld, previous, rows, models.AuditLogResultSuccess)
+	return s.ListPrices(tld)
+}
+
+// DeleteTLD remove a tabela de preços de uma TLD, que deixa de ser vendida.
+func (s *DomainPricingService) DeleteTLD(tld string, adminID int, ipAddress string) error {
+	tld = normalizeTLD(tld)
+	previous, err := models.GetDomainTLDPrices(s.db, tld)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar a tabela de preços: %w", err)
+	}
+	if len(previous) == 0 {
+		return ErrDomainPriceNotFound
+	}
+	if _, err := models.DeleteDomainTLDPrices(s.db, tld); err != nil {
+		return fmt.Errorf("falha ao remover os preços de .%s: %w", tld, err)
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_price.delete", "domain_tld", tld, previous, nil, models.AuditLogResultSuccess)
+	return nil
+}
+
+// ListMarginRules lista as regras de margem.
+func (s *DomainPricingService) ListMarginRules() ([]models.DomainMarginRule, error) {
+	rules, err := models.GetDomainMarginRules(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar as regras de margem: %w", err)
+	}
+	if rules == nil {
+		rules = []models.DomainMarginRule{}
+	}
+	return rules, nil
+}
+
+// SaveMarginRule grava a regra de margem do escopo da regra (TLD e operação, ambas opcionais),
+// substituindo a existente.
+func (s *DomainPricingService) SaveMarginRule(rule *models.DomainMarginRule, adminID int, ipAddress string) error {
+	if rule.TLD.Valid {
+		rule.TLD.String = normalizeTLD(rule.TLD.String)
+		if !validTLD(rule.TLD.String) {
+			return fmt.Errorf("%w: TLD '%s'", ErrInvalidDomainPricing, rule.TLD.String)
+		}
+	}
+	if rule.Operation.Valid && !models.DomainPriceOperation(rule.Operation.String).Valid() {
+		return fmt.Errorf("%w: operação '%s'", ErrInvalidDomainPricing, rule.Operation.String)
+	}
+	if rule.MarginPercent < 0 || rule.MarginFixed < 0 {
+		return fmt.Errorf("%w: a margem não pode ser negativa", ErrInvalidDomainPricing)
+	}
+
+	if err := models.UpsertDomainMarginRule(s.db, rule); err != nil {
+		return fmt.Errorf("falha ao gravar a regra de margem: %w", err)
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_margin_rule.save", "domain_margin_rule", strconv.Itoa(rule.ID), nil, rule, models.AuditLogResultSuccess)
+	return nil
+}
+
+// DeleteMarginRule remove uma regra de margem.
+func (s *DomainPricingService) DeleteMarginRule(id, adminID int, ipAddress string) error {
+	rule, err := models.GetDomainMarginRuleByID(s.db, id)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar a regra de margem: %w", err)
+	}
+	if rule == nil {
+		return ErrDomainMarginRuleNotFound
+	}
+	if _, err := models.DeleteDomainMarginRule(s.db, id); err != nil {
+		return fmt.Errorf("falha ao remover a regra de margem: %w", err)
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_margin_rule.delete", "domain_margin_rule", strconv.Itoa(id), rule, nil, models.AuditLogResultSuccess)
+	return nil
+}
+
+// ListPromotions lista as promoções vigentes e futuras ou, com includeEnded, também as encerradas.
+func (s *DomainPricingService) ListPromotions(includeEnded bool) ([]models.DomainPromotion, error) {
+	promotions, err := models.GetDomainPromotions(s.db, includeEnded)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar as promoções: %w", err)
+	}
+	if promotions == nil {
+		promotions = []models.DomainPromotion{}
+	}
+	return promotions, nil
+}
+
+// CreatePromotion valida e cria uma promoção.
+func (s *DomainPricingService) CreatePromotion(p *models.DomainPromotion, adminID int, ipAddress string) error {
+	p.TLD = normalizeTLD(p.TLD)
+	if p.Years == 0 {
+		p.Years = 1
+	}
+	switch {
+	case !validTLD(p.TLD):
+		return fmt.Errorf("%w: TLD '%s'", ErrInvalidDomainPricing, p.TLD)
+	case !p.Operation.Valid():
+		return fmt.Errorf("%w: operação '%s'", ErrInvalidDomainPricing, p.Operation)
+	case p.Years < models.DomainMinYears || p.Years > models.DomainMaxYears:
+		return fmt.Errorf("%w: o período deve ser de %d a %d anos", ErrInvalidDomainPricing, models.DomainMinYears, models.DomainMaxYears)
+	case roundCents(p.Price) <= 0:
+		return fmt.Errorf("%w: o preço promocional deve ser maior que zero", ErrInvalidDomainPricing)
+	case p.StartsAt.IsZero() || !p.EndsAt.After(p.StartsAt):
+		return fmt.Errorf("%w: o fim da promoção deve ser posterior ao início", ErrInvalidDomainPricing)
+	case !p.EndsAt.After(time.Now()):
+		return fmt.Errorf("%w: a promoção já terminou", ErrInvalidDomainPricing)
+	}
+	p.Price = roundCents(p.Price)
+
+	if err := models.CreateDomainPromotion(s.db, p); err != nil {
+		return fmt.Errorf("falha ao criar a promoção: %w", err)
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_promotion.create", "domain_promotion", strconv.Itoa(p.ID), nil, p, models.AuditLogResultSuccess)
+	return nil
+}
+
+// DeletePromotion remove uma promoção; para encerrar uma promoção vigente antes do prazo.
+func (s *DomainPricingService) DeletePromotion(id, adminID int, ipAddress string) error {
+	promotion, err := models.GetDomainPromotionByID(s.db, id)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar a promoção: %w", err)
+	}
+	if promotion == nil {
+		return ErrDomainPromotionNotFound
+	}
+	if _, err := models.DeleteDomainPromotion(s.db, id); err != nil {
+		return fmt.Errorf("falha ao remover a promoção: %w", err)
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_promotion.delete", "domain_promotion", strconv.Itoa(id), promotion, nil, models.AuditLogResultSuccess)
+	return nil
+}
+
+// DomainCostSyncResult resume uma sincronização de custos com o registrador.
+type DomainCostSyncResult struct {
+	Updated int      `json:"updated"` // Linhas da tabela com o custo atualizado
+	TLDs    int      `json:"tlds"`
+	Skipped []string `json:"skipped"` // TLDs ignoradas (ex: preço em outra moeda)
+}
+
+// SyncProviderCosts importa os custos do catálogo de domínios da Hostinger para a tabela de preços.
+// O catálogo tem um único preço por período, usado como custo do registro, da transferência e da
+// renovação. Preços fixos definidos pelo administrador não são alterados.
+func (s *DomainPricingService) SyncProviderCosts(adminID int, ipAddress string) (*DomainCostSyncResult, error) {
+	provisioner, err := provisioning.NewHostingerProvisioner()
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := provisioner.GetDomainCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DomainCostSyncResult{Skipped: []string{}}
+	for _, item := range catalog {
+		tld := normalizeTLD(item.Name)
+		if !validTLD(tld) {
+			continue
+		}
+		imported := false
+		for _, price := range item.Pricing {
+			if price.PeriodType != "year" || price.Period < models.DomainMinYears || price.Period > models.DomainMaxYears {
+				continue
+			}
+			if !strings.EqualFold(price.Currency, domainCostCurrency) {
+				continue
+			}
+			cost := roundCents(float64(price.Price) / 100) // O catálogo informa centavos
+			for _, operation := range []models.DomainPriceOperation{models.DomainPriceRegister, models.DomainPriceTransfer, models.DomainPriceRenew} {
+				if err := models.SetDomainTLDCostPrice(s.db, tld, operation, price.Period, cost); err != nil {
+					return nil, fmt.Errorf("falha ao gravar o custo de .%s: %w", tld, err)
+				}
+				result.Updated++
+			}
+			imported = true
+		}
+		if imported {
+			result.TLDs++
+		} else {
+			result.Skipped = append(result.Skipped, tld)
+		}
+	}
+
+	log.Printf("[Domain Pricing] Custos sincronizados: %d TLDs, %d preços, %d TLDs ignoradas.", result.TLDs, result.Updated, len(result.Skipped))
+	recordAuditLog(s.db, adminID, ipAddress, "domain_price.sync_costs", "domain_tld", "", nil, result, models.AuditLogResultSuccess)
+	return result, nil
+}
+
+// normalizeTLD remove espaços e pontos das pontas e converte para minúsculas (ex: ".COM" → "com").
+func normalizeTLD(tld string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(tld)), ".")
+}
+
+// validTLD verifica se cada rótulo da TLD é um rótulo de domínio válido.
+func validTLD(tld string) bool {
+	if tld == "" {
+		return false
+	}
+	for _, part := range strings.Split(tld, ".") {
+		if !validDomainLabel(part) {
+			return false
+		}
+	}
+	return true
+}
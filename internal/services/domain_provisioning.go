@@ -0,0 +1,109 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/orchestrator"
+)
+
+var (
+	ErrDomainNotFound    = errors.New("domínio não encontrado")
+	ErrDomainNotFailed   = errors.New("apenas domínios com falha no provisionamento podem ser retomados")
+	ErrDomainCancelled   = errors.New("o domínio foi cancelado")
+	ErrInvalidDomainStep = errors.New("etapa de provisionamento inválida")
+)
+
+// DomainTimeline é o histórico de provisionamento de um domínio: a situação de cada etapa e todos
+// os eventos registrados em domain_events.
+type DomainTimeline struct {
+	Domain *models.Domain                 `json:"domain"`
+	Steps  []orchestrator.DomainStepState `json:"steps"`
+	Events []models.DomainEvent           `json:"events"`
+}
+
+// DomainProvisioningService expõe aos administradores o acompanhamento e a retomada do
+// provisionamento de domínios.
+type DomainProvisioningService struct {
+	db *sql.DB
+}
+
+// NewDomainProvisioningService cria uma nova instância de DomainProvisioningService.
+func NewDomainProvisioningService(db *sql.DB) *DomainProvisioningService {
+	return &DomainProvisioningService{db: db}
+}
+
+// Timeline retorna a situação das etapas e os eventos do domínio.
+func (s *DomainProvisioningService) Timeline(domainID int) (*DomainTimeline, error) {
+	domain, err := s.getDomain(domainID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := models.GetDomainEvents(s.db, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar os eventos do domínio: %w", err)
+	}
+	if events == nil {
+		events = []models.DomainEvent{}
+	}
+	return &DomainTimeline{
+		Domain: domain,
+		Steps:  orchestrator.GetDomainStepStates(events),
+		Events: events,
+	}, nil
+}
+
+// Resume retoma o provisionamento de um domínio com falha a partir da etapa que falhou.
+func (s *DomainProvisioningService) Resume(domainID, adminID int, ipAddress string) error {
+	domain, err := s.getDomain(domainID)
+	if err != nil {
+		return err
+	}
+	if domain.Status != models.StatusFailed {
+		return ErrDomainNotFailed
+	}
+	if err := orchestrator.ResumeDomainProvisioning(s.db, domainID); err != nil {
+		return fmt.Errorf("falha ao retomar o provisionamento: %w", err)
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "domain.provisioning.resume", "domain", strconv.Itoa(domainID),
+		map[string]any{"status": domain.Status}, map[string]any{"status": models.StatusPendingProvisioning},
+		models.AuditLogResultSuccess)
+	return nil
+}
+
+// RetryStep executa novamente uma única etapa do provisionamento do domínio.
+func (s *DomainProvisioningService) RetryStep(domainID int, stepName string, adminID int, ipAddress string) error {
+	step, ok := orchestrator.ParseDomainStep(stepName)
+	if !ok {
+		return ErrInvalidDomainStep
+	}
+	domain, err := s.getDomain(domainID)
+	if err != nil {
+		return err
+	}
+	if domain.Status == models.StatusCancelled {
+		return ErrDomainCancelled
+	}
+	if err := orchestrator.RetryDomainStep(s.db, domainID, step); err != nil {
+		return fmt.Errorf("falha ao enfileirar a etapa: %w", err)
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "domain.provisioning.retry_step", "domain", strconv.Itoa(domainID),
+		nil, map[string]any{"step": step}, models.AuditLogResultSuccess)
+	return nil
+}
+
+func (s *DomainProvisioningService) getDomain(domainID int) (*models.Domain, error) {
+	domain, err := models.GetDomainByID(s.db, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o domínio: %w", err)
+	}
+	if domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/orchestrator"
+)
+
+var ErrInvalidDomainRenewalSettings = errors.New("regras de renovação de domínios inválidas")
+
+const (
+	// Quantos domínios têm o vencimento lido no registrador a cada ciclo, e de quanto em quanto tempo.
+	domainExpirySyncBatch    = 50
+	domainExpirySyncInterval = 24 * time.Hour
+	// Prazo após o vencimento para o registrador confirmar uma renovação enviada.
+	domainRenewalConfirmDays = 3
+)
+
+// DomainRenewalService renova os domínios registrados por nós: lê o vencimento no registrador, emite a
+// fatura de renovação, envia os lembretes ao cliente e aplica os estados de vencido e em redenção.
+type DomainRenewalService struct {
+	db *sql.DB
+}
+
+// NewDomainRenewalService cria o serviço de renovação de domínios.
+func NewDomainRenewalService(db *sql.DB) *DomainRenewalService {
+	return &DomainRenewalService{db: db}
+}
+
+// GetSettings retorna as regras de renovação de domínios.
+func (s *DomainRenewalService) GetSettings() (*models.DomainRenewalSettings, error) {
+	return models.GetDomainRenewalSettings(s.db)
+}
+
+// UpdateSettings valida e grava as regras de renovação de domínios.
+func (s *DomainRenewalService) UpdateSettings(settings *models.DomainRenewalSettings, adminID int, ipAddress string) (*models.DomainRenewalSettings, error) {
+	if settings.InvoiceDaysBefore < 1 || settings.InvoiceDaysBefore > 90 {
+		return nil, fmt.Errorf("%w: a fatura deve ser emitida de 1 a 90 dias antes do vencimento", ErrInvalidDomainRenewalSettings)
+	}
+	if settings.GraceDays < 0 || settings.RedemptionDays < 0 {
+		return nil, fmt.Errorf("%w: os prazos de carência e de redenção não podem ser negativos", ErrInvalidDomainRenewalSettings)
+	}
+	days := make([]string, 0, len(settings.ReminderDays))
+	for _, d := range settings.ReminderDays {
+		if d < 1 || d > 90 {
+			return nil, fmt.Errorf("%w: antecedência de lembrete inválida: %d", ErrInvalidDomainRenewalSettings, d)
+		}
+		days = append(days, strconv.Itoa(d))
+	}
+	settings.ReminderDays = models.ParseReminderDays(strings.Join(days, ","))
+
+	previous, err := models.GetDomainRenewalSettings(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.UpdateDomainRenewalSettings(s.db, settings); err != nil {
+		return nil, err
+	}
+	recordAuditLog(s.db, adminID, ipAddress, "domain_renewal.settings_update", "system_settings", "1", previous, settings, models.AuditLogResultSuccess)
+	return settings, nil
+}
+
+// RunCycle executa um ciclo de renovação. Cada etapa registra as suas falhas e não impede as demais.
+func (s *DomainRenewalService) RunCycle() error {
+	settings, err := models.GetDomainRenewalSettings(s.db)
+	if err != nil {
+		return fmt.Errorf("erro ao ler as regras de renovação: %w", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	s.syncExpiries()
+	s.issueRenewalInvoices(settings, today)
+	s.sendReminders(settings, today)
+	s.applyExpiryRules(settings, today)
+	s.checkStalledRenewals(today)
+	return nil
+}
+
+// syncExpiries lê no registrador o vencimento dos domínios não consultados nas últimas 24 horas.
+func (s *DomainRenewalService) syncExpiries() {
+	domains, err := models.GetDomainsForExpirySync(s.db, "hostinger", time.Now().Add(-domainExpirySyncInterval), domainExpirySyncBatch)
+	if err != nil {
+		log.Printf("[Domain Renewal] ERRO ao buscar domínios para sincronizar o vencimento: %v", err)
+		return
+	}
+	for i := range domains {
+		if err := orchestrator.SyncDomainExpiry(s.db, &domains[i]); err != nil {
+			log.Printf("[Domain Renewal] ERRO ao sincronizar o vencimento de %s: %v", domains[i].DomainName, err)
+		}
+	}
+}
+
+// issueRenewalInvoices emite a fatura de renovação dos domínios que vencem dentro da antecedência
+// configurada, uma única vez por vencimento.
+func (s *DomainRenewalService) issueRenewalInvoices(settings *models.DomainRenewalSettings, today time.Time) {
+	domains, err := models.GetDomainsExpiringBefore(s.db, today.AddDate(0, 0, settings.InvoiceDaysBefore))
+	if err != nil {
+		log.Printf("[Domain Renewal] ERRO ao buscar domínios a renovar: %v", err)
+		return
+	}
+	for i := range domains {
+		domain := &domains[i]
+		existing, err := models.GetDomainRenewal(s.db, domain.ID, domain.ExpiresAt.Time)
+		if err != nil {
+			log.Printf("[Domain Renewal] ERRO ao buscar a renovação de %s: %v", domain.DomainName, err)
+			continue
+		}
+		if existing != nil {
+			continue
+		}
+		if err := s.issueRenewalInvoice(domain, today); err != nil {
+			log.Printf("[Domain Renewal] ERRO ao emitir a fatura de renovação de %s: %v", domain.DomainName, err)
+		}
+	}
+}
+
+func (s *DomainRenewalService) issueRenewalInvoice(domain *models.Domain, today time.Time) error {
+	tld := domain.DomainName[strings.Index(domain.DomainName, ".")+1:]
+	quote, err := QuoteDomainPrice(s.db, tld, models.DomainPriceRenew, 1)
+	if errors.Is(err, ErrDomainPriceNotFound) {
+		models.OpenStaffTask(s.db, &models.StaffTask{
+			Kind:       "domain.renewal_price_missing",
+			Priority:   models.StaffTaskPriorityHigh,
+			TargetType: "domain",
+			TargetID:   strconv.Itoa(domain.ID),
+			Title:      fmt.Sprintf("Sem preço de renovação para .%s: fatura de %s não emitida", tld, domain.DomainName),
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	client, err := models.GetClientByID(s.db, domain.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("cliente #%d não encontrado", domain.ClientID)
+	}
+
+	dueDate := domain.ExpiresAt.Time
+	if dueDate.Before(today) {
+		dueDate = today
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invoiceID int
+	err = tx.QueryRow(`INSERT INTO invoices (user_id, service_id, issue_date, due_date, total_amount, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		client.UserID, domain.ServiceID, time.Now(), dueDate, quote.Price, models.InvoiceStatusUnpaid).Scan(&invoiceID)
+	if err != nil {
+		return err
+	}
+	description := fmt.Sprintf("Renovação de domínio: %s - 1 ano (vencimento em %s)", domain.DomainName, domain.ExpiresAt.Time.Format("02/01/2006"))
+	_, err = tx.Exec(`INSERT INTO invoice_items (invoice_id, service_id, description, amount, item_type) VALUES ($1, $2, $3, $4, $5)`,
+		invoiceID, domain.ServiceID, description, quote.Price, models.InvoiceItemTypeService)
+	if err != nil {
+		return err
+	}
+	renewal := &models.DomainRenewal{
+		DomainID:  domain.ID,
+		InvoiceID: sql.NullInt64{Int64: int64(invoiceID), Valid: true},
+		ExpiresAt: domain.ExpiresAt.Time,
+		Years:     1,
+		Price:     quote.Price,
+	}
+	created, err := models.CreateDomainRenewal(tx, renewal)
+	if err != nil || !created {
+		return err // Outro ciclo já emitiu a fatura deste vencimento
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	models.LogDomainEvent(s.db, domain.ID, "renewal.invoiced", fmt.Sprintf("Fatura de renovação #%d emitida", invoiceID),
+		map[string]any{"renewal_id": renewal.ID, "invoice_id": invoiceID, "price": quote.Price})
+	// Sem a renovação automática, o registrador não renova (nem cobra) antes de o cliente pagar.
+	if err := orchestrator.DisableDomainAutoRenewal(s.db, domain); err != nil {
+		log.Printf("[Domain Renewal] AVISO: falha ao desativar a renovação automática de %s: %v", domain.DomainName, err)
+	}
+	log.Printf("[Domain Renewal] Fatura de renovação #%d emitida para %s.", invoiceID, domain.DomainName)
+	return nil
+}
+
+// sendReminders envia ao cliente o lembrete do vencimento de domínios ativos ainda não renovados,
+// uma vez por antecedência configurada.
+func (s *DomainRenewalService) sendReminders(settings *models.DomainRenewalSettings, today time.Time) {
+	if len(settings.ReminderDays) == 0 {
+		return
+	}
+	domains, err := models.GetDomainsExpiringBefore(s.db, today.AddDate(0, 0, settings.ReminderDays[0]))
+	if err != nil {
+		log.Printf("[Domain Renewal] ERRO ao buscar domínios para lembrete: %v", err)
+		return
+	}
+	for i := range domains {
+		domain := &domains[i]
+		if domain.Status != models.StatusActive {
+			continue
+		}
+		daysLeft := int(domain.ExpiresAt.Time.Sub(today).Hours() / 24)
+		if daysLeft < 0 {
+			continue
+		}
+		// A menor antecedência que já foi alcançada: um ciclo perdido não gera lembretes em sequência.
+		threshold := 0
+		for _, d := range settings.ReminderDays {
+			if d >= daysLeft {
+				threshold = d
+			}
+		}
+		if threshold == 0 {
+			continue
+		}
+
+		renewal, err := models.GetDomainRenewal(s.db, domain.ID, domain.ExpiresAt.Time)
+		if err != nil {
+			log.Printf("[Domain Renewal] ERRO ao buscar a renovação de %s: %v", domain.DomainName, err)
+			continue
+		}
+		if renewal != nil && renewal.Status != models.DomainRenewalPendingPayment {
+			continue // Já paga
+		}
+
+		claimed, err := models.ClaimDomainRenewalReminder(s.db, domain.ID, domain.ExpiresAt.Time, threshold)
+		if err != nil || !claimed {
+			continue
+		}
+		if err := s.sendReminder(domain, renewal, daysLeft); err != nil {
+			log.Printf("[Domain Renewal] ERRO ao enviar o lembrete de %s: %v", domain.DomainName, err)
+			models.ReleaseDomainRenewalReminder(s.db, domain.ID, domain.ExpiresAt.Time, threshold)
+			continue
+		}
+		models.LogDomainEvent(s.db, domain.ID, "renewal.reminder_sent", fmt.Sprintf("Lembrete de vencimento enviado (%d dias)", daysLeft),
+			map[string]int{"days_before": threshold})
+	}
+}
+
+func (s *DomainRenewalService) sendReminder(domain *models.Domain, renewal *models.DomainRenewal, daysLeft int) error {
+	client, err := models.GetClientByID(s.db, domain.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("cliente #%d não encontrado", domain.ClientID)
+	}
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar o serviço de e-mail: %w", err)
+	}
+
+	clientName := client.Email
+	if client.ContactName.Valid && client.ContactName.String != "" {
+		clientName = client.ContactName.String
+	} else if client.CompanyName.Valid && client.CompanyName.String != "" {
+		clientName = client.CompanyName.String
+	}
+	data := map[string]string{
+		"ClientName": clientName,
+		"Domain":     domain.DomainName,
+		"DaysLeft":   strconv.Itoa(daysLeft),
+		"ExpiresAt":  domain.ExpiresAt.Time.Format("02/01/2006"),
+	}
+	if renewal != nil && renewal.InvoiceID.Valid {
+		data["InvoiceID"] = strconv.FormatInt(renewal.InvoiceID.Int64, 10)
+		data["Amount"] = fmt.Sprintf("%.2f", renewal.Price)
+	}
+
+	return emailService.Send(email.EmailData{
+		ToName:       clientName,
+		ToEmail:      client.Email,
+		Subject:      fmt.Sprintf("O domínio %s vence em %d dia(s)", domain.DomainName, daysLeft),
+		Template:     "domain_renewal_reminder.html",
+		TemplateData: data,
+	})
+}
+
+// applyExpiryRules move os domínios não renovados para vencido, depois para redenção e, terminada a
+// redenção, para cancelado, anulando a fatura de renovação em aberto.
+func (s *DomainRenewalService) applyExpiryRules(settings *models.DomainRenewalSettings, today time.Time) {
+	domains, err := models.GetDomainsExpiringBefore(s.db, today.AddDate(0, 0, -1))
+	if err != nil {
+		log.Printf("[Domain Renewal] ERRO ao buscar domínios vencidos: %v", err)
+		return
+	}
+	for i := range domains {
+		domain := &domains[i]
+		renewal, err := models.GetDomainRenewal(s.db, domain.ID, domain.ExpiresAt.Time)
+		if err != nil {
+			log.Printf("[Domain Renewal] ERRO ao buscar a renovação de %s: %v", domain.DomainName, err)
+			continue
+		}
+		if renewal != nil && (renewal.Status == models.DomainRenewalPaid || renewal.Status == models.DomainRenewalSubmitted) {
+			continue // Paga: aguardando o registrador
+		}
+
+		expiredFor := int(today.Sub(domain.ExpiresAt.Time).Hours() / 24)
+		next := models.StatusExpired
+		if expiredFor > settings.GraceDays+settings.RedemptionDays {
+			next = models.StatusCancelled
+		} else if expiredFor > settings.GraceDays {
+			next = models.StatusRedemption
+		}
+		if next == domain.Status {
+			continue
+		}
+
+		if err := models.UpdateDomainStatus(s.db, domain.ID, next); err != nil {
+			log.Printf("[Domain Renewal] ERRO ao mudar o status de %s para %s: %v", domain.DomainName, next, err)
+			continue
+		}
+		if next == models.StatusCancelled && renewal != nil && renewal.Status == models.DomainRenewalPendingPayment {
+			models.UpdateDomainRenewalStatus(s.db, renewal.ID, models.DomainRenewalPendingPayment, models.DomainRenewalCancelled)
+			if renewal.InvoiceID.Valid {
+				models.VoidUnpaidInvoice(s.db, int(renewal.InvoiceID.Int64))
+			}
+		}
+		models.LogDomainEvent(s.db, domain.ID, "renewal."+string(next), fmt.Sprintf("Domínio %s há %d dia(s) sem renovação", domain.DomainName, expiredFor),
+			map[string]string{"from": string(domain.Status), "to": string(next)})
+		log.Printf("[Domain Renewal] %s: %s -> %s.", domain.DomainName, domain.Status, next)
+	}
+}
+
+// checkStalledRenewals abre uma pendência para as renovações enviadas que o registrador não confirmou
+// alguns dias após o vencimento.
+func (s *DomainRenewalService) checkStalledRenewals(today time.Time) {
+	renewals, err := models.GetDomainRenewalsByStatus(s.db, models.DomainRenewalSubmitted)
+	if err != nil {
+		log.Printf("[Domain Renewal] ERRO ao buscar renovações enviadas: %v", err)
+		return
+	}
+	for _, renewal := range renewals {
+		if !today.After(renewal.ExpiresAt.AddDate(0, 0, domainRenewalConfirmDays)) {
+			continue
+		}
+		models.OpenStaffTask(s.db, &models.StaffTask{
+			Kind:       "domain.renewal_unconfirmed",
+			Priority:   models.StaffTaskPriorityHigh,
+			TargetType: "domain",
+			TargetID:   strconv.Itoa(renewal.DomainID),
+			Title:      fmt.Sprintf("Renovação #%d enviada e não confirmada pelo registrador (venceu em %s)", renewal.ID, renewal.ExpiresAt.Format("02/01/2006")),
+		})
+	}
+}
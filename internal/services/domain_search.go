@@ -0,0 +1,288 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services/rdap"
+)
+
+// ErrInvalidDomainKeyword indica que a palavra-chave não forma um nome de domínio válido.
+var ErrInvalidDomainKeyword = errors.New("palavra-chave inválida para um domínio")
+
+// DefaultSearchTLDs são as TLDs consultadas quando o cliente não informa nenhuma.
+var DefaultSearchTLDs = []string{"com.br", "com", "net", "org", "dev", "app", "io"}
+
+const (
+	// domainSearchConcurrency é o número máximo de consultas simultâneas em uma busca.
+	domainSearchConcurrency = 8
+	// domainSearchMaxTLDs limita as TLDs de uma busca.
+	domainSearchMaxTLDs = 20
+	// domainSearchMaxSuggestions limita as sugestões consultadas em uma busca.
+	domainSearchMaxSuggestions = 8
+	// domainSearchCacheTTL é a validade de um resultado conclusivo no cache; resultados incertos não
+	// são guardados.
+	domainSearchCacheTTL = 5 * time.Minute
+)
+
+// Prefixos e sufixos usados nas sugestões de nomes.
+var (
+	suggestionPrefixes = []string{"meu", "minha", "site", "loja"}
+	suggestionSuffixes = []string{"online", "digital", "app", "br", "oficial"}
+)
+
+// DomainSearchResult é a disponibilidade e o preço de registro de um domínio da busca.
+type DomainSearchResult struct {
+	Domain    string      `json:"domain"`
+	TLD       string      `json:"tld"`
+	Status    rdap.Status `json:"status"` // available, registered ou unknown
+	Available bool        `json:"available"`
+	// Preço de registro por um ano, com a promoção vigente; nulo se a TLD não é vendida.
+	Price        *float64 `json:"price"`
+	RegularPrice *float64 `json:"regular_price,omitempty"` // Preço sem a promoção, quando há uma
+}
+
+// DomainSearchResponse é o resultado de uma busca: o nome em cada TLD pedida e as sugestões.
+type DomainSearchResponse struct {
+	Keyword     string               `json:"keyword"`
+	Results     []DomainSearchResult `json:"results"`
+	Suggestions []DomainSearchResult `json:"suggestions"`
+}
+
+// DomainSearchService busca um nome em várias TLDs e sugere alternativas. As consultas são feitas
+// em paralelo, com limite de concorrência, e a disponibilidade fica alguns minutos em cache. Os preços
+// vêm da tabela de preços a cada busca, para refletir as promoções vigentes.
+type DomainSearchService struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	cache map[string]domainSearchCacheEntry
+}
+
+type domainSearchCacheEntry struct {
+	result    DomainSearchResult
+	expiresAt time.Time
+}
+
+// NewDomainSearchService cria uma nova instância de DomainSearchService.
+func NewDomainSearchService(db *sql.DB) *DomainSearchService {
+	return &DomainSearchService{db: db, cache: make(map[string]domainSearchCacheEntry)}
+}
+
+// Search consulta a palavra-chave em cada TLD (DefaultSearchTLDs se nenhuma for informada). Com
+// withSuggestions, nomes derivados da palavra-chave são consultados na primeira TLD.
+func (s *DomainSearchService) Search(keyword string, tlds []string, withSuggestions bool) (*DomainSearchResponse, error) {
+	label, words := normalizeDomainKeyword(keyword)
+	if !validDomainLabel(label) {
+		return nil, ErrInvalidDomainKeyword
+	}
+	tlds = normalizeTLDs(tlds)
+	if len(tlds) == 0 {
+		tlds = DefaultSearchTLDs
+	}
+	if len(tlds) > domainSearchMaxTLDs {
+		return nil, fmt.Errorf("informe no máximo %d TLDs", domainSearchMaxTLDs)
+	}
+	for _, tld := range tlds {
+		if !validTLD(tld) {
+			return nil, fmt.Errorf("TLD inválida: %s", tld)
+		}
+	}
+
+	domains := make([]string, 0, len(tlds))
+	for _, tld := range tlds {
+		domains = append(domains, label+"."+tld)
+	}
+	var suggestions []string
+	if withSuggestions {
+		for _, name := range domainSuggestions(label, words) {
+			suggestions = append(suggestions, name+"."+tlds[0])
+		}
+	}
+
+	results := s.checkAll(append(domains, suggestions...))
+	s.applyPrices(results)
+	return &DomainSearchResponse{
+		Keyword:     label,
+		Results:     results[:len(domains)],
+		Suggestions: results[len(domains):],
+	}, nil
+}
+
+// checkAll consulta os domínios em paralelo, no máximo domainSearchConcurrency por vez, e retorna os
+// resultados na mesma ordem.
+func (s *DomainSearchService) checkAll(domains []string) []DomainSearchResult {
+	results := make([]DomainSearchResult, len(domains))
+	sem := make(chan struct{}, domainSearchConcurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = s.check(domain)
+		}(i, domain)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *DomainSearchService) check(domain string) DomainSearchResult {
+	if result, ok := s.cached(domain); ok {
+		return result
+	}
+
+	_, tld, _ := strings.Cut(domain, ".")
+	availability := CheckDomainAvailability(domain)
+	result := DomainSearchResult{
+		Domain:    domain,
+		TLD:       tld,
+		Status:    availability.Status,
+		Available: availability.Status == rdap.StatusAvailable,
+	}
+	if result.Status != rdap.StatusUnknown {
+		s.store(domain, result)
+	}
+	return result
+}
+
+// applyPrices preenche o preço de registro por um ano de cada resultado, consultando uma vez cada TLD.
+func (s *DomainSearchService) applyPrices(results []DomainSearchResult) {
+	quotes := make(map[string]*DomainPriceQuote)
+	for i := range results {
+		tld := results[i].TLD
+		quote, ok := quotes[tld]
+		if !ok {
+			var err error
+			quote, err = QuoteDomainPrice(s.db, tld, models.DomainPriceRegister, 1)
+			if err != nil && !errors.Is(err, ErrDomainPriceNotFound) {
+				log.Printf("[Domain Search] Falha ao buscar o preço de .%s: %v", tld, err)
+			}
+			quotes[tld] = quote
+		}
+		if quote == nil {
+			continue
+		}
+		results[i].Price = &quote.Price
+		if quote.PromotionID != nil {
+			results[i].RegularPrice = &quote.RegularPrice
+		}
+	}
+}
+
+func (s *DomainSearchService) cached(domain string) (DomainSearchResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[domain]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return DomainSearchResult{}, false
+	}
+	return entry.result, true
+}
+
+func (s *DomainSearchService) store(domain string, result DomainSearchResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// Remove as entradas vencidas para o cache não crescer indefinidamente.
+	for key, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, key)
+		}
+	}
+	s.cache[domain] = domainSearchCacheEntry{result: result, expiresAt: now.Add(domainSearchCacheTTL)}
+}
+
+// normalizeDomainKeyword converte a palavra-chave em um rótulo de domínio: minúsculas, sem acentos e
+// sem espaços. Retorna também as palavras, usadas na sugestão com hífen.
+func normalizeDomainKeyword(keyword string) (string, []string) {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	// Um domínio completo vale pela parte antes da TLD (ex: "exemplo.com.br" → "exemplo").
+	keyword, _, _ = strings.Cut(keyword, ".")
+
+	var b strings.Builder
+	for _, r := range keyword {
+		if plain, ok := accentReplacer[r]; ok {
+			r = plain
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		case r == ' ' || r == '_':
+			b.WriteRune(' ')
+		}
+	}
+	words := strings.Fields(b.String())
+	return strings.Join(words, ""), words
+}
+
+var accentReplacer = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'ê': 'e', 'è': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// validDomainLabel verifica um rótulo de domínio: até 63 letras, números e hífens, sem hífen nas pontas.
+func validDomainLabel(label string) bool {
+	if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTLDs remove espaços, pontos iniciais e repetições da lista de TLDs.
+func normalizeTLDs(tlds []string) []string {
+	seen := make(map[string]bool, len(tlds))
+	var normalized []string
+	for _, tld := range tlds {
+		tld = normalizeTLD(tld)
+		if tld == "" || seen[tld] {
+			continue
+		}
+		seen[tld] = true
+		normalized = append(normalized, tld)
+	}
+	return normalized
+}
+
+// domainSuggestions gera nomes derivados da palavra-chave: com hífen entre as palavras, com prefixos
+// e com sufixos, sem repetições e limitados a domainSearchMaxSuggestions.
+func domainSuggestions(label string, words []string) []string {
+	seen := map[string]bool{label: true}
+	var suggestions []string
+	add := func(name string) {
+		if len(suggestions) < domainSearchMaxSuggestions && !seen[name] && validDomainLabel(name) {
+			seen[name] = true
+			suggestions = append(suggestions, name)
+		}
+	}
+
+	if len(words) > 1 {
+		add(strings.Join(words, "-"))
+	}
+	for i := 0; i < len(suggestionPrefixes) || i < len(suggestionSuffixes); i++ {
+		// Afixos que o nome já tem não são repetidos (ex: "meu" em "meusite").
+		if i < len(suggestionSuffixes) && !strings.HasSuffix(label, suggestionSuffixes[i]) {
+			add(label + suggestionSuffixes[i])
+			add(label + "-" + suggestionSuffixes[i])
+		}
+		if i < len(suggestionPrefixes) && !strings.HasPrefix(label, suggestionPrefixes[i]) {
+			add(suggestionPrefixes[i] + label)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/orchestrator"
+	"hosting-backend/internal/provisioning"
+	"hosting-backend/internal/utils"
+)
+
+var (
+	ErrInvalidAuthCode              = errors.New("código de transferência inválido")
+	ErrDomainTransferNotFound       = errors.New("transferência não encontrada")
+	ErrDomainTransferNotCorrectable = errors.New("a transferência não está aguardando correção")
+)
+
+const (
+	// Quantas transferências pendentes são consultadas a cada ciclo, e de quanto em quanto tempo.
+	domainTransferPollBatch    = 50
+	domainTransferPollInterval = 1 * time.Hour
+)
+
+// ValidateAuthCode normaliza e valida o código de transferência (EPP) informado pelo cliente.
+func ValidateAuthCode(authCode string) (string, error) {
+	authCode = strings.TrimSpace(authCode)
+	if len(authCode) < 6 || len(authCode) > 64 {
+		return "", fmt.Errorf("%w: o código deve ter de 6 a 64 caracteres", ErrInvalidAuthCode)
+	}
+	for _, c := range authCode {
+		if c <= ' ' || c > '~' {
+			return "", fmt.Errorf("%w: o código contém caracteres não permitidos", ErrInvalidAuthCode)
+		}
+	}
+	return authCode, nil
+}
+
+// ClientDomainTransfer é a situação da transferência de um domínio exibida ao cliente.
+type ClientDomainTransfer struct {
+	DomainID       int                         `json:"domain_id"`
+	Domain         string                      `json:"domain"`
+	Status         models.DomainTransferStatus `json:"status"`
+	FailureReason  string                      `json:"failure_reason,omitempty"`
+	FailureMessage string                      `json:"failure_message,omitempty"`
+	Attempts       int                         `json:"attempts"`
+	CanCorrect     bool                        `json:"can_correct"` // O cliente pode informar um novo código ou confirmar o desbloqueio
+	UpdatedAt      time.Time                   `json:"updated_at"`
+}
+
+// DomainTransferService acompanha as transferências de domínios para o nosso registrador.
+type DomainTransferService struct {
+	db *sql.DB
+}
+
+// NewDomainTransferService cria o serviço de transferências de domínios.
+func NewDomainTransferService(db *sql.DB) *DomainTransferService {
+	return &DomainTransferService{db: db}
+}
+
+// PollPending consulta no registrador as transferências pendentes não consultadas na última hora.
+func (s *DomainTransferService) PollPending() error {
+	transfers, err := models.GetPendingDomainTransfers(s.db, time.Now().Add(-domainTransferPollInterval), domainTransferPollBatch)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar as transferências pendentes: %w", err)
+	}
+	for i := range transfers {
+		domain, err := models.GetDomainByID(s.db, transfers[i].DomainID)
+		if err != nil || domain == nil || domain.Status == models.StatusCancelled {
+			continue
+		}
+		if err := orchestrator.PollDomainTransfer(s.db, domain, &transfers[i]); err != nil {
+			log.Printf("[Domain Transfer] ERRO ao consultar a transferência de %s: %v", domain.DomainName, err)
+		}
+	}
+	return nil
+}
+
+// GetForClient retorna a transferência de um domínio do cliente.
+func (s *DomainTransferService) GetForClient(userID, domainID int) (*ClientDomainTransfer, error) {
+	domain, transfer, err := s.clientTransfer(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientDomainTransfer{
+		DomainID:       domain.ID,
+		Domain:         domain.DomainName,
+		Status:         transfer.Status,
+		FailureReason:  transfer.FailureReason.String,
+		FailureMessage: transfer.FailureMessage.String,
+		Attempts:       transfer.Attempts,
+		CanCorrect:     transfer.Status == models.DomainTransferActionRequired,
+		UpdatedAt:      transfer.UpdatedAt,
+	}, nil
+}
+
+// Correct reenvia uma transferência recusada. Com authCode, o novo código EPP substitui o do pedido;
+// sem ele, o cliente confirma que removeu o bloqueio no registrador atual.
+func (s *DomainTransferService) Correct(userID, domainID int, authCode string) (*ClientDomainTransfer, error) {
+	domain, transfer, err := s.clientTransfer(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != models.DomainTransferActionRequired {
+		return nil, ErrDomainTransferNotCorrectable
+	}
+
+	if authCode != "" {
+		authCode, err = ValidateAuthCode(authCode)
+		if err != nil {
+			return nil, err
+		}
+		order, err := models.GetDomainOrderByDomainID(s.db, domain.ID)
+		if err != nil {
+			return nil, err
+		}
+		if order == nil {
+			return nil, ErrDomainTransferNotFound
+		}
+		encrypted, err := utils.Encrypt([]byte(authCode))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criptografar o código de transferência: %w", err)
+		}
+		if err := models.UpdateDomainOrderAuthCode(s.db, order.ID, encrypted); err != nil {
+			return nil, err
+		}
+	} else if reason := transfer.FailureReason.String; reason != provisioning.DomainTransferReasonDomainLocked {
+		return nil, fmt.Errorf("%w: informe um novo código de transferência", ErrInvalidAuthCode)
+	}
+
+	if err := orchestrator.ResubmitDomainTransfer(s.db, domain.ID); err != nil {
+		return nil, err
+	}
+	return s.GetForClient(userID, domainID)
+}
+
+// clientTransfer busca o domínio do cliente e a sua transferência.
+func (s *DomainTransferService) clientTransfer(userID, domainID int) (*models.Domain, *models.DomainTransfer, error) {
+	domain, err := clientDomain(s.db, userID, domainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	transfer, err := models.GetDomainTransfer(s.db, domain.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if transfer == nil {
+		return nil, nil, ErrDomainTransferNotFound
+	}
+	return domain, transfer, nil
+}
+
+// clientDomain busca um domínio do cliente autenticado. Domínios de outros clientes resultam em
+// ErrDomainNotFound.
+func clientDomain(db *sql.DB, userID, domainID int) (*models.Domain, error) {
+	client, err := models.GetClientByUserID(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := models.GetDomainByID(db, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || domain == nil || domain.ClientID != client.ID {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services/nfse"
+	"hosting-backend/internal/utils"
+)
+
+// Erros de negócio do fluxo fiscal, usados pelos handlers para escolher o status HTTP.
+var (
+	ErrFiscalDocumentNotFound      = errors.New("documento fiscal não encontrado")
+	ErrFiscalDocumentNotCancelable = errors.New("apenas notas autorizadas podem ser canceladas")
+	ErrFiscalDocumentNotReissuable = errors.New("a nota precisa estar cancelada, negada ou com erro para ser reemitida")
+	ErrCancellationWindowExpired   = errors.New("o prazo de cancelamento do município para esta nota já expirou")
+	ErrInvalidCancellationReason   = errors.New("a justificativa do cancelamento deve ter entre 15 e 255 caracteres")
+)
+
+const fiscalProviderFocus = "focusnfe"
+
+// FiscalService encapsula a emissão, o cancelamento e a reemissão de NFS-e.
+type FiscalService struct {
+	db *sql.DB
+}
+
+// NewFiscalService cria uma nova instância de FiscalService.
+func NewFiscalService(db *sql.DB) *FiscalService {
+	return &FiscalService{db: db}
+}
+
+// CancelDocument cancela uma NFS-e autorizada no provedor, respeitando o prazo do município emissor.
+func (s *FiscalService) CancelDocument(documentID int64, reason string, adminID int) (*models.FiscalDocument, error) {
+	reason = strings.TrimSpace(reason)
+	if n := utf8.RuneCountInString(reason); n < 15 || n > 255 {
+		return nil, ErrInvalidCancellationReason
+	}
+
+	doc, err := models.GetFiscalDocumentByID(s.db, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar documento fiscal: %w", err)
+	}
+	if doc == nil {
+		return nil, ErrFiscalDocumentNotFound
+	}
+	if doc.Status != models.FiscalDocumentStatusAuthorized {
+		return nil, ErrFiscalDocumentNotCancelable
+	}
+
+	settings, err := models.GetFiscalSettings(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar configurações fiscais: %w", err)
+	}
+	if err := s.checkCancellationWindow(settings, doc); err != nil {
+		return nil, err
+	}
+
+	client, err := nfse.NewClient(settings.Environment.String)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Fiscal Service] Solicitando cancelamento da NFS-e #%d (ref %s).", doc.ID, doc.ProviderReference.String)
+	resp, err := client.CancelNote(doc.ProviderReference.String, reason)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao cancelar a nota no provedor: %w", err)
+	}
+	if resp.Status != nfse.StatusCancelled {
+		return nil, fmt.Errorf("o provedor não confirmou o cancelamento (status '%s'): %s", resp.Status, resp.ErrorMessage())
+	}
+
+	if err := models.MarkFiscalDocumentCancelled(s.db, doc.ID, reason, adminID); err != nil {
+		// A nota já está cancelada no provedor; o registro local precisa ser corrigido manualmente.
+		log.Printf("[Fiscal Service] ERRO CRÍTICO: NFS-e #%d cancelada no provedor, mas falhou ao atualizar o banco: %v", doc.ID, err)
+		return nil, fmt.Errorf("nota cancelada no provedor, mas falhou ao salvar localmente: %w", err)
+	}
+
+	log.Printf("[Fiscal Service] NFS-e #%d cancelada pelo admin #%d.", doc.ID, adminID)
+	return models.GetFiscalDocumentByID(s.db, doc.ID)
+}
+
+// ReissueDocument emite uma nova NFS-e para a mesma fatura com os dados atuais do cliente,
+// vinculando-a ao documento original. Se o original ainda estiver autorizado, ele é cancelado
+// antes com a justificativa informada.
+func (s *FiscalService) ReissueDocument(documentID int64, reason string, adminID int) (*models.FiscalDocument, error) {
+	doc, err := models.GetFiscalDocumentByID(s.db, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar documento fiscal: %w", err)
+	}
+	if doc == nil {
+		return nil, ErrFiscalDocumentNotFound
+	}
+
+	switch doc.Status {
+	case models.FiscalDocumentStatusAuthorized:
+		if _, err := s.CancelDocument(doc.ID, reason, adminID); err != nil {
+			return nil, err
+		}
+	case models.FiscalDocumentStatusCancelled, models.FiscalDocumentStatusDenied, models.FiscalDocumentStatusError:
+		// O documento já não é válido; basta emitir a substituta.
+	default:
+		return nil, ErrFiscalDocumentNotReissuable
+	}
+
+	replacement, err := s.issueForInvoice(int(doc.InvoiceID), sql.NullInt64{Int64: doc.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao emitir a nota substituta: %w", err)
+	}
+
+	log.Printf("[Fiscal Service] NFS-e #%d emitida em substituição à #%d.", replacement.ID, doc.ID)
+	return replacement, nil
+}
+
+// checkCancellationWindow verifica se a nota ainda está dentro do prazo de cancelamento do município.
+// Municípios sem prazo configurado não têm restrição.
+func (s *FiscalService) checkCancellationWindow(settings *models.FiscalSettings, doc *models.FiscalDocument) error {
+	if !settings.MunicipalityCode.Valid || settings.MunicipalityCode.String == "" {
+		return nil
+	}
+
+	days, ok, err := models.GetCancellationWindowDays(s.db, settings.MunicipalityCode.String)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar o prazo de cancelamento do município: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	// O prazo legal conta a partir da autorização pela prefeitura, não da criação do registro,
+	// que pode ter ficado dias em processamento.
+	authorizedAt := doc.CreatedAt
+	if doc.AuthorizedAt.Valid {
+		authorizedAt = doc.AuthorizedAt.Time
+	}
+	if time.Since(authorizedAt) > time.Duration(days)*24*time.Hour {
+		return ErrCancellationWindowExpired
+	}
+	return nil
+}
+
+// issueForInvoice cria um documento fiscal para a fatura e o envia ao provedor.
+func (s *FiscalService) issueForInvoice(invoiceID int, replaces sql.NullInt64) (*models.FiscalDocument, error) {
+	settings, err := models.GetFiscalSettings(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar configurações fiscais: %w", err)
+	}
+
+	invoice, err := models.GetInvoiceByID(s.db, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar fatura: %w", err)
+	}
+	if invoice == nil {
+		return nil, fmt.Errorf("fatura #%d não encontrada", invoiceID)
+	}
+
+	customer, err := models.GetClientByUserID(s.db, invoice.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar cliente da fatura: %w", err)
+	}
+	if customer == nil {
+		return nil, fmt.Errorf("cliente da fatura #%d não encontrado", invoiceID)
+	}
+
+	// Tudo o que pode falhar antes do envio é resolvido antes de gravar o documento: um documento
+	// pendente bloqueia novas emissões para a fatura.
+	client, err := nfse.NewClient(settings.Environment.String)
+	if err != nil {
+		return nil, err
+	}
+	_, codes, err := s.invoiceServiceCodes(invoice)
+	if err != nil {
+		return nil, err
+	}
+	items, err := models.GetInvoiceItemsByInvoiceID(s.db, invoice.ID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar os itens da fatura: %w", err)
+	}
+
+	doc := &models.FiscalDocument{
+		InvoiceID:          int64(invoice.ID),
+		Provider:           sql.NullString{String: fiscalProviderFocus, Valid: true},
+		Status:             models.FiscalDocumentStatusPending,
+		ReplacesDocumentID: replaces,
+	}
+	docID, err := models.CreateFiscalDocument(s.db, doc)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao registrar documento fiscal: %w", err)
+	}
+	doc.ID = docID
+
+	// A referência precisa ser única por emissão, por isso inclui o ID do documento.
+	reference := fmt.Sprintf("fatura-%d-doc-%d", invoice.ID, docID)
+	if _, err := s.db.Exec(`UPDATE fiscal_documents SET provider_reference = $1 WHERE id = $2`, reference, docID); err != nil {
+		err = fmt.Errorf("falha ao salvar a referência do documento fiscal: %w", err)
+		s.markDocumentError(doc, err)
+		return nil, err
+	}
+	doc.ProviderReference = sql.NullString{String: reference, Valid: true}
+
+	resp, issueErr := client.IssueNote(reference, buildIssueRequest(settings, codes, customer, invoice, invoiceGrossAmount(items, invoice)))
+	applyNoteResponse(doc, resp, client.BaseURL)
+	if issueErr != nil {
+		doc.Status = models.FiscalDocumentStatusError
+		doc.ErrorMessage = sql.NullString{String: issueErr.Error(), Valid: true}
+	}
+
+	if err := models.UpdateFiscalDocumentResult(s.db, doc); err != nil {
+		return nil, fmt.Errorf("falha ao salvar o retorno do provedor: %w", err)
+	}
+	if issueErr != nil {
+		return nil, issueErr
+	}
+
+	return models.GetFiscalDocumentByID(s.db, docID)
+}
+
+// markDocumentError registra a falha de um documento ainda não enviado ao provedor, para que ele não
+// fique pendente e bloqueie a emissão de uma nova nota para a fatura.
+func (s *FiscalService) markDocumentError(doc *models.FiscalDocument, cause error) {
+	doc.Status = models.FiscalDocumentStatusError
+	doc.ErrorMessage = sql.NullString{String: cause.Error(), Valid: true}
+	if err := models.UpdateFiscalDocumentResult(s.db, doc); err != nil {
+		log.Printf("[Fiscal Service] ERRO ao marcar a NFS-e #%d como falha: %v", doc.ID, err)
+	}
+}
+
+// SyncProcessingDocuments consulta no provedor as notas ainda em processamento e grava o resultado.
+func (s *FiscalService) SyncProcessingDocuments() error {
+	docs, err := models.GetFiscalDocumentsByStatus(s.db, models.FiscalDocumentStatusProcessing)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar notas em processamento: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	settings, err := models.GetFiscalSettings(s.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar configurações fiscais: %w", err)
+	}
+	client, err := nfse.NewClient(settings.Environment.String)
+	if err != nil {
+		return err
+	}
+
+	for i := range docs {
+		doc := &docs[i]
+		resp, err := client.GetNote(doc.ProviderReference.String)
+		if err != nil {
+			log.Printf("[Fiscal Service] ERRO ao consultar a NFS-e #%d: %v", doc.ID, err)
+			continue
+		}
+
+		applyNoteResponse(doc, resp, client.BaseURL)
+		if doc.Status == models.FiscalDocumentStatusProcessing {
+			continue
+		}
+		if err := models.UpdateFiscalDocumentResult(s.db, doc); err != nil {
+			log.Printf("[Fiscal Service] ERRO ao salvar o retorno da NFS-e #%d: %v", doc.ID, err)
+			continue
+		}
+		log.Printf("[Fiscal Service] NFS-e #%d atualizada para o status '%s'.", doc.ID, doc.Status)
+	}
+	return nil
+}
+
+// NotifyAuthorizedDocuments envia ao cliente o e-mail com o link do PDF de cada nota autorizada
+// que ainda não foi notificada.
+func (s *FiscalService) NotifyAuthorizedDocuments() error {
+	docs, err := models.GetUnnotifiedAuthorizedFiscalDocuments(s.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar notas autorizadas: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return fmt.Errorf("falha ao inicializar o serviço de e-mail: %w", err)
+	}
+
+	for i := range docs {
+		if err := s.notifyAuthorizedDocument(emailService, &docs[i]); err != nil {
+			log.Printf("[Fiscal Service] ERRO ao notificar o cliente sobre a NFS-e #%d: %v", docs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *FiscalService) notifyAuthorizedDocument(emailService *email.EmailService, doc *models.FiscalDocument) error {
+	invoice, err := models.GetInvoiceByID(s.db, int(doc.InvoiceID))
+	if err != nil {
+		return fmt.Errorf("falha ao buscar fatura: %w", err)
+	}
+	if invoice == nil {
+		return fmt.Errorf("fatura #%d não encontrada", doc.InvoiceID)
+	}
+
+	customer, err := models.GetClientByUserID(s.db, invoice.UserID)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar cliente da fatura: %w", err)
+	}
+	if customer == nil {
+		return fmt.Errorf("cliente da fatura #%d não encontrado", invoice.ID)
+	}
+
+	clientName := customer.ContactName.String
+	if clientName == "" {
+		clientName = customer.CompanyName.String
+	}
+
+	err = emailService.Send(email.EmailData{
+		ToName:   clientName,
+		ToEmail:  customer.Email,
+		Subject:  fmt.Sprintf("Sua nota fiscal da fatura #%d está disponível", invoice.ID),
+		Template: "nfse_authorized.html",
+		TemplateData: map[string]any{
+			"ClientName": clientName,
+			"InvoiceID":  invoice.ID,
+			"NFNumber":   doc.NFNumber.String,
+			"Amount":     fmt.Sprintf("%.2f", invoice.TotalAmount),
+			"PDFURL":     doc.PDFURL.String,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return models.MarkFiscalDocumentClientNotified(s.db, doc.ID)
+}
+
+// buildIssueRequest monta o payload de emissão a partir das configurações fiscais, dos códigos
+// fiscais do plano, do cliente e do valor bruto da fatura.
+func buildIssueRequest(settings *models.FiscalSettings, codes *models.PlanFiscalCode, customer *models.Client, invoice *models.Invoice, gross float64) nfse.IssueRequest {
+	taker := nfse.Taker{
+		CompanyName: customer.CompanyName.String,
+		Email:       customer.Email,
+	}
+	if taker.CompanyName == "" {
+		taker.CompanyName = customer.ContactName.String
+	}
+
+	if isCompanyTaker(customer) {
+		taker.CNPJ = utils.NormalizeCNPJ(customer.CpfCnpj.String)
+	} else {
+		taker.CPF = utils.OnlyDigits(customer.CpfCnpj.String)
+	}
+
+	if customer.Address.Valid {
+		taker.Address = &nfse.Address{
+			Street: customer.Address.String,
+			State:  customer.State.String,
+			Zip:    utils.OnlyDigits(customer.Zip.String),
+		}
+	}
+
+	service := nfse.ServiceItem{
+		Amount:      gross,
+		ISSRate:     settings.ISSRate.Float64,
+		ISSWithheld: isCompanyTaker(customer) && settings.ISSWithheldForCompanies,
+		ServiceCode: os.Getenv("NFSE_SERVICE_CODE"),
+		Description: fmt.Sprintf("Serviços de hospedagem referentes à fatura #%d", invoice.ID),
+	}
+	if codes != nil {
+		service.ServiceCode = codes.ServiceCode
+		service.CNAECode = utils.OnlyDigits(codes.CNAECode.String)
+		if codes.ISSRate.Valid {
+			service.ISSRate = codes.ISSRate.Float64
+		}
+	}
+
+	return nfse.IssueRequest{
+		IssueDate: time.Now().Format(time.RFC3339),
+		Provider: nfse.Provider{
+			CNPJ:                  utils.NormalizeCNPJ(settings.CNPJ.String),
+			MunicipalRegistration: utils.OnlyDigits(settings.MunicipalRegistration.String),
+			MunicipalityCode:      settings.MunicipalityCode.String,
+		},
+		Taker:   taker,
+		Service: service,
+	}
+}
+
+// applyNoteResponse copia o retorno do provedor para o documento fiscal.
+func applyNoteResponse(doc *models.FiscalDocument, resp *nfse.NoteResponse, baseURL string) {
+	if resp == nil {
+		return
+	}
+
+	switch resp.Status {
+	case nfse.StatusAuthorized:
+		doc.Status = models.FiscalDocumentStatusAuthorized
+		if !doc.AuthorizedAt.Valid {
+			doc.AuthorizedAt = sql.NullTime{Time: noteAuthorizationTime(resp), Valid: true}
+		}
+	case nfse.StatusProcessing:
+		doc.Status = models.FiscalDocumentStatusProcessing
+	case nfse.StatusAuthorizationError:
+		doc.Status = models.FiscalDocumentStatusDenied
+	case nfse.StatusCancelled:
+		doc.Status = models.FiscalDocumentStatusCancelled
+	}
+
+	if resp.Number != "" {
+		doc.NFNumber = sql.NullString{String: resp.Number, Valid: true}
+	}
+	if resp.VerificationCode != "" {
+		doc.VerificationCode = sql.NullString{String: resp.VerificationCode, Valid: true}
+	}
+	if resp.PDFURL != "" {
+		doc.PDFURL = sql.NullString{String: resp.PDFURL, Valid: true}
+	}
+	if resp.XMLPath != "" {
+		doc.XMLURL = sql.NullString{String: baseURL + resp.XMLPath, Valid: true}
+	}
+	if msg := resp.ErrorMessage(); msg != "" {
+		doc.ErrorMessage = sql.NullString{String: msg, Valid: true}
+	}
+}
+
+// noteAuthorizationTime retorna a data de emissão informada pelo provedor ou, na ausência dela,
+// o momento atual (quando o sistema tomou conhecimento da autorização).
+func noteAuthorizationTime(resp *nfse.NoteResponse) time.Time {
+	if resp.IssuedAt != "" {
+		if t, err := time.Parse(time.RFC3339, resp.IssuedAt); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
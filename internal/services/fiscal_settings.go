@@ -0,0 +1,132 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/utils"
+)
+
+// ErrInvalidFiscalSettings indica que os dados fiscais enviados não passaram na validação.
+var ErrInvalidFiscalSettings = errors.New("configurações fiscais inválidas")
+
+var (
+	serviceCodePattern      = regexp.MustCompile(`^\d{2}\.\d{2}$`) // Item da LC 116, ex: 01.07
+	municipalityCodePattern = regexp.MustCompile(`^\d{7}$`)        // Código IBGE
+	statePattern            = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// UpdateSettings valida e grava as configurações fiscais, registrando a alteração no log de auditoria.
+func (s *FiscalService) UpdateSettings(settings *models.FiscalSettings, adminID int, ipAddress string) error {
+	normalizeFiscalSettings(settings)
+	if err := validateFiscalSettings(settings); err != nil {
+		return err
+	}
+
+	previous, err := models.GetFiscalSettings(s.db)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("falha ao buscar configurações fiscais: %w", err)
+	}
+
+	if err := models.UpdateFiscalSettings(s.db, settings); err != nil {
+		recordAuditLog(s.db, adminID, ipAddress, "update_fiscal_settings", "fiscal_settings", "1", previous, settings, models.AuditLogResultFailure)
+		return fmt.Errorf("falha ao salvar configurações fiscais: %w", err)
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "update_fiscal_settings", "fiscal_settings", "1", previous, settings, models.AuditLogResultSuccess)
+	return nil
+}
+
+// UpdatePlanFiscalCode valida e grava o código de serviço e o CNAE de um plano, registrando a alteração no log de auditoria.
+func (s *FiscalService) UpdatePlanFiscalCode(code *models.PlanFiscalCode, adminID int, ipAddress string) error {
+	code.ServiceCode = strings.TrimSpace(code.ServiceCode)
+	if !serviceCodePattern.MatchString(code.ServiceCode) {
+		return fmt.Errorf("%w: o código de serviço deve seguir o formato da LC 116 (ex: 01.07)", ErrInvalidFiscalSettings)
+	}
+	if code.CNAECode.Valid && code.CNAECode.String != "" {
+		if len(utils.OnlyDigits(code.CNAECode.String)) != 7 {
+			return fmt.Errorf("%w: o CNAE deve ter 7 dígitos", ErrInvalidFiscalSettings)
+		}
+	}
+	if code.ISSRate.Valid {
+		if err := validateISSRate(code.ISSRate.Float64); err != nil {
+			return err
+		}
+	}
+
+	previous, err := models.GetPlanFiscalCode(s.db, code.PlanID)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar os códigos fiscais do plano: %w", err)
+	}
+
+	planID := strconv.Itoa(code.PlanID)
+	if err := models.UpsertPlanFiscalCode(s.db, code); err != nil {
+		recordAuditLog(s.db, adminID, ipAddress, "update_plan_fiscal_code", "plan_fiscal_codes", planID, previous, code, models.AuditLogResultFailure)
+		return fmt.Errorf("falha ao salvar os códigos fiscais do plano: %w", err)
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "update_plan_fiscal_code", "plan_fiscal_codes", planID, previous, code, models.AuditLogResultSuccess)
+	return nil
+}
+
+// normalizeFiscalSettings remove a formatação dos documentos antes da validação e da gravação.
+func normalizeFiscalSettings(settings *models.FiscalSettings) {
+	if settings.CNPJ.Valid {
+		settings.CNPJ.String = utils.NormalizeCNPJ(settings.CNPJ.String)
+	}
+	if settings.MunicipalRegistration.Valid {
+		settings.MunicipalRegistration.String = utils.OnlyDigits(settings.MunicipalRegistration.String)
+	}
+	if settings.State.Valid {
+		settings.State.String = strings.ToUpper(strings.TrimSpace(settings.State.String))
+	}
+	if settings.Environment.Valid {
+		settings.Environment.String = strings.TrimSpace(settings.Environment.String)
+	}
+}
+
+func validateFiscalSettings(settings *models.FiscalSettings) error {
+	if !settings.CNPJ.Valid || !utils.IsValidCNPJ(settings.CNPJ.String) {
+		return fmt.Errorf("%w: CNPJ inválido", ErrInvalidFiscalSettings)
+	}
+	if n := len(settings.MunicipalRegistration.String); n < 3 || n > 15 {
+		return fmt.Errorf("%w: a inscrição municipal deve ter entre 3 e 15 dígitos", ErrInvalidFiscalSettings)
+	}
+	if settings.State.Valid && settings.State.String != "" && !statePattern.MatchString(settings.State.String) {
+		return fmt.Errorf("%w: UF inválida", ErrInvalidFiscalSettings)
+	}
+	if settings.MunicipalityCode.Valid && settings.MunicipalityCode.String != "" && !municipalityCodePattern.MatchString(settings.MunicipalityCode.String) {
+		return fmt.Errorf("%w: o código IBGE do município deve ter 7 dígitos", ErrInvalidFiscalSettings)
+	}
+	if env := settings.Environment.String; env != "sandbox" && env != "production" {
+		return fmt.Errorf("%w: o ambiente deve ser 'sandbox' ou 'production'", ErrInvalidFiscalSettings)
+	}
+	if settings.ISSRate.Valid {
+		if err := validateISSRate(settings.ISSRate.Float64); err != nil {
+			return err
+		}
+	}
+
+	for name, rate := range map[string]float64{"IRRF": settings.IRRFRate, "PIS": settings.PISRate, "COFINS": settings.COFINSRate, "CSLL": settings.CSLLRate} {
+		if rate < 0 || rate > 100 {
+			return fmt.Errorf("%w: a alíquota de %s deve estar entre 0 e 100", ErrInvalidFiscalSettings, name)
+		}
+	}
+	if settings.MinRetentionAmount < 0 {
+		return fmt.Errorf("%w: o valor mínimo de retenção não pode ser negativo", ErrInvalidFiscalSettings)
+	}
+	return nil
+}
+
+// validateISSRate aplica os limites de alíquota do ISS definidos pela LC 116 (mínimo de 2% e máximo de 5%).
+func validateISSRate(rate float64) error {
+	if rate < 2 || rate > 5 {
+		return fmt.Errorf("%w: a alíquota de ISS deve estar entre 2%% e 5%%", ErrInvalidFiscalSettings)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/utils"
+)
+
+// TaxLine é um lançamento de imposto calculado para uma fatura.
+type TaxLine struct {
+	Description string                 `json:"description"`
+	Amount      float64                `json:"amount"`
+	Type        models.InvoiceItemType `json:"item_type"`
+}
+
+// CalculateTaxes aplica as regras de tributação sobre o valor bruto de um serviço.
+//
+// O ISS já está incluso no preço e vira um lançamento informativo, exceto quando o tomador
+// é pessoa jurídica e as configurações determinam a retenção. As retenções federais (IRRF,
+// PIS, COFINS e CSLL) só se aplicam a tomadores pessoa jurídica quando a empresa não é optante
+// do Simples Nacional, e são dispensadas abaixo do valor mínimo configurado.
+// Retenções têm valor negativo, pois são abatidas do total a pagar.
+func CalculateTaxes(settings *models.FiscalSettings, codes *models.PlanFiscalCode, customer *models.Client, gross float64) []TaxLine {
+	var lines []TaxLine
+	company := isCompanyTaker(customer)
+
+	issRate := settings.ISSRate.Float64
+	if codes != nil && codes.ISSRate.Valid {
+		issRate = codes.ISSRate.Float64
+	}
+	if iss := roundCents(gross * issRate / 100); iss > 0 {
+		if company && settings.ISSWithheldForCompanies {
+			lines = append(lines, TaxLine{Description: fmt.Sprintf("ISS retido (%.2f%%)", issRate), Amount: -iss, Type: models.InvoiceItemTypeRetention})
+		} else {
+			lines = append(lines, TaxLine{Description: fmt.Sprintf("ISS incluso (%.2f%%)", issRate), Amount: iss, Type: models.InvoiceItemTypeTax})
+		}
+	}
+
+	if !company || settings.SimplesNacional {
+		return lines
+	}
+
+	retentions := []struct {
+		name string
+		rate float64
+	}{
+		{"IRRF", settings.IRRFRate},
+		{"PIS", settings.PISRate},
+		{"COFINS", settings.COFINSRate},
+		{"CSLL", settings.CSLLRate},
+	}
+	for _, r := range retentions {
+		amount := roundCents(gross * r.rate / 100)
+		if amount <= 0 || amount < settings.MinRetentionAmount {
+			continue
+		}
+		lines = append(lines, TaxLine{Description: fmt.Sprintf("%s retido (%.2f%%)", r.name, r.rate), Amount: -amount, Type: models.InvoiceItemTypeRetention})
+	}
+
+	return lines
+}
+
+// ApplyInvoiceTaxes lança o item do serviço e os impostos calculados em uma fatura recém-criada,
+// abatendo as retenções do total a pagar.
+func (s *FiscalService) ApplyInvoiceTaxes(invoice *models.Invoice) error {
+	settings, err := models.GetFiscalSettings(s.db)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil // Sem configurações fiscais, a fatura segue sem impostos
+		}
+		return fmt.Errorf("falha ao buscar configurações fiscais: %w", err)
+	}
+
+	customer, err := models.GetClientByUserID(s.db, invoice.UserID)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar cliente da fatura: %w", err)
+	}
+	if customer == nil {
+		return fmt.Errorf("cliente da fatura #%d não encontrado", invoice.ID)
+	}
+
+	service, codes, err := s.invoiceServiceCodes(invoice)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Serviço #%d", invoice.ServiceID)
+	if service != nil {
+		description = fmt.Sprintf("Hospedagem: %s", service.Domain)
+	}
+
+	gross := invoice.TotalAmount
+	err = models.CreateInvoiceItem(s.db, &models.InvoiceItem{
+		InvoiceID:   invoice.ID,
+		ServiceID:   sql.NullInt64{Int64: int64(invoice.ServiceID), Valid: invoice.ServiceID > 0},
+		Description: description,
+		Amount:      gross,
+		Type:        models.InvoiceItemTypeService,
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao lançar o item do serviço: %w", err)
+	}
+
+	total := gross
+	for _, line := range CalculateTaxes(settings, codes, customer, gross) {
+		err := models.CreateInvoiceItem(s.db, &models.InvoiceItem{
+			InvoiceID:   invoice.ID,
+			Description: line.Description,
+			Amount:      line.Amount,
+			Type:        line.Type,
+		})
+		if err != nil {
+			return fmt.Errorf("falha ao lançar o imposto '%s': %w", line.Description, err)
+		}
+		if line.Type == models.InvoiceItemTypeRetention {
+			total += line.Amount
+		}
+	}
+
+	total = roundCents(total)
+	if total != gross {
+		if err := models.UpdateInvoiceTotal(s.db, invoice.ID, total); err != nil {
+			return fmt.Errorf("falha ao atualizar o total da fatura: %w", err)
+		}
+		log.Printf("[Fiscal Service] Fatura #%d com retenções: total ajustado de %.2f para %.2f.", invoice.ID, gross, total)
+		invoice.TotalAmount = total
+	}
+
+	return nil
+}
+
+// invoiceServiceCodes busca o serviço da fatura e os códigos fiscais do seu plano.
+// Faturas sem serviço ou planos sem códigos configurados retornam nil sem erro.
+func (s *FiscalService) invoiceServiceCodes(invoice *models.Invoice) (*models.Service, *models.PlanFiscalCode, error) {
+	if invoice.ServiceID == 0 {
+		return nil, nil, nil
+	}
+
+	service, err := models.GetServiceByID(s.db, invoice.ServiceID, invoice.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao buscar o serviço da fatura: %w", err)
+	}
+	if service == nil {
+		return nil, nil, nil
+	}
+
+	// O product_id do serviço aponta para o plano contratado.
+	codes, err := models.GetPlanFiscalCode(s.db, service.ProductID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao buscar os códigos fiscais do plano: %w", err)
+	}
+	return service, codes, nil
+}
+
+// invoiceGrossAmount retorna o valor bruto dos serviços de uma fatura, antes das retenções.
+func invoiceGrossAmount(items []models.InvoiceItem, invoice *models.Invoice) float64 {
+	gross, found := 0.0, false
+	for _, item := range items {
+		if item.Type == models.InvoiceItemTypeService {
+			gross += item.Amount
+			found = true
+		}
+	}
+	if !found {
+		return invoice.TotalAmount
+	}
+	return roundCents(gross)
+}
+
+// isCompanyTaker indica se o tomador é pessoa jurídica (CNPJ).
+func isCompanyTaker(customer *models.Client) bool {
+	return len(utils.NormalizeCNPJ(customer.CpfCnpj.String)) == 14
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -1,61 +0,0 @@
-package hostinger
-
-import (
-	"errors"
-	"fmt"
-	"net/http"
-	"os"
-	"time"
-)
-
-// Client é o cliente da API para interagir com os serviços da Hostinger.
-type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	apiBaseURL string
-}
-
-// NewClient cria uma nova instância do cliente da API da Hostinger.
-func NewClient() (*Client, error) {
-	apiKey := os.Getenv("HOSTINGER_API_KEY")
-	if apiKey == "" {
-		return nil, errors.New("a chave de API da Hostinger (HOSTINGER_API_KEY) não está definida")
-	}
-
-	return &Client{
-		apiKey:     apiKey,
-		httpClient: &http.Client{},
-		apiBaseURL: "https://api.hostinger.com/v1", // URL base da API da Hostinger
-	}, nil
-}
-
-// RegisterDomain simula o registro de um novo domínio.
-// (Implementação real dependerá da documentação da API da Hostinger)
-func (c *Client) RegisterDomain(domainName string, clientDetails map[string]string) (string, error) {
-	// Placeholder para a lógica de registro de domínio.
-	fmt.Printf("Chamando a API da Hostinger para registrar o domínio: %s\n", domainName)
-
-	// Exemplo de como uma chamada de API seria estruturada:
-	// payload := map[string]interface{}{
-	// 	"domain": domainName,
-	// 	"registrant_details": clientDetails,
-	// }
-	// reqBody, _ := json.Marshal(payload)
-	// req, _ := http.NewRequest("POST", c.apiBaseURL+"/domains", bytes.NewBuffer(reqBody))
-	// req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	// req.Header.Set("Content-Type", "application/json")
-
-	// resp, err := c.httpClient.Do(req)
-	// ... tratamento de erro e resposta ...
-
-	// Para este exemplo, retornamos um ID de pedido falso.
-	orderID := "order_" + fmt.Sprintf("%d", time.Now().UnixNano())
-	return orderID, nil
-}
-
-// TransferDomain simula o início de uma transferência de domínio.
-func (c *Client) TransferDomain(domainName, authCode string) (string, error) {
-	fmt.Printf("Chamando a API da Hostinger para transferir o domínio: %s\n", domainName)
-	orderID := "transfer_" + fmt.Sprintf("%d", time.Now().UnixNano())
-	return orderID, nil
-}
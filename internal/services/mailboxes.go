@@ -0,0 +1,516 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+var (
+	ErrMailboxNotFound       = errors.New("conta de e-mail não encontrada")
+	ErrForwarderNotFound     = errors.New("redirecionamento não encontrado")
+	ErrAutoresponderNotFound = errors.New("resposta automática não encontrada")
+	ErrEmailDomainNotFound   = errors.New("o domínio do endereço não pertence à conta do serviço")
+	ErrInvalidEmailRequest   = errors.New("dados de e-mail inválidos")
+	ErrEmailLimitReached     = errors.New("limite do plano atingido")
+)
+
+const (
+	// Cota das novas contas quando o cliente não informa uma e o plano não define o máximo.
+	mailboxDefaultQuotaMB  = 1024
+	mailboxMinPassword     = 8
+	mailboxMaxPassword     = 128
+	autoresponderMaxBody   = 10000
+	autoresponderMaxHours  = 720
+	autoresponderMaxHeader = 255
+)
+
+var mailboxLocalPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._+-]{0,62}[a-z0-9])?$`)
+
+// EmailLimits são os limites de e-mail do plano do serviço. nil indica sem limite.
+type EmailLimits struct {
+	Accounts       *int `json:"accounts"`
+	QuotaMB        *int `json:"quota_mb"` // Cota máxima de cada conta
+	Forwarders     *int `json:"forwarders"`
+	Autoresponders *int `json:"autoresponders"`
+}
+
+// EmailUsage é o uso atual dos recursos de e-mail da conta.
+type EmailUsage struct {
+	Accounts       int `json:"accounts"`
+	Forwarders     int `json:"forwarders"`
+	Autoresponders int `json:"autoresponders"`
+}
+
+// EmailOverview resume os domínios, os limites do plano e o uso de e-mail da conta do serviço.
+type EmailOverview struct {
+	Domains []string    `json:"domains"`
+	Limits  EmailLimits `json:"limits"`
+	Usage   EmailUsage  `json:"usage"`
+}
+
+// MailboxService gerencia, pela UAPI do cPanel, as contas de e-mail, os redirecionamentos e as
+// respostas automáticas das contas dos clientes, dentro dos limites de Features do plano.
+type MailboxService struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewMailboxService cria o serviço de e-mail. whmProvisioner atende os serviços sem servidor cadastrado.
+func NewMailboxService(db *sql.DB, whmProvisioner *provisioning.WhmProvisioner) *MailboxService {
+	return &MailboxService{db: db, placement: NewServerPlacement(db, whmProvisioner)}
+}
+
+// Limits retorna os limites de e-mail do plano do serviço.
+func (s *MailboxService) Limits(service *models.Service) (*EmailLimits, error) {
+	plan, err := models.GetPlanByID(s.db, service.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o plano do serviço: %w", err)
+	}
+	limits := &EmailLimits{}
+	if plan == nil {
+		return limits, nil
+	}
+	limits.Accounts = planLimit(plan, models.PlanFeatureEmailAccounts)
+	limits.QuotaMB = planLimit(plan, models.PlanFeatureEmailQuotaMB)
+	limits.Forwarders = planLimit(plan, models.PlanFeatureEmailForwarders)
+	limits.Autoresponders = planLimit(plan, models.PlanFeatureEmailAutoresponders)
+	return limits, nil
+}
+
+// Overview retorna os domínios, os limites e o uso de e-mail da conta do serviço.
+func (s *MailboxService) Overview(userID, serviceID int) (*EmailOverview, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	mailboxes, err := account.whm.ListMailboxes(account.service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	forwarders, err := account.whm.ListForwarders(account.service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	autoresponders, err := account.autoresponders()
+	if err != nil {
+		return nil, err
+	}
+	return &EmailOverview{
+		Domains: account.domains,
+		Limits:  *account.limits,
+		Usage:   EmailUsage{Accounts: len(mailboxes), Forwarders: len(forwarders), Autoresponders: len(autoresponders)},
+	}, nil
+}
+
+// ListMailboxes lista as contas de e-mail com o uso de disco e a cota.
+func (s *MailboxService) ListMailboxes(userID, serviceID int) ([]provisioning.Mailbox, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return account.whm.ListMailboxes(account.service.CpanelUser)
+}
+
+// CreateMailbox cria uma conta de e-mail em um domínio da conta. Sem quotaMB, a cota é o máximo do
+// plano ou, se o plano não definir um máximo, mailboxDefaultQuotaMB.
+func (s *MailboxService) CreateMailbox(userID, serviceID int, address, password string, quotaMB *int) (*provisioning.Mailbox, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if address, err = account.address(address); err != nil {
+		return nil, err
+	}
+	if err := validateMailboxPassword(address, password); err != nil {
+		return nil, err
+	}
+	quota := mailboxDefaultQuotaMB
+	if quotaMB != nil {
+		quota = *quotaMB
+	} else if max := account.limits.QuotaMB; max != nil && *max > 0 {
+		quota = *max
+	}
+	if err := account.checkQuota(quota); err != nil {
+		return nil, err
+	}
+
+	mailboxes, err := account.whm.ListMailboxes(account.service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	if findMailbox(mailboxes, address) != nil {
+		return nil, fmt.Errorf("%w: a conta %s já existe", ErrInvalidEmailRequest, address)
+	}
+	if err := checkEmailLimit(account.limits.Accounts, len(mailboxes), "contas de e-mail"); err != nil {
+		return nil, err
+	}
+
+	if err := account.whm.CreateMailbox(account.service.CpanelUser, address, password, quota); err != nil {
+		return nil, err
+	}
+	log.Printf("[Email] Conta %s criada pelo cliente (serviço #%d).", address, account.service.ID)
+	return s.mailbox(account, address)
+}
+
+// DeleteMailbox remove uma conta de e-mail e as mensagens dela.
+func (s *MailboxService) DeleteMailbox(userID, serviceID int, address string) error {
+	account, address, err := s.existingMailbox(userID, serviceID, address)
+	if err != nil {
+		return err
+	}
+	if err := account.whm.DeleteMailbox(account.service.CpanelUser, address); err != nil {
+		return err
+	}
+	log.Printf("[Email] Conta %s removida pelo cliente (serviço #%d).", address, account.service.ID)
+	return nil
+}
+
+// ChangePassword altera a senha de uma conta de e-mail.
+func (s *MailboxService) ChangePassword(userID, serviceID int, address, password string) error {
+	account, address, err := s.existingMailbox(userID, serviceID, address)
+	if err != nil {
+		return err
+	}
+	if err := validateMailboxPassword(address, password); err != nil {
+		return err
+	}
+	return account.whm.ChangeMailboxPassword(account.service.CpanelUser, address, password)
+}
+
+// ChangeQuota altera a cota de uma conta de e-mail, em MB. Zero remove o limite, se o plano permitir.
+func (s *MailboxService) ChangeQuota(userID, serviceID int, address string, quotaMB int) (*provisioning.Mailbox, error) {
+	account, address, err := s.existingMailbox(userID, serviceID, address)
+	if err != nil {
+		return nil, err
+	}
+	if err := account.checkQuota(quotaMB); err != nil {
+		return nil, err
+	}
+	if err := account.whm.ChangeMailboxQuota(account.service.CpanelUser, address, quotaMB); err != nil {
+		return nil, err
+	}
+	return s.mailbox(account, address)
+}
+
+// ListForwarders lista os redirecionamentos da conta.
+func (s *MailboxService) ListForwarders(userID, serviceID int) ([]provisioning.EmailForwarder, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return account.whm.ListForwarders(account.service.CpanelUser)
+}
+
+// AddForwarder redireciona as mensagens de um endereço da conta para outro endereço, de qualquer domínio.
+func (s *MailboxService) AddForwarder(userID, serviceID int, source, destination string) (*provisioning.EmailForwarder, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if source, err = account.address(source); err != nil {
+		return nil, err
+	}
+	destination = strings.ToLower(strings.TrimSpace(destination))
+	if parsed, err := mail.ParseAddress(destination); err != nil || parsed.Address != destination {
+		return nil, fmt.Errorf("%w: '%s' não é um endereço de destino válido", ErrInvalidEmailRequest, destination)
+	}
+	if destination == source {
+		return nil, fmt.Errorf("%w: o endereço não pode ser redirecionado para ele mesmo", ErrInvalidEmailRequest)
+	}
+
+	forwarders, err := account.whm.ListForwarders(account.service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range forwarders {
+		if strings.EqualFold(f.Source, source) && strings.EqualFold(f.Destination, destination) {
+			return nil, fmt.Errorf("%w: o redirecionamento de %s para %s já existe", ErrInvalidEmailRequest, source, destination)
+		}
+	}
+	if err := checkEmailLimit(account.limits.Forwarders, len(forwarders), "redirecionamentos"); err != nil {
+		return nil, err
+	}
+
+	if err := account.whm.AddForwarder(account.service.CpanelUser, source, destination); err != nil {
+		return nil, err
+	}
+	log.Printf("[Email] Redirecionamento de %s para %s criado pelo cliente (serviço #%d).", source, destination, account.service.ID)
+	return &provisioning.EmailForwarder{Source: source, Destination: destination}, nil
+}
+
+// DeleteForwarder remove o redirecionamento de source para destination.
+func (s *MailboxService) DeleteForwarder(userID, serviceID int, source, destination string) error {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return err
+	}
+	forwarders, err := account.whm.ListForwarders(account.service.CpanelUser)
+	if err != nil {
+		return err
+	}
+	for _, f := range forwarders {
+		if strings.EqualFold(f.Source, source) && strings.EqualFold(f.Destination, destination) {
+			return account.whm.DeleteForwarder(account.service.CpanelUser, f.Source, f.Destination)
+		}
+	}
+	return ErrForwarderNotFound
+}
+
+// ListAutoresponders lista as respostas automáticas de todos os domínios da conta.
+func (s *MailboxService) ListAutoresponders(userID, serviceID int) ([]provisioning.Autoresponder, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return account.autoresponders()
+}
+
+// GetAutoresponder retorna a resposta automática completa de um endereço.
+func (s *MailboxService) GetAutoresponder(userID, serviceID int, address string) (*provisioning.Autoresponder, error) {
+	account, address, err := s.existingAutoresponder(userID, serviceID, address)
+	if err != nil {
+		return nil, err
+	}
+	return account.whm.GetAutoresponder(account.service.CpanelUser, address)
+}
+
+// SaveAutoresponder cria ou substitui a resposta automática de um endereço da conta.
+func (s *MailboxService) SaveAutoresponder(userID, serviceID int, a provisioning.Autoresponder) (*provisioning.Autoresponder, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Email, err = account.address(a.Email); err != nil {
+		return nil, err
+	}
+	if err := validateAutoresponder(&a); err != nil {
+		return nil, err
+	}
+
+	existing, err := account.autoresponders()
+	if err != nil {
+		return nil, err
+	}
+	if findAutoresponder(existing, a.Email) == nil {
+		if err := checkEmailLimit(account.limits.Autoresponders, len(existing), "respostas automáticas"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := account.whm.SaveAutoresponder(account.service.CpanelUser, a); err != nil {
+		return nil, err
+	}
+	return account.whm.GetAutoresponder(account.service.CpanelUser, a.Email)
+}
+
+// DeleteAutoresponder remove a resposta automática de um endereço.
+func (s *MailboxService) DeleteAutoresponder(userID, serviceID int, address string) error {
+	account, address, err := s.existingAutoresponder(userID, serviceID, address)
+	if err != nil {
+		return err
+	}
+	return account.whm.DeleteAutoresponder(account.service.CpanelUser, address)
+}
+
+// mailAccount é a conta cPanel de um serviço do cliente, com os domínios e os limites do plano.
+type mailAccount struct {
+	service *models.Service
+	whm     *provisioning.WhmProvisioner
+	domains []string
+	limits  *EmailLimits
+}
+
+func (s *MailboxService) clientAccount(userID, serviceID int) (*mailAccount, error) {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := whm.ListAccountDomains(service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.Limits(service)
+	if err != nil {
+		return nil, err
+	}
+	return &mailAccount{service: service, whm: whm, domains: domains.All(), limits: limits}, nil
+}
+
+func (s *MailboxService) existingMailbox(userID, serviceID int, address string) (*mailAccount, string, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, "", err
+	}
+	if address, err = account.address(address); err != nil {
+		return nil, "", err
+	}
+	mailboxes, err := account.whm.ListMailboxes(account.service.CpanelUser)
+	if err != nil {
+		return nil, "", err
+	}
+	if findMailbox(mailboxes, address) == nil {
+		return nil, "", ErrMailboxNotFound
+	}
+	return account, address, nil
+}
+
+func (s *MailboxService) existingAutoresponder(userID, serviceID int, address string) (*mailAccount, string, error) {
+	account, err := s.clientAccount(userID, serviceID)
+	if err != nil {
+		return nil, "", err
+	}
+	if address, err = account.address(address); err != nil {
+		return nil, "", err
+	}
+	_, domain := splitMailAddress(address)
+	autoresponders, err := account.whm.ListAutoresponders(account.service.CpanelUser, domain)
+	if err != nil {
+		return nil, "", err
+	}
+	if findAutoresponder(autoresponders, address) == nil {
+		return nil, "", ErrAutoresponderNotFound
+	}
+	return account, address, nil
+}
+
+// mailbox busca uma conta recém-alterada para devolvê-la ao cliente.
+func (s *MailboxService) mailbox(account *mailAccount, address string) (*provisioning.Mailbox, error) {
+	mailboxes, err := account.whm.ListMailboxes(account.service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	if m := findMailbox(mailboxes, address); m != nil {
+		return m, nil
+	}
+	return nil, ErrMailboxNotFound
+}
+
+// address normaliza o endereço e confirma que o domínio pertence à conta.
+func (a *mailAccount) address(address string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	local, domain := splitMailAddress(address)
+	if !mailboxLocalPattern.MatchString(local) || strings.Contains(local, "..") {
+		return "", fmt.Errorf("%w: '%s' não é um endereço de e-mail válido", ErrInvalidEmailRequest, address)
+	}
+	if !containsDomain(a.domains, domain) {
+		return "", ErrEmailDomainNotFound
+	}
+	return address, nil
+}
+
+// checkQuota confere a cota pedida contra o máximo do plano. Com máximo, a conta não pode ser ilimitada.
+func (a *mailAccount) checkQuota(quotaMB int) error {
+	if quotaMB < 0 {
+		return fmt.Errorf("%w: a cota não pode ser negativa", ErrInvalidEmailRequest)
+	}
+	if max := a.limits.QuotaMB; max != nil && *max > 0 && (quotaMB == 0 || quotaMB > *max) {
+		return fmt.Errorf("%w: a cota de cada conta deve ser de 1 a %d MB", ErrEmailLimitReached, *max)
+	}
+	return nil
+}
+
+func (a *mailAccount) autoresponders() ([]provisioning.Autoresponder, error) {
+	all := []provisioning.Autoresponder{}
+	for _, domain := range a.domains {
+		list, err := a.whm.ListAutoresponders(a.service.CpanelUser, domain)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return all, nil
+}
+
+// planLimit lê um limite de Features do plano; nil quando o plano não limita o recurso.
+func planLimit(plan *models.Plan, key string) *int {
+	limit, ok := plan.FeatureLimit(key)
+	if !ok || limit < 0 {
+		return nil
+	}
+	return &limit
+}
+
+func checkEmailLimit(limit *int, used int, resource string) error {
+	switch {
+	case limit == nil:
+		return nil
+	case *limit == 0:
+		return fmt.Errorf("%w: o plano não inclui %s", ErrEmailLimitReached, resource)
+	case used >= *limit:
+		return fmt.Errorf("%w: o plano permite até %d %s", ErrEmailLimitReached, *limit, resource)
+	}
+	return nil
+}
+
+// validateMailboxPassword exige de 8 a 128 caracteres, com letras e números, diferente do endereço.
+func validateMailboxPassword(address, password string) error {
+	if len(password) < mailboxMinPassword || len(password) > mailboxMaxPassword {
+		return fmt.Errorf("%w: a senha deve ter de %d a %d caracteres", ErrInvalidEmailRequest, mailboxMinPassword, mailboxMaxPassword)
+	}
+	var letter, digit bool
+	for _, c := range password {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	if !letter || !digit {
+		return fmt.Errorf("%w: a senha deve conter letras e números", ErrInvalidEmailRequest)
+	}
+	local, _ := splitMailAddress(address)
+	if strings.Contains(strings.ToLower(password), local) {
+		return fmt.Errorf("%w: a senha não pode conter o nome da conta", ErrInvalidEmailRequest)
+	}
+	return nil
+}
+
+func validateAutoresponder(a *provisioning.Autoresponder) error {
+	a.Subject = strings.TrimSpace(a.Subject)
+	a.From = strings.TrimSpace(a.From)
+	if a.Subject == "" || len(a.Subject) > autoresponderMaxHeader || strings.ContainsAny(a.Subject, "\r\n") {
+		return fmt.Errorf("%w: o assunto deve ter de 1 a %d caracteres, em uma linha", ErrInvalidEmailRequest, autoresponderMaxHeader)
+	}
+	if len(a.From) > autoresponderMaxHeader || strings.ContainsAny(a.From, "\r\n") {
+		return fmt.Errorf("%w: o remetente deve ter até %d caracteres, em uma linha", ErrInvalidEmailRequest, autoresponderMaxHeader)
+	}
+	if strings.TrimSpace(a.Body) == "" || len(a.Body) > autoresponderMaxBody {
+		return fmt.Errorf("%w: a mensagem deve ter de 1 a %d caracteres", ErrInvalidEmailRequest, autoresponderMaxBody)
+	}
+	if a.IntervalHours < 0 || a.IntervalHours > autoresponderMaxHours {
+		return fmt.Errorf("%w: o intervalo entre respostas deve ser de 0 a %d horas", ErrInvalidEmailRequest, autoresponderMaxHours)
+	}
+	if a.Start != nil && a.Stop != nil && !a.Stop.After(*a.Start) {
+		return fmt.Errorf("%w: o fim da resposta automática deve ser depois do início", ErrInvalidEmailRequest)
+	}
+	return nil
+}
+
+func splitMailAddress(address string) (string, string) {
+	local, domain, _ := strings.Cut(address, "@")
+	return local, domain
+}
+
+func findMailbox(mailboxes []provisioning.Mailbox, address string) *provisioning.Mailbox {
+	for i := range mailboxes {
+		if strings.EqualFold(mailboxes[i].Email, address) {
+			return &mailboxes[i]
+		}
+	}
+	return nil
+}
+
+func findAutoresponder(autoresponders []provisioning.Autoresponder, address string) *provisioning.Autoresponder {
+	for i := range autoresponders {
+		if strings.EqualFold(autoresponders[i].Email, address) {
+			return &autoresponders[i]
+		}
+	}
+	return nil
+}
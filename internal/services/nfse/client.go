@@ -0,0 +1,205 @@
+package nfse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	focusProductionURL = "https://api.focusnfe.com.br"
+	focusSandboxURL    = "https://homologacao.focusnfe.com.br"
+)
+
+// Status retornados pelo provedor (Focus NFe) para uma NFS-e.
+const (
+	StatusProcessing         = "processando_autorizacao"
+	StatusAuthorized         = "autorizado"
+	StatusAuthorizationError = "erro_autorizacao"
+	StatusCancelled          = "cancelado"
+	StatusCancellationError  = "erro_cancelamento"
+)
+
+// Client é o cliente para interagir com a API de NFS-e da Focus NFe.
+type Client struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// NewClient cria uma nova instância do cliente de NFS-e para o ambiente informado
+// ("production" ou "sandbox"). O token é lido da variável de ambiente NFSE_API_TOKEN.
+func NewClient(environment string) (*Client, error) {
+	token := os.Getenv("NFSE_API_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("a variável de ambiente NFSE_API_TOKEN não está definida")
+	}
+
+	baseURL := focusSandboxURL
+	if environment == "production" {
+		baseURL = focusProductionURL
+	}
+
+	return &Client{
+		BaseURL: baseURL,
+		Token:   token,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// doRequest é uma função auxiliar para fazer requisições à API de NFS-e.
+// Respostas 4xx com corpo JSON são devolvidas ao chamador, pois o provedor
+// descreve erros de validação e de cancelamento no próprio corpo.
+func (c *Client) doRequest(method, path string, body any) ([]byte, int, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, 0, fmt.Errorf("falha ao encodar o corpo da requisição: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, &buf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("falha ao criar a requisição http: %w", err)
+	}
+	req.SetBasicAuth(c.Token, "")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("falha ao executar a requisição http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("falha ao ler o corpo da resposta: %w", err)
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, resp.StatusCode, fmt.Errorf("API de NFS-e retornou status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return respBody, resp.StatusCode, nil
+}
+
+// --- Structs de Requisição ---
+
+// Provider representa o prestador do serviço (a nossa empresa).
+type Provider struct {
+	CNPJ                  string `json:"cnpj"`
+	MunicipalRegistration string `json:"inscricao_municipal"`
+	MunicipalityCode      string `json:"codigo_municipio"`
+}
+
+// Address representa o endereço do tomador.
+type Address struct {
+	Street           string `json:"logradouro,omitempty"`
+	Number           string `json:"numero,omitempty"`
+	District         string `json:"bairro,omitempty"`
+	MunicipalityCode string `json:"codigo_municipio,omitempty"`
+	State            string `json:"uf,omitempty"`
+	Zip              string `json:"cep,omitempty"`
+}
+
+// Taker representa o tomador do serviço (o cliente).
+type Taker struct {
+	CPF         string   `json:"cpf,omitempty"`
+	CNPJ        string   `json:"cnpj,omitempty"`
+	CompanyName string   `json:"razao_social"`
+	Email       string   `json:"email,omitempty"`
+	Address     *Address `json:"endereco,omitempty"`
+}
+
+// ServiceItem descreve o serviço prestado e a sua tributação.
+type ServiceItem struct {
+	Amount      float64 `json:"valor_servicos"`
+	ISSRate     float64 `json:"aliquota"`
+	ISSWithheld bool    `json:"iss_retido"`
+	ServiceCode string  `json:"item_lista_servico"`
+	CNAECode    string  `json:"codigo_cnae,omitempty"`
+	Description string  `json:"discriminacao"`
+}
+
+// IssueRequest representa os dados para emitir uma NFS-e.
+type IssueRequest struct {
+	IssueDate string      `json:"data_emissao"`
+	Provider  Provider    `json:"prestador"`
+	Taker     Taker       `json:"tomador"`
+	Service   ServiceItem `json:"servico"`
+}
+
+// --- Structs de Resposta ---
+
+// ProviderError é um erro descrito pelo provedor no corpo da resposta.
+type ProviderError struct {
+	Code    string `json:"codigo"`
+	Message string `json:"mensagem"`
+}
+
+// NoteResponse é a resposta da API com o estado de uma NFS-e.
+type NoteResponse struct {
+	Reference        string          `json:"ref"`
+	Status           string          `json:"status"`
+	Number           string          `json:"numero"`
+	VerificationCode string          `json:"codigo_verificacao"`
+	IssuedAt         string          `json:"data_emissao"`
+	PDFURL           string          `json:"url_danfse"`
+	XMLPath          string          `json:"caminho_xml_nota_fiscal"`
+	Code             string          `json:"codigo"`
+	Message          string          `json:"mensagem"`
+	Errors           []ProviderError `json:"erros"`
+}
+
+// ErrorMessage consolida as mensagens de erro retornadas pelo provedor.
+func (n *NoteResponse) ErrorMessage() string {
+	msg := n.Message
+	for _, e := range n.Errors {
+		if msg != "" {
+			msg += "; "
+		}
+		msg += fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return msg
+}
+
+// --- Funções da API ---
+
+// IssueNote envia uma NFS-e para autorização. A referência identifica a nota no provedor
+// e deve ser única por emissão.
+func (c *Client) IssueNote(reference string, req IssueRequest) (*NoteResponse, error) {
+	return c.noteRequest("POST", "/v2/nfse?ref="+url.QueryEscape(reference), req)
+}
+
+// GetNote consulta o estado atual de uma NFS-e.
+func (c *Client) GetNote(reference string) (*NoteResponse, error) {
+	return c.noteRequest("GET", "/v2/nfse/"+url.PathEscape(reference), nil)
+}
+
+// CancelNote solicita o cancelamento de uma NFS-e autorizada com a justificativa informada.
+func (c *Client) CancelNote(reference, reason string) (*NoteResponse, error) {
+	return c.noteRequest("DELETE", "/v2/nfse/"+url.PathEscape(reference), map[string]string{"justificativa": reason})
+}
+
+func (c *Client) noteRequest(method, path string, body any) (*NoteResponse, error) {
+	resp, statusCode, err := c.doRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var note NoteResponse
+	if err := json.Unmarshal(resp, &note); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar resposta da NFS-e (status %d): %w", statusCode, err)
+	}
+
+	if statusCode >= 400 {
+		return &note, fmt.Errorf("API de NFS-e recusou a requisição (status %d): %s", statusCode, note.ErrorMessage())
+	}
+
+	return &note, nil
+}
@@ -0,0 +1,277 @@
+package rdap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBootstrapURL é o arquivo da IANA que associa cada TLD aos seus servidores RDAP.
+const DefaultBootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+const (
+	// bootstrapTTL é a validade do arquivo de bootstrap em cache; a IANA o atualiza com pouca frequência.
+	bootstrapTTL = 24 * time.Hour
+	// bootstrapRetry é o intervalo entre as tentativas de download quando a IANA não responde.
+	bootstrapRetry = 5 * time.Minute
+	// maxRetryWait é a maior espera pelo Retry-After dentro da própria consulta. Esperas maiores
+	// bloqueiam o servidor até o prazo e a consulta retorna StatusUnknown.
+	maxRetryWait = 2 * time.Second
+	// defaultRateLimitWait é o bloqueio aplicado quando o servidor responde 429 sem Retry-After.
+	defaultRateLimitWait = 30 * time.Second
+)
+
+// Status é o resultado da consulta de disponibilidade de um domínio.
+type Status string
+
+const (
+	StatusAvailable  Status = "available"
+	StatusRegistered Status = "registered"
+	StatusUnknown    Status = "unknown" // O RDAP não respondeu de forma conclusiva
+)
+
+// Result é o resultado tipado de uma consulta RDAP.
+type Result struct {
+	Domain string `json:"domain"`
+	Status Status `json:"status"`
+	Server string `json:"server,omitempty"` // Servidor RDAP consultado
+	Reason string `json:"reason,omitempty"` // Motivo de um resultado StatusUnknown
+}
+
+// Client consulta a disponibilidade de domínios no servidor RDAP de cada TLD. Os servidores são
+// descobertos pelo arquivo de bootstrap da IANA, mantido em cache e atualizado a cada bootstrapTTL.
+type Client struct {
+	bootstrapURL string
+	httpClient   *http.Client
+
+	refreshMu    sync.Mutex // Evita downloads simultâneos do bootstrap
+	mu           sync.RWMutex
+	servers      map[string][]string // TLD → URLs base dos servidores RDAP
+	fetchedAt    time.Time
+	blockedUntil map[string]time.Time // Servidor → fim do bloqueio por limite de requisições
+}
+
+// bootstrapFile é o formato do arquivo de bootstrap (RFC 9224).
+type bootstrapFile struct {
+	Services [][][]string `json:"services"`
+}
+
+var (
+	defaultClient     *Client
+	defaultClientOnce sync.Once
+)
+
+// Default retorna o cliente compartilhado pela aplicação, para que o cache do bootstrap e os
+// bloqueios por limite de requisições valham para todas as consultas. RDAP_BOOTSTRAP_URL é opcional.
+func Default() *Client {
+	defaultClientOnce.Do(func() {
+		bootstrapURL := os.Getenv("RDAP_BOOTSTRAP_URL")
+		if bootstrapURL == "" {
+			bootstrapURL = DefaultBootstrapURL
+		}
+		defaultClient = NewClient(bootstrapURL)
+	})
+	return defaultClient
+}
+
+// NewClient cria um cliente RDAP que descobre os servidores pelo arquivo de bootstrap informado.
+func NewClient(bootstrapURL string) *Client {
+	return &Client{
+		bootstrapURL: bootstrapURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		blockedUntil: make(map[string]time.Time),
+	}
+}
+
+// Refresh baixa novamente o arquivo de bootstrap. Em caso de falha, o cache anterior é mantido.
+func (c *Client) Refresh() error {
+	resp, err := c.httpClient.Get(c.bootstrapURL)
+	if err != nil {
+		return fmt.Errorf("falha ao baixar o bootstrap RDAP: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bootstrap RDAP retornou status %d", resp.StatusCode)
+	}
+
+	var file bootstrapFile
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return fmt.Errorf("falha ao decodificar o bootstrap RDAP: %w", err)
+	}
+	servers := make(map[string][]string)
+	for _, service := range file.Services {
+		if len(service) < 2 {
+			continue
+		}
+		for _, tld := range service[0] {
+			servers[strings.ToLower(tld)] = service[1]
+		}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("bootstrap RDAP sem servidores")
+	}
+
+	c.mu.Lock()
+	c.servers = servers
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	log.Printf("[RDAP] Bootstrap atualizado: %d TLDs.", len(servers))
+	return nil
+}
+
+// Server retorna a URL base do servidor RDAP da TLD do domínio, preferindo HTTPS. A TLD mais
+// específica tem precedência (ex: "com.br" antes de "br").
+func (c *Client) Server(domain string) (string, error) {
+	if err := c.ensureBootstrap(); err != nil {
+		return "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(domain, ".")), ".")
+	for i := 1; i < len(labels); i++ {
+		urls, ok := c.servers[strings.Join(labels[i:], ".")]
+		if !ok || len(urls) == 0 {
+			continue
+		}
+		for _, u := range urls {
+			if strings.HasPrefix(u, "https://") {
+				return u, nil
+			}
+		}
+		return urls[0], nil
+	}
+	return "", fmt.Errorf("a TLD de '%s' não tem servidor RDAP", domain)
+}
+
+// ensureBootstrap baixa o bootstrap se ele ainda não foi carregado ou se expirou. Se o download
+// falhar com um cache já carregado, o cache expirado continua em uso.
+func (c *Client) ensureBootstrap() error {
+	c.mu.RLock()
+	stale := c.servers == nil || time.Since(c.fetchedAt) > bootstrapTTL
+	c.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	c.mu.RLock()
+	empty := c.servers == nil
+	stale = empty || time.Since(c.fetchedAt) > bootstrapTTL
+	c.mu.RUnlock()
+	if !stale {
+		return nil // Atualizado por outra consulta enquanto esta aguardava
+	}
+	if err := c.Refresh(); err != nil {
+		if empty {
+			return err
+		}
+		log.Printf("[RDAP] Usando o bootstrap em cache: %v", err)
+		// Nova tentativa de download em bootstrapRetry, e não a cada consulta.
+		c.mu.Lock()
+		c.fetchedAt = time.Now().Add(bootstrapRetry - bootstrapTTL)
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// Check consulta a disponibilidade do domínio. Erros de rede, limites de requisições e TLDs sem
+// servidor RDAP resultam em StatusUnknown, com o motivo em Result.Reason.
+func (c *Client) Check(domain string) Result {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	result := Result{Domain: domain, Status: StatusUnknown}
+
+	server, err := c.Server(domain)
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	result.Server = server
+	host := serverHost(server)
+	if until := c.blocked(host); !until.IsZero() {
+		result.Reason = fmt.Sprintf("limite de requisições do servidor RDAP até %s", until.Format(time.RFC3339))
+		return result
+	}
+
+	queryURL := strings.TrimSuffix(server, "/") + "/domain/" + url.PathEscape(domain)
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := c.httpClient.Get(queryURL)
+		if err != nil {
+			result.Reason = fmt.Sprintf("falha ao consultar o RDAP: %v", err)
+			return result
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			result.Status = StatusAvailable
+			return result
+		case resp.StatusCode == http.StatusOK:
+			result.Status = StatusRegistered
+			return result
+		case resp.StatusCode == http.StatusTooManyRequests:
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			if attempt == 0 && wait <= maxRetryWait {
+				time.Sleep(wait)
+				continue
+			}
+			c.block(host, wait)
+			result.Reason = "limite de requisições do servidor RDAP"
+			return result
+		default:
+			result.Reason = fmt.Sprintf("servidor RDAP retornou status %d", resp.StatusCode)
+			return result
+		}
+	}
+	return result
+}
+
+func (c *Client) blocked(host string) time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if until, ok := c.blockedUntil[host]; ok && time.Now().Before(until) {
+		return until
+	}
+	return time.Time{}
+}
+
+func (c *Client) block(host string, wait time.Duration) {
+	c.mu.Lock()
+	c.blockedUntil[host] = time.Now().Add(wait)
+	c.mu.Unlock()
+	log.Printf("[RDAP] Limite de requisições atingido em %s; consultas suspensas por %s.", host, wait)
+}
+
+// retryAfter interpreta o cabeçalho Retry-After (segundos ou data HTTP).
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return defaultRateLimitWait
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultRateLimitWait
+}
+
+func serverHost(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return server
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/models"
+)
+
+var (
+	ErrServerAlertNotFound = errors.New("alerta não encontrado")
+	ErrServerAlertNotOpen  = errors.New("apenas alertas abertos podem ser reconhecidos")
+)
+
+// ServerAlertThresholds são os limites avaliados pelas regras de alerta. Os valores padrão
+// podem ser alterados pelas variáveis de ambiente SERVER_ALERT_*.
+type ServerAlertThresholds struct {
+	DiskUsedPercent     float64 // Uso máximo da partição das contas, em %
+	Load5               float64 // Carga média máxima em 5 minutos
+	AccountLimitPercent float64 // Percentual de servers.max_accounts a partir do qual o alerta é aberto
+	CertificateDays     int     // Dias de antecedência para o alerta de expiração do certificado do WHM
+}
+
+// LoadServerAlertThresholds lê os limites do ambiente, usando os valores padrão quando ausentes ou inválidos.
+func LoadServerAlertThresholds() ServerAlertThresholds {
+	return ServerAlertThresholds{
+		DiskUsedPercent:     envFloat("SERVER_ALERT_DISK_PERCENT", 90),
+		Load5:               envFloat("SERVER_ALERT_LOAD", 8),
+		AccountLimitPercent: envFloat("SERVER_ALERT_ACCOUNTS_PERCENT", 90),
+		CertificateDays:     int(envFloat("SERVER_ALERT_CERT_DAYS", 14)),
+	}
+}
+
+func envFloat(key string, fallback float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
+
+// alertCheck é o resultado de uma regra para um servidor. Regras sem dados suficientes
+// (known = false) não abrem nem resolvem alertas.
+type alertCheck struct {
+	alertType models.ServerAlertType
+	known     bool
+	firing    bool
+	message   string
+	value     float64
+	threshold float64
+}
+
+// ServerAlertService avalia as regras de capacidade e saúde dos servidores e notifica os administradores.
+type ServerAlertService struct {
+	db         *sql.DB
+	thresholds ServerAlertThresholds
+	recipients []string // SERVER_ALERT_EMAILS, separados por vírgula
+	webhookURL string   // SERVER_ALERT_WEBHOOK_URL
+	httpClient *http.Client
+}
+
+// NewServerAlertService cria uma nova instância de ServerAlertService.
+func NewServerAlertService(db *sql.DB) *ServerAlertService {
+	var recipients []string
+	for _, addr := range strings.Split(os.Getenv("SERVER_ALERT_EMAILS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+
+	return &ServerAlertService{
+		db:         db,
+		thresholds: LoadServerAlertThresholds(),
+		recipients: recipients,
+		webhookURL: os.Getenv("SERVER_ALERT_WEBHOOK_URL"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// EvaluateAll avalia as regras de todos os servidores monitorados. Um alerta é aberto e notificado
+// uma única vez enquanto a condição persistir, e uma notificação de recuperação é enviada ao resolvê-lo.
+func (s *ServerAlertService) EvaluateAll() error {
+	servers, err := models.GetAllServers(s.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar os servidores: %w", err)
+	}
+
+	unresolved, err := models.GetUnresolvedServerAlerts(s.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar os alertas em aberto: %w", err)
+	}
+	current := make(map[int]map[models.ServerAlertType]*models.ServerAlert)
+	for i := range unresolved {
+		a := &unresolved[i]
+		if current[a.ServerID] == nil {
+			current[a.ServerID] = make(map[models.ServerAlertType]*models.ServerAlert)
+		}
+		current[a.ServerID][a.AlertType] = a
+	}
+
+	for i := range servers {
+		server := &servers[i]
+		if !shouldMonitorServer(server) {
+			s.resolveUnmonitored(server, current[server.ID])
+			continue
+		}
+		for _, check := range s.evaluateServer(server) {
+			if !check.known {
+				continue
+			}
+			if err := s.apply(server, check, current[server.ID][check.alertType]); err != nil {
+				log.Printf("[Server Alerts] ERRO ao processar o alerta '%s' do servidor '%s': %v", check.alertType, server.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ServerAlertService) evaluateServer(server *models.Server) []alertCheck {
+	unreachable := server.SyncFailures >= serverMaxSyncFailures
+	checks := []alertCheck{{
+		alertType: models.ServerAlertUnreachable,
+		known:     true,
+		firing:    unreachable,
+		message:   fmt.Sprintf("O servidor não responde à API do WHM há %d sincronizações: %s", server.SyncFailures, server.LastSyncError.String),
+		value:     float64(server.SyncFailures),
+		threshold: serverMaxSyncFailures,
+	}}
+
+	// Com o servidor inacessível as métricas gravadas estão desatualizadas.
+	metrics := server.ParsedMetrics()
+	if metrics != nil && !unreachable {
+		checks = append(checks,
+			alertCheck{
+				alertType: models.ServerAlertDiskUsage,
+				known:     metrics.DiskTotalMB > 0,
+				firing:    metrics.DiskUsedPercent > s.thresholds.DiskUsedPercent,
+				message:   fmt.Sprintf("Uso de disco em %.1f%% (%.0f MB livres)", metrics.DiskUsedPercent, metrics.DiskFreeMB),
+				value:     metrics.DiskUsedPercent,
+				threshold: s.thresholds.DiskUsedPercent,
+			},
+			alertCheck{
+				alertType: models.ServerAlertLoad,
+				known:     true,
+				firing:    metrics.Load5 > s.thresholds.Load5,
+				message:   fmt.Sprintf("Carga média de %.2f nos últimos 5 minutos", metrics.Load5),
+				value:     metrics.Load5,
+				threshold: s.thresholds.Load5,
+			},
+		)
+
+		accountCheck := alertCheck{alertType: models.ServerAlertAccountLimit, known: true}
+		if server.MaxAccounts != nil && *server.MaxAccounts > 0 {
+			limit := float64(*server.MaxAccounts) * s.thresholds.AccountLimitPercent / 100
+			accountCheck.firing = float64(metrics.AccountCount) >= limit
+			accountCheck.message = fmt.Sprintf("%d de %d contas em uso", metrics.AccountCount, *server.MaxAccounts)
+			accountCheck.value = float64(metrics.AccountCount)
+			accountCheck.threshold = limit
+		}
+		checks = append(checks, accountCheck)
+	}
+
+	if !unreachable {
+		certCheck := alertCheck{alertType: models.ServerAlertCertificateExpiry, threshold: float64(s.thresholds.CertificateDays)}
+		if notAfter, err := fetchCertificateExpiry(server.Hostname); err != nil {
+			log.Printf("[Server Alerts] Não foi possível verificar o certificado de '%s': %v", server.Hostname, err)
+		} else {
+			daysLeft := time.Until(notAfter).Hours() / 24
+			certCheck.known = true
+			certCheck.firing = daysLeft < float64(s.thresholds.CertificateDays)
+			certCheck.message = fmt.Sprintf("O certificado SSL de %s expira em %s", server.Hostname, notAfter.Format("02/01/2006"))
+			certCheck.value = roundCents(daysLeft)
+		}
+		checks = append(checks, certCheck)
+	}
+
+	return checks
+}
+
+// fetchCertificateExpiry retorna a data de expiração do certificado apresentado pelo WHM (porta 2087).
+// A verificação da cadeia é desativada para que certificados já expirados também sejam lidos.
+func fetchCertificateExpiry(hostname string) (time.Time, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(hostname, "2087"), &tls.Config{
+		ServerName:         hostname,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, errors.New("nenhum certificado apresentado")
+	}
+	return certs[0].NotAfter, nil
+}
+
+func (s *ServerAlertService) apply(server *models.Server, check alertCheck, existing *models.ServerAlert) error {
+	value := sql.NullFloat64{Float64: check.value, Valid: true}
+
+	switch {
+	case check.firing && existing == nil:
+		alert := &models.ServerAlert{
+			ServerID:   server.ID,
+			ServerName: server.Name,
+			AlertType:  check.alertType,
+			Message:    check.message,
+			Value:      value,
+			Threshold:  sql.NullFloat64{Float64: check.threshold, Valid: check.threshold > 0},
+		}
+		created, err := models.CreateServerAlert(s.db, alert)
+		if err != nil {
+			return err
+		}
+		if created {
+			log.Printf("[Server Alerts] Alerta '%s' aberto para o servidor '%s': %s", check.alertType, server.Name, check.message)
+			s.notify(server, alert, false)
+		}
+	case check.firing:
+		return models.TouchServerAlert(s.db, existing.ID, check.message, value)
+	case existing != nil:
+		if err := models.ResolveServerAlert(s.db, existing.ID); err != nil {
+			return err
+		}
+		existing.Status = models.ServerAlertStatusResolved
+		existing.ResolvedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		log.Printf("[Server Alerts] Alerta '%s' do servidor '%s' resolvido.", check.alertType, server.Name)
+		s.notify(server, existing, true)
+	}
+	return nil
+}
+
+// resolveUnmonitored encerra os alertas em aberto de um servidor que saiu do monitoramento
+// (manutenção, desativação pelo administrador ou troca de painel). Sem coleta, as condições não
+// seriam mais reavaliadas e os alertas ficariam abertos para sempre. Não há notificação de
+// recuperação, já que a condição não foi de fato resolvida.
+func (s *ServerAlertService) resolveUnmonitored(server *models.Server, open map[models.ServerAlertType]*models.ServerAlert) {
+	for alertType, alert := range open {
+		if err := models.ResolveServerAlert(s.db, alert.ID); err != nil {
+			log.Printf("[Server Alerts] ERRO ao encerrar o alerta '%s' do servidor '%s': %v", alertType, server.Name, err)
+			continue
+		}
+		log.Printf("[Server Alerts] Alerta '%s' do servidor '%s' encerrado: servidor fora do monitoramento (status '%s').", alertType, server.Name, server.Status)
+	}
+}
+
+// notify envia o alerta (ou a sua recuperação) por e-mail e webhook. Falhas são apenas registradas.
+func (s *ServerAlertService) notify(server *models.Server, alert *models.ServerAlert, resolved bool) {
+	if len(s.recipients) > 0 {
+		if err := s.sendEmail(server, alert, resolved); err != nil {
+			log.Printf("[Server Alerts] ERRO ao enviar e-mail do alerta #%d: %v", alert.ID, err)
+		}
+	}
+	if s.webhookURL != "" {
+		if err := s.sendWebhook(server, alert, resolved); err != nil {
+			log.Printf("[Server Alerts] ERRO ao enviar webhook do alerta #%d: %v", alert.ID, err)
+		}
+	}
+}
+
+func (s *ServerAlertService) sendEmail(server *models.Server, alert *models.ServerAlert, resolved bool) error {
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return fmt.Errorf("falha ao inicializar o serviço de e-mail: %w", err)
+	}
+
+	subject := fmt.Sprintf("[ALERTA] %s: %s", server.Name, alert.AlertType)
+	if resolved {
+		subject = fmt.Sprintf("[RESOLVIDO] %s: %s", server.Name, alert.AlertType)
+	}
+
+	for _, recipient := range s.recipients {
+		err := emailService.Send(email.EmailData{
+			ToName:   "Equipe de Infraestrutura",
+			ToEmail:  recipient,
+			Subject:  subject,
+			Template: "server_alert.html",
+			TemplateData: map[string]any{
+				"ServerName": server.Name,
+				"Hostname":   server.Hostname,
+				"AlertType":  string(alert.AlertType),
+				"Message":    alert.Message,
+				"Resolved":   resolved,
+				"OpenedAt":   alert.OpenedAt.Format("02/01/2006 15:04"),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServerAlertService) sendWebhook(server *models.Server, alert *models.ServerAlert, resolved bool) error {
+	event := "server.alert.opened"
+	if resolved {
+		event = "server.alert.resolved"
+	}
+	payload, err := json.Marshal(map[string]any{
+		"event":    event,
+		"server":   map[string]any{"id": server.ID, "name": server.Name, "hostname": server.Hostname},
+		"alert":    alert,
+		"sent_at":  time.Now(),
+		"resolved": resolved,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Post(s.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook retornou status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Acknowledge marca um alerta aberto como reconhecido. O alerta continua ativo até que a condição
+// deixe de ocorrer, mas não é notificado novamente.
+func (s *ServerAlertService) Acknowledge(alertID int64, adminID int, ipAddress string) (*models.ServerAlert, error) {
+	alert, err := models.GetServerAlertByID(s.db, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o alerta: %w", err)
+	}
+	if alert == nil {
+		return nil, ErrServerAlertNotFound
+	}
+
+	ok, err := models.AcknowledgeServerAlert(s.db, alertID, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao reconhecer o alerta: %w", err)
+	}
+	if !ok {
+		return nil, ErrServerAlertNotOpen
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "server_alert.acknowledge", "server_alert", strconv.FormatInt(alertID, 10),
+		map[string]any{"status": alert.Status}, map[string]any{"status": models.ServerAlertStatusAcknowledged}, models.AuditLogResultSuccess)
+
+	return models.GetServerAlertByID(s.db, alertID)
+}
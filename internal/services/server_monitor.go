@@ -0,0 +1,148 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+// Número de falhas consecutivas de sincronização após o qual o servidor é marcado como inativo.
+const serverMaxSyncFailures = 3
+
+// ServerMonitor coleta as métricas dos servidores do pool através da API do WHM.
+type ServerMonitor struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewServerMonitor cria uma nova instância de ServerMonitor.
+func NewServerMonitor(db *sql.DB) *ServerMonitor {
+	return &ServerMonitor{db: db, placement: NewServerPlacement(db, nil)}
+}
+
+// SyncAll sincroniza as métricas de todos os servidores cPanel monitoráveis.
+// Servidores em manutenção e servidores desativados manualmente são ignorados; servidores
+// desativados pelo próprio monitor continuam sendo consultados e são reativados quando voltam a responder.
+func (m *ServerMonitor) SyncAll() error {
+	servers, err := models.GetAllServers(m.db)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar os servidores: %w", err)
+	}
+
+	for i := range servers {
+		server := &servers[i]
+		if !shouldMonitorServer(server) {
+			continue
+		}
+		if err := m.SyncServer(server); err != nil {
+			log.Printf("[Server Monitor] Falha ao sincronizar o servidor '%s' (#%d): %v", server.Name, server.ID, err)
+		}
+	}
+	return nil
+}
+
+func shouldMonitorServer(server *models.Server) bool {
+	if server.ServerType != models.ServerTypeCPanel {
+		return false
+	}
+	switch server.Status {
+	case models.ServerStatusActive:
+		return true
+	case models.ServerStatusInactive:
+		return server.SyncFailures >= serverMaxSyncFailures
+	default:
+		return false
+	}
+}
+
+// SyncServer coleta e grava as métricas de um servidor. Uma falha de coleta é contabilizada
+// e, após serverMaxSyncFailures falhas seguidas, o servidor deixa de receber novas contas.
+func (m *ServerMonitor) SyncServer(server *models.Server) error {
+	metrics, err := m.collect(server)
+	if err != nil {
+		failures, recErr := models.RecordServerSyncFailure(m.db, server.ID, err.Error())
+		if recErr != nil {
+			return fmt.Errorf("%v (falha ao registrar o erro: %v)", err, recErr)
+		}
+		if failures >= serverMaxSyncFailures && server.Status == models.ServerStatusActive {
+			if err := models.SetServerStatus(m.db, server.ID, models.ServerStatusInactive); err != nil {
+				return fmt.Errorf("falha ao marcar o servidor como inativo: %w", err)
+			}
+			log.Printf("[Server Monitor] Servidor '%s' (#%d) marcado como inativo após %d falhas consecutivas.", server.Name, server.ID, failures)
+		}
+		return err
+	}
+
+	if server.Status == models.ServerStatusInactive {
+		log.Printf("[Server Monitor] Servidor '%s' (#%d) voltou a responder e foi reativado.", server.Name, server.ID)
+	}
+	if err := models.UpdateServerMetrics(m.db, server.ID, models.ServerStatusActive, metrics); err != nil {
+		return fmt.Errorf("falha ao gravar as métricas: %w", err)
+	}
+	return nil
+}
+
+func (m *ServerMonitor) collect(server *models.Server) (*models.ServerMetrics, error) {
+	provisioner, err := m.placement.ProvisionerFor(server)
+	if err != nil {
+		return nil, err
+	}
+	whm, ok := provisioner.(*provisioning.WhmProvisioner)
+	if !ok {
+		return nil, fmt.Errorf("coleta de métricas não suportada para servidores '%s'", server.ServerType)
+	}
+
+	load, err := whm.LoadAverage()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar a carga do servidor: %w", err)
+	}
+	partitions, err := whm.DiskUsage()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar o uso de disco: %w", err)
+	}
+	accounts, err := whm.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar as contas: %w", err)
+	}
+	version, err := whm.Version()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar a versão do WHM: %w", err)
+	}
+
+	metrics := &models.ServerMetrics{
+		Load1:        load.One,
+		Load5:        load.Five,
+		Load15:       load.Fifteen,
+		AccountCount: len(accounts),
+		Version:      version,
+		CollectedAt:  time.Now(),
+	}
+	if partition := accountsPartition(partitions); partition != nil {
+		// getdiskusage retorna os tamanhos em blocos de 1 KiB.
+		metrics.DiskTotalMB = roundCents(float64(partition.Total) / 1024)
+		metrics.DiskUsedMB = roundCents(float64(partition.Used) / 1024)
+		metrics.DiskFreeMB = roundCents(float64(partition.Available) / 1024)
+		if partition.Total > 0 {
+			metrics.DiskUsedPercent = roundCents(float64(partition.Used) * 100 / float64(partition.Total))
+		}
+	}
+	return metrics, nil
+}
+
+// accountsPartition escolhe a partição em que ficam as contas: /home quando existir, senão a raiz.
+func accountsPartition(partitions []provisioning.WhmDiskPartition) *provisioning.WhmDiskPartition {
+	var root *provisioning.WhmDiskPartition
+	for i := range partitions {
+		switch partitions[i].Mount {
+		case "/home":
+			return &partitions[i]
+		case "/":
+			root = &partitions[i]
+		}
+	}
+	return root
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+// ErrNoServerAvailable indica que nenhum servidor ativo atende à política de alocação.
+var ErrNoServerAvailable = errors.New("nenhum servidor ativo disponível para novas contas")
+
+// ServerPlacement escolhe o servidor do pool em que uma nova conta será criada.
+type ServerPlacement struct {
+	db       *sql.DB
+	fallback *provisioning.WhmProvisioner // Servidor do WHM_HOST, usado enquanto o pool estiver vazio
+}
+
+// NewServerPlacement cria uma nova instância de ServerPlacement.
+func NewServerPlacement(db *sql.DB, fallback *provisioning.WhmProvisioner) *ServerPlacement {
+	return &ServerPlacement{db: db, fallback: fallback}
+}
+
+// PickServer escolhe, entre os servidores do tipo de painel do plano, o servidor de uma nova conta
+// conforme a política do plano. Retorna nil, nil quando nenhum servidor está cadastrado, caso em
+// que o servidor cPanel do ambiente é usado.
+func (p *ServerPlacement) PickServer(plan *models.Plan) (*models.Server, error) {
+	serverType := plan.ServerType
+	if serverType == "" {
+		serverType = models.ServerTypeCPanel
+	}
+	servers, err := models.GetActiveServersByType(p.db, serverType)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar os servidores ativos: %w", err)
+	}
+
+	if len(servers) == 0 {
+		all, err := models.GetAllServers(p.db)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao buscar os servidores: %w", err)
+		}
+		if len(all) == 0 && serverType == models.ServerTypeCPanel {
+			return nil, nil
+		}
+		return nil, ErrNoServerAvailable
+	}
+
+	switch plan.PlacementPolicy {
+	case models.PlacementPolicyPinned:
+		return pickPinnedServer(servers, plan)
+	case models.PlacementPolicyLeastAccounts:
+		counts, err := models.CountAccountsByServer(p.db)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao contar as contas por servidor: %w", err)
+		}
+		return pickLeastAccountsServer(servers, counts), nil
+	case models.PlacementPolicyMostFreeDisk:
+		if server := pickMostFreeDiskServer(servers); server != nil {
+			return server, nil
+		}
+		log.Printf("[Placement] Nenhum servidor com métricas de disco; usando o servidor padrão para o plano #%d.", plan.ID)
+		return pickDefaultServer(servers), nil
+	default:
+		return pickDefaultServer(servers), nil
+	}
+}
+
+// ProvisionerFor cria o provisionador do painel do servidor com as credenciais em api_credentials.
+// Um servidor nil corresponde ao servidor WHM configurado no ambiente.
+func (p *ServerPlacement) ProvisionerFor(server *models.Server) (provisioning.HostingProvisioner, error) {
+	if server == nil {
+		if p.fallback == nil {
+			return nil, ErrNoServerAvailable
+		}
+		return p.fallback, nil
+	}
+
+	if !server.APIKeyID.Valid {
+		return nil, fmt.Errorf("o servidor '%s' não possui credencial configurada", server.Name)
+	}
+
+	username, token, err := models.GetDecryptedServerCredential(p.db, server.APIKeyID.Int64)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao carregar a credencial do servidor '%s': %w", server.Name, err)
+	}
+
+	switch server.ServerType {
+	case models.ServerTypeCPanel:
+		return provisioning.NewWhmProvisionerWithCredentials(server.Hostname, username, token), nil
+	case models.ServerTypePlesk:
+		return provisioning.NewPleskProvisionerWithCredentials(server.Hostname, username, token), nil
+	default:
+		return nil, fmt.Errorf("tipo de servidor '%s' não suportado", server.ServerType)
+	}
+}
+
+// ProvisionerForService retorna o provisionador do servidor em que a conta do serviço foi criada.
+// Serviços sem server_id foram criados no servidor do ambiente.
+func (p *ServerPlacement) ProvisionerForService(service *models.Service) (provisioning.HostingProvisioner, error) {
+	if !service.ServerID.Valid {
+		return p.ProvisionerFor(nil)
+	}
+
+	server, err := models.GetServerByID(p.db, int(service.ServerID.Int64))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar o servidor do serviço #%d: %w", service.ID, err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("o servidor #%d do serviço #%d não existe", service.ServerID.Int64, service.ID)
+	}
+	return p.ProvisionerFor(server)
+}
+
+func pickPinnedServer(servers []models.Server, plan *models.Plan) (*models.Server, error) {
+	if !plan.PinnedServerID.Valid {
+		return nil, fmt.Errorf("o plano #%d usa a política 'pinned', mas não tem servidor definido", plan.ID)
+	}
+	for i := range servers {
+		if int64(servers[i].ID) == plan.PinnedServerID.Int64 {
+			return &servers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: o servidor #%d do plano #%d não está ativo", ErrNoServerAvailable, plan.PinnedServerID.Int64, plan.ID)
+}
+
+// pickDefaultServer retorna o servidor marcado como padrão ou, na falta dele, o primeiro ativo.
+func pickDefaultServer(servers []models.Server) *models.Server {
+	for i := range servers {
+		if servers[i].IsDefault {
+			return &servers[i]
+		}
+	}
+	return &servers[0]
+}
+
+func pickLeastAccountsServer(servers []models.Server, counts map[int]int) *models.Server {
+	best := &servers[0]
+	for i := range servers[1:] {
+		candidate := &servers[i+1]
+		if counts[candidate.ID] < counts[best.ID] {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// pickMostFreeDiskServer usa as métricas sincronizadas de cada servidor; servidores sem métricas são ignorados.
+func pickMostFreeDiskServer(servers []models.Server) *models.Server {
+	var best *models.Server
+	bestFree := -1.0
+	for i := range servers {
+		metrics := servers[i].ParsedMetrics()
+		if metrics == nil {
+			continue
+		}
+		if metrics.DiskFreeMB > bestFree {
+			best = &servers[i]
+			bestFree = metrics.DiskFreeMB
+		}
+	}
+	return best
+}
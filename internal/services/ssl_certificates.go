@@ -0,0 +1,494 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hosting-backend/internal/email"
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/provisioning"
+)
+
+var (
+	ErrSSLDomainNotFound  = errors.New("domínio não encontrado na conta do serviço")
+	ErrInvalidCertificate = errors.New("certificado inválido")
+)
+
+// Situação do certificado de um virtual host exibida ao cliente.
+const (
+	SSLStatusValid      = "valid"
+	SSLStatusExpiring   = "expiring"
+	SSLStatusExpired    = "expired"
+	SSLStatusSelfSigned = "self_signed"
+	SSLStatusMissing    = "missing" // Domínio sem certificado instalado
+)
+
+const (
+	// Antecedência a partir da qual um certificado sem renovação automática aparece como vencendo.
+	sslExpiringDays = 30
+	// Um certificado do AutoSSL ainda não renovado a poucos dias do vencimento indica falha no AutoSSL.
+	sslAutoRenewExpiringDays = 7
+)
+
+// sslExpiryAlertDays são as antecedências, em dias, dos avisos de vencimento dos certificados sem
+// renovação automática, da maior para a menor. Zero corresponde ao certificado já vencido.
+var sslExpiryAlertDays = []int{30, 7, 0}
+
+// autoSSLIssuers são os emissores dos certificados do AutoSSL, renovados pelo próprio cPanel.
+var autoSSLIssuers = []string{"cPanel, Inc.", "Let's Encrypt"}
+
+// ClientSSLCertificate é o certificado de um virtual host da conta exibido ao cliente.
+type ClientSSLCertificate struct {
+	VirtualHost      string     `json:"virtual_host"`
+	Domains          []string   `json:"domains"`
+	CoveredDomains   []string   `json:"covered_domains"`
+	UncoveredDomains []string   `json:"uncovered_domains"` // Domínios do virtual host sem certificado válido
+	Issuer           string     `json:"issuer,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	DaysLeft         *int       `json:"days_left,omitempty"`
+	SelfSigned       bool       `json:"self_signed"`
+	AutoRenewed      bool       `json:"auto_renewed"`
+	Status           string     `json:"status"`
+}
+
+// ServiceSSLStatus é a situação dos certificados da conta de um serviço.
+type ServiceSSLStatus struct {
+	Certificates     []ClientSSLCertificate        `json:"certificates"`
+	UncoveredDomains []string                      `json:"uncovered_domains"`
+	AutoSSLProblems  []provisioning.AutoSSLProblem `json:"autossl_problems,omitempty"` // Apenas na leitura direta do servidor
+	SyncedAt         *time.Time                    `json:"synced_at,omitempty"`
+}
+
+// CustomCertificate é um certificado enviado pelo cliente para um domínio da conta, em PEM.
+type CustomCertificate struct {
+	Domain      string `json:"domain"`
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key"`
+	CABundle    string `json:"ca_bundle"`
+}
+
+// SSLCertificateService consulta no WHM os certificados das contas cPanel, dispara o AutoSSL, instala
+// certificados enviados pelos clientes e avisa sobre o vencimento dos que não são renovados
+// automaticamente.
+type SSLCertificateService struct {
+	db        *sql.DB
+	placement *ServerPlacement
+}
+
+// NewSSLCertificateService cria o serviço de certificados. whmProvisioner atende os serviços sem
+// servidor cadastrado; o worker usa nil e percorre apenas os servidores do pool.
+func NewSSLCertificateService(db *sql.DB, whmProvisioner *provisioning.WhmProvisioner) *SSLCertificateService {
+	return &SSLCertificateService{db: db, placement: NewServerPlacement(db, whmProvisioner)}
+}
+
+// GetStatus lê no servidor os certificados da conta do serviço, atualiza o cache e inclui os
+// problemas registrados pelo AutoSSL.
+func (s *SSLCertificateService) GetStatus(userID, serviceID int) (*ServiceSSLStatus, error) {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh(service, whm); err != nil {
+		return nil, err
+	}
+	status, err := s.CachedStatus(service.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := whm.AutoSSLProblems(service.CpanelUser)
+	if err != nil {
+		log.Printf("[SSL] AVISO: falha ao ler os logs do AutoSSL da conta %s: %v", service.CpanelUser, err)
+	}
+	status.AutoSSLProblems = problems
+	return status, nil
+}
+
+// CachedStatus monta a situação dos certificados a partir do cache, sem consultar o servidor.
+func (s *SSLCertificateService) CachedStatus(serviceID int) (*ServiceSSLStatus, error) {
+	certificates, err := models.GetSSLCertificatesByService(s.db, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ServiceSSLStatus{Certificates: []ClientSSLCertificate{}, UncoveredDomains: []string{}}
+	now := time.Now()
+	for i := range certificates {
+		view := newClientSSLCertificate(&certificates[i], now)
+		status.Certificates = append(status.Certificates, view)
+		status.UncoveredDomains = append(status.UncoveredDomains, view.UncoveredDomains...)
+		if status.SyncedAt == nil || certificates[i].SyncedAt.Before(*status.SyncedAt) {
+			status.SyncedAt = &certificates[i].SyncedAt
+		}
+	}
+	return status, nil
+}
+
+// RunAutoSSL inicia uma verificação do AutoSSL para a conta do serviço. Os certificados emitidos
+// aparecem na próxima consulta, depois de alguns minutos.
+func (s *SSLCertificateService) RunAutoSSL(userID, serviceID int) error {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return err
+	}
+	if err := whm.StartAutoSSL(service.CpanelUser); err != nil {
+		return err
+	}
+	log.Printf("[SSL] Verificação do AutoSSL iniciada pelo cliente para a conta %s (serviço #%d).", service.CpanelUser, service.ID)
+	return nil
+}
+
+// InstallCertificate valida e instala um certificado enviado pelo cliente em um domínio da conta.
+// A chave privada é repassada ao servidor e não é gravada.
+func (s *SSLCertificateService) InstallCertificate(userID, serviceID int, cert CustomCertificate) (*ServiceSSLStatus, error) {
+	service, whm, err := clientCpanelService(s.db, s.placement, userID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(cert.Domain)), ".")
+	domains, err := whm.ListAccountDomains(service.CpanelUser)
+	if err != nil {
+		return nil, err
+	}
+	if !containsDomain(domains.All(), domain) {
+		return nil, ErrSSLDomainNotFound
+	}
+	if err := ValidateCustomCertificate(domain, cert.Certificate, cert.PrivateKey, cert.CABundle, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := whm.InstallSSL(service.CpanelUser, domain, cert.Certificate, cert.PrivateKey, cert.CABundle); err != nil {
+		return nil, err
+	}
+	log.Printf("[SSL] Certificado instalado pelo cliente em %s (serviço #%d).", domain, service.ID)
+	return s.GetStatus(userID, serviceID)
+}
+
+// ValidateCustomCertificate confere um certificado em PEM: a chave privada corresponde ao
+// certificado, o certificado está no prazo de validade e cobre o domínio, e a cadeia (opcional)
+// contém apenas certificados.
+func ValidateCustomCertificate(domain, certPEM, keyPEM, bundlePEM string, now time.Time) error {
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return fmt.Errorf("%w: o certificado e a chave privada não puderam ser lidos ou não correspondem (%v)", ErrInvalidCertificate, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("%w: o certificado só é válido a partir de %s", ErrInvalidCertificate, leaf.NotBefore.Format("02/01/2006"))
+	}
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("%w: o certificado venceu em %s", ErrInvalidCertificate, leaf.NotAfter.Format("02/01/2006"))
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return fmt.Errorf("%w: o certificado não cobre o domínio %s", ErrInvalidCertificate, domain)
+	}
+
+	rest := []byte(strings.TrimSpace(bundlePEM))
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%w: a cadeia de certificados (CA bundle) deve conter apenas certificados em PEM", ErrInvalidCertificate)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%w: a cadeia de certificados contém um certificado inválido (%v)", ErrInvalidCertificate, err)
+		}
+		rest = []byte(strings.TrimSpace(string(rest)))
+	}
+	return nil
+}
+
+// SyncAll lê os certificados de todas as contas dos servidores cPanel do pool e avisa os clientes
+// sobre os certificados sem renovação automática que estão vencendo.
+func (s *SSLCertificateService) SyncAll() error {
+	servers, err := models.GetActiveServersByType(s.db, models.ServerTypeCPanel)
+	if err != nil {
+		return fmt.Errorf("falha ao buscar os servidores: %w", err)
+	}
+	for i := range servers {
+		if err := s.syncServer(&servers[i]); err != nil {
+			log.Printf("[SSL] ERRO ao ler os certificados do servidor '%s' (#%d): %v", servers[i].Name, servers[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *SSLCertificateService) syncServer(server *models.Server) error {
+	provisioner, err := s.placement.ProvisionerFor(server)
+	if err != nil {
+		return err
+	}
+	whm, ok := provisioner.(*provisioning.WhmProvisioner)
+	if !ok {
+		return fmt.Errorf("o servidor '%s' não é um servidor cPanel", server.Name)
+	}
+
+	vhosts, err := whm.FetchSSLVhosts()
+	if err != nil {
+		return err
+	}
+	serverDomains, err := whm.ListServerDomains()
+	if err != nil {
+		return err
+	}
+	usernames, err := models.GetServiceUsernamesByServer(s.db, server.ID)
+	if err != nil {
+		return err
+	}
+
+	vhostsByUser := make(map[string][]provisioning.SSLVhost)
+	for _, v := range vhosts {
+		vhostsByUser[v.User] = append(vhostsByUser[v.User], v)
+	}
+	domainsByUser := make(map[string][]string)
+	for _, d := range serverDomains {
+		domainsByUser[d.User] = append(domainsByUser[d.User], d.Domain)
+	}
+
+	for username, serviceID := range usernames {
+		if len(domainsByUser[username]) == 0 {
+			continue // Conta em outro servidor
+		}
+		service, err := models.GetServiceByIDAdmin(s.db, serviceID)
+		if err != nil || service == nil || service.Status != models.ServiceStatusActive {
+			continue
+		}
+		if err := s.store(service.ID, vhostsByUser[username], domainsByUser[username]); err != nil {
+			log.Printf("[SSL] ERRO ao gravar os certificados da conta %s (serviço #%d): %v", username, service.ID, err)
+			continue
+		}
+		s.sendExpiryAlerts(service)
+	}
+	return nil
+}
+
+// refresh lê no servidor os certificados da conta do serviço e atualiza o cache.
+func (s *SSLCertificateService) refresh(service *models.Service, whm *provisioning.WhmProvisioner) error {
+	vhosts, err := whm.FetchSSLVhosts()
+	if err != nil {
+		return err
+	}
+	domains, err := whm.ListAccountDomains(service.CpanelUser)
+	if err != nil {
+		return err
+	}
+
+	var accountVhosts []provisioning.SSLVhost
+	for _, v := range vhosts {
+		if v.User == service.CpanelUser {
+			accountVhosts = append(accountVhosts, v)
+		}
+	}
+	return s.store(service.ID, accountVhosts, domains.All())
+}
+
+// store grava os virtual hosts da conta. Domínios da conta fora de qualquer virtual host com SSL são
+// gravados sem certificado.
+func (s *SSLCertificateService) store(serviceID int, vhosts []provisioning.SSLVhost, accountDomains []string) error {
+	syncedAt := time.Now()
+	served := make(map[string]bool)
+
+	for _, v := range vhosts {
+		row := &models.SSLCertificate{
+			ServiceID:   serviceID,
+			VirtualHost: v.ServerName,
+			Domains:     strings.Join(v.Domains, ","),
+			SyncedAt:    syncedAt,
+		}
+		if c := v.Certificate; c != nil {
+			row.CoveredDomains = sql.NullString{String: strings.Join(c.Domains, ","), Valid: true}
+			row.Issuer = sql.NullString{String: c.Issuer(), Valid: c.Issuer() != ""}
+			row.NotBefore = sql.NullTime{Time: c.NotBefore, Valid: true}
+			row.NotAfter = sql.NullTime{Time: c.NotAfter, Valid: true}
+			row.SelfSigned = c.SelfSigned
+			row.AutoRenewed = !c.SelfSigned && isAutoSSLIssuer(c.IssuerOrganization)
+		}
+		if err := models.UpsertSSLCertificate(s.db, row); err != nil {
+			return err
+		}
+		served[v.ServerName] = true
+		for _, d := range v.Domains {
+			served[d] = true
+		}
+	}
+
+	for _, d := range accountDomains {
+		if served[d] {
+			continue
+		}
+		served[d] = true
+		if err := models.UpsertSSLCertificate(s.db, &models.SSLCertificate{ServiceID: serviceID, VirtualHost: d, Domains: d, SyncedAt: syncedAt}); err != nil {
+			return err
+		}
+	}
+	return models.DeleteSSLCertificatesSyncedBefore(s.db, serviceID, syncedAt)
+}
+
+// sendExpiryAlerts avisa o cliente sobre os certificados sem renovação automática que estão vencendo.
+// Cada antecedência de sslExpiryAlertDays gera um único aviso por vencimento.
+func (s *SSLCertificateService) sendExpiryAlerts(service *models.Service) {
+	certificates, err := models.GetSSLCertificatesByService(s.db, service.ID)
+	if err != nil {
+		log.Printf("[SSL] ERRO ao buscar os certificados do serviço #%d: %v", service.ID, err)
+		return
+	}
+	for i := range certificates {
+		cert := &certificates[i]
+		if !cert.NotAfter.Valid || cert.AutoRenewed || cert.SelfSigned {
+			continue
+		}
+		daysLeft := sslDaysLeft(cert.NotAfter.Time, time.Now())
+		// A menor antecedência já alcançada: um ciclo perdido não gera avisos em sequência.
+		threshold := -1
+		for _, d := range sslExpiryAlertDays {
+			if daysLeft <= d {
+				threshold = d
+			}
+		}
+		if threshold < 0 {
+			continue
+		}
+
+		claimed, err := models.ClaimSSLExpiryAlert(s.db, service.ID, cert.VirtualHost, cert.NotAfter.Time, threshold)
+		if err != nil || !claimed {
+			continue
+		}
+		if err := s.sendExpiryAlert(service, cert, daysLeft); err != nil {
+			log.Printf("[SSL] ERRO ao avisar o vencimento do certificado de %s (serviço #%d): %v", cert.VirtualHost, service.ID, err)
+			models.ReleaseSSLExpiryAlert(s.db, service.ID, cert.VirtualHost, cert.ExpiryAlertDays)
+		}
+	}
+}
+
+func (s *SSLCertificateService) sendExpiryAlert(service *models.Service, cert *models.SSLCertificate, daysLeft int) error {
+	client, err := models.GetClientByUserID(s.db, service.UserID)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("cliente do usuário #%d não encontrado", service.UserID)
+	}
+	emailService, err := email.NewEmailService()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar o serviço de e-mail: %w", err)
+	}
+
+	clientName := client.Email
+	if client.ContactName.Valid && client.ContactName.String != "" {
+		clientName = client.ContactName.String
+	} else if client.CompanyName.Valid && client.CompanyName.String != "" {
+		clientName = client.CompanyName.String
+	}
+
+	subject := fmt.Sprintf("O certificado SSL de %s vence em %d dia(s)", cert.VirtualHost, daysLeft)
+	if daysLeft < 0 {
+		subject = fmt.Sprintf("O certificado SSL de %s venceu", cert.VirtualHost)
+	}
+	return emailService.Send(email.EmailData{
+		ToName:   clientName,
+		ToEmail:  client.Email,
+		Subject:  subject,
+		Template: "ssl_expiry_alert.html",
+		TemplateData: map[string]any{
+			"ClientName": clientName,
+			"Domain":     cert.VirtualHost,
+			"Issuer":     cert.Issuer.String,
+			"ExpiresAt":  cert.NotAfter.Time.Format("02/01/2006"),
+			"DaysLeft":   daysLeft,
+			"Expired":    daysLeft < 0,
+		},
+	})
+}
+
+func newClientSSLCertificate(c *models.SSLCertificate, now time.Time) ClientSSLCertificate {
+	view := ClientSSLCertificate{
+		VirtualHost:    c.VirtualHost,
+		Domains:        c.DomainList(),
+		CoveredDomains: c.CoveredDomainList(),
+		Issuer:         c.Issuer.String,
+		SelfSigned:     c.SelfSigned,
+		AutoRenewed:    c.AutoRenewed,
+	}
+
+	if !c.NotAfter.Valid {
+		view.Status = SSLStatusMissing
+	} else {
+		daysLeft := sslDaysLeft(c.NotAfter.Time, now)
+		view.ExpiresAt = &c.NotAfter.Time
+		view.DaysLeft = &daysLeft
+		switch {
+		case c.NotAfter.Time.Before(now):
+			view.Status = SSLStatusExpired
+		case c.SelfSigned:
+			view.Status = SSLStatusSelfSigned
+		case daysLeft <= sslAutoRenewExpiringDays || (!c.AutoRenewed && daysLeft <= sslExpiringDays):
+			view.Status = SSLStatusExpiring
+		default:
+			view.Status = SSLStatusValid
+		}
+	}
+
+	view.UncoveredDomains = []string{}
+	for _, d := range view.Domains {
+		valid := view.Status == SSLStatusValid || view.Status == SSLStatusExpiring
+		if !valid || !certificateCovers(view.CoveredDomains, d) {
+			view.UncoveredDomains = append(view.UncoveredDomains, d)
+		}
+	}
+	return view
+}
+
+// certificateCovers informa se algum dos nomes do certificado cobre o domínio. Um curinga cobre
+// apenas um nível (*.exemplo.com.br cobre www.exemplo.com.br, não exemplo.com.br).
+func certificateCovers(names []string, domain string) bool {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if name == domain {
+			return true
+		}
+		if strings.HasPrefix(name, "*.") {
+			if i := strings.Index(domain, "."); i > 0 && domain[i+1:] == name[2:] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sslDaysLeft retorna os dias inteiros até o vencimento; negativo para um certificado vencido.
+func sslDaysLeft(notAfter, now time.Time) int {
+	left := notAfter.Sub(now)
+	if left < 0 {
+		return -1 - int(-left.Hours()/24)
+	}
+	return int(left.Hours() / 24)
+}
+
+func isAutoSSLIssuer(organization string) bool {
+	for _, issuer := range autoSSLIssuers {
+		if strings.EqualFold(organization, issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
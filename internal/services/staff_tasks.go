@@ -0,0 +1,72 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"hosting-backend/internal/models"
+)
+
+var (
+	ErrStaffTaskNotFound = errors.New("pendência não encontrada")
+	ErrStaffTaskNotOpen  = errors.New("a pendência já foi resolvida")
+)
+
+// Quantidade máxima de pendências resolvidas retornadas na listagem.
+const resolvedStaffTasksLimit = 100
+
+// StaffTaskService expõe aos administradores a fila "precisa de atenção": as falhas de
+// provisionamento que a compensação automática não resolveu.
+type StaffTaskService struct {
+	db *sql.DB
+}
+
+// NewStaffTaskService cria uma nova instância de StaffTaskService.
+func NewStaffTaskService(db *sql.DB) *StaffTaskService {
+	return &StaffTaskService{db: db}
+}
+
+// List retorna as pendências abertas (por prioridade) ou as últimas resolvidas.
+func (s *StaffTaskService) List(status models.StaffTaskStatus) ([]models.StaffTask, error) {
+	var tasks []models.StaffTask
+	var err error
+	if status == models.StaffTaskStatusResolved {
+		tasks, err = models.GetResolvedStaffTasks(s.db, resolvedStaffTasksLimit)
+	} else {
+		tasks, err = models.GetOpenStaffTasks(s.db)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar as pendências: %w", err)
+	}
+	if tasks == nil {
+		tasks = []models.StaffTask{}
+	}
+	return tasks, nil
+}
+
+// Resolve marca a pendência como resolvida, com a descrição do que foi feito.
+func (s *StaffTaskService) Resolve(id int64, adminID int, ipAddress, resolution string) (*models.StaffTask, error) {
+	task, err := models.GetStaffTaskByID(s.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar a pendência: %w", err)
+	}
+	if task == nil {
+		return nil, ErrStaffTaskNotFound
+	}
+	resolved, err := models.ResolveStaffTask(s.db, id, adminID, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao resolver a pendência: %w", err)
+	}
+	if !resolved {
+		return nil, ErrStaffTaskNotOpen
+	}
+
+	recordAuditLog(s.db, adminID, ipAddress, "staff_task.resolve", "staff_task", strconv.FormatInt(id, 10),
+		map[string]any{"status": task.Status, "kind": task.Kind, "target_type": task.TargetType, "target_id": task.TargetID},
+		map[string]any{"status": models.StaffTaskStatusResolved, "resolution": resolution},
+		models.AuditLogResultSuccess)
+
+	return models.GetStaffTaskByID(s.db, id)
+}
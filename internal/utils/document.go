@@ -0,0 +1,61 @@
+package utils
+
+import "strings"
+
+// OnlyDigits remove a formatação de documentos como CPF, CNPJ e CEP.
+func OnlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeCNPJ remove a pontuação de um CNPJ e converte as letras para maiúsculas.
+func NormalizeCNPJ(cnpj string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(cnpj) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsValidCNPJ verifica o tamanho e os dígitos verificadores de um CNPJ.
+// Aceita tanto o formato numérico quanto o alfanumérico (raiz e ordem com letras e
+// dígitos verificadores numéricos), em que cada caractere vale o seu código ASCII menos 48.
+func IsValidCNPJ(cnpj string) bool {
+	cnpj = NormalizeCNPJ(cnpj)
+	if len(cnpj) != 14 {
+		return false
+	}
+	if cnpj[12] < '0' || cnpj[12] > '9' || cnpj[13] < '0' || cnpj[13] > '9' {
+		return false
+	}
+	if strings.Count(cnpj, cnpj[:1]) == 14 {
+		return false // Sequências repetidas passam no cálculo, mas não são CNPJs válidos
+	}
+
+	return cnpjCheckDigit(cnpj[:12]) == int(cnpj[12]-'0') && cnpjCheckDigit(cnpj[:13]) == int(cnpj[13]-'0')
+}
+
+// cnpjCheckDigit calcula um dígito verificador do CNPJ (módulo 11, pesos de 2 a 9 da direita para a esquerda).
+func cnpjCheckDigit(base string) int {
+	sum, weight := 0, 2
+	for i := len(base) - 1; i >= 0; i-- {
+		sum += int(base[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return 0
+	}
+	return 11 - rest
+}
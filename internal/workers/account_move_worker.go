@@ -0,0 +1,30 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker avançar as migrações de contas entre servidores.
+const accountMoveInterval = 1 * time.Minute
+
+// AccountMoveWorker é um processo de fundo que executa os passos das migrações de contas
+// pendentes ou em andamento, acompanhando as sessões de transferência do WHM.
+func AccountMoveWorker(db *sql.DB) {
+	log.Println("[Account Move Worker] Iniciado. Processando migrações de contas a cada", accountMoveInterval)
+	mover := services.NewAccountMover(db)
+	ticker := time.NewTicker(accountMoveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := mover.ProcessPending(); err != nil {
+				log.Printf("[Account Move Worker] ERRO ao processar as migrações: %v", err)
+			}
+		}
+	}
+}
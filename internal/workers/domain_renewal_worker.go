@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/modules"
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker executar o ciclo de renovação de domínios.
+const domainRenewalInterval = 6 * time.Hour
+
+// DomainRenewalWorker é um processo de fundo que sincroniza o vencimento dos domínios com o
+// registrador, emite as faturas de renovação, envia os lembretes de vencimento e aplica os
+// estados de vencido e em redenção aos domínios não renovados.
+// Os ciclos são ignorados enquanto o módulo domain_sales estiver desativado.
+func DomainRenewalWorker(db *sql.DB, registry *modules.Registry) {
+	log.Println("[Domain Renewal Worker] Iniciado. Verificando renovações de domínios a cada", domainRenewalInterval)
+	renewals := services.NewDomainRenewalService(db)
+	ticker := time.NewTicker(domainRenewalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !registry.IsEnabled(modules.DomainSales) {
+				continue
+			}
+			if err := renewals.RunCycle(); err != nil {
+				log.Printf("[Domain Renewal Worker] ERRO no ciclo de renovação: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/modules"
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker consultar as transferências de domínios pendentes.
+const domainTransferInterval = 30 * time.Minute
+
+// DomainTransferWorker é um processo de fundo que acompanha no registrador as transferências de
+// domínios pendentes, continuando o provisionamento das concluídas e avisando o cliente das recusadas.
+// Os ciclos são ignorados enquanto o módulo domain_sales estiver desativado.
+func DomainTransferWorker(db *sql.DB, registry *modules.Registry) {
+	log.Println("[Domain Transfer Worker] Iniciado. Consultando transferências pendentes a cada", domainTransferInterval)
+	transfers := services.NewDomainTransferService(db)
+	ticker := time.NewTicker(domainTransferInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !registry.IsEnabled(modules.DomainSales) {
+				continue
+			}
+			if err := transfers.PollPending(); err != nil {
+				log.Printf("[Domain Transfer Worker] ERRO ao consultar as transferências: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/modules"
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker consultar as NFS-e em processamento no provedor.
+const fiscalSyncInterval = 5 * time.Minute
+
+// FiscalSyncWorker é um processo de fundo que acompanha as NFS-e em processamento
+// e envia ao cliente o e-mail com o PDF assim que a nota é autorizada.
+// Os ciclos são ignorados enquanto o módulo fiscal_nfse estiver desativado.
+func FiscalSyncWorker(db *sql.DB, registry *modules.Registry) {
+	log.Println("[Fiscal Worker] Iniciado. Sincronizando notas fiscais a cada", fiscalSyncInterval)
+	fiscalService := services.NewFiscalService(db)
+	ticker := time.NewTicker(fiscalSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !registry.IsEnabled(modules.FiscalNFSe) {
+				continue
+			}
+			if err := fiscalService.SyncProcessingDocuments(); err != nil {
+				log.Printf("[Fiscal Worker] ERRO ao sincronizar notas em processamento: %v", err)
+			}
+			if err := fiscalService.NotifyAuthorizedDocuments(); err != nil {
+				log.Printf("[Fiscal Worker] ERRO ao notificar clientes sobre notas autorizadas: %v", err)
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
 )
 
 const (
@@ -47,6 +48,7 @@ func generateDueInvoices(db *sql.DB) {
 	}
 
 	log.Printf("[Invoicing Worker] Encontrado(s) %d serviço(s) para gerar fatura.", len(servicesToInvoice))
+	fiscalService := services.NewFiscalService(db)
 
 	// 2. Itera sobre cada serviço e cria a fatura correspondente.
 	for _, service := range servicesToInvoice {
@@ -69,6 +71,11 @@ func generateDueInvoices(db *sql.DB) {
 			continue // Pula para o próximo serviço
 		}
 
+		// 4.1. Lançar o item do serviço e os impostos (ISS e retenções) na fatura.
+		if err := fiscalService.ApplyInvoiceTaxes(&invoice); err != nil {
+			log.Printf("[Invoicing Worker] AVISO: Fatura #%d criada, mas falhou ao calcular os impostos: %v", invoice.ID, err)
+		}
+
 		// 5. Calcular a próxima data de vencimento com base no ciclo de faturamento.
 		var newNextDueDate time.Time
 		if service.BillingCycle == models.Monthly {
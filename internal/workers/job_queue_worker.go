@@ -0,0 +1,28 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"hosting-backend/internal/jobs"
+)
+
+// Define o intervalo para o worker buscar novas tarefas na fila de provisionamento.
+const jobQueueInterval = 5 * time.Second
+
+// JobQueueWorker é um processo de fundo que reserva as tarefas prontas da fila de provisionamento
+// (domínios, contas de hospedagem e VPS) e as executa respeitando o limite de cada provedor.
+func JobQueueWorker(queue *jobs.Queue) {
+	log.Println("[Job Queue Worker] Iniciado. Buscando tarefas a cada", jobQueueInterval)
+	ticker := time.NewTicker(jobQueueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := queue.Poll(); err != nil {
+				log.Printf("[Job Queue Worker] ERRO ao processar a fila: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker avaliar as regras de alerta dos servidores.
+const serverAlertsInterval = 15 * time.Minute
+
+// ServerAlertsWorker é um processo de fundo que avalia as regras de capacidade e saúde dos servidores
+// (disco, carga, limite de contas, certificado e disponibilidade) e notifica os administradores.
+func ServerAlertsWorker(db *sql.DB) {
+	log.Println("[Server Alerts Worker] Iniciado. Avaliando alertas dos servidores a cada", serverAlertsInterval)
+	alertService := services.NewServerAlertService(db)
+	ticker := time.NewTicker(serverAlertsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := alertService.EvaluateAll(); err != nil {
+				log.Printf("[Server Alerts Worker] ERRO ao avaliar os alertas: %v", err)
+			}
+		}
+	}
+}
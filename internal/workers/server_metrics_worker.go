@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/models"
+	"hosting-backend/internal/services"
+)
+
+const (
+	// Define o intervalo para o worker coletar as métricas dos servidores.
+	serverMetricsInterval = 10 * time.Minute
+	// Tempo de retenção da série histórica de métricas.
+	serverMetricsRetention = 30 * 24 * time.Hour
+)
+
+// ServerMetricsWorker é um processo de fundo que coleta periodicamente a carga, o uso de disco,
+// o número de contas e a versão do WHM de cada servidor do pool.
+func ServerMetricsWorker(db *sql.DB) {
+	log.Println("[Server Metrics Worker] Iniciado. Coletando métricas dos servidores a cada", serverMetricsInterval)
+	monitor := services.NewServerMonitor(db)
+	ticker := time.NewTicker(serverMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := monitor.SyncAll(); err != nil {
+				log.Printf("[Server Metrics Worker] ERRO ao sincronizar os servidores: %v", err)
+			}
+			removed, err := models.DeleteServerMetricsOlderThan(db, time.Now().Add(-serverMetricsRetention))
+			if err != nil {
+				log.Printf("[Server Metrics Worker] ERRO ao remover métricas antigas: %v", err)
+			} else if removed > 0 {
+				log.Printf("[Server Metrics Worker] %d pontos de métricas antigos removidos.", removed)
+			}
+		}
+	}
+}
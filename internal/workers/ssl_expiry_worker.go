@@ -0,0 +1,30 @@
+package workers
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"hosting-backend/internal/services"
+)
+
+// Define o intervalo para o worker ler os certificados SSL das contas dos servidores.
+const sslExpiryInterval = 12 * time.Hour
+
+// SSLExpiryWorker é um processo de fundo que lê os certificados SSL das contas dos servidores cPanel
+// e avisa os clientes antes do vencimento dos certificados que não são renovados pelo AutoSSL.
+func SSLExpiryWorker(db *sql.DB) {
+	log.Println("[SSL Expiry Worker] Iniciado. Lendo os certificados das contas a cada", sslExpiryInterval)
+	certificates := services.NewSSLCertificateService(db, nil)
+	ticker := time.NewTicker(sslExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := certificates.SyncAll(); err != nil {
+				log.Printf("[SSL Expiry Worker] ERRO ao ler os certificados: %v", err)
+			}
+		}
+	}
+}
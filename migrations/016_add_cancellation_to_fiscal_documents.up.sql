@@ -0,0 +1,49 @@
+-- Suporte ao cancelamento e à reemissão de NFS-e
+
+-- Código IBGE do município emissor, exigido pelos provedores de NFS-e
+ALTER TABLE fiscal_settings
+ADD COLUMN IF NOT EXISTS municipality_code VARCHAR(7) NULL;
+
+ALTER TABLE fiscal_documents
+ADD COLUMN IF NOT EXISTS provider_reference VARCHAR(100) NULL, -- Referência enviada ao provedor na emissão
+ADD COLUMN IF NOT EXISTS authorized_at TIMESTAMPTZ NULL, -- Autorização pela prefeitura; início do prazo de cancelamento
+ADD COLUMN IF NOT EXISTS replaces_document_id BIGINT NULL, -- Documento original substituído por esta nota
+ADD COLUMN IF NOT EXISTS cancellation_reason TEXT NULL,
+ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ NULL,
+ADD COLUMN IF NOT EXISTS cancelled_by BIGINT NULL;
+
+-- Notas autorizadas antes desta coluna não têm o momento exato da autorização; usa-se a criação do registro.
+UPDATE fiscal_documents SET authorized_at = created_at
+WHERE authorized_at IS NULL AND status IN ('authorized', 'cancelled');
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_fiscal_documents_replaces') THEN
+        ALTER TABLE fiscal_documents
+        ADD CONSTRAINT fk_fiscal_documents_replaces FOREIGN KEY (replaces_document_id) REFERENCES fiscal_documents(id);
+    END IF;
+END$$;
+
+-- Uma fatura pode ter várias notas (a original cancelada e a substituta),
+-- mas apenas uma nota válida por vez.
+ALTER TABLE fiscal_documents DROP CONSTRAINT IF EXISTS fiscal_documents_invoice_id_key;
+DROP INDEX IF EXISTS idx_invoice_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fiscal_documents_active_invoice
+    ON fiscal_documents(invoice_id)
+    WHERE status IN ('pending', 'processing', 'authorized');
+
+-- Prazo de cancelamento de NFS-e por município (em dias após a emissão)
+CREATE TABLE IF NOT EXISTS nfse_cancellation_windows (
+    municipality_code VARCHAR(7) PRIMARY KEY, -- Código IBGE do município
+    city VARCHAR(100) NULL,
+    state CHAR(2) NULL,
+    window_days INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_nfse_cancellation_windows_updated_at ON nfse_cancellation_windows;
+CREATE TRIGGER update_nfse_cancellation_windows_updated_at
+BEFORE UPDATE ON nfse_cancellation_windows
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- Controle do envio do e-mail de NFS-e autorizada ao cliente
+ALTER TABLE fiscal_documents
+ADD COLUMN IF NOT EXISTS client_notified_at TIMESTAMPTZ NULL;
+
+-- Notas autorizadas antes deste controle não devem gerar e-mail retroativo na primeira execução do worker.
+UPDATE fiscal_documents SET client_notified_at = NOW()
+WHERE client_notified_at IS NULL AND status IN ('authorized', 'cancelled');
+
+-- Acelera a consulta das notas ainda em processamento pelo worker de sincronização
+CREATE INDEX IF NOT EXISTS idx_fiscal_documents_status ON fiscal_documents(status);
@@ -0,0 +1,41 @@
+-- Regras de tributação usadas no cálculo de ISS e de retenções nas faturas
+ALTER TABLE fiscal_settings
+ADD COLUMN IF NOT EXISTS simples_nacional BOOLEAN NOT NULL DEFAULT FALSE, -- Optantes do Simples não sofrem retenções federais
+ADD COLUMN IF NOT EXISTS iss_withheld_for_companies BOOLEAN NOT NULL DEFAULT FALSE, -- ISS retido pelo tomador pessoa jurídica
+ADD COLUMN IF NOT EXISTS irrf_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS pis_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS cofins_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS csll_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS min_retention_amount DECIMAL(10,2) NOT NULL DEFAULT 10.00; -- Retenções abaixo deste valor são dispensadas
+
+-- Código de serviço (LC 116) e CNAE usados na NFS-e de cada plano
+CREATE TABLE IF NOT EXISTS plan_fiscal_codes (
+    plan_id INT PRIMARY KEY,
+    service_code VARCHAR(10) NOT NULL, -- Item da lista de serviços, ex: 01.07
+    cnae_code VARCHAR(10) NULL,
+    iss_rate DECIMAL(5,2) NULL, -- Sobrescreve a alíquota padrão das configurações fiscais
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE
+);
+
+DROP TRIGGER IF EXISTS update_plan_fiscal_codes_updated_at ON plan_fiscal_codes;
+CREATE TRIGGER update_plan_fiscal_codes_updated_at
+BEFORE UPDATE ON plan_fiscal_codes
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Itens de fatura, incluindo os lançamentos de impostos e retenções
+CREATE TABLE IF NOT EXISTS invoice_items (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    service_id INT NULL,
+    description VARCHAR(255) NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE invoice_items
+ADD COLUMN IF NOT EXISTS item_type VARCHAR(20) NOT NULL DEFAULT 'service'; -- service, tax ou retention
+
+CREATE INDEX IF NOT EXISTS idx_invoice_items_invoice_id ON invoice_items(invoice_id);
@@ -0,0 +1,10 @@
+-- Descrição exibida no painel para cada módulo
+ALTER TABLE system_modules
+ADD COLUMN IF NOT EXISTS description TEXT NULL;
+
+-- Módulos conhecidos. A NFS-e e as vendas de VPS e de domínios já estavam em produção,
+-- por isso nascem ativadas; cupons ainda não têm implementação e nascem desativados.
+INSERT INTO system_modules (name, enabled, enabled_at) VALUES ('fiscal_nfse', TRUE, NOW()) ON CONFLICT (name) DO NOTHING;
+INSERT INTO system_modules (name, enabled, enabled_at) VALUES ('vps_sales', TRUE, NOW()) ON CONFLICT (name) DO NOTHING;
+INSERT INTO system_modules (name, enabled, enabled_at) VALUES ('domain_sales', TRUE, NOW()) ON CONFLICT (name) DO NOTHING;
+INSERT INTO system_modules (name, enabled) VALUES ('coupons', FALSE) ON CONFLICT (name) DO NOTHING;
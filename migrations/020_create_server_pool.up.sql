@@ -0,0 +1,53 @@
+-- Pool de servidores de hospedagem e política de alocação de novas contas
+
+CREATE TABLE IF NOT EXISTS servers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    hostname VARCHAR(255) NOT NULL UNIQUE,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    server_type VARCHAR(20) NOT NULL DEFAULT 'cpanel', -- cpanel, plesk
+    api_key_id INT NULL, -- Credencial do servidor em api_credentials
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, inactive, maintenance
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    metrics JSONB NULL,
+    last_sync_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Apenas um servidor pode ser o padrão
+CREATE UNIQUE INDEX IF NOT EXISTS idx_servers_single_default ON servers(is_default) WHERE is_default;
+
+DROP TRIGGER IF EXISTS update_servers_updated_at ON servers;
+CREATE TRIGGER update_servers_updated_at
+BEFORE UPDATE ON servers
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Credenciais por servidor: além das credenciais globais (uma por provedor),
+-- cada servidor pode ter a sua própria credencial, referenciada por servers.api_key_id.
+ALTER TABLE api_credentials
+ADD COLUMN IF NOT EXISTS username VARCHAR(100) NULL, -- Usuário da API, ex: root no WHM
+ADD COLUMN IF NOT EXISTS server_scoped BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE api_credentials DROP CONSTRAINT IF EXISTS api_credentials_provider_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_credentials_global_provider ON api_credentials(provider) WHERE NOT server_scoped;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_servers_api_key') THEN
+        ALTER TABLE servers
+        ADD CONSTRAINT fk_servers_api_key FOREIGN KEY (api_key_id) REFERENCES api_credentials(id) ON DELETE SET NULL;
+    END IF;
+END$$;
+
+-- Política de alocação por plano: default, least_accounts, most_free_disk ou pinned
+ALTER TABLE plans
+ADD COLUMN IF NOT EXISTS placement_policy VARCHAR(20) NOT NULL DEFAULT 'default',
+ADD COLUMN IF NOT EXISTS pinned_server_id INT NULL REFERENCES servers(id) ON DELETE SET NULL;
+
+-- Servidor em que a conta do serviço foi criada
+ALTER TABLE services
+ADD COLUMN IF NOT EXISTS server_id INT NULL REFERENCES servers(id);
+
+CREATE INDEX IF NOT EXISTS idx_services_server_id ON services(server_id);
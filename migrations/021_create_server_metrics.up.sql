@@ -0,0 +1,22 @@
+-- Sincronização periódica das métricas dos servidores do pool
+
+-- Falhas consecutivas de sincronização. Um servidor que não responde é marcado como inativo
+-- e volta a ficar ativo automaticamente quando responder novamente.
+ALTER TABLE servers
+ADD COLUMN IF NOT EXISTS sync_failures INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_sync_error TEXT NULL;
+
+-- Série histórica das métricas coletadas de cada servidor
+CREATE TABLE IF NOT EXISTS server_metrics (
+    id BIGSERIAL PRIMARY KEY,
+    server_id INT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    load_1 DECIMAL(8, 2) NOT NULL DEFAULT 0,
+    load_5 DECIMAL(8, 2) NOT NULL DEFAULT 0,
+    load_15 DECIMAL(8, 2) NOT NULL DEFAULT 0,
+    disk_used_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    disk_free_mb DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    account_count INT NOT NULL DEFAULT 0,
+    collected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_metrics_server_collected ON server_metrics(server_id, collected_at);
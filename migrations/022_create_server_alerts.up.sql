@@ -0,0 +1,27 @@
+-- Alertas de capacidade e saúde dos servidores do pool
+
+-- Limite de contas do servidor (ex: licença do cPanel); NULL desativa o alerta de contas
+ALTER TABLE servers
+ADD COLUMN IF NOT EXISTS max_accounts INT NULL;
+
+CREATE TABLE IF NOT EXISTS server_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    server_id INT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    alert_type VARCHAR(30) NOT NULL, -- disk_usage, load, account_limit, certificate_expiry, unreachable
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, acknowledged, resolved
+    message TEXT NOT NULL,
+    value DECIMAL(14, 2) NULL, -- Valor medido na última avaliação
+    threshold DECIMAL(14, 2) NULL,
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    acknowledged_at TIMESTAMPTZ NULL,
+    acknowledged_by BIGINT NULL,
+    resolved_at TIMESTAMPTZ NULL
+);
+
+-- Deduplicação: no máximo um alerta não resolvido por servidor e tipo
+CREATE UNIQUE INDEX IF NOT EXISTS idx_server_alerts_unresolved
+    ON server_alerts(server_id, alert_type)
+    WHERE status IN ('open', 'acknowledged');
+
+CREATE INDEX IF NOT EXISTS idx_server_alerts_status ON server_alerts(status);
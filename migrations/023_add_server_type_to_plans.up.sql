@@ -0,0 +1,4 @@
+-- Planos de servidores Plesk: o tipo de painel do plano restringe os servidores elegíveis na alocação.
+-- Para planos Plesk, whm_package_name guarda o nome do plano de serviço no Plesk.
+ALTER TABLE plans
+ADD COLUMN IF NOT EXISTS server_type VARCHAR(20) NOT NULL DEFAULT 'cpanel';
@@ -0,0 +1,33 @@
+-- Suporte à migração de dados do WHMCS (cmd/tools/whmcs_import)
+
+-- Mapeamento entre os IDs do WHMCS e os registros criados na plataforma.
+-- Permite executar a importação novamente sem duplicar dados.
+CREATE TABLE IF NOT EXISTS whmcs_id_map (
+    entity VARCHAR(30) NOT NULL, -- client, product, server, service, domain, invoice, invoice_item, transaction
+    whmcs_id BIGINT NOT NULL,
+    local_id BIGINT NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity, whmcs_id)
+);
+
+-- Pagamentos recebidos (e estornos) lançados contra as faturas
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    invoice_id INT NULL,
+    gateway VARCHAR(50) NULL, -- Ex: asaas, stripe, paypal
+    transaction_id VARCHAR(255) NULL, -- ID da transação no gateway
+    description TEXT NULL,
+    amount_in DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    amount_out DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Estornos e reembolsos
+    fees DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    paid_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_invoice_id ON transactions(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id);
+
+-- Domínios registrados sem plano de hospedagem não têm serviço associado
+ALTER TABLE domains ALTER COLUMN service_id DROP NOT NULL;
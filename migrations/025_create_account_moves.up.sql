@@ -0,0 +1,44 @@
+-- Migração de contas de hospedagem entre servidores do pool
+
+CREATE TABLE IF NOT EXISTS account_moves (
+    id BIGSERIAL PRIMARY KEY,
+    service_id INT NOT NULL,
+    source_server_id INT NOT NULL REFERENCES servers(id),
+    target_server_id INT NOT NULL REFERENCES servers(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, failed, completed
+    current_step VARCHAR(30) NOT NULL DEFAULT 'transfer', -- transfer, wait_transfer, verify, update_dns, switch_server, remove_source, done
+    transfer_session_id VARCHAR(100) NULL, -- Sessão do Transfer Tool no servidor de destino
+    source_ip VARCHAR(45) NULL,
+    target_ip VARCHAR(45) NULL,
+    options JSONB NULL, -- Acesso SSH ao servidor de origem (senha criptografada) e flags da migração
+    attempts INT NOT NULL DEFAULT 0, -- Tentativas do passo atual
+    last_error TEXT NULL,
+    created_by BIGINT NULL,
+    started_at TIMESTAMPTZ NULL,
+    completed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Apenas uma migração em andamento por serviço
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_moves_active_service
+    ON account_moves(service_id)
+    WHERE status IN ('pending', 'running', 'failed');
+
+DROP TRIGGER IF EXISTS update_account_moves_updated_at ON account_moves;
+CREATE TRIGGER update_account_moves_updated_at
+BEFORE UPDATE ON account_moves
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Histórico de execução dos passos de cada migração
+CREATE TABLE IF NOT EXISTS account_move_events (
+    id BIGSERIAL PRIMARY KEY,
+    move_id BIGINT NOT NULL REFERENCES account_moves(id) ON DELETE CASCADE,
+    step VARCHAR(30) NOT NULL,
+    result VARCHAR(20) NOT NULL, -- started, waiting, succeeded, failed
+    message TEXT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_move_events_move_id ON account_move_events(move_id);
@@ -0,0 +1,36 @@
+-- Fila persistente de tarefas de provisionamento (domínios, contas de hospedagem e VPS)
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL, -- Ex: 'domain.provision', 'hosting.provision_account', 'vps.provision'
+    provider VARCHAR(50) NOT NULL, -- Provedor acessado pela tarefa; define o limite de concorrência
+    payload JSONB NOT NULL DEFAULT '{}',
+    dedup_key VARCHAR(255) NULL, -- Impede tarefas duplicadas enquanto houver uma na fila ou em execução
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- queued, running, succeeded, dead
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Próxima execução (backoff exponencial entre as tentativas)
+    locked_by VARCHAR(100) NULL, -- Instância que está executando a tarefa
+    locked_at TIMESTAMPTZ NULL,
+    last_error TEXT NULL,
+    finished_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs(provider, run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_dedup_active
+    ON jobs(dedup_key)
+    WHERE dedup_key IS NOT NULL AND status IN ('queued', 'running');
+
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON jobs;
+CREATE TRIGGER update_jobs_updated_at
+BEFORE UPDATE ON jobs
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- A senha do VPS é necessária no provisionamento, que agora ocorre de forma assíncrona
+ALTER TABLE vps_orders
+ADD COLUMN IF NOT EXISTS password_encrypted TEXT NULL;
@@ -0,0 +1,32 @@
+-- Fila "precisa de atenção": tarefas abertas para a equipe quando uma falha de provisionamento
+-- não pode ser resolvida automaticamente (ex: domínio registrado sem conta de hospedagem)
+
+CREATE TABLE IF NOT EXISTS staff_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL, -- Ex: 'domain.without_hosting', 'hosting.orphan_account', 'vps.failed'
+    priority VARCHAR(10) NOT NULL DEFAULT 'normal', -- low, normal, high
+    target_type VARCHAR(50) NOT NULL, -- domain, service, client, vps_order
+    target_id VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    details TEXT NULL,
+    job_id BIGINT NULL REFERENCES jobs(id) ON DELETE SET NULL, -- Tarefa da fila que originou a pendência
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, resolved
+    resolution TEXT NULL,
+    resolved_by BIGINT NULL,
+    resolved_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- No máximo uma pendência aberta por alvo e tipo
+CREATE UNIQUE INDEX IF NOT EXISTS idx_staff_tasks_open
+    ON staff_tasks(kind, target_type, target_id)
+    WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS idx_staff_tasks_status ON staff_tasks(status);
+
+DROP TRIGGER IF EXISTS update_staff_tasks_updated_at ON staff_tasks;
+CREATE TRIGGER update_staff_tasks_updated_at
+BEFORE UPDATE ON staff_tasks
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- Vínculo dos pedidos de domínio com a fatura e com o serviço criado no provisionamento.
+-- O pagamento da fatura dispara o provisionamento dos pedidos vinculados a ela.
+-- Status do pedido: pending_payment, pending_approval (aprovação manual), pending_registration, completed, failed, cancelled
+
+ALTER TABLE domain_orders
+ADD COLUMN IF NOT EXISTS invoice_id INT NULL REFERENCES invoices(id),
+ADD COLUMN IF NOT EXISTS service_id INT NULL REFERENCES services(id);
+
+CREATE INDEX IF NOT EXISTS idx_domain_orders_invoice_id ON domain_orders(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_vps_orders_invoice_id ON vps_orders(invoice_id);
@@ -0,0 +1,78 @@
+-- Tabela de preços de domínios por TLD: registro, transferência e renovação por período (1 a 10 anos).
+-- O preço de venda é o preço fixo da linha ou, sem ele, o custo no registrador com a regra de margem
+-- mais específica. Promoções vigentes têm precedência sobre ambos.
+
+CREATE TABLE IF NOT EXISTS domain_tld_prices (
+    id SERIAL PRIMARY KEY,
+    tld VARCHAR(63) NOT NULL, -- Sem o ponto inicial (ex: 'com.br')
+    operation VARCHAR(20) NOT NULL, -- register, transfer, renew
+    years SMALLINT NOT NULL CHECK (years BETWEEN 1 AND 10),
+    cost_price NUMERIC(10, 2) NULL, -- Custo no registrador, sincronizado do catálogo da Hostinger
+    price NUMERIC(10, 2) NULL, -- Preço de venda fixo; NULL aplica a margem sobre o custo
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (tld, operation, years)
+);
+
+-- Regras de margem sobre o custo. TLD ou operação nulas valem para todas; a regra mais específica vence.
+CREATE TABLE IF NOT EXISTS domain_margin_rules (
+    id SERIAL PRIMARY KEY,
+    tld VARCHAR(63) NULL,
+    operation VARCHAR(20) NULL,
+    margin_percent NUMERIC(6, 2) NOT NULL DEFAULT 0,
+    margin_fixed NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domain_margin_rules_scope
+    ON domain_margin_rules(COALESCE(tld, ''), COALESCE(operation, ''));
+
+-- Preços promocionais de uma TLD, operação e período, válidos de starts_at até ends_at.
+CREATE TABLE IF NOT EXISTS domain_price_promotions (
+    id SERIAL PRIMARY KEY,
+    tld VARCHAR(63) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    years SMALLINT NOT NULL DEFAULT 1 CHECK (years BETWEEN 1 AND 10),
+    price NUMERIC(10, 2) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    description VARCHAR(255) NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_price_promotions_lookup
+    ON domain_price_promotions(tld, operation, years, ends_at);
+
+-- Período contratado no pedido de domínio, usado no registro.
+ALTER TABLE domain_orders
+ADD COLUMN IF NOT EXISTS years SMALLINT NOT NULL DEFAULT 1;
+
+DROP TRIGGER IF EXISTS update_domain_tld_prices_updated_at ON domain_tld_prices;
+CREATE TRIGGER update_domain_tld_prices_updated_at
+BEFORE UPDATE ON domain_tld_prices
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_domain_margin_rules_updated_at ON domain_margin_rules;
+CREATE TRIGGER update_domain_margin_rules_updated_at
+BEFORE UPDATE ON domain_margin_rules
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_domain_price_promotions_updated_at ON domain_price_promotions;
+CREATE TRIGGER update_domain_price_promotions_updated_at
+BEFORE UPDATE ON domain_price_promotions
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Preços iniciais: até esta migração todas as TLDs eram vendidas a R$ 40,00 por ano. As TLDs
+-- oferecidas na busca (services.DefaultSearchTLDs) mantêm esse preço fixo em registro, renovação e
+-- transferência por 1 ano, para que pedidos, busca e renovações continuem funcionando após o deploy.
+-- Demais TLDs e períodos são cadastrados pelo admin em /admin/domain-pricing/tlds.
+INSERT INTO domain_tld_prices (tld, operation, years, price)
+SELECT tld, operation, 1, 40.00
+FROM unnest(ARRAY['com.br', 'com', 'net', 'org', 'dev', 'app', 'io']) AS tld
+CROSS JOIN unnest(ARRAY['register', 'renew', 'transfer']) AS operation
+ON CONFLICT (tld, operation, years) DO NOTHING;
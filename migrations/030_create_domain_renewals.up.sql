@@ -0,0 +1,56 @@
+-- Renovação de domínios: vencimento sincronizado com o registrador, faturas de renovação emitidas
+-- antes do vencimento, lembretes ao cliente e os estados de domínio vencido e em redenção.
+
+-- Novos status: vencido sem renovação (ainda renovável pelo preço normal) e em redenção (período
+-- de resgate no registrador). Terminada a redenção, o domínio é cancelado.
+ALTER TYPE domain_status ADD VALUE IF NOT EXISTS 'expired';
+ALTER TYPE domain_status ADD VALUE IF NOT EXISTS 'redemption';
+
+ALTER TABLE domains
+ADD COLUMN IF NOT EXISTS provider_subscription_id VARCHAR(255) NULL, -- Assinatura do domínio no registrador
+ADD COLUMN IF NOT EXISTS expiry_synced_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_domains_expires_at ON domains(expires_at);
+
+-- Uma renovação por vencimento: a fatura é emitida uma única vez para cada data de vencimento.
+-- Status: pending_payment, paid (enviada ao registrador), submitted (aguardando o novo vencimento),
+-- completed, failed, cancelled
+CREATE TABLE IF NOT EXISTS domain_renewals (
+    id SERIAL PRIMARY KEY,
+    domain_id INT NOT NULL REFERENCES domains(id),
+    invoice_id INT NULL REFERENCES invoices(id),
+    expires_at DATE NOT NULL, -- Vencimento que esta renovação estende
+    years SMALLINT NOT NULL DEFAULT 1,
+    price NUMERIC(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_payment',
+    submitted_at TIMESTAMPTZ NULL,
+    completed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (domain_id, expires_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_renewals_invoice_id ON domain_renewals(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_domain_renewals_status ON domain_renewals(status);
+
+-- Lembretes de vencimento enviados, no máximo um por domínio, vencimento e antecedência.
+CREATE TABLE IF NOT EXISTS domain_renewal_reminders (
+    domain_id INT NOT NULL REFERENCES domains(id),
+    expires_at DATE NOT NULL,
+    days_before INT NOT NULL,
+    sent_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (domain_id, expires_at, days_before)
+);
+
+-- Regras de renovação configuráveis
+ALTER TABLE system_settings
+ADD COLUMN IF NOT EXISTS domain_renewal_invoice_days INT NOT NULL DEFAULT 30, -- Antecedência da fatura de renovação
+ADD COLUMN IF NOT EXISTS domain_renewal_reminder_days VARCHAR(100) NOT NULL DEFAULT '30,15,7,1', -- Dias antes do vencimento
+ADD COLUMN IF NOT EXISTS domain_grace_days INT NOT NULL DEFAULT 30, -- Dias como vencido antes da redenção
+ADD COLUMN IF NOT EXISTS domain_redemption_days INT NOT NULL DEFAULT 30; -- Dias em redenção antes do cancelamento
+
+DROP TRIGGER IF EXISTS update_domain_renewals_updated_at ON domain_renewals;
+CREATE TRIGGER update_domain_renewals_updated_at
+BEFORE UPDATE ON domain_renewals
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,31 @@
+-- Transferência de domínios para a nossa conta no registrador (transfer-in) com o código EPP.
+
+-- Tipo do pedido (register ou transfer) e o código EPP da transferência, criptografado pela
+-- aplicação (AES-GCM com ENCRYPTION_KEY).
+ALTER TABLE domain_orders
+ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'register',
+ADD COLUMN IF NOT EXISTS auth_code_encrypted TEXT NULL;
+
+-- Situação da transferência, acompanhada no registrador até a conclusão.
+-- Status: pending (no registrador), action_required (recusada; aguardando a correção do cliente),
+-- completed, cancelled
+CREATE TABLE IF NOT EXISTS domain_transfers (
+    domain_id INT PRIMARY KEY REFERENCES domains(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    provider_status VARCHAR(50) NULL, -- Status informado pelo registrador
+    failure_reason VARCHAR(50) NULL, -- Ex: invalid_auth_code, domain_locked
+    failure_message TEXT NULL,
+    attempts INT NOT NULL DEFAULT 0, -- Envios ao registrador, incluindo as correções do cliente
+    checked_at TIMESTAMPTZ NULL, -- Última consulta ao registrador
+    completed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_transfers_status ON domain_transfers(status);
+
+DROP TRIGGER IF EXISTS update_domain_transfers_updated_at ON domain_transfers;
+CREATE TRIGGER update_domain_transfers_updated_at
+BEFORE UPDATE ON domain_transfers
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
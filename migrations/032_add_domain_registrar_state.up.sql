@@ -0,0 +1,8 @@
+-- Estado dos domínios no registrador, mantido em cache para a área do cliente: nameservers, bloqueio
+-- de transferência e proteção de privacidade do WHOIS.
+
+ALTER TABLE domains
+ADD COLUMN IF NOT EXISTS nameservers TEXT NULL, -- Separados por vírgula, na ordem do registrador
+ADD COLUMN IF NOT EXISTS registrar_locked BOOLEAN NULL,
+ADD COLUMN IF NOT EXISTS whois_privacy BOOLEAN NULL,
+ADD COLUMN IF NOT EXISTS registrar_synced_at TIMESTAMPTZ NULL; -- Última leitura do estado no registrador
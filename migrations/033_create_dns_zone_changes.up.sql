@@ -0,0 +1,19 @@
+-- Histórico das alterações feitas pelos clientes nas zonas DNS dos serviços de hospedagem (WHM).
+-- Action: add, edit, delete. Alterações feitas por um modelo (ex: google_workspace) registram o
+-- modelo em template, uma linha por registro alterado.
+CREATE TABLE IF NOT EXISTS dns_zone_changes (
+    id BIGSERIAL PRIMARY KEY,
+    service_id INT NOT NULL REFERENCES services(id),
+    zone VARCHAR(255) NOT NULL,
+    user_id INT NOT NULL, -- Usuário que fez a alteração
+    ip_address VARCHAR(45) NULL,
+    action VARCHAR(10) NOT NULL,
+    template VARCHAR(50) NULL,
+    record_type VARCHAR(10) NOT NULL,
+    record_name VARCHAR(255) NOT NULL,
+    old_record JSONB NULL, -- Registro antes da alteração (edit, delete)
+    new_record JSONB NULL, -- Registro depois da alteração (add, edit)
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dns_zone_changes_service_zone ON dns_zone_changes(service_id, zone, created_at);
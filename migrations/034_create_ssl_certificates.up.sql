@@ -0,0 +1,19 @@
+-- Certificados SSL dos virtual hosts das contas cPanel, lidos do WHM (fetch_ssl_vhosts) pela área do
+-- cliente e pelo worker de vencimento. Domínios da conta sem certificado têm uma linha sem emissor
+-- nem vencimento.
+CREATE TABLE IF NOT EXISTS ssl_certificates (
+    service_id INT NOT NULL REFERENCES services(id),
+    vhost VARCHAR(255) NOT NULL, -- Domínio principal do virtual host
+    domains TEXT NOT NULL, -- Domínios atendidos pelo virtual host, separados por vírgula
+    covered_domains TEXT NULL, -- Nomes cobertos pelo certificado, separados por vírgula
+    issuer VARCHAR(255) NULL,
+    not_before TIMESTAMPTZ NULL,
+    not_after TIMESTAMPTZ NULL,
+    self_signed BOOLEAN NOT NULL DEFAULT FALSE,
+    auto_renewed BOOLEAN NOT NULL DEFAULT FALSE, -- Emitido pelo AutoSSL
+    expiry_alert_days INT NULL, -- Menor antecedência, em dias, já avisada ao cliente para este vencimento
+    synced_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (service_id, vhost)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ssl_certificates_not_after ON ssl_certificates(not_after);
@@ -0,0 +1,13 @@
+-- Vínculo do pedido de domínio com o domínio criado no provisionamento. As etapas de registro e de
+-- transferência leem o pedido (documento, período, código EPP) pelo domínio, e não pelo nome: o mesmo
+-- nome pode ter pedidos de outros clientes.
+
+ALTER TABLE domain_orders
+ADD COLUMN IF NOT EXISTS domain_id INT NULL REFERENCES domains(id);
+
+-- Pedidos já provisionados: o domínio de mesmo nome e do mesmo cliente.
+UPDATE domain_orders o SET domain_id = d.id
+FROM domains d
+WHERE o.domain_id IS NULL AND d.domain_name = o.domain_name AND d.client_id = o.client_id;
+
+CREATE INDEX IF NOT EXISTS idx_domain_orders_domain_id ON domain_orders(domain_id);